@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"contactsAI/contacts/internal/apikey"
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/db"
+	"contactsAI/contacts/internal/tenant"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const apiKeyUsage = "usage: contactsService apikey create <name> <owner-id> <scope>[,<scope>...] [<tenant-id>] [flags]"
+
+// runAPIKey implements the apikey subcommand, which creates keys without
+// going through the admin API, e.g. the first admin key of a tenant. Keys
+// belong to the default tenant unless another one is given.
+func runAPIKey(ctx context.Context, cfg *config.Config, command []string, out io.Writer) error {
+	if command[0] != "create" {
+		return fmt.Errorf("unknown apikey command %q, %s", command[0], apiKeyUsage)
+	}
+	name := command[1]
+	ownerID, err := strconv.ParseInt(command[2], 10, 32)
+	if err != nil || ownerID < 1 {
+		return fmt.Errorf("invalid owner ID %q, %s", command[2], apiKeyUsage)
+	}
+	scopes := strings.Split(command[3], ",")
+	if err = apikey.ValidateScopes(scopes); err != nil {
+		return err
+	}
+	tenantID := int64(tenant.DefaultID)
+	if len(command) > 4 { //nolint:mnd // "create", its three arguments and the tenant ID
+		if tenantID, err = strconv.ParseInt(command[4], 10, 32); err != nil {
+			return fmt.Errorf("invalid tenant ID %q, %s", command[4], apiKeyUsage)
+		}
+	}
+
+	pool, err := pgxpool.New(ctx, cfg.Database.URL.Value())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	key := apikey.Generate()
+	//nolint:exhaustruct // keys created here do not expire
+	created, err := db.New(pool).CreateAPIKey(ctx, db.CreateAPIKeyParams{
+		Name:     name,
+		Prefix:   key.Prefix,
+		KeyHash:  key.Hash,
+		OwnerID:  int32(ownerID),
+		Scopes:   scopes,
+		TenantID: int32(tenantID),
+	})
+	if err != nil {
+		return fmt.Errorf("create API key: %w", err)
+	}
+	fmt.Fprintf(out, "created API key %d (%s), store the secret now as it is not shown again:\n%s\n",
+		created.ID, created.Prefix, key.Secret)
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	migrateCommand = "migrate"
+	apiKeyCommand  = "apikey"
+	tenantCommand  = "tenant"
+)
+
+// splitCommand returns the words of a subcommand such as "migrate up" and the
+// remaining flags, or no command when args start with a flag.
+func splitCommand(args []string) ([]string, []string, error) {
+	if len(args) == 0 {
+		return nil, args, nil
+	}
+	var words int
+	var usage string
+	switch args[0] {
+	case migrateCommand:
+		words, usage = 2, migrateUsage //nolint:mnd // "migrate" and the command
+	case apiKeyCommand:
+		words, usage = 5, apiKeyUsage //nolint:mnd // "apikey create" and its arguments
+		if len(args) > words && !strings.HasPrefix(args[words], "-") {
+			words++ // the optional tenant ID
+		}
+	case tenantCommand:
+		words, usage = 2, tenantUsage //nolint:mnd // "tenant" and the command
+		if len(args) > 1 && args[1] == "create" {
+			words++ // the name
+		}
+	default:
+		return nil, args, nil
+	}
+	if len(args) < words {
+		return nil, nil, errors.New(usage)
+	}
+	return args[:words], args[words:], nil
+}
@@ -279,6 +279,10 @@ const docTemplate = `{
             "properties": {
                 "message": {
                     "type": "string"
+                },
+                "trace_id": {
+                    "description": "TraceID identifies the request's trace, so that a reported error can be\nlooked up in the tracing backend.",
+                    "type": "string"
                 }
             }
         },
@@ -0,0 +1,138 @@
+// Package access decides who may see and change contacts and teams.
+//
+// Contacts are visible to their owner and to the users and teams they are
+// shared with. Which contacts a query returns is decided in the database by
+// contact_access; this package supplies its viewer and holds the team rules.
+package access
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"contactsAI/contacts/internal/apikey"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Role is the role of a user in a team.
+type Role string
+
+const (
+	// RoleOwner manages the team, its members and their roles.
+	RoleOwner Role = "owner"
+	// RoleAdmin manages members and viewers, but not owners.
+	RoleAdmin Role = "admin"
+	// RoleMember uses the contacts shared with the team.
+	RoleMember Role = "member"
+	// RoleViewer reads the contacts shared with the team, even if the share
+	// allows writing.
+	RoleViewer Role = "viewer"
+)
+
+const (
+	PermissionRead  = "read"
+	PermissionWrite = "write"
+)
+
+var (
+	ErrForbidden = errors.New("forbidden")
+	// ErrLastOwner prevents teams without owners.
+	ErrLastOwner = errors.New("a team needs at least one owner")
+)
+
+// Roles lists the team roles from most to least privileged.
+func Roles() []Role {
+	return []Role{RoleOwner, RoleAdmin, RoleMember, RoleViewer}
+}
+
+// ParseRole returns the role named s.
+func ParseRole(s string) (Role, error) {
+	role := Role(s)
+	if !slices.Contains(Roles(), role) {
+		names := make([]string, 0, len(Roles()))
+		for _, r := range Roles() {
+			names = append(names, string(r))
+		}
+		return "", fmt.Errorf("unknown role %q, use one of %s", s, strings.Join(names, ", "))
+	}
+	return role, nil
+}
+
+// AnonymousViewer is the viewer of anonymous requests, which are only
+// admitted when authentication is optional. contact_access lets it see the
+// contacts without an owner, which anonymous requests create, and nothing
+// shared. API key owners are positive, so no user has this ID.
+const AnonymousViewer int32 = 0
+
+// Viewer is the user whose access restricts contact queries, AnonymousViewer
+// for anonymous requests, or NULL for the unrestricted admin keys.
+func Viewer(ctx context.Context) pgtype.Int4 {
+	principal := apikey.FromContext(ctx)
+	if principal == nil {
+		return pgtype.Int4{Int32: AnonymousViewer, Valid: true}
+	}
+	if principal.HasScope(apikey.ScopeAdmin) {
+		return pgtype.Int4{Int32: 0, Valid: false}
+	}
+	return pgtype.Int4{Int32: principal.OwnerID, Valid: true}
+}
+
+// User is the user making the request, or NULL for anonymous requests. New
+// contacts and teams belong to this user.
+func User(ctx context.Context) pgtype.Int4 {
+	principal := apikey.FromContext(ctx)
+	if principal == nil {
+		return pgtype.Int4{Int32: 0, Valid: false}
+	}
+	return pgtype.Int4{Int32: principal.OwnerID, Valid: true}
+}
+
+// CanEdit reports whether a contact permission returned by contact_access
+// allows changes.
+func CanEdit(permission pgtype.Text) bool {
+	return permission.Valid && permission.String == PermissionWrite
+}
+
+// CanManageTeam reports whether actor may delete the team.
+func CanManageTeam(actor Role) error {
+	if actor != RoleOwner {
+		return fmt.Errorf("%w: only owners can delete a team", ErrForbidden)
+	}
+	return nil
+}
+
+// CanSetRole reports whether actor may give a user the role next. current is
+// the user's role so far, or empty for new members.
+func CanSetRole(actor, current, next Role) error {
+	switch {
+	case actor == RoleOwner:
+		return nil
+	case actor != RoleAdmin:
+		return fmt.Errorf("%w: only owners and admins can manage members", ErrForbidden)
+	case current == RoleOwner || next == RoleOwner:
+		return fmt.Errorf("%w: only owners can manage owners", ErrForbidden)
+	default:
+		return nil
+	}
+}
+
+// CanRemove reports whether actor may remove a member with role target from
+// the team. Everyone may leave a team.
+func CanRemove(actor, target Role, self bool) error {
+	if self {
+		return nil
+	}
+	return CanSetRole(actor, target, "")
+}
+
+// CheckOwners fails with ErrLastOwner when a change leaves a team of owners
+// without one. owners is the number of owners before the change.
+func CheckOwners(owners int64, current, next Role) error {
+	if current == RoleOwner && next != RoleOwner && owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package access_test
+
+import (
+	"context"
+	"testing"
+
+	"contactsAI/contacts/internal/access"
+	"contactsAI/contacts/internal/apikey"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestViewer(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, pgtype.Int4{Int32: access.AnonymousViewer, Valid: true}, access.Viewer(ctx),
+		"anonymous requests are restricted")
+	assert.False(t, access.User(ctx).Valid)
+
+	user := apikey.NewContext(ctx, &apikey.Principal{KeyID: 1, OwnerID: 7, Scopes: []string{apikey.ScopeContactsRead}})
+	assert.Equal(t, pgtype.Int4{Int32: 7, Valid: true}, access.Viewer(user))
+	assert.Equal(t, pgtype.Int4{Int32: 7, Valid: true}, access.User(user))
+
+	admin := apikey.NewContext(ctx, &apikey.Principal{KeyID: 2, OwnerID: 8, Scopes: []string{apikey.ScopeAdmin}})
+	assert.False(t, access.Viewer(admin).Valid, "admin keys are unrestricted")
+	assert.Equal(t, pgtype.Int4{Int32: 8, Valid: true}, access.User(admin), "admins still own what they create")
+}
+
+func TestParseRole(t *testing.T) {
+	role, err := access.ParseRole("viewer")
+	require.NoError(t, err)
+	assert.Equal(t, access.RoleViewer, role)
+
+	_, err = access.ParseRole("guest")
+	assert.Error(t, err)
+}
+
+func TestCanEdit(t *testing.T) {
+	assert.True(t, access.CanEdit(pgtype.Text{String: access.PermissionWrite, Valid: true}))
+	assert.False(t, access.CanEdit(pgtype.Text{String: access.PermissionRead, Valid: true}))
+	assert.False(t, access.CanEdit(pgtype.Text{String: "", Valid: false}))
+}
+
+func TestCanSetRole(t *testing.T) {
+	tests := []struct {
+		actor, current, next access.Role
+		allowed              bool
+	}{
+		{access.RoleOwner, "", access.RoleOwner, true},
+		{access.RoleOwner, access.RoleOwner, access.RoleViewer, true},
+		{access.RoleAdmin, "", access.RoleMember, true},
+		{access.RoleAdmin, access.RoleMember, access.RoleAdmin, true},
+		{access.RoleAdmin, "", access.RoleOwner, false},
+		{access.RoleAdmin, access.RoleOwner, access.RoleMember, false},
+		{access.RoleMember, "", access.RoleViewer, false},
+		{access.RoleViewer, access.RoleViewer, access.RoleMember, false},
+	}
+	for _, tt := range tests {
+		err := access.CanSetRole(tt.actor, tt.current, tt.next)
+		if tt.allowed {
+			assert.NoError(t, err, "%s sets %q to %s", tt.actor, tt.current, tt.next)
+		} else {
+			assert.ErrorIs(t, err, access.ErrForbidden, "%s sets %q to %s", tt.actor, tt.current, tt.next)
+		}
+	}
+}
+
+func TestCanRemove(t *testing.T) {
+	require.NoError(t, access.CanRemove(access.RoleViewer, access.RoleViewer, true), "members can leave")
+	require.NoError(t, access.CanRemove(access.RoleAdmin, access.RoleMember, false))
+	require.ErrorIs(t, access.CanRemove(access.RoleAdmin, access.RoleOwner, false), access.ErrForbidden)
+	require.ErrorIs(t, access.CanRemove(access.RoleMember, access.RoleViewer, false), access.ErrForbidden)
+}
+
+func TestCanManageTeam(t *testing.T) {
+	require.NoError(t, access.CanManageTeam(access.RoleOwner))
+	require.ErrorIs(t, access.CanManageTeam(access.RoleAdmin), access.ErrForbidden)
+}
+
+func TestCheckOwners(t *testing.T) {
+	require.ErrorIs(t, access.CheckOwners(1, access.RoleOwner, access.RoleAdmin), access.ErrLastOwner)
+	require.ErrorIs(t, access.CheckOwners(1, access.RoleOwner, ""), access.ErrLastOwner)
+	require.NoError(t, access.CheckOwners(2, access.RoleOwner, ""))
+	require.NoError(t, access.CheckOwners(1, access.RoleMember, ""))
+}
@@ -0,0 +1,51 @@
+package apikey
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"contactsAI/contacts/internal/db"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Authenticator resolves API key secrets to principals.
+type Authenticator struct {
+	queries db.Querier
+}
+
+func NewAuthenticator(queries db.Querier) *Authenticator {
+	return &Authenticator{queries: queries}
+}
+
+// Authenticate returns the principal of secret and records that the key was
+// used. Unknown and malformed keys fail with ErrInvalidKey.
+func (a *Authenticator) Authenticate(ctx context.Context, secret string) (*Principal, error) {
+	prefix, err := ParsePrefix(secret)
+	if err != nil {
+		return nil, err
+	}
+	key, err := a.queries.GetAPIKeyByPrefix(ctx, prefix)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(key.KeyHash, Hash(secret)) != 1 {
+		return nil, ErrInvalidKey
+	}
+	if key.RevokedAt.Valid {
+		return nil, ErrRevokedKey
+	}
+	if key.ExpiresAt.Valid && !time.Now().Before(key.ExpiresAt.Time) {
+		return nil, ErrExpiredKey
+	}
+
+	if err = a.queries.TouchAPIKey(ctx, key.ID); err != nil {
+		return nil, err
+	}
+	return &Principal{KeyID: key.ID, OwnerID: key.OwnerID, TenantID: key.TenantID, Scopes: key.Scopes}, nil
+}
@@ -0,0 +1,105 @@
+package apikey_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"contactsAI/contacts/internal/apikey"
+	"contactsAI/contacts/internal/db"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAndParsePrefix(t *testing.T) {
+	key := apikey.Generate()
+
+	assert.True(t, strings.HasPrefix(key.Secret, "ck_"+key.Prefix+"_"))
+	assert.Equal(t, apikey.Hash(key.Secret), key.Hash)
+	assert.NotEqual(t, key.Secret, apikey.Generate().Secret)
+
+	prefix, err := apikey.ParsePrefix(key.Secret)
+	require.NoError(t, err)
+	assert.Equal(t, key.Prefix, prefix)
+
+	for _, secret := range []string{"", "ck_", "ck_short_x", "xx_abcdefgh_secret", "ck_abcdefghsecret"} {
+		_, err = apikey.ParsePrefix(secret)
+		assert.ErrorIs(t, err, apikey.ErrInvalidKey, secret)
+	}
+}
+
+func TestValidateScopes(t *testing.T) {
+	require.NoError(t, apikey.ValidateScopes([]string{apikey.ScopeContactsRead, apikey.ScopeAvatarsWrite}))
+	assert.Error(t, apikey.ValidateScopes(nil))
+	assert.Error(t, apikey.ValidateScopes([]string{"contacts:delete"}))
+}
+
+func TestCheckScope(t *testing.T) {
+	ctx := context.Background()
+	require.NoError(t, apikey.CheckScope(ctx, apikey.ScopeContactsWrite), "anonymous requests pass")
+
+	reader := &apikey.Principal{KeyID: 1, OwnerID: 1, Scopes: []string{apikey.ScopeContactsRead}}
+	ctx = apikey.NewContext(ctx, reader)
+	assert.Same(t, reader, apikey.FromContext(ctx))
+	require.NoError(t, apikey.CheckScope(ctx, apikey.ScopeContactsRead))
+	require.ErrorIs(t, apikey.CheckScope(ctx, apikey.ScopeContactsWrite), apikey.ErrForbidden)
+
+	admin := &apikey.Principal{KeyID: 2, OwnerID: 1, Scopes: []string{apikey.ScopeAdmin}}
+	assert.True(t, admin.HasScope(apikey.ScopeAvatarsWrite))
+}
+
+type fakeQueries struct {
+	db.Querier
+
+	keys    map[string]db.ApiKey
+	touched []int32
+}
+
+func (f *fakeQueries) GetAPIKeyByPrefix(_ context.Context, prefix string) (db.ApiKey, error) {
+	key, ok := f.keys[prefix]
+	if !ok {
+		return db.ApiKey{}, pgx.ErrNoRows
+	}
+	return key, nil
+}
+
+func (f *fakeQueries) TouchAPIKey(_ context.Context, id int32) error {
+	f.touched = append(f.touched, id)
+	return nil
+}
+
+func TestAuthenticator(t *testing.T) {
+	valid, expired, revoked := apikey.Generate(), apikey.Generate(), apikey.Generate()
+	past := pgtype.Timestamptz{Time: time.Now().Add(-time.Hour), Valid: true}
+	queries := &fakeQueries{keys: map[string]db.ApiKey{
+		valid.Prefix: {
+			ID: 1, Prefix: valid.Prefix, KeyHash: valid.Hash, OwnerID: 7, TenantID: 2,
+			Scopes: []string{apikey.ScopeContactsRead},
+		},
+		expired.Prefix: {ID: 2, Prefix: expired.Prefix, KeyHash: expired.Hash, ExpiresAt: past},
+		revoked.Prefix: {ID: 3, Prefix: revoked.Prefix, KeyHash: revoked.Hash, RevokedAt: past},
+	}}
+	authn := apikey.NewAuthenticator(queries)
+	ctx := context.Background()
+
+	principal, err := authn.Authenticate(ctx, valid.Secret)
+	require.NoError(t, err)
+	assert.Equal(t, &apikey.Principal{
+		KeyID: 1, OwnerID: 7, TenantID: 2, Scopes: []string{apikey.ScopeContactsRead},
+	}, principal)
+	assert.Equal(t, []int32{1}, queries.touched)
+
+	_, err = authn.Authenticate(ctx, valid.Secret+"x")
+	require.ErrorIs(t, err, apikey.ErrInvalidKey, "a wrong secret with a known prefix")
+	_, err = authn.Authenticate(ctx, apikey.Generate().Secret)
+	require.ErrorIs(t, err, apikey.ErrInvalidKey)
+	_, err = authn.Authenticate(ctx, expired.Secret)
+	require.ErrorIs(t, err, apikey.ErrExpiredKey)
+	_, err = authn.Authenticate(ctx, revoked.Secret)
+	require.ErrorIs(t, err, apikey.ErrRevokedKey)
+	assert.Equal(t, []int32{1}, queries.touched, "rejected keys are not touched")
+}
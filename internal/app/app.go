@@ -0,0 +1,132 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const readHeaderTimeout = 10 * time.Second
+
+// Worker is a background task that runs until its context is cancelled.
+type Worker func(ctx context.Context) error
+
+type namedWorker struct {
+	name string
+	run  Worker
+}
+
+// App runs the HTTP server together with the background workers and shuts
+// both down gracefully.
+type App struct {
+	logger          *slog.Logger
+	server          *http.Server
+	shutdownTimeout time.Duration
+	drainDelay      time.Duration
+	workers         []namedWorker
+	onShutdown      []func()
+}
+
+// New returns an App serving handler. On shutdown it waits drainDelay after
+// the OnShutdown hooks before it stops accepting connections.
+func New(logger *slog.Logger, handler http.Handler, shutdownTimeout, drainDelay time.Duration) *App {
+	//nolint:exhaustruct // defaults of the remaining server settings are fine
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
+	return &App{
+		logger:          logger,
+		server:          server,
+		shutdownTimeout: shutdownTimeout,
+		drainDelay:      drainDelay,
+		workers:         nil,
+		onShutdown:      nil,
+	}
+}
+
+// AddWorker registers a worker that is started by Serve and stopped on shutdown.
+func (a *App) AddWorker(name string, worker Worker) {
+	a.workers = append(a.workers, namedWorker{name: name, run: worker})
+}
+
+// OnShutdown registers a function that is called when shutdown begins, before
+// the drain delay and before connections are drained.
+func (a *App) OnShutdown(hook func()) {
+	a.onShutdown = append(a.onShutdown, hook)
+}
+
+// Run listens on addr and serves until ctx is cancelled.
+func (a *App) Run(ctx context.Context, addr string) error {
+	var lc net.ListenConfig
+	listener, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return a.Serve(ctx, listener)
+}
+
+// Serve accepts connections on listener until ctx is cancelled. It then keeps
+// serving for the drain delay, stops accepting new connections, waits for
+// in-flight requests to finish and stops the workers, giving up after the
+// shutdown timeout.
+func (a *App) Serve(ctx context.Context, listener net.Listener) error {
+	workerCtx, stopWorkers := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopWorkers()
+
+	var workers sync.WaitGroup
+	for _, w := range a.workers {
+		workers.Go(func() {
+			if err := w.run(workerCtx); err != nil && !errors.Is(err, context.Canceled) {
+				a.logger.Error("Worker stopped", "worker", w.name, "error", err)
+			}
+		})
+	}
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- a.server.Serve(listener) }()
+	a.logger.Info("Server listening", "addr", listener.Addr().String())
+
+	select {
+	case err := <-serveErr:
+		stopWorkers()
+		workers.Wait()
+		return err
+	case <-ctx.Done():
+	}
+
+	a.logger.Info("Shutting down", "drain_delay", a.drainDelay, "timeout", a.shutdownTimeout)
+	for _, hook := range a.onShutdown {
+		hook()
+	}
+	// Keep serving while load balancers notice that readiness fails.
+	time.Sleep(a.drainDelay)
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), a.shutdownTimeout)
+	defer cancel()
+
+	err := a.server.Shutdown(shutdownCtx)
+	if err != nil {
+		err = fmt.Errorf("drain connections: %w", err)
+		_ = a.server.Close()
+	}
+	<-serveErr
+
+	stopWorkers()
+	stopped := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		err = errors.Join(err, fmt.Errorf("stop workers: %w", shutdownCtx.Err()))
+	}
+	return err
+}
@@ -0,0 +1,121 @@
+package app_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"contactsAI/contacts/internal/app"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+	var lc net.ListenConfig
+	listener, err := lc.Listen(t.Context(), "tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	return listener
+}
+
+func TestServeDrainsRequestsAndStopsWorkers(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		_, _ = io.WriteString(w, "done")
+	})
+
+	workerStopped := make(chan struct{})
+	application := app.New(slog.New(slog.DiscardHandler), handler, time.Second, 0)
+	application.AddWorker("test", func(ctx context.Context) error {
+		<-ctx.Done()
+		close(workerStopped)
+		return ctx.Err()
+	})
+
+	shutdownCalled := false
+	application.OnShutdown(func() { shutdownCalled = true })
+
+	ctx, cancel := context.WithCancel(t.Context())
+	listener := listen(t)
+	served := make(chan error, 1)
+	go func() { served <- application.Serve(ctx, listener) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://"+listener.Addr().String(), nil)
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		responses <- result{body: string(body), err: err}
+	}()
+
+	<-started
+	cancel()
+
+	res := <-responses
+	require.NoError(t, res.err)
+	assert.Equal(t, "done", res.body, "in-flight requests complete during shutdown")
+	require.NoError(t, <-served)
+	assert.True(t, shutdownCalled)
+	select {
+	case <-workerStopped:
+	default:
+		t.Fatal("worker was not stopped")
+	}
+}
+
+func TestServeKeepsServingDuringTheDrainDelay(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { _, _ = io.WriteString(w, "ok") })
+	application := app.New(slog.New(slog.DiscardHandler), handler, time.Second, 200*time.Millisecond)
+	draining := make(chan struct{})
+	application.OnShutdown(func() { close(draining) })
+
+	ctx, cancel := context.WithCancel(t.Context())
+	listener := listen(t)
+	served := make(chan error, 1)
+	go func() { served <- application.Serve(ctx, listener) }()
+
+	cancel()
+	<-draining
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://"+listener.Addr().String(), nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "new connections are accepted after the shutdown hooks ran")
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, <-served)
+}
+
+func TestServeReportsShutdownTimeout(t *testing.T) {
+	handler := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	application := app.New(slog.New(slog.DiscardHandler), handler, 50*time.Millisecond, 0)
+	application.AddWorker("stuck", func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	err := application.Serve(ctx, listen(t))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
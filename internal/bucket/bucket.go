@@ -6,64 +6,103 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
+	"net/http"
+	"time"
 
 	aws "github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
+// Store is the BlobStore backed by an S3-compatible bucket.
 type Store struct {
 	Client *s3.Client
 	Bucket string
 }
 
-// OpenFromEnv initializes an S3 client pointed at OCI Object Storage S3-compatible endpoint.
-func OpenFromEnv(ctx context.Context) (*Store, error) {
-	endpoint := os.Getenv("OCI_S3_ENDPOINT")
-	region := os.Getenv("OCI_S3_REGION")
-	accessKey := os.Getenv("OCI_S3_ACCESS_KEY")
-	secretKey := os.Getenv("OCI_S3_SECRET_KEY")
-	bucket := os.Getenv("OCI_BUCKET_NAME")
-
-	areEnvsEmpty := endpoint == "" || region == "" || accessKey == "" || secretKey == "" || bucket == ""
+// S3Config describes an S3-compatible endpoint such as OCI Object Storage or MinIO.
+// Endpoint may be empty to use the AWS default for Region.
+type S3Config struct {
+	Endpoint     string
+	Region       string
+	AccessKey    string
+	SecretKey    string
+	Bucket       string
+	UsePathStyle bool
+}
 
-	if areEnvsEmpty {
-		return nil, errors.New("missing required env vars for S3-compatible OCI: endpoint/region/credentials/bucket")
+// NewS3Store initializes an S3 client for the given S3-compatible endpoint.
+func NewS3Store(ctx context.Context, cfg S3Config) (*Store, error) {
+	if cfg.Region == "" || cfg.AccessKey == "" || cfg.SecretKey == "" || cfg.Bucket == "" {
+		return nil, errors.New("missing required config for S3-compatible storage: region/credentials/bucket")
 	}
 
 	// Load a minimal AWS config with static credentials.
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithRegion(region),
-		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(accessKey, secretKey, "")),
+	awsCfg, err := config.LoadDefaultConfig(ctx,
+		config.WithRegion(cfg.Region),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, "")),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("load aws config: %w", err)
 	}
 
-	client := s3.NewFromConfig(cfg,
+	client := s3.NewFromConfig(awsCfg,
 		func(o *s3.Options) {
-			o.BaseEndpoint = aws.String("https://fro5sh7tqrh3.compat.objectstorage.eu-frankfurt-1.oraclecloud.com")
-			o.UsePathStyle = true
+			if cfg.Endpoint != "" {
+				o.BaseEndpoint = aws.String(cfg.Endpoint)
+			}
+			o.UsePathStyle = cfg.UsePathStyle
 		},
-		s3.WithSigV4SigningRegion("eu-frankfurt-1"),
+		s3.WithSigV4SigningRegion(cfg.Region),
 		s3.WithSigV4SigningName("s3"),
 	)
 
-	return &Store{Client: client, Bucket: bucket}, nil
+	return &Store{Client: client, Bucket: cfg.Bucket}, nil
 }
 
-// Upload writes bytes as an object to the OCI bucket via S3 API.
-func (s *Store) Upload(ctx context.Context, key string, data []byte, contentType string) error {
+const (
+	bytesPerMiB = 1024 * 1024
+	// PartSize is the size of each part of a multipart upload. S3 requires every
+	// part except the last one to be at least 5 MiB.
+	PartSize = 5 * bytesPerMiB
+	// MaxPutSize is the largest object S3 accepts in a single PutObject call.
+	MaxPutSize = 5 * 1024 * bytesPerMiB
+)
+
+// Upload streams body as an object to the OCI bucket via S3 API.
+// Bodies of a known size up to MaxPutSize are streamed with a single PutObject
+// call without being buffered. Bodies of UnknownSize that fit in PartSize are
+// buffered and sent the same way; larger ones use a multipart upload so that
+// at most one part is held in memory.
+func (s *Store) Upload(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
 	if s == nil || s.Client == nil {
 		return errors.New("nil store/client")
 	}
+	if size >= 0 && size <= MaxPutSize {
+		return s.putObject(ctx, key, body, size, contentType)
+	}
+
+	first := make([]byte, PartSize)
+	n, err := io.ReadFull(body, first)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return s.putObject(ctx, key, bytes.NewReader(first[:n]), int64(n), contentType)
+	}
+	if err != nil {
+		return err
+	}
+	return s.multipartUpload(ctx, key, io.MultiReader(bytes.NewReader(first), body), contentType)
+}
+
+func (s *Store) putObject(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
 	//nolint: exhaustruct // not necessary
 	input := &s3.PutObjectInput{
-		Bucket: aws.String(s.Bucket),
-		Key:    aws.String(key),
-		Body:   bytes.NewReader(data),
+		Bucket:        aws.String(s.Bucket),
+		Key:           aws.String(key),
+		Body:          body,
+		ContentLength: aws.Int64(size),
 	}
 	if contentType != "" {
 		input.ContentType = aws.String(contentType)
@@ -72,6 +111,78 @@ func (s *Store) Upload(ctx context.Context, key string, data []byte, contentType
 	return err
 }
 
+func (s *Store) multipartUpload(ctx context.Context, key string, body io.Reader, contentType string) error {
+	//nolint: exhaustruct // not necessary
+	input := &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	upload, err := s.Client.CreateMultipartUpload(ctx, input)
+	if err != nil {
+		return fmt.Errorf("create multipart upload: %w", err)
+	}
+
+	parts, err := s.uploadParts(ctx, key, upload.UploadId, body)
+	if err != nil {
+		// Use a fresh context so the upload is aborted even if ctx was canceled.
+		//nolint: exhaustruct // not necessary
+		_, abortErr := s.Client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(s.Bucket),
+			Key:      aws.String(key),
+			UploadId: upload.UploadId,
+		})
+		return errors.Join(err, abortErr)
+	}
+
+	//nolint: exhaustruct // not necessary
+	_, err = s.Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.Bucket),
+		Key:             aws.String(key),
+		UploadId:        upload.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return fmt.Errorf("complete multipart upload: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) uploadParts(ctx context.Context, key string, uploadID *string, body io.Reader) ([]types.CompletedPart, error) {
+	var parts []types.CompletedPart
+	buf := make([]byte, PartSize)
+	for partNumber := int32(1); ; partNumber++ {
+		n, readErr := io.ReadFull(body, buf)
+		if n == 0 && errors.Is(readErr, io.EOF) {
+			return parts, nil
+		}
+		if readErr != nil && !errors.Is(readErr, io.ErrUnexpectedEOF) {
+			return nil, readErr
+		}
+
+		//nolint: exhaustruct // not necessary
+		out, err := s.Client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:        aws.String(s.Bucket),
+			Key:           aws.String(key),
+			UploadId:      uploadID,
+			PartNumber:    aws.Int32(partNumber),
+			Body:          bytes.NewReader(buf[:n]),
+			ContentLength: aws.Int64(int64(n)),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("upload part %d: %w", partNumber, err)
+		}
+		//nolint: exhaustruct // not necessary
+		parts = append(parts, types.CompletedPart{ETag: out.ETag, PartNumber: aws.Int32(partNumber)})
+
+		if readErr != nil {
+			return parts, nil
+		}
+	}
+}
+
 // Download retrieves an object and returns its bytes. Helper for future usage.
 func (s *Store) Download(ctx context.Context, key string) ([]byte, error) {
 	if s == nil || s.Client == nil {
@@ -92,18 +203,161 @@ func (s *Store) Download(ctx context.Context, key string) ([]byte, error) {
 
 // GetStream retrieves an object handle from S3 for streaming.
 // The caller is responsible for closing the returned object's Body.
-func (s *Store) GetStream(ctx context.Context, key string) (*s3.GetObjectOutput, error) {
+// ErrNotModified and ErrInvalidRange are returned when opts preconditions
+// are not met.
+func (s *Store) GetStream(ctx context.Context, key string, opts GetOptions) (*Object, error) {
 	if s == nil || s.Client == nil {
 		return nil, errors.New("nil store/client")
 	}
 
 	//nolint: exhaustruct // not necessary
-	out, err := s.Client.GetObject(ctx, &s3.GetObjectInput{
+	input := &s3.GetObjectInput{
 		Bucket: aws.String(s.Bucket),
 		Key:    aws.String(key),
-	})
+	}
+	if opts.IfNoneMatch != "" {
+		input.IfNoneMatch = aws.String(opts.IfNoneMatch)
+	}
+	if !opts.IfModifiedSince.IsZero() {
+		input.IfModifiedSince = aws.Time(opts.IfModifiedSince)
+	}
+	if opts.Range != "" {
+		input.Range = aws.String(opts.Range)
+	}
+
+	out, err := s.Client.GetObject(ctx, input)
+	if err != nil {
+		return nil, mapObjectError(err)
+	}
+	return &Object{
+		ObjectInfo: ObjectInfo{
+			Key:          key,
+			ContentType:  aws.ToString(out.ContentType),
+			Size:         aws.ToInt64(out.ContentLength),
+			ETag:         aws.ToString(out.ETag),
+			LastModified: aws.ToTime(out.LastModified),
+		},
+		ContentRange: aws.ToString(out.ContentRange),
+		Body:         out.Body,
+	}, nil
+}
+
+// PresignPut returns a presigned PUT request that only accepts a body of the given
+// content type and exact size.
+func (s *Store) PresignPut(
+	ctx context.Context, key, contentType string, size int64, ttl time.Duration,
+) (*PresignedRequest, error) {
+	if s == nil || s.Client == nil {
+		return nil, errors.New("nil store/client")
+	}
+	//nolint: exhaustruct // not necessary
+	req, err := s3.NewPresignClient(s.Client).PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.Bucket),
+		Key:           aws.String(key),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(size),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return nil, fmt.Errorf("presign put: %w", err)
+	}
+	return toPresignedRequest(req.URL, req.Method, req.SignedHeader, ttl), nil
+}
+
+// PresignGet returns a presigned GET request for downloading an object.
+func (s *Store) PresignGet(ctx context.Context, key string, ttl time.Duration) (*PresignedRequest, error) {
+	if s == nil || s.Client == nil {
+		return nil, errors.New("nil store/client")
+	}
+	//nolint: exhaustruct // not necessary
+	req, err := s3.NewPresignClient(s.Client).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(ttl))
 	if err != nil {
-		return nil, err // Return the S3 error directly
+		return nil, fmt.Errorf("presign get: %w", err)
+	}
+	return toPresignedRequest(req.URL, req.Method, req.SignedHeader, ttl), nil
+}
+
+// Head returns object metadata without downloading it. ErrNotFound is returned
+// when the object does not exist. Only the conditional fields of opts apply.
+func (s *Store) Head(ctx context.Context, key string, opts GetOptions) (*ObjectInfo, error) {
+	if s == nil || s.Client == nil {
+		return nil, errors.New("nil store/client")
+	}
+	//nolint: exhaustruct // not necessary
+	input := &s3.HeadObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+	}
+	if opts.IfNoneMatch != "" {
+		input.IfNoneMatch = aws.String(opts.IfNoneMatch)
+	}
+	if !opts.IfModifiedSince.IsZero() {
+		input.IfModifiedSince = aws.Time(opts.IfModifiedSince)
+	}
+
+	out, err := s.Client.HeadObject(ctx, input)
+	if err != nil {
+		return nil, mapObjectError(err)
+	}
+	return &ObjectInfo{
+		Key:          key,
+		ContentType:  aws.ToString(out.ContentType),
+		Size:         aws.ToInt64(out.ContentLength),
+		ETag:         aws.ToString(out.ETag),
+		LastModified: aws.ToTime(out.LastModified),
+	}, nil
+}
+
+// Delete removes an object. S3 reports success for missing keys as well.
+func (s *Store) Delete(ctx context.Context, key string) error {
+	if s == nil || s.Client == nil {
+		return errors.New("nil store/client")
+	}
+	//nolint: exhaustruct // not necessary
+	_, err := s.Client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(s.Bucket), Key: aws.String(key)})
+	return err
+}
+
+// Ping checks that the bucket exists and the credentials can access it.
+func (s *Store) Ping(ctx context.Context) error {
+	if s == nil || s.Client == nil {
+		return errors.New("nil store/client")
+	}
+	_, err := s.Client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket:              aws.String(s.Bucket),
+		ExpectedBucketOwner: nil,
+	})
+	return err
+}
+
+// mapObjectError translates S3 responses that callers act on into sentinel errors.
+func mapObjectError(err error) error {
+	var noSuchKey *types.NoSuchKey
+	var notFound *types.NotFound
+	if errors.As(err, &noSuchKey) || errors.As(err, &notFound) {
+		return ErrNotFound
+	}
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) {
+		switch respErr.HTTPStatusCode() {
+		case http.StatusNotModified:
+			return ErrNotModified
+		case http.StatusRequestedRangeNotSatisfiable:
+			return ErrInvalidRange
+		case http.StatusNotFound:
+			return ErrNotFound
+		}
+	}
+	return err
+}
+
+func toPresignedRequest(url, method string, headers http.Header, ttl time.Duration) *PresignedRequest {
+	return &PresignedRequest{
+		URL:       url,
+		Method:    method,
+		Headers:   headers,
+		ExpiresAt: time.Now().Add(ttl),
 	}
-	return out, nil
 }
@@ -0,0 +1,214 @@
+package bucket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	fsDirPerm   = 0o750
+	fsFilePerm  = 0o600
+	metaFileExt = ".meta.json"
+	tmpFileGlob = ".upload-*"
+)
+
+// fsMeta is stored next to every object since files do not carry a content type.
+type fsMeta struct {
+	ContentType string `json:"content_type"`
+	ETag        string `json:"etag"`
+}
+
+// FSStore keeps objects as files below a root directory, using the object key
+// as the relative path.
+type FSStore struct {
+	root string
+}
+
+func NewFSStore(root string) (*FSStore, error) {
+	if root == "" {
+		return nil, errors.New("missing root directory for filesystem storage")
+	}
+	if err := os.MkdirAll(root, fsDirPerm); err != nil {
+		return nil, fmt.Errorf("create storage root: %w", err)
+	}
+	return &FSStore{root: root}, nil
+}
+
+// Upload writes body and its metadata to temporary files and renames them into
+// place, the data first, so readers never observe a partially written object
+// and a crash never leaves metadata without its object.
+func (s *FSStore) Upload(ctx context.Context, key string, body io.Reader, _ int64, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err = os.MkdirAll(dir, fsDirPerm); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, tmpFileGlob)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	etag := newETagHash()
+	_, err = io.Copy(io.MultiWriter(tmp, etag), body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return err
+	}
+
+	meta, err := json.Marshal(fsMeta{ContentType: contentType, ETag: formatETag(etag)})
+	if err != nil {
+		return err
+	}
+	tmpMeta, err := os.CreateTemp(dir, tmpFileGlob)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpMeta.Name())
+	_, err = tmpMeta.Write(meta)
+	if closeErr := tmpMeta.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return os.Rename(tmpMeta.Name(), path+metaFileExt)
+}
+
+func (s *FSStore) GetStream(_ context.Context, key string, opts GetOptions) (*Object, error) {
+	path, info, err := s.stat(key, opts)
+	if err != nil {
+		return nil, err
+	}
+	start, length, partial, err := resolveRange(opts.Range, info.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path) //nolint:gosec // path is validated to stay below the root
+	if err != nil {
+		return nil, mapFSError(err)
+	}
+	result := &Object{
+		ObjectInfo:   *info,
+		ContentRange: "",
+		Body: struct {
+			io.Reader
+			io.Closer
+		}{io.NewSectionReader(file, start, length), file},
+	}
+	if partial {
+		result.Size = length
+		result.ContentRange = formatContentRange(start, length, info.Size)
+	}
+	return result, nil
+}
+
+func (s *FSStore) Head(_ context.Context, key string, opts GetOptions) (*ObjectInfo, error) {
+	_, info, err := s.stat(key, opts)
+	return info, err
+}
+
+// Delete removes the object before its metadata, so that a partial delete
+// leaves no object without metadata behind.
+func (s *FSStore) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{path, path + metaFileExt} {
+		if err = os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FSStore) PresignPut(context.Context, string, string, int64, time.Duration) (*PresignedRequest, error) {
+	return nil, ErrPresignNotSupported
+}
+
+func (s *FSStore) PresignGet(context.Context, string, time.Duration) (*PresignedRequest, error) {
+	return nil, ErrPresignNotSupported
+}
+
+// Ping checks that the root directory is still present.
+func (s *FSStore) Ping(context.Context) error {
+	info, err := os.Stat(s.root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("storage root %s is not a directory", s.root)
+	}
+	return nil
+}
+
+// path maps key below the root. Keys ending in metaFileExt are rejected, since
+// they would overwrite the metadata of another object.
+func (s *FSStore) path(key string) (string, error) {
+	rel := filepath.FromSlash(key)
+	if !filepath.IsLocal(rel) || strings.HasSuffix(key, metaFileExt) {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(s.root, rel), nil
+}
+
+func (s *FSStore) stat(key string, opts GetOptions) (string, *ObjectInfo, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return "", nil, err
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return "", nil, mapFSError(err)
+	}
+	raw, err := os.ReadFile(path + metaFileExt) //nolint:gosec // path is validated to stay below the root
+	if err != nil {
+		return "", nil, mapFSError(err)
+	}
+	var meta fsMeta
+	if err = json.Unmarshal(raw, &meta); err != nil {
+		return "", nil, fmt.Errorf("read metadata for %q: %w", key, err)
+	}
+
+	info := &ObjectInfo{
+		Key:          key,
+		ContentType:  meta.ContentType,
+		Size:         stat.Size(),
+		ETag:         meta.ETag,
+		LastModified: stat.ModTime().UTC(),
+	}
+	if err = checkConditions(info, opts); err != nil {
+		return "", nil, err
+	}
+	return path, info, nil
+}
+
+func mapFSError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,79 @@
+package bucket
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrNotFound     = errors.New("object not found")
+	ErrNotModified  = errors.New("object not modified")
+	ErrInvalidRange = errors.New("requested range not satisfiable")
+	// ErrPresignNotSupported is returned by backends that clients cannot reach directly.
+	ErrPresignNotSupported = errors.New("presigned URLs are not supported by this storage backend")
+)
+
+// UnknownSize may be passed to Upload when the body length is not known upfront.
+const UnknownSize = -1
+
+// BlobStore stores avatar objects. Store (S3-compatible), FSStore and
+// MemoryStore implement it.
+type BlobStore interface {
+	// Upload writes body under key. size may be UnknownSize.
+	Upload(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// GetStream opens an object for reading. The caller must close the Body.
+	GetStream(ctx context.Context, key string, opts GetOptions) (*Object, error)
+	// Head returns object metadata, honoring the conditional fields of opts.
+	Head(ctx context.Context, key string, opts GetOptions) (*ObjectInfo, error)
+	// Delete removes an object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	PresignPut(ctx context.Context, key, contentType string, size int64, ttl time.Duration) (*PresignedRequest, error)
+	PresignGet(ctx context.Context, key string, ttl time.Duration) (*PresignedRequest, error)
+	// Ping checks that the storage is reachable.
+	Ping(ctx context.Context) error
+}
+
+var (
+	_ BlobStore = (*Store)(nil)
+	_ BlobStore = (*FSStore)(nil)
+	_ BlobStore = (*MemoryStore)(nil)
+)
+
+// GetOptions are passed through to S3 so that unchanged objects and bytes
+// outside of the requested range are not transferred.
+type GetOptions struct {
+	IfNoneMatch     string
+	IfModifiedSince time.Time
+	// Range is an HTTP Range header value, e.g. "bytes=0-1023".
+	Range string
+}
+
+// Object is a streamed object with its metadata. ContentRange is set when
+// only a part of the object was requested.
+type Object struct {
+	ObjectInfo
+
+	ContentRange string
+	Body         io.ReadCloser
+}
+
+// PresignedRequest is a time-limited request a client can send directly to the bucket.
+// Headers must be sent unchanged, since they are part of the signature.
+type PresignedRequest struct {
+	URL       string
+	Method    string
+	Headers   http.Header
+	ExpiresAt time.Time
+}
+
+// ObjectInfo holds the metadata returned by HeadObject.
+type ObjectInfo struct {
+	Key          string
+	ContentType  string
+	Size         int64
+	ETag         string
+	LastModified time.Time
+}
@@ -0,0 +1,117 @@
+package bucket_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"contactsAI/contacts/internal/bucket"
+
+	aws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 answers the object and multipart calls made by Store.Upload and
+// records them as "PutObject", "CreateMultipartUpload", "UploadPart" and
+// "CompleteMultipartUpload".
+type fakeS3 struct {
+	mu      sync.Mutex
+	calls   []string
+	lengths []string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_, _ = io.Copy(io.Discard, r.Body)
+	query := r.URL.Query()
+	var call string
+	switch {
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		call = "UploadPart"
+		w.Header().Set("ETag", `"part-`+query.Get("partNumber")+`"`)
+	case r.Method == http.MethodPut:
+		call = "PutObject"
+		w.Header().Set("ETag", `"object"`)
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		call = "CreateMultipartUpload"
+		fmt.Fprint(w, `<InitiateMultipartUploadResult><Bucket>avatars</Bucket><Key>a</Key>`+
+			`<UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`)
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		call = "CompleteMultipartUpload"
+		fmt.Fprint(w, `<CompleteMultipartUploadResult><Bucket>avatars</Bucket><Key>a</Key>`+
+			`<ETag>"object"</ETag></CompleteMultipartUploadResult>`)
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+	length := r.Header.Get("X-Amz-Decoded-Content-Length")
+	if length == "" {
+		length = r.Header.Get("Content-Length")
+	}
+	f.lengths = append(f.lengths, length)
+}
+
+func newFakeS3Store(t *testing.T) (*bucket.Store, *fakeS3) {
+	t.Helper()
+	fake := &fakeS3{}
+	server := httptest.NewTLSServer(fake)
+	t.Cleanup(server.Close)
+
+	//nolint:exhaustruct // defaults
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		HTTPClient:   server.Client(),
+		Credentials:  credentials.NewStaticCredentialsProvider("key", "secret", ""),
+	})
+	return &bucket.Store{Client: client, Bucket: "avatars"}, fake
+}
+
+// onlyReader hides any Seek or WriterTo method of the wrapped reader, like a
+// request body does.
+type onlyReader struct{ io.Reader }
+
+func TestS3UploadStreamsBodiesOfKnownSize(t *testing.T) {
+	store, fake := newFakeS3Store(t)
+	body := bytes.Repeat([]byte("a"), bucket.PartSize+bucket.PartSize/2)
+
+	err := store.Upload(context.Background(), "a", onlyReader{bytes.NewReader(body)}, int64(len(body)), "image/png")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PutObject"}, fake.calls)
+	assert.Equal(t, []string{fmt.Sprint(len(body))}, fake.lengths)
+}
+
+func TestS3UploadOfUnknownSize(t *testing.T) {
+	t.Run("small bodies are put at once", func(t *testing.T) {
+		store, fake := newFakeS3Store(t)
+		err := store.Upload(context.Background(), "a", onlyReader{strings.NewReader("hello")}, bucket.UnknownSize, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"PutObject"}, fake.calls)
+		assert.Equal(t, []string{"5"}, fake.lengths)
+	})
+
+	t.Run("large bodies are uploaded in parts", func(t *testing.T) {
+		store, fake := newFakeS3Store(t)
+		body := bytes.Repeat([]byte("a"), bucket.PartSize+bucket.PartSize/2)
+		err := store.Upload(context.Background(), "a", onlyReader{bytes.NewReader(body)}, bucket.UnknownSize, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"CreateMultipartUpload", "UploadPart", "UploadPart", "CompleteMultipartUpload",
+		}, fake.calls)
+		assert.Equal(t, fmt.Sprint(bucket.PartSize), fake.lengths[1])
+		assert.Equal(t, fmt.Sprint(bucket.PartSize/2), fake.lengths[2])
+	})
+}
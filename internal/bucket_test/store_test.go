@@ -0,0 +1,150 @@
+package bucket_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"contactsAI/contacts/internal/bucket"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func backends(t *testing.T) map[string]bucket.BlobStore {
+	t.Helper()
+	fsStore, err := bucket.NewFSStore(t.TempDir())
+	require.NoError(t, err)
+	return map[string]bucket.BlobStore{
+		"memory": bucket.NewMemoryStore(),
+		"fs":     fsStore,
+	}
+}
+
+func readAll(t *testing.T, object *bucket.Object) string {
+	t.Helper()
+	defer object.Body.Close()
+	data, err := io.ReadAll(object.Body)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestBlobStores(t *testing.T) {
+	ctx := context.Background()
+	const key = "avatars/1/abc"
+	const content = "0123456789"
+
+	for name, store := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, store.Upload(ctx, key, strings.NewReader(content), bucket.UnknownSize, "image/png"))
+
+			object, err := store.GetStream(ctx, key, bucket.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, "image/png", object.ContentType)
+			assert.Equal(t, int64(len(content)), object.Size)
+			assert.NotEmpty(t, object.ETag)
+			assert.Empty(t, object.ContentRange)
+			assert.Equal(t, content, readAll(t, object))
+
+			info, err := store.Head(ctx, key, bucket.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, object.ETag, info.ETag)
+
+			_, err = store.GetStream(ctx, key, bucket.GetOptions{IfNoneMatch: info.ETag})
+			require.ErrorIs(t, err, bucket.ErrNotModified)
+
+			_, err = store.Head(ctx, key, bucket.GetOptions{IfModifiedSince: time.Now().Add(time.Minute)})
+			require.ErrorIs(t, err, bucket.ErrNotModified)
+
+			_, err = store.GetStream(ctx, "avatars/1/missing", bucket.GetOptions{})
+			require.ErrorIs(t, err, bucket.ErrNotFound)
+
+			_, err = store.PresignGet(ctx, key, time.Minute)
+			require.ErrorIs(t, err, bucket.ErrPresignNotSupported)
+
+			require.NoError(t, store.Delete(ctx, key))
+			_, err = store.Head(ctx, key, bucket.GetOptions{})
+			require.ErrorIs(t, err, bucket.ErrNotFound)
+			require.NoError(t, store.Delete(ctx, key), "missing objects are deleted too")
+		})
+	}
+}
+
+func TestBlobStoreRanges(t *testing.T) {
+	ctx := context.Background()
+	const key = "avatars/2/abc"
+
+	tests := []struct {
+		rangeHeader  string
+		content      string
+		contentRange string
+	}{
+		{rangeHeader: "bytes=2-4", content: "234", contentRange: "bytes 2-4/10"},
+		{rangeHeader: "bytes=7-", content: "789", contentRange: "bytes 7-9/10"},
+		{rangeHeader: "bytes=-2", content: "89", contentRange: "bytes 8-9/10"},
+		{rangeHeader: "bytes=8-100", content: "89", contentRange: "bytes 8-9/10"},
+		{rangeHeader: "bytes=0-1,4-5", content: "0123456789", contentRange: ""},
+	}
+
+	for name, store := range backends(t) {
+		require.NoError(t, store.Upload(ctx, key, strings.NewReader("0123456789"), 10, "image/png"))
+		for _, tt := range tests {
+			t.Run(name+" "+tt.rangeHeader, func(t *testing.T) {
+				object, err := store.GetStream(ctx, key, bucket.GetOptions{Range: tt.rangeHeader})
+				require.NoError(t, err)
+				assert.Equal(t, tt.contentRange, object.ContentRange)
+				assert.Equal(t, int64(len(tt.content)), object.Size)
+				assert.Equal(t, tt.content, readAll(t, object))
+			})
+		}
+
+		_, err := store.GetStream(ctx, key, bucket.GetOptions{Range: "bytes=10-"})
+		require.ErrorIs(t, err, bucket.ErrInvalidRange)
+	}
+}
+
+func TestFSStoreRejectsKeysOutsideRoot(t *testing.T) {
+	store, err := bucket.NewFSStore(t.TempDir())
+	require.NoError(t, err)
+
+	err = store.Upload(context.Background(), "../escape", strings.NewReader("x"), 1, "text/plain")
+	require.Error(t, err)
+}
+
+func TestFSStoreRejectsMetadataKeys(t *testing.T) {
+	store, err := bucket.NewFSStore(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, store.Upload(ctx, "avatars/1/a", strings.NewReader("x"), 1, "text/plain"))
+
+	err = store.Upload(ctx, "avatars/1/a.meta.json", strings.NewReader("{}"), 2, "application/json")
+	require.Error(t, err)
+	info, err := store.Head(ctx, "avatars/1/a", bucket.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", info.ContentType, "the metadata is intact")
+}
+
+func TestFSStoreLeavesOnlyTheObjectAndItsMetadata(t *testing.T) {
+	root := t.TempDir()
+	store, err := bucket.NewFSStore(root)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, store.Upload(ctx, "a", strings.NewReader("first"), 5, "text/plain"))
+	require.NoError(t, store.Upload(ctx, "a", strings.NewReader("second"), 6, "image/png"))
+
+	entries, err := os.ReadDir(root)
+	require.NoError(t, err)
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	assert.ElementsMatch(t, []string{"a", "a.meta.json"}, names)
+
+	object, err := store.GetStream(ctx, "a", bucket.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", object.ContentType)
+	assert.Equal(t, "second", readAll(t, object))
+}
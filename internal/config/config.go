@@ -0,0 +1,910 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"contactsAI/contacts/internal/logging"
+	"contactsAI/contacts/internal/ratelimit"
+	"contactsAI/contacts/internal/reminders"
+	"contactsAI/contacts/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-yaml"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pelletier/go-toml/v2"
+)
+
+const (
+	StorageS3     = "s3"
+	StorageFS     = "fs"
+	StorageMemory = "memory"
+
+	RateLimitStoreMemory   = "memory"
+	RateLimitStorePostgres = "postgres"
+
+	defaultPort            = 33500
+	maxPort                = 65535
+	defaultStartupTimeout  = time.Minute
+	defaultShutdownTimeout = 30 * time.Second
+	defaultDrainDelay      = 5 * time.Second
+	defaultMetricsInterval = time.Minute
+	defaultCORSMaxAge      = 12 * time.Hour
+	defaultHSTSMaxAge      = 365 * 24 * time.Hour
+	defaultReminderCheck   = time.Minute
+	defaultReminderHorizon = 30 * 24 * time.Hour
+	defaultSMTPPort        = 587
+
+	// configFileEnv names the configuration file when --config is not given.
+	configFileEnv = "CONFIG_FILE"
+	redacted      = "[REDACTED]"
+)
+
+// Secret is a string that is redacted whenever it is printed, logged or marshaled.
+// Use Value to get the actual secret.
+type Secret string
+
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Secret) UnmarshalText(text []byte) error {
+	*s = Secret(text)
+	return nil
+}
+
+// Duration is a time.Duration written as a string such as "30s" in files,
+// environment variables and flags.
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// Config is the complete service configuration. Values are resolved from, in
+// increasing order of precedence: defaults, the configuration file, environment
+// variables and command line flags.
+type Config struct {
+	Server    ServerConfig    `yaml:"server"     toml:"server"`
+	Database  DatabaseConfig  `yaml:"database"   toml:"database"`
+	Storage   StorageConfig   `yaml:"storage"    toml:"storage"`
+	Avatars   AvatarsConfig   `yaml:"avatars"    toml:"avatars"`
+	Metrics   MetricsConfig   `yaml:"metrics"    toml:"metrics"`
+	Tracing   TracingConfig   `yaml:"tracing"    toml:"tracing"`
+	Log       LogConfig       `yaml:"log"        toml:"log"`
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	Auth      AuthConfig      `yaml:"auth"       toml:"auth"`
+	CORS      CORSConfig      `yaml:"cors"       toml:"cors"`
+	Security  SecurityConfig  `yaml:"security"   toml:"security"`
+	Reminders RemindersConfig `yaml:"reminders"  toml:"reminders"`
+}
+
+type ServerConfig struct {
+	Port int `yaml:"port" toml:"port"`
+	// Mode is the gin mode: debug, release or test.
+	Mode string `yaml:"mode" toml:"mode"`
+	// StartupTimeout bounds how long unreachable dependencies are retried on start.
+	StartupTimeout Duration `yaml:"startup_timeout" toml:"startup_timeout"`
+	// ShutdownTimeout bounds draining requests and stopping workers on shutdown.
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// DrainDelay is how long readiness fails on shutdown before connections
+	// are closed, so that load balancers stop sending requests first.
+	DrainDelay Duration `yaml:"drain_delay" toml:"drain_delay"`
+	// TrustedProxies are the IPs and CIDRs whose X-Forwarded-For and X-Real-IP
+	// headers are believed when determining the client IP.
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies"`
+}
+
+type DatabaseConfig struct {
+	URL Secret `yaml:"url" toml:"url"`
+	// AutoMigrate applies pending migrations on start instead of requiring
+	// the migrate subcommand.
+	AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate"`
+}
+
+type StorageConfig struct {
+	// Backend selects the blob storage: s3, fs or memory.
+	Backend string   `yaml:"backend" toml:"backend"`
+	FSRoot  string   `yaml:"fs_root" toml:"fs_root"`
+	S3      S3Config `yaml:"s3"      toml:"s3"`
+}
+
+// S3Config describes an S3-compatible endpoint such as OCI Object Storage or MinIO.
+// Endpoint may be empty to use the AWS default for Region.
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint"   toml:"endpoint"`
+	Region    string `yaml:"region"     toml:"region"`
+	AccessKey string `yaml:"access_key" toml:"access_key"`
+	SecretKey Secret `yaml:"secret_key" toml:"secret_key"`
+	Bucket    string `yaml:"bucket"     toml:"bucket"`
+	PathStyle bool   `yaml:"path_style" toml:"path_style"`
+}
+
+type AvatarsConfig struct {
+	// CacheControl is the Cache-Control policy sent with avatar downloads.
+	CacheControl string `yaml:"cache_control" toml:"cache_control"`
+}
+
+type MetricsConfig struct {
+	// RefreshInterval is how often the business gauges are read from the database.
+	RefreshInterval Duration `yaml:"refresh_interval" toml:"refresh_interval"`
+}
+
+type TracingConfig struct {
+	// Exporter selects where spans are sent: none, stdout or otlp.
+	Exporter string `yaml:"exporter" toml:"exporter"`
+	// Endpoint is the OTLP/HTTP collector address, e.g. "otel-collector:4318".
+	// When empty the standard OTEL_EXPORTER_OTLP_* variables apply.
+	Endpoint string `yaml:"endpoint" toml:"endpoint"`
+	// Insecure sends spans to the collector over plain HTTP.
+	Insecure bool `yaml:"insecure" toml:"insecure"`
+	// SampleRatio is the fraction of new traces that are recorded, from 0 to 1.
+	// Incoming traces keep the sampling decision of their parent.
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+	ServiceName string  `yaml:"service_name" toml:"service_name"`
+}
+
+type LogConfig struct {
+	// Format is text or json.
+	Format string `yaml:"format" toml:"format"`
+	// Level is the initial minimum level; it can be changed at runtime through
+	// the admin API.
+	Level slog.Level `yaml:"level" toml:"level"`
+}
+
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Store keeps the token buckets: memory for each replica on its own, or
+	// postgres to share the limits between replicas.
+	Store string `yaml:"store" toml:"store"`
+	// Default applies to every API route without a stricter limit.
+	Default ratelimit.Limit `yaml:"default" toml:"default"`
+	// Uploads applies to avatar uploads.
+	Uploads ratelimit.Limit `yaml:"uploads" toml:"uploads"`
+	// IP applies to every API and admin request of a client IP, before its API
+	// key is checked, so that keys cannot be guessed faster.
+	IP ratelimit.Limit `yaml:"ip" toml:"ip"`
+}
+
+type AuthConfig struct {
+	// Required rejects API requests without an API key. Admin routes always
+	// require a key with the admin scope.
+	Required bool `yaml:"required" toml:"required"`
+}
+
+// CORSConfig lists the browser origins allowed to call the API. No origins
+// disables cross-origin requests.
+type CORSConfig struct {
+	// AllowedOrigins are origins like "https://app.example.com", patterns whose
+	// leftmost label is a wildcard like "https://*.example.com", or "*" for any
+	// origin.
+	AllowedOrigins []string `yaml:"allowed_origins" toml:"allowed_origins"`
+	AllowedMethods []string `yaml:"allowed_methods" toml:"allowed_methods"`
+	AllowedHeaders []string `yaml:"allowed_headers" toml:"allowed_headers"`
+	// ExposedHeaders are the response headers that scripts may read.
+	ExposedHeaders   []string `yaml:"exposed_headers"   toml:"exposed_headers"`
+	AllowCredentials bool     `yaml:"allow_credentials" toml:"allow_credentials"`
+	// MaxAge is how long browsers may cache the result of a preflight request.
+	MaxAge Duration `yaml:"max_age" toml:"max_age"`
+}
+
+// SecurityConfig sets the security headers of every response. Empty values
+// omit the header.
+type SecurityConfig struct {
+	// HSTSMaxAge is the max-age of Strict-Transport-Security; zero omits it.
+	HSTSMaxAge            Duration `yaml:"hsts_max_age"            toml:"hsts_max_age"`
+	HSTSIncludeSubdomains bool     `yaml:"hsts_include_subdomains" toml:"hsts_include_subdomains"`
+	// FrameOptions is DENY or SAMEORIGIN.
+	FrameOptions          string `yaml:"frame_options"           toml:"frame_options"`
+	ReferrerPolicy        string `yaml:"referrer_policy"         toml:"referrer_policy"`
+	ContentSecurityPolicy string `yaml:"content_security_policy" toml:"content_security_policy"`
+	// DocsContentSecurityPolicy replaces ContentSecurityPolicy for the Swagger
+	// UI, which needs to run its own scripts and styles.
+	DocsContentSecurityPolicy string `yaml:"docs_content_security_policy" toml:"docs_content_security_policy"`
+}
+
+type RemindersConfig struct {
+	// Enabled runs the scheduler that notifies due reminders. Replicas elect
+	// one of them to run it.
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Interval is how often due reminders are notified.
+	Interval Duration `yaml:"interval" toml:"interval"`
+	// Horizon is how far ahead birthdays and anniversaries become reminders,
+	// and so how far ahead they are listed as upcoming.
+	Horizon Duration `yaml:"horizon" toml:"horizon"`
+	// Notifiers deliver the notifications: log, webhook and smtp.
+	Notifiers []string      `yaml:"notifiers" toml:"notifiers"`
+	Webhook   WebhookConfig `yaml:"webhook"   toml:"webhook"`
+	SMTP      SMTPConfig    `yaml:"smtp"      toml:"smtp"`
+}
+
+type WebhookConfig struct {
+	URL string `yaml:"url" toml:"url"`
+	// Secret signs the bodies with HMAC-SHA256 in the X-Signature header.
+	Secret Secret `yaml:"secret" toml:"secret"`
+}
+
+// SMTPConfig describes the mail server that sends notifications to the
+// email addresses users set in their reminder settings.
+type SMTPConfig struct {
+	Host string `yaml:"host" toml:"host"`
+	Port int    `yaml:"port" toml:"port"`
+	// Username and Password are left empty for servers without authentication.
+	Username string `yaml:"username" toml:"username"`
+	Password Secret `yaml:"password" toml:"password"`
+	From     string `yaml:"from"     toml:"from"`
+}
+
+// StrictTransportSecurity is the Strict-Transport-Security header value, or
+// empty when HSTS is disabled.
+func (c SecurityConfig) StrictTransportSecurity() string {
+	if c.HSTSMaxAge.Duration <= 0 {
+		return ""
+	}
+	value := "max-age=" + strconv.FormatInt(int64(c.HSTSMaxAge.Seconds()), 10)
+	if c.HSTSIncludeSubdomains {
+		value += "; includeSubDomains"
+	}
+	return value
+}
+
+// Options are command line switches that are not part of the configuration itself.
+type Options struct {
+	File        string
+	PrintConfig bool
+}
+
+// Default returns the configuration used for every value that is not set explicitly.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:            defaultPort,
+			Mode:            gin.DebugMode,
+			StartupTimeout:  Duration{defaultStartupTimeout},
+			ShutdownTimeout: Duration{defaultShutdownTimeout},
+			DrainDelay:      Duration{defaultDrainDelay},
+			TrustedProxies:  nil,
+		},
+		Database: DatabaseConfig{URL: "", AutoMigrate: false},
+		Storage: StorageConfig{
+			Backend: StorageS3,
+			FSRoot:  "",
+			S3: S3Config{
+				Endpoint:  "",
+				Region:    "",
+				AccessKey: "",
+				SecretKey: "",
+				Bucket:    "",
+				PathStyle: true,
+			},
+		},
+		Avatars: AvatarsConfig{CacheControl: "private, max-age=300"},
+		Metrics: MetricsConfig{RefreshInterval: Duration{defaultMetricsInterval}},
+		Tracing: TracingConfig{
+			Exporter:    tracing.ExporterNone,
+			Endpoint:    "",
+			Insecure:    false,
+			SampleRatio: 1,
+			ServiceName: "contacts-service",
+		},
+		Log: LogConfig{Format: logging.FormatText, Level: slog.LevelInfo},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Store:   RateLimitStoreMemory,
+			Default: ratelimit.Limit{Burst: 300, Period: time.Minute},
+			Uploads: ratelimit.Limit{Burst: 20, Period: time.Minute},
+			IP:      ratelimit.Limit{Burst: 600, Period: time.Minute},
+		},
+		Auth: AuthConfig{Required: false},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"http://localhost:5173"},
+			AllowedMethods: []string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS"},
+			AllowedHeaders: []string{"Origin", "Content-Type", "Authorization", "X-API-Key", "X-Request-ID"},
+			ExposedHeaders: []string{
+				"X-Request-ID", "ETag", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset",
+			},
+			AllowCredentials: true,
+			MaxAge:           Duration{defaultCORSMaxAge},
+		},
+		Security: SecurityConfig{
+			HSTSMaxAge:            Duration{defaultHSTSMaxAge},
+			HSTSIncludeSubdomains: false,
+			FrameOptions:          "DENY",
+			ReferrerPolicy:        "no-referrer",
+			ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+			DocsContentSecurityPolicy: "default-src 'self'; script-src 'self' 'unsafe-inline'; " +
+				"style-src 'self' 'unsafe-inline'; img-src 'self' data:; frame-ancestors 'none'",
+		},
+		Reminders: RemindersConfig{
+			Enabled:   true,
+			Interval:  Duration{defaultReminderCheck},
+			Horizon:   Duration{defaultReminderHorizon},
+			Notifiers: []string{reminders.NotifierLog},
+			Webhook:   WebhookConfig{URL: "", Secret: ""},
+			SMTP:      SMTPConfig{Host: "", Port: defaultSMTPPort, Username: "", Password: "", From: ""},
+		},
+	}
+}
+
+// setting binds one configuration value to its file key, environment variable
+// and command line flag.
+type setting struct {
+	key   string
+	env   string
+	flag  string
+	usage string
+	value flag.Value
+}
+
+func (c *Config) settings() []setting {
+	values := flag.NewFlagSet("settings", flag.ContinueOnError)
+	values.IntVar(&c.Server.Port, "port", c.Server.Port, "")
+	values.StringVar(&c.Server.Mode, "mode", c.Server.Mode, "")
+	values.TextVar(&c.Server.StartupTimeout, "startup-timeout", c.Server.StartupTimeout, "")
+	values.TextVar(&c.Server.ShutdownTimeout, "shutdown-timeout", c.Server.ShutdownTimeout, "")
+	values.TextVar(&c.Server.DrainDelay, "drain-delay", c.Server.DrainDelay, "")
+	values.Var(listValue{&c.Server.TrustedProxies}, "trusted-proxies", "")
+	values.TextVar(&c.Database.URL, "db-url", c.Database.URL, "")
+	values.BoolVar(&c.Database.AutoMigrate, "db-auto-migrate", c.Database.AutoMigrate, "")
+	values.StringVar(&c.Storage.Backend, "storage-backend", c.Storage.Backend, "")
+	values.StringVar(&c.Storage.FSRoot, "storage-fs-root", c.Storage.FSRoot, "")
+	values.StringVar(&c.Storage.S3.Endpoint, "s3-endpoint", c.Storage.S3.Endpoint, "")
+	values.StringVar(&c.Storage.S3.Region, "s3-region", c.Storage.S3.Region, "")
+	values.StringVar(&c.Storage.S3.AccessKey, "s3-access-key", c.Storage.S3.AccessKey, "")
+	values.TextVar(&c.Storage.S3.SecretKey, "s3-secret-key", c.Storage.S3.SecretKey, "")
+	values.StringVar(&c.Storage.S3.Bucket, "s3-bucket", c.Storage.S3.Bucket, "")
+	values.BoolVar(&c.Storage.S3.PathStyle, "s3-path-style", c.Storage.S3.PathStyle, "")
+	values.StringVar(&c.Avatars.CacheControl, "avatar-cache-control", c.Avatars.CacheControl, "")
+	values.TextVar(&c.Metrics.RefreshInterval, "metrics-refresh-interval", c.Metrics.RefreshInterval, "")
+	values.StringVar(&c.Tracing.Exporter, "tracing-exporter", c.Tracing.Exporter, "")
+	values.StringVar(&c.Tracing.Endpoint, "tracing-endpoint", c.Tracing.Endpoint, "")
+	values.BoolVar(&c.Tracing.Insecure, "tracing-insecure", c.Tracing.Insecure, "")
+	values.Float64Var(&c.Tracing.SampleRatio, "tracing-sample-ratio", c.Tracing.SampleRatio, "")
+	values.StringVar(&c.Tracing.ServiceName, "tracing-service-name", c.Tracing.ServiceName, "")
+	values.StringVar(&c.Log.Format, "log-format", c.Log.Format, "")
+	values.TextVar(&c.Log.Level, "log-level", c.Log.Level, "")
+	values.BoolVar(&c.RateLimit.Enabled, "rate-limit", c.RateLimit.Enabled, "")
+	values.StringVar(&c.RateLimit.Store, "rate-limit-store", c.RateLimit.Store, "")
+	values.TextVar(&c.RateLimit.Default, "rate-limit-default", c.RateLimit.Default, "")
+	values.TextVar(&c.RateLimit.Uploads, "rate-limit-uploads", c.RateLimit.Uploads, "")
+	values.TextVar(&c.RateLimit.IP, "rate-limit-ip", c.RateLimit.IP, "")
+	values.BoolVar(&c.Auth.Required, "auth-required", c.Auth.Required, "")
+	values.Var(listValue{&c.CORS.AllowedOrigins}, "cors-allowed-origins", "")
+	values.Var(listValue{&c.CORS.AllowedMethods}, "cors-allowed-methods", "")
+	values.Var(listValue{&c.CORS.AllowedHeaders}, "cors-allowed-headers", "")
+	values.Var(listValue{&c.CORS.ExposedHeaders}, "cors-exposed-headers", "")
+	values.BoolVar(&c.CORS.AllowCredentials, "cors-allow-credentials", c.CORS.AllowCredentials, "")
+	values.TextVar(&c.CORS.MaxAge, "cors-max-age", c.CORS.MaxAge, "")
+	values.TextVar(&c.Security.HSTSMaxAge, "hsts-max-age", c.Security.HSTSMaxAge, "")
+	values.BoolVar(&c.Security.HSTSIncludeSubdomains, "hsts-include-subdomains", c.Security.HSTSIncludeSubdomains, "")
+	values.StringVar(&c.Security.FrameOptions, "frame-options", c.Security.FrameOptions, "")
+	values.StringVar(&c.Security.ReferrerPolicy, "referrer-policy", c.Security.ReferrerPolicy, "")
+	values.StringVar(&c.Security.ContentSecurityPolicy, "csp", c.Security.ContentSecurityPolicy, "")
+	values.StringVar(&c.Security.DocsContentSecurityPolicy, "docs-csp", c.Security.DocsContentSecurityPolicy, "")
+	values.BoolVar(&c.Reminders.Enabled, "reminders", c.Reminders.Enabled, "")
+	values.TextVar(&c.Reminders.Interval, "reminders-interval", c.Reminders.Interval, "")
+	values.TextVar(&c.Reminders.Horizon, "reminders-horizon", c.Reminders.Horizon, "")
+	values.Var(listValue{&c.Reminders.Notifiers}, "reminders-notifiers", "")
+	values.StringVar(&c.Reminders.Webhook.URL, "reminders-webhook-url", c.Reminders.Webhook.URL, "")
+	values.TextVar(&c.Reminders.Webhook.Secret, "reminders-webhook-secret", c.Reminders.Webhook.Secret, "")
+	values.StringVar(&c.Reminders.SMTP.Host, "smtp-host", c.Reminders.SMTP.Host, "")
+	values.IntVar(&c.Reminders.SMTP.Port, "smtp-port", c.Reminders.SMTP.Port, "")
+	values.StringVar(&c.Reminders.SMTP.Username, "smtp-username", c.Reminders.SMTP.Username, "")
+	values.TextVar(&c.Reminders.SMTP.Password, "smtp-password", c.Reminders.SMTP.Password, "")
+	values.StringVar(&c.Reminders.SMTP.From, "smtp-from", c.Reminders.SMTP.From, "")
+
+	settings := []setting{
+		{key: "server.port", env: "PORT", flag: "port", usage: "HTTP listen port"},
+		{key: "server.mode", env: "GIN_MODE", flag: "mode", usage: "gin mode: debug, release or test"},
+		{
+			key: "server.startup_timeout", env: "STARTUP_TIMEOUT", flag: "startup-timeout",
+			usage: "how long to retry unreachable dependencies on start",
+		},
+		{
+			key: "server.shutdown_timeout", env: "SHUTDOWN_TIMEOUT", flag: "shutdown-timeout",
+			usage: "how long to drain requests and stop workers on shutdown",
+		},
+		{
+			key: "server.drain_delay", env: "DRAIN_DELAY", flag: "drain-delay",
+			usage: "how long readiness fails on shutdown before connections are closed",
+		},
+		{
+			key: "server.trusted_proxies", env: "TRUSTED_PROXIES", flag: "trusted-proxies",
+			usage: "comma-separated proxy IPs and CIDRs trusted to report the client IP",
+		},
+		{key: "database.url", env: "DB_URL", flag: "db-url", usage: "PostgreSQL connection string"},
+		{
+			key: "database.auto_migrate", env: "DB_AUTO_MIGRATE", flag: "db-auto-migrate",
+			usage: "apply pending database migrations on start",
+		},
+		{
+			key: "storage.backend", env: "BLOB_BACKEND", flag: "storage-backend",
+			usage: "blob storage backend: s3, fs or memory",
+		},
+		{key: "storage.fs_root", env: "BLOB_FS_ROOT", flag: "storage-fs-root", usage: "root directory of the fs backend"},
+		{key: "storage.s3.endpoint", env: "OCI_S3_ENDPOINT", flag: "s3-endpoint", usage: "S3-compatible endpoint URL"},
+		{key: "storage.s3.region", env: "OCI_S3_REGION", flag: "s3-region", usage: "S3 region"},
+		{key: "storage.s3.access_key", env: "OCI_S3_ACCESS_KEY", flag: "s3-access-key", usage: "S3 access key ID"},
+		{key: "storage.s3.secret_key", env: "OCI_S3_SECRET_KEY", flag: "s3-secret-key", usage: "S3 secret access key"},
+		{key: "storage.s3.bucket", env: "OCI_BUCKET_NAME", flag: "s3-bucket", usage: "S3 bucket name"},
+		{key: "storage.s3.path_style", env: "OCI_S3_PATH_STYLE", flag: "s3-path-style", usage: "use path-style S3 URLs"},
+		{
+			key: "avatars.cache_control", env: "AVATAR_CACHE_CONTROL", flag: "avatar-cache-control",
+			usage: "Cache-Control header of avatar downloads",
+		},
+		{
+			key: "metrics.refresh_interval", env: "METRICS_REFRESH_INTERVAL", flag: "metrics-refresh-interval",
+			usage: "how often business metrics are refreshed",
+		},
+		{
+			key: "tracing.exporter", env: "TRACING_EXPORTER", flag: "tracing-exporter",
+			usage: "trace exporter: none, stdout or otlp",
+		},
+		{key: "tracing.endpoint", env: "TRACING_ENDPOINT", flag: "tracing-endpoint", usage: "OTLP/HTTP collector address"},
+		{
+			key: "tracing.insecure", env: "TRACING_INSECURE", flag: "tracing-insecure",
+			usage: "send spans to the collector without TLS",
+		},
+		{
+			key: "tracing.sample_ratio", env: "TRACING_SAMPLE_RATIO", flag: "tracing-sample-ratio",
+			usage: "fraction of new traces that are recorded",
+		},
+		{
+			key: "tracing.service_name", env: "TRACING_SERVICE_NAME", flag: "tracing-service-name",
+			usage: "service name reported with spans",
+		},
+		{key: "log.format", env: "LOG_FORMAT", flag: "log-format", usage: "log format: text or json"},
+		{key: "log.level", env: "LOG_LEVEL", flag: "log-level", usage: "minimum log level: debug, info, warn or error"},
+		{key: "rate_limit.enabled", env: "RATE_LIMIT_ENABLED", flag: "rate-limit", usage: "limit the request rate per client"},
+		{
+			key: "rate_limit.store", env: "RATE_LIMIT_STORE", flag: "rate-limit-store",
+			usage: "rate limit store: memory or postgres",
+		},
+		{
+			key: "rate_limit.default", env: "RATE_LIMIT_DEFAULT", flag: "rate-limit-default",
+			usage: "requests per period allowed on API routes, e.g. 300/1m",
+		},
+		{
+			key: "rate_limit.uploads", env: "RATE_LIMIT_UPLOADS", flag: "rate-limit-uploads",
+			usage: "requests per period allowed on avatar uploads, e.g. 20/1m",
+		},
+		{
+			key: "rate_limit.ip", env: "RATE_LIMIT_IP", flag: "rate-limit-ip",
+			usage: "requests per period allowed per client IP before authentication, e.g. 600/1m",
+		},
+		{key: "auth.required", env: "AUTH_REQUIRED", flag: "auth-required", usage: "reject API requests without an API key"},
+		{
+			key: "cors.allowed_origins", env: "CORS_ALLOWED_ORIGINS", flag: "cors-allowed-origins",
+			usage: "comma-separated browser origins allowed to call the API, e.g. https://*.example.com",
+		},
+		{
+			key: "cors.allowed_methods", env: "CORS_ALLOWED_METHODS", flag: "cors-allowed-methods",
+			usage: "comma-separated methods allowed in cross-origin requests",
+		},
+		{
+			key: "cors.allowed_headers", env: "CORS_ALLOWED_HEADERS", flag: "cors-allowed-headers",
+			usage: "comma-separated request headers allowed in cross-origin requests",
+		},
+		{
+			key: "cors.exposed_headers", env: "CORS_EXPOSED_HEADERS", flag: "cors-exposed-headers",
+			usage: "comma-separated response headers readable by cross-origin scripts",
+		},
+		{
+			key: "cors.allow_credentials", env: "CORS_ALLOW_CREDENTIALS", flag: "cors-allow-credentials",
+			usage: "allow cross-origin requests with credentials",
+		},
+		{
+			key: "cors.max_age", env: "CORS_MAX_AGE", flag: "cors-max-age",
+			usage: "how long browsers may cache preflight results",
+		},
+		{
+			key: "security.hsts_max_age", env: "HSTS_MAX_AGE", flag: "hsts-max-age",
+			usage: "max-age of Strict-Transport-Security, 0 to omit it",
+		},
+		{
+			key: "security.hsts_include_subdomains", env: "HSTS_INCLUDE_SUBDOMAINS", flag: "hsts-include-subdomains",
+			usage: "extend Strict-Transport-Security to subdomains",
+		},
+		{
+			key: "security.frame_options", env: "FRAME_OPTIONS", flag: "frame-options",
+			usage: "X-Frame-Options: DENY, SAMEORIGIN or empty",
+		},
+		{key: "security.referrer_policy", env: "REFERRER_POLICY", flag: "referrer-policy", usage: "Referrer-Policy header"},
+		{
+			key: "security.content_security_policy", env: "CONTENT_SECURITY_POLICY", flag: "csp",
+			usage: "Content-Security-Policy of API responses",
+		},
+		{
+			key: "security.docs_content_security_policy", env: "DOCS_CONTENT_SECURITY_POLICY", flag: "docs-csp",
+			usage: "Content-Security-Policy of the Swagger UI",
+		},
+		{key: "reminders.enabled", env: "REMINDERS_ENABLED", flag: "reminders", usage: "notify due reminders"},
+		{
+			key: "reminders.interval", env: "REMINDERS_INTERVAL", flag: "reminders-interval",
+			usage: "how often due reminders are notified",
+		},
+		{
+			key: "reminders.horizon", env: "REMINDERS_HORIZON", flag: "reminders-horizon",
+			usage: "how far ahead birthdays and anniversaries become reminders",
+		},
+		{
+			key: "reminders.notifiers", env: "REMINDERS_NOTIFIERS", flag: "reminders-notifiers",
+			usage: "comma-separated notifiers of due reminders: log, webhook, smtp",
+		},
+		{
+			key: "reminders.webhook.url", env: "REMINDERS_WEBHOOK_URL", flag: "reminders-webhook-url",
+			usage: "URL that the webhook notifier posts to",
+		},
+		{
+			key: "reminders.webhook.secret", env: "REMINDERS_WEBHOOK_SECRET", flag: "reminders-webhook-secret",
+			usage: "key signing webhook notifications",
+		},
+		{key: "reminders.smtp.host", env: "SMTP_HOST", flag: "smtp-host", usage: "mail server of the smtp notifier"},
+		{key: "reminders.smtp.port", env: "SMTP_PORT", flag: "smtp-port", usage: "mail server port"},
+		{key: "reminders.smtp.username", env: "SMTP_USERNAME", flag: "smtp-username", usage: "mail server username"},
+		{key: "reminders.smtp.password", env: "SMTP_PASSWORD", flag: "smtp-password", usage: "mail server password"},
+		{key: "reminders.smtp.from", env: "SMTP_FROM", flag: "smtp-from", usage: "sender of reminder emails"},
+	}
+	for i := range settings {
+		settings[i].value = values.Lookup(settings[i].flag).Value
+	}
+	return settings
+}
+
+// Load resolves the configuration from args (without the program name), the
+// environment and the configuration file named by --config or CONFIG_FILE.
+// Validation problems are returned together as a single joined error along
+// with the resolved configuration, so that it can still be printed.
+func Load(args []string, lookupEnv func(key string) (string, bool)) (*Config, Options, error) {
+	cfg := Default()
+	settings := cfg.settings()
+
+	var opts Options
+	cli := flag.NewFlagSet("contacts", flag.ContinueOnError)
+	cli.StringVar(&opts.File, "config", "", "path to a YAML or TOML configuration file (env "+configFileEnv+")")
+	cli.BoolVar(&opts.PrintConfig, "print-config", false, "print the configuration with secrets redacted and exit")
+
+	// Flags are recorded first and applied last so that they override the file
+	// and the environment.
+	type flagValue struct {
+		setting *setting
+		value   string
+	}
+	var flagged []flagValue
+	for i := range settings {
+		s := &settings[i]
+		record := func(value string) error {
+			flagged = append(flagged, flagValue{setting: s, value: value})
+			return nil
+		}
+		usage := fmt.Sprintf("%s (env %s)", s.usage, s.env)
+		if isBoolValue(s.value) {
+			cli.BoolFunc(s.flag, usage, record)
+		} else {
+			cli.Func(s.flag, usage, record)
+		}
+	}
+	if err := cli.Parse(args); err != nil {
+		return nil, opts, err
+	}
+
+	if opts.File == "" {
+		opts.File, _ = lookupEnv(configFileEnv)
+	}
+	if opts.File != "" {
+		if err := cfg.loadFile(opts.File); err != nil {
+			return nil, opts, err
+		}
+	}
+
+	var errs []error
+	for _, s := range settings {
+		if value, ok := lookupEnv(s.env); ok && value != "" {
+			if err := s.value.Set(value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid value in %s: %w", s.key, s.env, err))
+			}
+		}
+	}
+	for _, f := range flagged {
+		if err := f.setting.value.Set(f.value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid value in --%s: %w", f.setting.key, f.setting.flag, err))
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return cfg, opts, errors.Join(errs...)
+}
+
+// listValue is a flag.Value writing a list comma-separated, so that lists can
+// be set from environment variables and flags as well as from files.
+type listValue struct {
+	list *[]string
+}
+
+func (v listValue) String() string {
+	if v.list == nil {
+		return ""
+	}
+	return strings.Join(*v.list, ",")
+}
+
+func (v listValue) Set(value string) error {
+	*v.list = nil
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*v.list = append(*v.list, item)
+		}
+	}
+	return nil
+}
+
+func isBoolValue(value flag.Value) bool {
+	b, ok := value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// loadFile decodes a YAML or TOML file, chosen by extension, on top of c.
+// Unknown keys are rejected so that typos do not silently fall back to defaults.
+func (c *Config) loadFile(path string) error {
+	file, err := os.Open(path) //nolint:gosec // the path is chosen by the operator
+	if err != nil {
+		return fmt.Errorf("open config file: %w", err)
+	}
+	defer file.Close()
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.NewDecoder(file, yaml.DisallowUnknownField()).Decode(c)
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+	case ".toml":
+		err = toml.NewDecoder(file).DisallowUnknownFields().Decode(c)
+	default:
+		return fmt.Errorf("unsupported config file extension %q, use .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate checks every value and reports all problems at once.
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	if c.Server.Port < 1 || c.Server.Port > maxPort {
+		invalid("server.port", "must be between 1 and %d, got %d", maxPort, c.Server.Port)
+	}
+	switch c.Server.Mode {
+	case gin.DebugMode, gin.ReleaseMode, gin.TestMode:
+	default:
+		invalid("server.mode", "must be one of debug, release, test, got %q", c.Server.Mode)
+	}
+	if c.Server.StartupTimeout.Duration <= 0 {
+		invalid("server.startup_timeout", "must be positive")
+	}
+	if c.Server.ShutdownTimeout.Duration <= 0 {
+		invalid("server.shutdown_timeout", "must be positive")
+	}
+	if c.Server.DrainDelay.Duration < 0 {
+		invalid("server.drain_delay", "must not be negative")
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			invalid("server.trusted_proxies", "%q is neither an IP nor a CIDR", proxy)
+		}
+	}
+
+	if c.Database.URL == "" {
+		invalid("database.url", "is required")
+	} else if _, err := pgxpool.ParseConfig(c.Database.URL.Value()); err != nil {
+		// The parse error may quote the connection string, so it is not included.
+		invalid("database.url", "is not a valid connection string")
+	}
+
+	switch c.Storage.Backend {
+	case StorageS3:
+		c.Storage.S3.validate(invalid)
+	case StorageFS:
+		if c.Storage.FSRoot == "" {
+			invalid("storage.fs_root", "is required for the fs backend")
+		}
+	case StorageMemory:
+	default:
+		invalid("storage.backend", "must be one of s3, fs, memory, got %q", c.Storage.Backend)
+	}
+
+	if c.Avatars.CacheControl == "" {
+		invalid("avatars.cache_control", "must not be empty")
+	}
+	if c.Metrics.RefreshInterval.Duration <= 0 {
+		invalid("metrics.refresh_interval", "must be positive")
+	}
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	default:
+		invalid("tracing.exporter", "must be one of none, stdout, otlp, got %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		invalid("tracing.sample_ratio", "must be between 0 and 1, got %v", c.Tracing.SampleRatio)
+	}
+	if c.Tracing.ServiceName == "" {
+		invalid("tracing.service_name", "must not be empty")
+	}
+
+	switch c.Log.Format {
+	case logging.FormatText, logging.FormatJSON:
+	default:
+		invalid("log.format", "must be one of text, json, got %q", c.Log.Format)
+	}
+
+	switch c.RateLimit.Store {
+	case RateLimitStoreMemory, RateLimitStorePostgres:
+	default:
+		invalid("rate_limit.store", "must be one of memory, postgres, got %q", c.RateLimit.Store)
+	}
+	if err := c.RateLimit.Default.Validate(); err != nil {
+		invalid("rate_limit.default", "%v", err)
+	}
+	if err := c.RateLimit.Uploads.Validate(); err != nil {
+		invalid("rate_limit.uploads", "%v", err)
+	}
+	if err := c.RateLimit.IP.Validate(); err != nil {
+		invalid("rate_limit.ip", "%v", err)
+	}
+
+	c.CORS.validate(invalid)
+	if c.Security.HSTSMaxAge.Duration < 0 {
+		invalid("security.hsts_max_age", "must not be negative")
+	}
+	switch c.Security.FrameOptions {
+	case "", "DENY", "SAMEORIGIN":
+	default:
+		invalid("security.frame_options", "must be one of DENY, SAMEORIGIN or empty, got %q", c.Security.FrameOptions)
+	}
+	c.Reminders.validate(invalid)
+	return errors.Join(errs...)
+}
+
+func (c RemindersConfig) validate(invalid func(key, format string, args ...any)) {
+	if c.Interval.Duration <= 0 {
+		invalid("reminders.interval", "must be positive")
+	}
+	if c.Horizon.Duration <= 0 {
+		invalid("reminders.horizon", "must be positive")
+	}
+	for _, notifier := range c.Notifiers {
+		switch notifier {
+		case reminders.NotifierLog:
+		case reminders.NotifierWebhook:
+			if u, err := url.Parse(c.Webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				invalid("reminders.webhook.url", "must be an http(s) URL for the webhook notifier, got %q", c.Webhook.URL)
+			}
+		case reminders.NotifierSMTP:
+			if c.SMTP.Host == "" {
+				invalid("reminders.smtp.host", "is required for the smtp notifier")
+			}
+			if c.SMTP.Port < 1 || c.SMTP.Port > maxPort {
+				invalid("reminders.smtp.port", "must be between 1 and %d, got %d", maxPort, c.SMTP.Port)
+			}
+			if _, err := mail.ParseAddress(c.SMTP.From); err != nil {
+				invalid("reminders.smtp.from", "must be an email address, got %q", c.SMTP.From)
+			}
+		default:
+			invalid("reminders.notifiers", "must be log, webhook or smtp, got %q", notifier)
+		}
+	}
+}
+
+func (c CORSConfig) validate(invalid func(key, format string, args ...any)) {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				invalid("cors.allowed_origins", "\"*\" cannot be combined with cors.allow_credentials")
+			}
+			continue
+		}
+		if !validOrigin(origin) {
+			invalid("cors.allowed_origins",
+				"%q must be an http(s) origin, whose leftmost label may be a wildcard like https://*.example.com",
+				origin)
+		}
+	}
+	if c.MaxAge.Duration < 0 {
+		invalid("cors.max_age", "must not be negative")
+	}
+}
+
+// validOrigin reports whether origin is an http(s) origin such as
+// "https://app.example.com", or a pattern such as "https://*.example.com"
+// whose wildcard is a whole leftmost label, so that it only matches
+// subdomains of the domain.
+func validOrigin(origin string) bool {
+	scheme, host, ok := strings.Cut(origin, "://")
+	if !ok || (scheme != "http" && scheme != "https") {
+		return false
+	}
+	host = strings.TrimPrefix(host, "*.")
+	if host == "" || strings.ContainsAny(host, "*/?#@") {
+		return false
+	}
+	u, err := url.Parse(scheme + "://" + host)
+	return err == nil && u.Host == host
+}
+
+func (c S3Config) validate(invalid func(key, format string, args ...any)) {
+	if c.Endpoint != "" {
+		if u, err := url.Parse(c.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid("storage.s3.endpoint", "must be an http(s) URL, got %q", c.Endpoint)
+		}
+	}
+	required := []struct{ key, value string }{
+		{"storage.s3.region", c.Region},
+		{"storage.s3.access_key", c.AccessKey},
+		{"storage.s3.secret_key", c.SecretKey.Value()},
+		{"storage.s3.bucket", c.Bucket},
+	}
+	for _, r := range required {
+		if r.value == "" {
+			invalid(r.key, "is required for the s3 backend")
+		}
+	}
+}
+
+// Addr is the listen address for the HTTP server.
+func (c *Config) Addr() string {
+	return ":" + strconv.Itoa(c.Server.Port)
+}
+
+// Print writes the configuration as YAML with secrets redacted.
+func (c *Config) Print(w io.Writer) error {
+	out, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
@@ -2,44 +2,226 @@ package config
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"contactsAI/contacts/internal/apikey"
 	"contactsAI/contacts/internal/bucket"
 	"contactsAI/contacts/internal/db"
+	"contactsAI/contacts/internal/health"
+	"contactsAI/contacts/internal/logging"
+	"contactsAI/contacts/internal/metrics"
+	"contactsAI/contacts/internal/migrate"
+	"contactsAI/contacts/internal/ratelimit"
+	"contactsAI/contacts/internal/reminders"
+	"contactsAI/contacts/internal/tenant"
+	"contactsAI/contacts/internal/tracing"
+	"contactsAI/contacts/sql/migrations"
 
+	"github.com/jackc/pgx/v5/multitracer"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const (
+	initialRetryDelay = 500 * time.Millisecond
+	maxRetryDelay     = 10 * time.Second
+)
+
 type Env struct {
+	// Queries run for the tenant in the context, see tenant.DB.
 	*db.Queries
 	*slog.Logger
 
-	Bucket *bucket.Store
+	// System runs queries without tenant isolation, for authentication and
+	// background jobs that are not part of a tenant's request.
+	System *db.Queries
+	// Tenant runs several of the Queries in one transaction, see
+	// tenant.DB.WithTx.
+	Tenant *tenant.DB
+
+	Pool     *pgxpool.Pool
+	Bucket   bucket.BlobStore
+	Health   *health.Checker
+	Migrator *migrate.Migrator
+	Metrics  *metrics.Metrics
+	// LogLevel is the minimum level of Logger and can be changed at runtime.
+	LogLevel *slog.LevelVar
+	// AvatarCacheControl is the Cache-Control policy sent with avatar downloads.
+	AvatarCacheControl string
+	TrustedProxies     []string
+	RateLimit          RateLimitConfig
+	RateLimitStore     ratelimit.Store
+	Auth               AuthConfig
+	Authenticator      *apikey.Authenticator
+	CORS               CORSConfig
+	Security           SecurityConfig
+	// Scheduler notifies due reminders; main runs it when reminders are enabled.
+	Scheduler *reminders.Scheduler
+}
+
+// NewEnv Create a new Env instance from a validated configuration. Unreachable
+// dependencies are retried with backoff until ctx is done, while invalid
+// settings fail immediately.
+func NewEnv(ctx context.Context, cfg *Config) (*Env, error) {
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(cfg.Log.Level)
+	logger, err := logging.New(os.Stderr, cfg.Log.Format, logLevel)
+	if err != nil {
+		return nil, err
+	}
+	m := metrics.New()
+
+	poolCfg, err := pgxpool.ParseConfig(cfg.Database.URL.Value())
+	if err != nil {
+		return nil, fmt.Errorf("parse database url: %w", err)
+	}
+	poolCfg.ConnConfig.Tracer = multitracer.New(m.QueryTracer(), tracing.QueryTracer())
+	conn, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		return nil, err
+	}
+	m.RegisterPool(conn)
+	if err = retry(ctx, logger, "database", conn.Ping); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	store, err := openBucket(ctx, cfg.Storage)
+	if err == nil {
+		err = retry(ctx, logger, "blob storage", store.Ping)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	store = tracing.InstrumentBlobStore(m.InstrumentBlobStore(store))
+
+	migrator, err := migrate.New(conn, migrations.FS, logger)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	checker := health.NewChecker(health.DefaultTimeout)
+	checker.Add("database", conn.Ping)
+	checker.Add("storage", store.Ping)
+	checker.Add("migrations", migrator.CheckCurrent)
+	checker.SetMigrationVersion(migrator.Version)
+
+	system := db.New(conn)
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimit.Store == RateLimitStorePostgres {
+		rateLimitStore = ratelimit.NewPostgresStore(system)
+	}
+
+	tenantDB := tenant.NewDB(conn)
+	return &Env{
+		Queries:            db.New(tenantDB),
+		Logger:             logger,
+		System:             system,
+		Tenant:             tenantDB,
+		Pool:               conn,
+		Bucket:             store,
+		Health:             checker,
+		Migrator:           migrator,
+		Metrics:            m,
+		LogLevel:           logLevel,
+		AvatarCacheControl: cfg.Avatars.CacheControl,
+		TrustedProxies:     cfg.Server.TrustedProxies,
+		RateLimit:          cfg.RateLimit,
+		RateLimitStore:     rateLimitStore,
+		Auth:               cfg.Auth,
+		Authenticator:      apikey.NewAuthenticator(system),
+		CORS:               cfg.CORS,
+		Security:           cfg.Security,
+		Scheduler: reminders.NewScheduler(
+			system, newNotifiers(cfg.Reminders, logger), logger, cfg.Reminders.Horizon.Duration,
+		),
+	}, nil
 }
 
-// NewEnv Create a new Env instance.
-func NewEnv(dbURL string, isTestEnv bool) (*Env, error) {
-	ctx := context.Background()
-	conn, connErr := pgxpool.New(ctx, dbURL)
-	if connErr != nil {
-		return nil, connErr
+// newNotifiers names the configured notifiers.
+func newNotifiers(cfg RemindersConfig, logger *slog.Logger) reminders.Notifiers {
+	notifiers := make(reminders.Notifiers, 0, len(cfg.Notifiers))
+	for _, name := range cfg.Notifiers {
+		var notifier reminders.Notifier
+		switch name {
+		case reminders.NotifierLog:
+			notifier = reminders.LogNotifier{Logger: logger}
+		case reminders.NotifierWebhook:
+			notifier = reminders.NewWebhookNotifier(cfg.Webhook.URL, cfg.Webhook.Secret.Value())
+		case reminders.NotifierSMTP:
+			addr := net.JoinHostPort(cfg.SMTP.Host, strconv.Itoa(cfg.SMTP.Port))
+			notifier = reminders.NewSMTPNotifier(addr, cfg.SMTP.From, cfg.SMTP.Username, cfg.SMTP.Password.Value())
+		default:
+			continue
+		}
+		notifiers = append(notifiers, reminders.NamedNotifier{Name: name, Notifier: notifier})
 	}
+	return notifiers
+}
 
-	//nolint:exhaustruct // necessary because of bucket's conditional occurrence
-	env := Env{Logger: slog.Default()}
+// Close releases the database connections.
+func (env *Env) Close() {
+	env.Pool.Close()
+}
 
-	// Test the connection
-	if pingErr := conn.Ping(ctx); pingErr != nil {
-		return nil, pingErr
+func openBucket(ctx context.Context, cfg StorageConfig) (bucket.BlobStore, error) {
+	switch cfg.Backend {
+	case StorageS3:
+		return bucket.NewS3Store(ctx, bucket.S3Config{
+			Endpoint:     cfg.S3.Endpoint,
+			Region:       cfg.S3.Region,
+			AccessKey:    cfg.S3.AccessKey,
+			SecretKey:    cfg.S3.SecretKey.Value(),
+			Bucket:       cfg.S3.Bucket,
+			UsePathStyle: cfg.S3.PathStyle,
+		})
+	case StorageFS:
+		return bucket.NewFSStore(cfg.FSRoot)
+	case StorageMemory:
+		return bucket.NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown blob backend %q", cfg.Backend)
 	}
-	env.Queries = db.New(conn)
+}
 
-	if !isTestEnv {
-		bucket, err := bucket.OpenFromEnv(ctx)
-		if err != nil {
-			return nil, err
+// retry calls check with exponential backoff until it succeeds or ctx is done,
+// in which case the last error is returned. Permanent errors are returned
+// right away.
+func retry(ctx context.Context, logger *slog.Logger, name string, check func(context.Context) error) error {
+	delay := initialRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := check(ctx)
+		if err == nil {
+			return nil
 		}
-		env.Bucket = bucket
+		if isPermanent(err) {
+			return fmt.Errorf("%s not ready: %w", name, err)
+		}
+		logger.Warn("Dependency not ready", "dependency", name, "attempt", attempt, "retry_in", delay, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%s not ready after %d attempts: %w", name, attempt, err)
+		case <-timer.C:
+		}
+		delay = min(2*delay, maxRetryDelay)
 	}
-	return &env, nil
+}
+
+// isPermanent reports whether err cannot go away by retrying: the server
+// rejected the credentials (class 28) or the database does not exist (3D000).
+func isPermanent(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (strings.HasPrefix(pgErr.Code, "28") || pgErr.Code == "3D000")
 }
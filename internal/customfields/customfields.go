@@ -0,0 +1,209 @@
+// Package customfields validates the custom fields that owners define for
+// their contacts and the values contacts store for them.
+//
+// Values are kept as JSON: text, url and enum values and dates (YYYY-MM-DD)
+// are strings, numbers are numbers and bools are booleans, so that values of
+// one field compare and sort as their type.
+package customfields
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Type is the type of the values of a field.
+type Type string
+
+const (
+	TypeText   Type = "text"
+	TypeNumber Type = "number"
+	TypeDate   Type = "date"
+	TypeBool   Type = "bool"
+	TypeEnum   Type = "enum"
+	TypeURL    Type = "url"
+)
+
+// MaxTextLength bounds text and url values.
+const MaxTextLength = 1000
+
+// ErrInvalid is wrapped by the errors of this package.
+var ErrInvalid = errors.New("invalid custom field")
+
+// Definition describes a custom field.
+type Definition struct {
+	// Name keys the values of the field, e.g. customer_number.
+	Name     string
+	Type     Type
+	Required bool
+	// Pattern is a regular expression that text and url values must match.
+	Pattern string
+	// Options are the values allowed for an enum.
+	Options []string
+
+	// pattern is Pattern compiled by Compile.
+	pattern *regexp.Regexp
+}
+
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// Types lists the field types.
+func Types() []Type {
+	return []Type{TypeText, TypeNumber, TypeDate, TypeBool, TypeEnum, TypeURL}
+}
+
+// ValidateDefinition checks that def is a usable field definition.
+func ValidateDefinition(def Definition) error {
+	if !namePattern.MatchString(def.Name) {
+		return fmt.Errorf("%w: name %q must be lower case letters, digits and underscores, starting with a letter",
+			ErrInvalid, def.Name)
+	}
+	if !slices.Contains(Types(), def.Type) {
+		return fmt.Errorf("%w: unknown type %q", ErrInvalid, def.Type)
+	}
+	if def.Pattern != "" {
+		if def.Type != TypeText && def.Type != TypeURL {
+			return fmt.Errorf("%w: only text and url fields have a pattern", ErrInvalid)
+		}
+		if _, err := regexp.Compile(def.Pattern); err != nil {
+			return fmt.Errorf("%w: pattern: %w", ErrInvalid, err)
+		}
+	}
+	if def.Type == TypeEnum && len(def.Options) == 0 {
+		return fmt.Errorf("%w: enum fields need options", ErrInvalid)
+	}
+	if def.Type != TypeEnum && len(def.Options) > 0 {
+		return fmt.Errorf("%w: only enum fields have options", ErrInvalid)
+	}
+	return nil
+}
+
+// Compile returns def with its pattern compiled, so that checking values does
+// not compile it again. Definitions are compiled once when they are loaded.
+func Compile(def Definition) (Definition, error) {
+	if def.Pattern == "" {
+		return def, nil
+	}
+	pattern, err := regexp.Compile(def.Pattern)
+	if err != nil {
+		return def, fmt.Errorf("%w: pattern of %q: %w", ErrInvalid, def.Name, err)
+	}
+	def.pattern = pattern
+	return def, nil
+}
+
+// Validate checks values, as decoded from JSON, against the definitions and
+// returns them normalized. Null values are left out, and fields that are not
+// defined are rejected.
+func Validate(defs []Definition, values map[string]any) (map[string]any, error) {
+	valid := make(map[string]any, len(values))
+	for name, value := range values {
+		i := slices.IndexFunc(defs, func(def Definition) bool { return def.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %q is not defined", ErrInvalid, name)
+		}
+		if value == nil {
+			continue
+		}
+		normalized, err := validateValue(defs[i], value)
+		if err != nil {
+			return nil, err
+		}
+		valid[name] = normalized
+	}
+	for _, def := range defs {
+		if _, ok := valid[def.Name]; def.Required && !ok {
+			return nil, fmt.Errorf("%w: %q is required", ErrInvalid, def.Name)
+		}
+	}
+	return valid, nil
+}
+
+// ParseValue converts raw, e.g. from a query string, to a value of def.
+func ParseValue(def Definition, raw string) (any, error) {
+	switch def.Type {
+	case TypeNumber:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q must be a number", ErrInvalid, def.Name)
+		}
+		return validateValue(def, number)
+	case TypeBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q must be true or false", ErrInvalid, def.Name)
+		}
+		return b, nil
+	case TypeText, TypeDate, TypeEnum, TypeURL:
+		return validateValue(def, raw)
+	default:
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalid, def.Type)
+	}
+}
+
+func validateValue(def Definition, value any) (any, error) {
+	switch def.Type {
+	case TypeNumber:
+		number, ok := value.(float64)
+		if !ok || math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, fmt.Errorf("%w: %q must be a number", ErrInvalid, def.Name)
+		}
+		return number, nil
+	case TypeBool:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q must be true or false", ErrInvalid, def.Name)
+		}
+		return b, nil
+	case TypeText, TypeDate, TypeEnum, TypeURL:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q must be a string", ErrInvalid, def.Name)
+		}
+		return validateString(def, s)
+	default:
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalid, def.Type)
+	}
+}
+
+func validateString(def Definition, s string) (string, error) {
+	switch def.Type {
+	case TypeDate:
+		date, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			return "", fmt.Errorf("%w: %q must be a date like 2006-01-02", ErrInvalid, def.Name)
+		}
+		return date.Format(time.DateOnly), nil
+	case TypeEnum:
+		if !slices.Contains(def.Options, s) {
+			return "", fmt.Errorf("%w: %q must be one of %v", ErrInvalid, def.Name, def.Options)
+		}
+		return s, nil
+	case TypeURL:
+		u, err := url.Parse(s)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", fmt.Errorf("%w: %q must be an http or https URL", ErrInvalid, def.Name)
+		}
+	case TypeText, TypeNumber, TypeBool:
+	}
+	if len(s) > MaxTextLength {
+		return "", fmt.Errorf("%w: %q is longer than %d characters", ErrInvalid, def.Name, MaxTextLength)
+	}
+	if def.Pattern != "" {
+		if def.pattern == nil {
+			var err error
+			if def, err = Compile(def); err != nil {
+				return "", err
+			}
+		}
+		if !def.pattern.MatchString(s) {
+			return "", fmt.Errorf("%w: %q does not match %s", ErrInvalid, def.Name, def.Pattern)
+		}
+	}
+	return s, nil
+}
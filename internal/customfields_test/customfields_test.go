@@ -0,0 +1,109 @@
+package customfields_test
+
+import (
+	"testing"
+
+	"contactsAI/contacts/internal/customfields"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateDefinition(t *testing.T) {
+	valid := []customfields.Definition{
+		{Name: "customer_number", Type: customfields.TypeText, Pattern: `^C-\d+$`},
+		{Name: "language", Type: customfields.TypeEnum, Options: []string{"pl", "en"}},
+		{Name: "vip", Type: customfields.TypeBool, Required: true},
+	}
+	for _, def := range valid {
+		require.NoError(t, customfields.ValidateDefinition(def), def.Name)
+	}
+
+	invalid := []customfields.Definition{
+		{Name: "Customer Number", Type: customfields.TypeText},
+		{Name: "1st", Type: customfields.TypeText},
+		{Name: "size", Type: "integer"},
+		{Name: "nip", Type: customfields.TypeText, Pattern: `(`},
+		{Name: "score", Type: customfields.TypeNumber, Pattern: `^\d+$`},
+		{Name: "language", Type: customfields.TypeEnum},
+		{Name: "vip", Type: customfields.TypeBool, Options: []string{"yes"}},
+	}
+	for _, def := range invalid {
+		assert.ErrorIs(t, customfields.ValidateDefinition(def), customfields.ErrInvalid, def.Name)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	defs := []customfields.Definition{
+		{Name: "nip", Type: customfields.TypeText, Required: true, Pattern: `^\d{10}$`},
+		{Name: "score", Type: customfields.TypeNumber},
+		{Name: "since", Type: customfields.TypeDate},
+		{Name: "vip", Type: customfields.TypeBool},
+		{Name: "language", Type: customfields.TypeEnum, Options: []string{"pl", "en"}},
+		{Name: "website", Type: customfields.TypeURL},
+	}
+
+	values, err := customfields.Validate(defs, map[string]any{
+		"nip": "1234567890", "score": 4.5, "since": "2024-02-29", "vip": true, "language": "pl",
+		"website": "https://example.com/about",
+	})
+	require.NoError(t, err)
+	assert.Len(t, values, 6)
+
+	values, err = customfields.Validate(defs, map[string]any{"nip": "1234567890", "score": nil})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"nip": "1234567890"}, values, "null values are left out")
+
+	invalid := []map[string]any{
+		{},
+		{"nip": nil},
+		{"nip": "123"},
+		{"nip": 1234567890.0},
+		{"nip": "1234567890", "fax": "123"},
+		{"nip": "1234567890", "score": "high"},
+		{"nip": "1234567890", "since": "2023-02-29"},
+		{"nip": "1234567890", "vip": "yes"},
+		{"nip": "1234567890", "language": "de"},
+		{"nip": "1234567890", "website": "ftp://example.com"},
+		{"nip": "1234567890", "website": "example.com"},
+	}
+	for _, value := range invalid {
+		_, err = customfields.Validate(defs, value)
+		assert.ErrorIs(t, err, customfields.ErrInvalid, value)
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		def  customfields.Definition
+		raw  string
+		want any
+	}{
+		{customfields.Definition{Name: "score", Type: customfields.TypeNumber}, "42", 42.0},
+		{customfields.Definition{Name: "vip", Type: customfields.TypeBool}, "true", true},
+		{customfields.Definition{Name: "since", Type: customfields.TypeDate}, "2024-01-31", "2024-01-31"},
+		{customfields.Definition{Name: "nip", Type: customfields.TypeText}, "123", "123"},
+	}
+	for _, tt := range tests {
+		got, err := customfields.ParseValue(tt.def, tt.raw)
+		require.NoError(t, err, tt.def.Name)
+		assert.Equal(t, tt.want, got, tt.def.Name)
+	}
+
+	_, err := customfields.ParseValue(customfields.Definition{Name: "score", Type: customfields.TypeNumber}, "NaN")
+	require.ErrorIs(t, err, customfields.ErrInvalid)
+	_, err = customfields.ParseValue(customfields.Definition{Name: "vip", Type: customfields.TypeBool}, "maybe")
+	require.ErrorIs(t, err, customfields.ErrInvalid)
+}
+
+func TestCompile(t *testing.T) {
+	def, err := customfields.Compile(customfields.Definition{Name: "nip", Type: customfields.TypeText, Pattern: `^\d{10}$`})
+	require.NoError(t, err)
+	_, err = customfields.Validate([]customfields.Definition{def}, map[string]any{"nip": "1234567890"})
+	require.NoError(t, err)
+	_, err = customfields.Validate([]customfields.Definition{def}, map[string]any{"nip": "123"})
+	require.ErrorIs(t, err, customfields.ErrInvalid)
+
+	_, err = customfields.Compile(customfields.Definition{Name: "nip", Type: customfields.TypeText, Pattern: `(`})
+	require.ErrorIs(t, err, customfields.ErrInvalid)
+}
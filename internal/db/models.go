@@ -8,10 +8,190 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ApiKey struct {
+	ID         int32              `json:"id"`
+	Name       string             `json:"name"`
+	Prefix     string             `json:"prefix"`
+	KeyHash    []byte             `json:"key_hash"`
+	OwnerID    int32              `json:"owner_id"`
+	Scopes     []string           `json:"scopes"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	RevokedAt  pgtype.Timestamptz `json:"revoked_at"`
+	TenantID   int32              `json:"tenant_id"`
+}
+
 type Contact struct {
-	ID        int32            `json:"id"`
-	Name      string           `json:"name"`
-	Phone     string           `json:"phone"`
-	OwnerID   pgtype.Int4      `json:"owner_id"`
-	CreatedAt pgtype.Timestamp `json:"created_at"`
+	ID             int32            `json:"id"`
+	Name           string           `json:"name"`
+	Phone          string           `json:"phone"`
+	OwnerID        pgtype.Int4      `json:"owner_id"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+	TenantID       int32            `json:"tenant_id"`
+	CustomFields   []byte           `json:"custom_fields"`
+	Prefix         string           `json:"prefix"`
+	GivenName      string           `json:"given_name"`
+	MiddleName     string           `json:"middle_name"`
+	FamilyName     string           `json:"family_name"`
+	Suffix         string           `json:"suffix"`
+	Nickname       string           `json:"nickname"`
+	Company        string           `json:"company"`
+	JobTitle       string           `json:"job_title"`
+	Birthday       pgtype.Date      `json:"birthday"`
+	Anniversaries  []byte           `json:"anniversaries"`
+	Notes          string           `json:"notes"`
+	Website        string           `json:"website"`
+	SocialProfiles []byte           `json:"social_profiles"`
+}
+
+type ContactAvatar struct {
+	ContactID      int32            `json:"contact_id"`
+	ObjectKey      string           `json:"object_key"`
+	ContentType    string           `json:"content_type"`
+	SizeBytes      int64            `json:"size_bytes"`
+	Etag           pgtype.Text      `json:"etag"`
+	ChecksumSha256 pgtype.Text      `json:"checksum_sha256"`
+	UploadedAt     pgtype.Timestamp `json:"uploaded_at"`
+	TenantID       int32            `json:"tenant_id"`
+}
+
+type ContactFavorite struct {
+	OwnerID   pgtype.Int4        `json:"owner_id"`
+	ContactID int32              `json:"contact_id"`
+	Pinned    bool               `json:"pinned"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	TenantID  int32              `json:"tenant_id"`
+}
+
+type ContactRelation struct {
+	ID        int32              `json:"id"`
+	ContactID int32              `json:"contact_id"`
+	RelatedID int32              `json:"related_id"`
+	Type      string             `json:"type"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	TenantID  int32              `json:"tenant_id"`
+}
+
+type ContactShare struct {
+	ID         int32              `json:"id"`
+	ContactID  int32              `json:"contact_id"`
+	UserID     pgtype.Int4        `json:"user_id"`
+	TeamID     pgtype.Int4        `json:"team_id"`
+	Permission string             `json:"permission"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	TenantID   int32              `json:"tenant_id"`
+}
+
+type ContactTag struct {
+	ContactID int32 `json:"contact_id"`
+	TagID     int32 `json:"tag_id"`
+	TenantID  int32 `json:"tenant_id"`
+}
+
+type ContactView struct {
+	OwnerID   pgtype.Int4        `json:"owner_id"`
+	ContactID int32              `json:"contact_id"`
+	ViewedAt  pgtype.Timestamptz `json:"viewed_at"`
+	TenantID  int32              `json:"tenant_id"`
+}
+
+type CustomField struct {
+	ID        int32              `json:"id"`
+	OwnerID   pgtype.Int4        `json:"owner_id"`
+	Name      string             `json:"name"`
+	Type      string             `json:"type"`
+	Required  bool               `json:"required"`
+	Pattern   pgtype.Text        `json:"pattern"`
+	Options   []string           `json:"options"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	TenantID  int32              `json:"tenant_id"`
+}
+
+type Interaction struct {
+	ID              int32              `json:"id"`
+	ContactID       int32              `json:"contact_id"`
+	OwnerID         pgtype.Int4        `json:"owner_id"`
+	Type            string             `json:"type"`
+	Direction       string             `json:"direction"`
+	OccurredAt      pgtype.Timestamptz `json:"occurred_at"`
+	DurationSeconds pgtype.Int4        `json:"duration_seconds"`
+	Notes           string             `json:"notes"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	TenantID        int32              `json:"tenant_id"`
+}
+
+type RateLimit struct {
+	Key       string             `json:"key"`
+	Tokens    float64            `json:"tokens"`
+	Allowed   bool               `json:"allowed"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	FullAt    pgtype.Timestamptz `json:"full_at"`
+}
+
+type Reminder struct {
+	ID            int32              `json:"id"`
+	ContactID     int32              `json:"contact_id"`
+	OwnerID       pgtype.Int4        `json:"owner_id"`
+	Kind          string             `json:"kind"`
+	Label         string             `json:"label"`
+	Note          string             `json:"note"`
+	OccursOn      pgtype.Date        `json:"occurs_on"`
+	DueAt         pgtype.Timestamptz `json:"due_at"`
+	NotifiedAt    pgtype.Timestamptz `json:"notified_at"`
+	SnoozedAt     pgtype.Timestamptz `json:"snoozed_at"`
+	CompletedAt   pgtype.Timestamptz `json:"completed_at"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	TenantID      int32              `json:"tenant_id"`
+	Attempts      int32              `json:"attempts"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	DeliveredTo   []string           `json:"delivered_to"`
+}
+
+type ReminderSetting struct {
+	OwnerID    pgtype.Int4        `json:"owner_id"`
+	TimeZone   string             `json:"time_zone"`
+	NotifyHour int32              `json:"notify_hour"`
+	Email      string             `json:"email"`
+	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+	TenantID   int32              `json:"tenant_id"`
+}
+
+type SmartGroup struct {
+	ID        int32              `json:"id"`
+	OwnerID   pgtype.Int4        `json:"owner_id"`
+	Name      string             `json:"name"`
+	Filter    string             `json:"filter"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	TenantID  int32              `json:"tenant_id"`
+}
+
+type Tag struct {
+	ID        int32              `json:"id"`
+	OwnerID   pgtype.Int4        `json:"owner_id"`
+	Name      string             `json:"name"`
+	Color     string             `json:"color"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	TenantID  int32              `json:"tenant_id"`
+}
+
+type Team struct {
+	ID        int32              `json:"id"`
+	Name      string             `json:"name"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	TenantID  int32              `json:"tenant_id"`
+}
+
+type TeamMember struct {
+	TeamID    int32              `json:"team_id"`
+	UserID    int32              `json:"user_id"`
+	Role      string             `json:"role"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	TenantID  int32              `json:"tenant_id"`
+}
+
+type Tenant struct {
+	ID        int32              `json:"id"`
+	Name      string             `json:"name"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
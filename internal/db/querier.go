@@ -6,14 +6,145 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	// Leases up to batch_size due reminders of every tenant for lease_seconds and
+	// returns what their notifications need. Replicas that claim at the same time
+	// get different reminders, and a lease that runs out without the reminder
+	// being marked notified or retried makes it due again. Reminders that were
+	// tried max_attempts times are left alone.
+	ClaimDueReminders(ctx context.Context, arg ClaimDueRemindersParams) ([]ClaimDueRemindersRow, error)
+	CompleteReminder(ctx context.Context, arg CompleteReminderParams) (Reminder, error)
+	CountContactsByOwnerIDs(ctx context.Context, arg CountContactsByOwnerIDsParams) ([]CountContactsByOwnerIDsRow, error)
+	CountTeamOwners(ctx context.Context, teamID int32) (int64, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
 	CreateContact(ctx context.Context, arg CreateContactParams) (Contact, error)
-	DeleteContact(ctx context.Context, id int32) error
-	GetContactByID(ctx context.Context, id int32) (Contact, error)
-	GetContacts(ctx context.Context) ([]Contact, error)
+	CreateCustomField(ctx context.Context, arg CreateCustomFieldParams) (CustomField, error)
+	CreateFollowUp(ctx context.Context, arg CreateFollowUpParams) (Reminder, error)
+	CreateInteraction(ctx context.Context, arg CreateInteractionParams) (Interaction, error)
+	// An occurrence that has a reminder already is left alone, whether it is
+	// done or not.
+	CreateOccurrenceReminder(ctx context.Context, arg CreateOccurrenceReminderParams) (int64, error)
+	CreateRelation(ctx context.Context, arg CreateRelationParams) (ContactRelation, error)
+	CreateSmartGroup(ctx context.Context, arg CreateSmartGroupParams) (SmartGroup, error)
+	CreateTag(ctx context.Context, arg CreateTagParams) (Tag, error)
+	// The creator becomes the first owner of the team.
+	CreateTeam(ctx context.Context, arg CreateTeamParams) (Team, error)
+	CreateTenant(ctx context.Context, name string) (Tenant, error)
+	DeleteContact(ctx context.Context, arg DeleteContactParams) (int64, error)
+	DeleteContactShare(ctx context.Context, arg DeleteContactShareParams) (int64, error)
+	// Deleting a field removes its values from the contacts of its owner.
+	DeleteCustomField(ctx context.Context, arg DeleteCustomFieldParams) (int64, error)
+	DeleteFavorite(ctx context.Context, arg DeleteFavoriteParams) (int64, error)
+	DeleteFullRateLimits(ctx context.Context) (int64, error)
+	DeleteRelation(ctx context.Context, id int32) (int64, error)
+	DeleteSmartGroup(ctx context.Context, arg DeleteSmartGroupParams) (int64, error)
+	// Deletes the pending birthday and anniversary reminders of every tenant whose
+	// date was changed or removed since they were planned. A February 29 date
+	// falls on February 28 in other years.
+	DeleteStaleOccurrenceReminders(ctx context.Context) (int64, error)
+	DeleteTag(ctx context.Context, arg DeleteTagParams) (int64, error)
+	DeleteTeam(ctx context.Context, id int32) (int64, error)
+	DeleteTeamMember(ctx context.Context, arg DeleteTeamMemberParams) (int64, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (ApiKey, error)
+	GetContactAccess(ctx context.Context, arg GetContactAccessParams) (pgtype.Text, error)
+	GetContactAvatar(ctx context.Context, arg GetContactAvatarParams) (ContactAvatar, error)
+	GetContactByID(ctx context.Context, arg GetContactByIDParams) (Contact, error)
+	GetContactStats(ctx context.Context) (GetContactStatsRow, error)
+	// Without tags every visible contact is returned. Otherwise contacts need one
+	// of the tags of tag_owner_id with these names, or all of them with match_all.
+	// Tag names are matched regardless of case.
+	// Contacts also need to contain the custom_fields object, if given. They are
+	// sorted by sort_by, last_contacted_at or interaction_count, with contacts
+	// never contacted as the least recently contacted ones, or by the custom
+	// field sort_field, missing values last, then by name, or only by name
+	// without either. With favorites_first, the pinned contacts and then the
+	// other favorites of favorite_owner_id come before the rest.
+	GetContacts(ctx context.Context, arg GetContactsParams) ([]Contact, error)
+	GetContactsByIDs(ctx context.Context, arg GetContactsByIDsParams) ([]Contact, error)
+	GetCustomField(ctx context.Context, arg GetCustomFieldParams) (CustomField, error)
+	// The relation if it has the contact at either end.
+	GetRelation(ctx context.Context, arg GetRelationParams) (ContactRelation, error)
+	// The contacts related to a contact through at most depth relations, with the
+	// number of relations to the nearest path. Paths only lead through contacts
+	// the viewer can see.
+	GetRelationGraph(ctx context.Context, arg GetRelationGraphParams) ([]GetRelationGraphRow, error)
+	GetReminderSettings(ctx context.Context, ownerID pgtype.Int4) (ReminderSetting, error)
+	GetSmartGroup(ctx context.Context, arg GetSmartGroupParams) (SmartGroup, error)
+	GetTag(ctx context.Context, arg GetTagParams) (Tag, error)
+	GetTeam(ctx context.Context, id int32) (Team, error)
+	GetTeamMember(ctx context.Context, arg GetTeamMemberParams) (TeamMember, error)
+	ListAPIKeys(ctx context.Context, ownerID pgtype.Int4) ([]ApiKey, error)
+	// A page of the contacts of every tenant with an owner and a birthday or
+	// anniversaries, after the contact after_id, with the reminder settings of
+	// their owners if they have any. Reminders belong to users, so contacts
+	// created anonymously get none.
+	ListContactDates(ctx context.Context, arg ListContactDatesParams) ([]ListContactDatesRow, error)
+	ListContactShares(ctx context.Context, contactID int32) ([]ContactShare, error)
+	ListContactTags(ctx context.Context, arg ListContactTagsParams) ([]ListContactTagsRow, error)
+	ListContactsPage(ctx context.Context, arg ListContactsPageParams) ([]Contact, error)
+	ListCustomFields(ctx context.Context, ownerID pgtype.Int4) ([]CustomField, error)
+	// The favorites of the owner that the viewer can see, pinned ones first.
+	ListFavoriteContacts(ctx context.Context, arg ListFavoriteContactsParams) ([]Contact, error)
+	// The contacts among contact_ids that are favorites of the owner.
+	ListFavoriteFlags(ctx context.Context, arg ListFavoriteFlagsParams) ([]ListFavoriteFlagsRow, error)
+	// The time of the last interaction and the number of interactions with
+	// each of the contacts that have any.
+	ListInteractionStats(ctx context.Context, contactIds []int32) ([]ListInteractionStatsRow, error)
+	// A page of the interactions with a contact the viewer can see, latest
+	// first, after the interaction before_at and before_id if given.
+	ListInteractions(ctx context.Context, arg ListInteractionsParams) ([]Interaction, error)
+	// The contacts the owner viewed that the viewer can still see, the most
+	// recently viewed first.
+	ListRecentContacts(ctx context.Context, arg ListRecentContactsParams) ([]Contact, error)
+	// The relations of a contact with the contacts the viewer can see, by the
+	// name of the other contact.
+	ListRelations(ctx context.Context, arg ListRelationsParams) ([]ListRelationsRow, error)
+	// The relations between the contacts.
+	ListRelationsAmong(ctx context.Context, contactIds []int32) ([]ContactRelation, error)
+	ListSmartGroups(ctx context.Context, ownerID pgtype.Int4) ([]SmartGroup, error)
+	ListTags(ctx context.Context, ownerID pgtype.Int4) ([]Tag, error)
+	ListTeamMembers(ctx context.Context, teamID int32) ([]TeamMember, error)
+	ListTeamsForUser(ctx context.Context, userID int32) ([]ListTeamsForUserRow, error)
+	ListTenants(ctx context.Context) ([]Tenant, error)
+	// Open reminders of the owner that are due before until, overdue ones
+	// included, on contacts the viewer can still see.
+	ListUpcomingReminders(ctx context.Context, arg ListUpcomingRemindersParams) ([]ListUpcomingRemindersRow, error)
+	// Ends the lease of a reminder that every notifier has sent.
+	MarkReminderNotified(ctx context.Context, arg MarkReminderNotifiedParams) error
+	RecordContactView(ctx context.Context, arg RecordContactViewParams) error
+	// Makes a reminder that some notifiers failed to send due again at retry_at,
+	// remembering the notifiers that did send it.
+	RetryReminder(ctx context.Context, arg RetryReminderParams) error
+	RevokeAPIKey(ctx context.Context, id int32) (ApiKey, error)
+	ShareContactWithTeam(ctx context.Context, arg ShareContactWithTeamParams) (ContactShare, error)
+	ShareContactWithUser(ctx context.Context, arg ShareContactWithUserParams) (ContactShare, error)
+	// Snoozing makes the reminder due again at until, even after its
+	// notification was sent.
+	SnoozeReminder(ctx context.Context, arg SnoozeReminderParams) (Reminder, error)
+	// Contacts that viewer_id cannot see and those already tagged are skipped.
+	TagContacts(ctx context.Context, arg TagContactsParams) (int64, error)
+	TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenParams) (TakeRateLimitTokenRow, error)
+	// Writes are skipped while the recorded time is recent, so that busy keys do
+	// not update their row on every request.
+	TouchAPIKey(ctx context.Context, id int32) error
+	UntagContacts(ctx context.Context, arg UntagContactsParams) (int64, error)
+	// A NULL custom_fields keeps the current values.
 	UpdateContact(ctx context.Context, arg UpdateContactParams) (Contact, error)
+	// The name and type of a field are fixed, as contacts store values by name.
+	UpdateCustomField(ctx context.Context, arg UpdateCustomFieldParams) (CustomField, error)
+	UpdateRelation(ctx context.Context, arg UpdateRelationParams) (ContactRelation, error)
+	UpdateSmartGroup(ctx context.Context, arg UpdateSmartGroupParams) (SmartGroup, error)
+	UpdateTag(ctx context.Context, arg UpdateTagParams) (Tag, error)
+	UpsertContactAvatar(ctx context.Context, arg UpsertContactAvatarParams) (ContactAvatar, error)
+	UpsertFavorite(ctx context.Context, arg UpsertFavoriteParams) (ContactFavorite, error)
+	// Birthday and anniversary reminders that are pending and not snoozed move
+	// to the new hour and time zone.
+	UpsertReminderSettings(ctx context.Context, arg UpsertReminderSettingsParams) (ReminderSetting, error)
+	UpsertTeamMember(ctx context.Context, arg UpsertTeamMemberParams) (TeamMember, error)
 }
 
 var _ Querier = (*Queries)(nil)
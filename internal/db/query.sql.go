@@ -7,109 +7,2759 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const claimDueReminders = `-- name: ClaimDueReminders :many
+UPDATE reminders r
+SET attempts = r.attempts + 1,
+    next_attempt_at = now() + make_interval(secs => $1::int)
+FROM contacts c
+WHERE c.id = r.contact_id
+    AND r.id IN (
+        SELECT id
+        FROM reminders
+        WHERE notified_at IS NULL
+            AND completed_at IS NULL
+            AND due_at <= now()
+            AND (
+                next_attempt_at IS NULL
+                OR next_attempt_at <= now()
+            )
+            AND attempts < $2::int
+        ORDER BY due_at ASC
+        LIMIT $3 FOR
+        UPDATE SKIP LOCKED
+    )
+RETURNING r.id,
+    r.tenant_id,
+    r.owner_id,
+    r.contact_id,
+    c.name AS contact_name,
+    r.kind,
+    r.label,
+    r.note,
+    r.occurs_on,
+    r.due_at,
+    r.attempts,
+    r.delivered_to,
+    COALESCE(
+        (
+            SELECT s.email
+            FROM reminder_settings s
+            WHERE s.tenant_id = r.tenant_id
+                AND s.owner_id IS NOT DISTINCT FROM r.owner_id
+        ),
+        ''
+    )::text AS email
+`
+
+type ClaimDueRemindersParams struct {
+	LeaseSeconds int32 `json:"lease_seconds"`
+	MaxAttempts  int32 `json:"max_attempts"`
+	BatchSize    int32 `json:"batch_size"`
+}
+
+type ClaimDueRemindersRow struct {
+	ID          int32              `json:"id"`
+	TenantID    int32              `json:"tenant_id"`
+	OwnerID     pgtype.Int4        `json:"owner_id"`
+	ContactID   int32              `json:"contact_id"`
+	ContactName string             `json:"contact_name"`
+	Kind        string             `json:"kind"`
+	Label       string             `json:"label"`
+	Note        string             `json:"note"`
+	OccursOn    pgtype.Date        `json:"occurs_on"`
+	DueAt       pgtype.Timestamptz `json:"due_at"`
+	Attempts    int32              `json:"attempts"`
+	DeliveredTo []string           `json:"delivered_to"`
+	Email       string             `json:"email"`
+}
+
+// Leases up to batch_size due reminders of every tenant for lease_seconds and
+// returns what their notifications need. Replicas that claim at the same time
+// get different reminders, and a lease that runs out without the reminder
+// being marked notified or retried makes it due again. Reminders that were
+// tried max_attempts times are left alone.
+func (q *Queries) ClaimDueReminders(ctx context.Context, arg ClaimDueRemindersParams) ([]ClaimDueRemindersRow, error) {
+	rows, err := q.db.Query(ctx, claimDueReminders, arg.LeaseSeconds, arg.MaxAttempts, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimDueRemindersRow
+	for rows.Next() {
+		var i ClaimDueRemindersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.OwnerID,
+			&i.ContactID,
+			&i.ContactName,
+			&i.Kind,
+			&i.Label,
+			&i.Note,
+			&i.OccursOn,
+			&i.DueAt,
+			&i.Attempts,
+			&i.DeliveredTo,
+			&i.Email,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const completeReminder = `-- name: CompleteReminder :one
+UPDATE reminders
+SET completed_at = COALESCE(completed_at, now())
+WHERE id = $1
+    AND owner_id IS NOT DISTINCT FROM $2::int
+RETURNING id, contact_id, owner_id, kind, label, note, occurs_on, due_at, notified_at, snoozed_at, completed_at, created_at, tenant_id, attempts, next_attempt_at, delivered_to
+`
+
+type CompleteReminderParams struct {
+	ID      int32       `json:"id"`
+	OwnerID pgtype.Int4 `json:"owner_id"`
+}
+
+func (q *Queries) CompleteReminder(ctx context.Context, arg CompleteReminderParams) (Reminder, error) {
+	row := q.db.QueryRow(ctx, completeReminder, arg.ID, arg.OwnerID)
+	var i Reminder
+	err := row.Scan(
+		&i.ID,
+		&i.ContactID,
+		&i.OwnerID,
+		&i.Kind,
+		&i.Label,
+		&i.Note,
+		&i.OccursOn,
+		&i.DueAt,
+		&i.NotifiedAt,
+		&i.SnoozedAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.TenantID,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.DeliveredTo,
+	)
+	return i, err
+}
+
+const countContactsByOwnerIDs = `-- name: CountContactsByOwnerIDs :many
+SELECT owner_id,
+    COUNT(*) AS contact_count
+FROM contacts
+WHERE owner_id = ANY($1::int[])
+    AND contact_access(id, owner_id, $2::int) IS NOT NULL
+GROUP BY owner_id
+`
+
+type CountContactsByOwnerIDsParams struct {
+	OwnerIds []int32     `json:"owner_ids"`
+	ViewerID pgtype.Int4 `json:"viewer_id"`
+}
+
+type CountContactsByOwnerIDsRow struct {
+	OwnerID      pgtype.Int4 `json:"owner_id"`
+	ContactCount int64       `json:"contact_count"`
+}
+
+func (q *Queries) CountContactsByOwnerIDs(ctx context.Context, arg CountContactsByOwnerIDsParams) ([]CountContactsByOwnerIDsRow, error) {
+	rows, err := q.db.Query(ctx, countContactsByOwnerIDs, arg.OwnerIds, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountContactsByOwnerIDsRow
+	for rows.Next() {
+		var i CountContactsByOwnerIDsRow
+		if err := rows.Scan(&i.OwnerID, &i.ContactCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countTeamOwners = `-- name: CountTeamOwners :one
+SELECT COUNT(*)
+FROM team_members
+WHERE team_id = $1
+    AND role = 'owner'
+`
+
+func (q *Queries) CountTeamOwners(ctx context.Context, teamID int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countTeamOwners, teamID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAPIKey = `-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+        name,
+        prefix,
+        key_hash,
+        owner_id,
+        scopes,
+        expires_at,
+        tenant_id
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, name, prefix, key_hash, owner_id, scopes, expires_at, last_used_at, created_at, revoked_at, tenant_id
+`
+
+type CreateAPIKeyParams struct {
+	Name      string             `json:"name"`
+	Prefix    string             `json:"prefix"`
+	KeyHash   []byte             `json:"key_hash"`
+	OwnerID   int32              `json:"owner_id"`
+	Scopes    []string           `json:"scopes"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	TenantID  int32              `json:"tenant_id"`
+}
+
+func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, createAPIKey,
+		arg.Name,
+		arg.Prefix,
+		arg.KeyHash,
+		arg.OwnerID,
+		arg.Scopes,
+		arg.ExpiresAt,
+		arg.TenantID,
+	)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		&i.OwnerID,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+		&i.RevokedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const createContact = `-- name: CreateContact :one
+INSERT INTO contacts (
+        name,
+        phone,
+        owner_id,
+        prefix,
+        given_name,
+        middle_name,
+        family_name,
+        suffix,
+        nickname,
+        company,
+        job_title,
+        birthday,
+        anniversaries,
+        notes,
+        website,
+        social_profiles,
+        custom_fields
+    )
+VALUES (
+        $1,
+        $2,
+        $3,
+        $4,
+        $5,
+        $6,
+        $7,
+        $8,
+        $9,
+        $10,
+        $11,
+        $12,
+        $13,
+        $14,
+        $15,
+        $16,
+        COALESCE($17::jsonb, '{}')
+    )
+RETURNING id, name, phone, owner_id, created_at, tenant_id, custom_fields, prefix, given_name, middle_name, family_name, suffix, nickname, company, job_title, birthday, anniversaries, notes, website, social_profiles
+`
+
+type CreateContactParams struct {
+	Name           string      `json:"name"`
+	Phone          string      `json:"phone"`
+	OwnerID        pgtype.Int4 `json:"owner_id"`
+	Prefix         string      `json:"prefix"`
+	GivenName      string      `json:"given_name"`
+	MiddleName     string      `json:"middle_name"`
+	FamilyName     string      `json:"family_name"`
+	Suffix         string      `json:"suffix"`
+	Nickname       string      `json:"nickname"`
+	Company        string      `json:"company"`
+	JobTitle       string      `json:"job_title"`
+	Birthday       pgtype.Date `json:"birthday"`
+	Anniversaries  []byte      `json:"anniversaries"`
+	Notes          string      `json:"notes"`
+	Website        string      `json:"website"`
+	SocialProfiles []byte      `json:"social_profiles"`
+	CustomFields   []byte      `json:"custom_fields"`
+}
+
+func (q *Queries) CreateContact(ctx context.Context, arg CreateContactParams) (Contact, error) {
+	row := q.db.QueryRow(ctx, createContact,
+		arg.Name,
+		arg.Phone,
+		arg.OwnerID,
+		arg.Prefix,
+		arg.GivenName,
+		arg.MiddleName,
+		arg.FamilyName,
+		arg.Suffix,
+		arg.Nickname,
+		arg.Company,
+		arg.JobTitle,
+		arg.Birthday,
+		arg.Anniversaries,
+		arg.Notes,
+		arg.Website,
+		arg.SocialProfiles,
+		arg.CustomFields,
+	)
+	var i Contact
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Phone,
+		&i.OwnerID,
+		&i.CreatedAt,
+		&i.TenantID,
+		&i.CustomFields,
+		&i.Prefix,
+		&i.GivenName,
+		&i.MiddleName,
+		&i.FamilyName,
+		&i.Suffix,
+		&i.Nickname,
+		&i.Company,
+		&i.JobTitle,
+		&i.Birthday,
+		&i.Anniversaries,
+		&i.Notes,
+		&i.Website,
+		&i.SocialProfiles,
+	)
+	return i, err
+}
+
+const createCustomField = `-- name: CreateCustomField :one
+INSERT INTO custom_fields (name, type, required, pattern, options, owner_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, owner_id, name, type, required, pattern, options, created_at, tenant_id
+`
+
+type CreateCustomFieldParams struct {
+	Name     string      `json:"name"`
+	Type     string      `json:"type"`
+	Required bool        `json:"required"`
+	Pattern  pgtype.Text `json:"pattern"`
+	Options  []string    `json:"options"`
+	OwnerID  pgtype.Int4 `json:"owner_id"`
+}
+
+func (q *Queries) CreateCustomField(ctx context.Context, arg CreateCustomFieldParams) (CustomField, error) {
+	row := q.db.QueryRow(ctx, createCustomField,
+		arg.Name,
+		arg.Type,
+		arg.Required,
+		arg.Pattern,
+		arg.Options,
+		arg.OwnerID,
+	)
+	var i CustomField
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Name,
+		&i.Type,
+		&i.Required,
+		&i.Pattern,
+		&i.Options,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const createFollowUp = `-- name: CreateFollowUp :one
+INSERT INTO reminders (contact_id, kind, note, due_at, owner_id)
+VALUES ($1, 'follow_up', $2, $3, $4)
+RETURNING id, contact_id, owner_id, kind, label, note, occurs_on, due_at, notified_at, snoozed_at, completed_at, created_at, tenant_id, attempts, next_attempt_at, delivered_to
+`
+
+type CreateFollowUpParams struct {
+	ContactID int32              `json:"contact_id"`
+	Note      string             `json:"note"`
+	DueAt     pgtype.Timestamptz `json:"due_at"`
+	OwnerID   pgtype.Int4        `json:"owner_id"`
+}
+
+func (q *Queries) CreateFollowUp(ctx context.Context, arg CreateFollowUpParams) (Reminder, error) {
+	row := q.db.QueryRow(ctx, createFollowUp,
+		arg.ContactID,
+		arg.Note,
+		arg.DueAt,
+		arg.OwnerID,
+	)
+	var i Reminder
+	err := row.Scan(
+		&i.ID,
+		&i.ContactID,
+		&i.OwnerID,
+		&i.Kind,
+		&i.Label,
+		&i.Note,
+		&i.OccursOn,
+		&i.DueAt,
+		&i.NotifiedAt,
+		&i.SnoozedAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.TenantID,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.DeliveredTo,
+	)
+	return i, err
+}
+
+const createInteraction = `-- name: CreateInteraction :one
+INSERT INTO interactions (
+        contact_id,
+        owner_id,
+        type,
+        direction,
+        occurred_at,
+        duration_seconds,
+        notes
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, contact_id, owner_id, type, direction, occurred_at, duration_seconds, notes, created_at, tenant_id
+`
+
+type CreateInteractionParams struct {
+	ContactID       int32              `json:"contact_id"`
+	OwnerID         pgtype.Int4        `json:"owner_id"`
+	Type            string             `json:"type"`
+	Direction       string             `json:"direction"`
+	OccurredAt      pgtype.Timestamptz `json:"occurred_at"`
+	DurationSeconds pgtype.Int4        `json:"duration_seconds"`
+	Notes           string             `json:"notes"`
+}
+
+func (q *Queries) CreateInteraction(ctx context.Context, arg CreateInteractionParams) (Interaction, error) {
+	row := q.db.QueryRow(ctx, createInteraction,
+		arg.ContactID,
+		arg.OwnerID,
+		arg.Type,
+		arg.Direction,
+		arg.OccurredAt,
+		arg.DurationSeconds,
+		arg.Notes,
+	)
+	var i Interaction
+	err := row.Scan(
+		&i.ID,
+		&i.ContactID,
+		&i.OwnerID,
+		&i.Type,
+		&i.Direction,
+		&i.OccurredAt,
+		&i.DurationSeconds,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const createOccurrenceReminder = `-- name: CreateOccurrenceReminder :execrows
+INSERT INTO reminders (
+        contact_id,
+        owner_id,
+        kind,
+        label,
+        occurs_on,
+        due_at,
+        tenant_id
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (contact_id, kind, label, occurs_on)
+WHERE occurs_on IS NOT NULL DO NOTHING
+`
+
+type CreateOccurrenceReminderParams struct {
+	ContactID int32              `json:"contact_id"`
+	OwnerID   pgtype.Int4        `json:"owner_id"`
+	Kind      string             `json:"kind"`
+	Label     string             `json:"label"`
+	OccursOn  pgtype.Date        `json:"occurs_on"`
+	DueAt     pgtype.Timestamptz `json:"due_at"`
+	TenantID  int32              `json:"tenant_id"`
+}
+
+// An occurrence that has a reminder already is left alone, whether it is
+// done or not.
+func (q *Queries) CreateOccurrenceReminder(ctx context.Context, arg CreateOccurrenceReminderParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createOccurrenceReminder,
+		arg.ContactID,
+		arg.OwnerID,
+		arg.Kind,
+		arg.Label,
+		arg.OccursOn,
+		arg.DueAt,
+		arg.TenantID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const createRelation = `-- name: CreateRelation :one
+INSERT INTO contact_relations (contact_id, related_id, type)
+VALUES ($1, $2, $3)
+RETURNING id, contact_id, related_id, type, created_at, tenant_id
+`
+
+type CreateRelationParams struct {
+	ContactID int32  `json:"contact_id"`
+	RelatedID int32  `json:"related_id"`
+	Type      string `json:"type"`
+}
+
+func (q *Queries) CreateRelation(ctx context.Context, arg CreateRelationParams) (ContactRelation, error) {
+	row := q.db.QueryRow(ctx, createRelation, arg.ContactID, arg.RelatedID, arg.Type)
+	var i ContactRelation
+	err := row.Scan(
+		&i.ID,
+		&i.ContactID,
+		&i.RelatedID,
+		&i.Type,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const createSmartGroup = `-- name: CreateSmartGroup :one
+INSERT INTO smart_groups (name, filter, owner_id)
+VALUES ($1, $2, $3)
+RETURNING id, owner_id, name, filter, created_at, tenant_id
+`
+
+type CreateSmartGroupParams struct {
+	Name    string      `json:"name"`
+	Filter  string      `json:"filter"`
+	OwnerID pgtype.Int4 `json:"owner_id"`
+}
+
+func (q *Queries) CreateSmartGroup(ctx context.Context, arg CreateSmartGroupParams) (SmartGroup, error) {
+	row := q.db.QueryRow(ctx, createSmartGroup, arg.Name, arg.Filter, arg.OwnerID)
+	var i SmartGroup
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Name,
+		&i.Filter,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const createTag = `-- name: CreateTag :one
+INSERT INTO tags (name, color, owner_id)
+VALUES ($1, $2, $3)
+RETURNING id, owner_id, name, color, created_at, tenant_id
+`
+
+type CreateTagParams struct {
+	Name    string      `json:"name"`
+	Color   string      `json:"color"`
+	OwnerID pgtype.Int4 `json:"owner_id"`
+}
+
+func (q *Queries) CreateTag(ctx context.Context, arg CreateTagParams) (Tag, error) {
+	row := q.db.QueryRow(ctx, createTag, arg.Name, arg.Color, arg.OwnerID)
+	var i Tag
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Name,
+		&i.Color,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const createTeam = `-- name: CreateTeam :one
+WITH team AS (
+    INSERT INTO teams (name)
+    VALUES ($1)
+    RETURNING id, name, created_at, tenant_id
+),
+owner AS (
+    INSERT INTO team_members (team_id, user_id, role)
+    SELECT id,
+        $2,
+        'owner'
+    FROM team
+)
+SELECT id, name, created_at, tenant_id
+FROM team
+`
+
+type CreateTeamParams struct {
+	Name    string `json:"name"`
+	OwnerID int32  `json:"owner_id"`
+}
+
+// The creator becomes the first owner of the team.
+func (q *Queries) CreateTeam(ctx context.Context, arg CreateTeamParams) (Team, error) {
+	row := q.db.QueryRow(ctx, createTeam, arg.Name, arg.OwnerID)
+	var i Team
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const createTenant = `-- name: CreateTenant :one
+INSERT INTO tenants (name)
+VALUES ($1)
+RETURNING id, name, created_at
+`
+
+func (q *Queries) CreateTenant(ctx context.Context, name string) (Tenant, error) {
+	row := q.db.QueryRow(ctx, createTenant, name)
+	var i Tenant
+	err := row.Scan(&i.ID, &i.Name, &i.CreatedAt)
+	return i, err
+}
+
+const deleteContact = `-- name: DeleteContact :execrows
+DELETE FROM contacts
+WHERE id = $1
+    AND contact_access(id, owner_id, $2::int) = 'write'
+`
+
+type DeleteContactParams struct {
+	ID       int32       `json:"id"`
+	ViewerID pgtype.Int4 `json:"viewer_id"`
+}
+
+func (q *Queries) DeleteContact(ctx context.Context, arg DeleteContactParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteContact, arg.ID, arg.ViewerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteContactShare = `-- name: DeleteContactShare :execrows
+DELETE FROM contact_shares
+WHERE id = $1
+    AND contact_id = $2
+`
+
+type DeleteContactShareParams struct {
+	ID        int32 `json:"id"`
+	ContactID int32 `json:"contact_id"`
+}
+
+func (q *Queries) DeleteContactShare(ctx context.Context, arg DeleteContactShareParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteContactShare, arg.ID, arg.ContactID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteCustomField = `-- name: DeleteCustomField :one
+WITH deleted AS (
+    DELETE FROM custom_fields
+    WHERE id = $1
+        AND owner_id IS NOT DISTINCT FROM $2::int
+    RETURNING owner_id,
+        name
+),
+cleared AS (
+    UPDATE contacts c
+    SET custom_fields = c.custom_fields - d.name
+    FROM deleted d
+    WHERE c.owner_id IS NOT DISTINCT FROM d.owner_id
+        AND c.custom_fields ? d.name
+    RETURNING c.id
 )
+SELECT COUNT(*)
+FROM deleted
+`
+
+type DeleteCustomFieldParams struct {
+	ID      int32       `json:"id"`
+	OwnerID pgtype.Int4 `json:"owner_id"`
+}
+
+// Deleting a field removes its values from the contacts of its owner.
+func (q *Queries) DeleteCustomField(ctx context.Context, arg DeleteCustomFieldParams) (int64, error) {
+	row := q.db.QueryRow(ctx, deleteCustomField, arg.ID, arg.OwnerID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteFavorite = `-- name: DeleteFavorite :execrows
+DELETE FROM contact_favorites
+WHERE contact_id = $1
+    AND owner_id IS NOT DISTINCT FROM $2::int
+`
+
+type DeleteFavoriteParams struct {
+	ContactID int32       `json:"contact_id"`
+	OwnerID   pgtype.Int4 `json:"owner_id"`
+}
+
+func (q *Queries) DeleteFavorite(ctx context.Context, arg DeleteFavoriteParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteFavorite, arg.ContactID, arg.OwnerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteFullRateLimits = `-- name: DeleteFullRateLimits :execrows
+DELETE FROM rate_limits
+WHERE full_at <= now()
+`
+
+func (q *Queries) DeleteFullRateLimits(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteFullRateLimits)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteRelation = `-- name: DeleteRelation :execrows
+DELETE FROM contact_relations
+WHERE id = $1
+`
+
+func (q *Queries) DeleteRelation(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteRelation, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteSmartGroup = `-- name: DeleteSmartGroup :execrows
+DELETE FROM smart_groups
+WHERE id = $1
+    AND owner_id IS NOT DISTINCT FROM $2::int
+`
+
+type DeleteSmartGroupParams struct {
+	ID      int32       `json:"id"`
+	OwnerID pgtype.Int4 `json:"owner_id"`
+}
+
+func (q *Queries) DeleteSmartGroup(ctx context.Context, arg DeleteSmartGroupParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSmartGroup, arg.ID, arg.OwnerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteStaleOccurrenceReminders = `-- name: DeleteStaleOccurrenceReminders :execrows
+DELETE FROM reminders r USING contacts c
+WHERE c.id = r.contact_id
+    AND r.occurs_on IS NOT NULL
+    AND r.notified_at IS NULL
+    AND r.completed_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1
+        FROM (
+                SELECT 'birthday' AS kind,
+                    '' AS label,
+                    to_char(c.birthday, 'MM-DD') AS month_day
+                WHERE c.birthday IS NOT NULL
+                UNION ALL
+                SELECT 'anniversary',
+                    a->>'label',
+                    right(a->>'date', 5)
+                FROM jsonb_array_elements(c.anniversaries) a
+            ) d
+        WHERE d.kind = r.kind
+            AND d.label = r.label
+            AND (
+                d.month_day = to_char(r.occurs_on, 'MM-DD')
+                OR (
+                    d.month_day = '02-29'
+                    AND to_char(r.occurs_on, 'MM-DD') = '02-28'
+                )
+            )
+    )
+`
+
+// Deletes the pending birthday and anniversary reminders of every tenant whose
+// date was changed or removed since they were planned. A February 29 date
+// falls on February 28 in other years.
+func (q *Queries) DeleteStaleOccurrenceReminders(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteStaleOccurrenceReminders)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteTag = `-- name: DeleteTag :execrows
+DELETE FROM tags
+WHERE id = $1
+    AND owner_id IS NOT DISTINCT FROM $2::int
+`
+
+type DeleteTagParams struct {
+	ID      int32       `json:"id"`
+	OwnerID pgtype.Int4 `json:"owner_id"`
+}
+
+func (q *Queries) DeleteTag(ctx context.Context, arg DeleteTagParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteTag, arg.ID, arg.OwnerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteTeam = `-- name: DeleteTeam :execrows
+DELETE FROM teams
+WHERE id = $1
+`
+
+func (q *Queries) DeleteTeam(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteTeam, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteTeamMember = `-- name: DeleteTeamMember :execrows
+DELETE FROM team_members
+WHERE team_id = $1
+    AND user_id = $2
+`
+
+type DeleteTeamMemberParams struct {
+	TeamID int32 `json:"team_id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) DeleteTeamMember(ctx context.Context, arg DeleteTeamMemberParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteTeamMember, arg.TeamID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getAPIKeyByPrefix = `-- name: GetAPIKeyByPrefix :one
+SELECT id, name, prefix, key_hash, owner_id, scopes, expires_at, last_used_at, created_at, revoked_at, tenant_id
+FROM api_keys
+WHERE prefix = $1
+`
+
+func (q *Queries) GetAPIKeyByPrefix(ctx context.Context, prefix string) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, getAPIKeyByPrefix, prefix)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		&i.OwnerID,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+		&i.RevokedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const getContactAccess = `-- name: GetContactAccess :one
+SELECT contact_access(id, owner_id, $2::int) AS access
+FROM contacts
+WHERE id = $1
+`
+
+type GetContactAccessParams struct {
+	ID       int32       `json:"id"`
+	ViewerID pgtype.Int4 `json:"viewer_id"`
+}
+
+func (q *Queries) GetContactAccess(ctx context.Context, arg GetContactAccessParams) (pgtype.Text, error) {
+	row := q.db.QueryRow(ctx, getContactAccess, arg.ID, arg.ViewerID)
+	var access pgtype.Text
+	err := row.Scan(&access)
+	return access, err
+}
+
+const getContactAvatar = `-- name: GetContactAvatar :one
+SELECT a.contact_id, a.object_key, a.content_type, a.size_bytes, a.etag, a.checksum_sha256, a.uploaded_at, a.tenant_id
+FROM contact_avatars a
+    JOIN contacts c ON c.id = a.contact_id
+WHERE a.contact_id = $1
+    AND contact_access(c.id, c.owner_id, $2::int) IS NOT NULL
+`
+
+type GetContactAvatarParams struct {
+	ContactID int32       `json:"contact_id"`
+	ViewerID  pgtype.Int4 `json:"viewer_id"`
+}
+
+func (q *Queries) GetContactAvatar(ctx context.Context, arg GetContactAvatarParams) (ContactAvatar, error) {
+	row := q.db.QueryRow(ctx, getContactAvatar, arg.ContactID, arg.ViewerID)
+	var i ContactAvatar
+	err := row.Scan(
+		&i.ContactID,
+		&i.ObjectKey,
+		&i.ContentType,
+		&i.SizeBytes,
+		&i.Etag,
+		&i.ChecksumSha256,
+		&i.UploadedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const getContactByID = `-- name: GetContactByID :one
+SELECT id, name, phone, owner_id, created_at, tenant_id, custom_fields, prefix, given_name, middle_name, family_name, suffix, nickname, company, job_title, birthday, anniversaries, notes, website, social_profiles
+FROM contacts
+WHERE id = $1
+    AND contact_access(id, owner_id, $2::int) IS NOT NULL
+`
+
+type GetContactByIDParams struct {
+	ID       int32       `json:"id"`
+	ViewerID pgtype.Int4 `json:"viewer_id"`
+}
+
+func (q *Queries) GetContactByID(ctx context.Context, arg GetContactByIDParams) (Contact, error) {
+	row := q.db.QueryRow(ctx, getContactByID, arg.ID, arg.ViewerID)
+	var i Contact
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Phone,
+		&i.OwnerID,
+		&i.CreatedAt,
+		&i.TenantID,
+		&i.CustomFields,
+		&i.Prefix,
+		&i.GivenName,
+		&i.MiddleName,
+		&i.FamilyName,
+		&i.Suffix,
+		&i.Nickname,
+		&i.Company,
+		&i.JobTitle,
+		&i.Birthday,
+		&i.Anniversaries,
+		&i.Notes,
+		&i.Website,
+		&i.SocialProfiles,
+	)
+	return i, err
+}
+
+const getContactStats = `-- name: GetContactStats :one
+SELECT (
+        SELECT COUNT(*)
+        FROM contacts
+    ) AS contacts,
+    (
+        SELECT COUNT(*)
+        FROM contact_avatars
+    ) AS avatars,
+    (
+        SELECT COUNT(DISTINCT owner_id)
+        FROM contacts
+    ) AS owners,
+    (
+        SELECT COALESCE(SUM(size_bytes), 0)
+        FROM contact_avatars
+    )::bigint AS avatar_bytes
+`
+
+type GetContactStatsRow struct {
+	Contacts    int64 `json:"contacts"`
+	Avatars     int64 `json:"avatars"`
+	Owners      int64 `json:"owners"`
+	AvatarBytes int64 `json:"avatar_bytes"`
+}
+
+func (q *Queries) GetContactStats(ctx context.Context) (GetContactStatsRow, error) {
+	row := q.db.QueryRow(ctx, getContactStats)
+	var i GetContactStatsRow
+	err := row.Scan(
+		&i.Contacts,
+		&i.Avatars,
+		&i.Owners,
+		&i.AvatarBytes,
+	)
+	return i, err
+}
+
+const getContacts = `-- name: GetContacts :many
+SELECT id, name, phone, owner_id, created_at, tenant_id, custom_fields, prefix, given_name, middle_name, family_name, suffix, nickname, company, job_title, birthday, anniversaries, notes, website, social_profiles
+FROM contacts c
+WHERE contact_access(c.id, c.owner_id, $1::int) IS NOT NULL
+    AND (
+        $2::text[] IS NULL
+        OR (
+            SELECT COUNT(*)
+            FROM contact_tags ct
+                JOIN tags t ON t.id = ct.tag_id
+            WHERE ct.contact_id = c.id
+                AND t.owner_id IS NOT DISTINCT FROM $3::int
+                AND lower(t.name) IN (
+                    SELECT lower(tag)
+                    FROM unnest($2::text[]) tag
+                )
+        ) >= CASE
+            WHEN $4::bool THEN (
+                SELECT COUNT(DISTINCT lower(tag))
+                FROM unnest($2::text[]) tag
+            )
+            ELSE 1
+        END
+    )
+    AND (
+        $5::jsonb IS NULL
+        OR c.custom_fields @> $5::jsonb
+    )
+ORDER BY CASE
+        WHEN $6::bool THEN (
+            SELECT CASE
+                    WHEN f.pinned THEN 0
+                    ELSE 1
+                END
+            FROM contact_favorites f
+            WHERE f.contact_id = c.id
+                AND f.owner_id IS NOT DISTINCT FROM $7::int
+        )
+    END ASC NULLS LAST,
+    CASE
+        WHEN NOT $8::bool
+        AND $9::text = 'last_contacted_at' THEN (
+            SELECT MAX(i.occurred_at)
+            FROM interactions i
+            WHERE i.contact_id = c.id
+        )
+    END ASC NULLS FIRST,
+    CASE
+        WHEN $8::bool
+        AND $9::text = 'last_contacted_at' THEN (
+            SELECT MAX(i.occurred_at)
+            FROM interactions i
+            WHERE i.contact_id = c.id
+        )
+    END DESC NULLS LAST,
+    CASE
+        WHEN NOT $8::bool
+        AND $9::text = 'interaction_count' THEN (
+            SELECT COUNT(*)
+            FROM interactions i
+            WHERE i.contact_id = c.id
+        )
+    END ASC,
+    CASE
+        WHEN $8::bool
+        AND $9::text = 'interaction_count' THEN (
+            SELECT COUNT(*)
+            FROM interactions i
+            WHERE i.contact_id = c.id
+        )
+    END DESC,
+    CASE
+        WHEN NOT $8::bool THEN c.custom_fields -> $10::text
+    END ASC NULLS LAST,
+    CASE
+        WHEN $8::bool THEN c.custom_fields -> $10::text
+    END DESC NULLS LAST,
+    CASE
+        WHEN $8::bool
+        AND $10::text IS NULL
+        AND $9::text IS NULL THEN c.name
+    END DESC,
+    c.name ASC
+`
+
+type GetContactsParams struct {
+	ViewerID        pgtype.Int4 `json:"viewer_id"`
+	Tags            []string    `json:"tags"`
+	TagOwnerID      pgtype.Int4 `json:"tag_owner_id"`
+	MatchAll        bool        `json:"match_all"`
+	CustomFields    []byte      `json:"custom_fields"`
+	FavoritesFirst  bool        `json:"favorites_first"`
+	FavoriteOwnerID pgtype.Int4 `json:"favorite_owner_id"`
+	SortDesc        bool        `json:"sort_desc"`
+	SortBy          pgtype.Text `json:"sort_by"`
+	SortField       pgtype.Text `json:"sort_field"`
+}
+
+// Without tags every visible contact is returned. Otherwise contacts need one
+// of the tags of tag_owner_id with these names, or all of them with match_all.
+// Tag names are matched regardless of case.
+// Contacts also need to contain the custom_fields object, if given. They are
+// sorted by sort_by, last_contacted_at or interaction_count, with contacts
+// never contacted as the least recently contacted ones, or by the custom
+// field sort_field, missing values last, then by name, or only by name
+// without either. With favorites_first, the pinned contacts and then the
+// other favorites of favorite_owner_id come before the rest.
+func (q *Queries) GetContacts(ctx context.Context, arg GetContactsParams) ([]Contact, error) {
+	rows, err := q.db.Query(ctx, getContacts,
+		arg.ViewerID,
+		arg.Tags,
+		arg.TagOwnerID,
+		arg.MatchAll,
+		arg.CustomFields,
+		arg.FavoritesFirst,
+		arg.FavoriteOwnerID,
+		arg.SortDesc,
+		arg.SortBy,
+		arg.SortField,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Contact
+	for rows.Next() {
+		var i Contact
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Phone,
+			&i.OwnerID,
+			&i.CreatedAt,
+			&i.TenantID,
+			&i.CustomFields,
+			&i.Prefix,
+			&i.GivenName,
+			&i.MiddleName,
+			&i.FamilyName,
+			&i.Suffix,
+			&i.Nickname,
+			&i.Company,
+			&i.JobTitle,
+			&i.Birthday,
+			&i.Anniversaries,
+			&i.Notes,
+			&i.Website,
+			&i.SocialProfiles,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getContactsByIDs = `-- name: GetContactsByIDs :many
+SELECT id, name, phone, owner_id, created_at, tenant_id, custom_fields, prefix, given_name, middle_name, family_name, suffix, nickname, company, job_title, birthday, anniversaries, notes, website, social_profiles
+FROM contacts
+WHERE id = ANY($1::int[])
+    AND contact_access(id, owner_id, $2::int) IS NOT NULL
+`
+
+type GetContactsByIDsParams struct {
+	Ids      []int32     `json:"ids"`
+	ViewerID pgtype.Int4 `json:"viewer_id"`
+}
+
+func (q *Queries) GetContactsByIDs(ctx context.Context, arg GetContactsByIDsParams) ([]Contact, error) {
+	rows, err := q.db.Query(ctx, getContactsByIDs, arg.Ids, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Contact
+	for rows.Next() {
+		var i Contact
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Phone,
+			&i.OwnerID,
+			&i.CreatedAt,
+			&i.TenantID,
+			&i.CustomFields,
+			&i.Prefix,
+			&i.GivenName,
+			&i.MiddleName,
+			&i.FamilyName,
+			&i.Suffix,
+			&i.Nickname,
+			&i.Company,
+			&i.JobTitle,
+			&i.Birthday,
+			&i.Anniversaries,
+			&i.Notes,
+			&i.Website,
+			&i.SocialProfiles,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCustomField = `-- name: GetCustomField :one
+SELECT id, owner_id, name, type, required, pattern, options, created_at, tenant_id
+FROM custom_fields
+WHERE id = $1
+    AND owner_id IS NOT DISTINCT FROM $2::int
+`
+
+type GetCustomFieldParams struct {
+	ID      int32       `json:"id"`
+	OwnerID pgtype.Int4 `json:"owner_id"`
+}
+
+func (q *Queries) GetCustomField(ctx context.Context, arg GetCustomFieldParams) (CustomField, error) {
+	row := q.db.QueryRow(ctx, getCustomField, arg.ID, arg.OwnerID)
+	var i CustomField
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Name,
+		&i.Type,
+		&i.Required,
+		&i.Pattern,
+		&i.Options,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const getRelation = `-- name: GetRelation :one
+SELECT id, contact_id, related_id, type, created_at, tenant_id
+FROM contact_relations
+WHERE id = $1
+    AND $2::int IN (contact_id, related_id)
+`
+
+type GetRelationParams struct {
+	ID        int32 `json:"id"`
+	ContactID int32 `json:"contact_id"`
+}
+
+// The relation if it has the contact at either end.
+func (q *Queries) GetRelation(ctx context.Context, arg GetRelationParams) (ContactRelation, error) {
+	row := q.db.QueryRow(ctx, getRelation, arg.ID, arg.ContactID)
+	var i ContactRelation
+	err := row.Scan(
+		&i.ID,
+		&i.ContactID,
+		&i.RelatedID,
+		&i.Type,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const getRelationGraph = `-- name: GetRelationGraph :many
+WITH RECURSIVE edges AS (
+    SELECT contact_id AS from_id,
+        related_id AS to_id
+    FROM contact_relations
+    UNION ALL
+    SELECT related_id,
+        contact_id
+    FROM contact_relations
+),
+reach (id, depth) AS (
+    SELECT $1::int,
+        0
+    UNION
+    SELECT e.to_id,
+        r.depth + 1
+    FROM reach r
+        JOIN edges e ON e.from_id = r.id
+        JOIN contacts c ON c.id = e.to_id
+    WHERE r.depth < $2::int
+        AND contact_access(c.id, c.owner_id, $3::int) IS NOT NULL
+)
+SELECT c.id,
+    c.name,
+    MIN(r.depth)::int AS depth
+FROM reach r
+    JOIN contacts c ON c.id = r.id
+GROUP BY c.id,
+    c.name
+ORDER BY depth ASC,
+    c.name ASC
+`
+
+type GetRelationGraphParams struct {
+	ContactID int32       `json:"contact_id"`
+	Depth     int32       `json:"depth"`
+	ViewerID  pgtype.Int4 `json:"viewer_id"`
+}
+
+type GetRelationGraphRow struct {
+	ID    int32  `json:"id"`
+	Name  string `json:"name"`
+	Depth int32  `json:"depth"`
+}
+
+// The contacts related to a contact through at most depth relations, with the
+// number of relations to the nearest path. Paths only lead through contacts
+// the viewer can see.
+func (q *Queries) GetRelationGraph(ctx context.Context, arg GetRelationGraphParams) ([]GetRelationGraphRow, error) {
+	rows, err := q.db.Query(ctx, getRelationGraph, arg.ContactID, arg.Depth, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRelationGraphRow
+	for rows.Next() {
+		var i GetRelationGraphRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.Depth); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReminderSettings = `-- name: GetReminderSettings :one
+SELECT owner_id, time_zone, notify_hour, email, updated_at, tenant_id
+FROM reminder_settings
+WHERE owner_id IS NOT DISTINCT FROM $1::int
+`
+
+func (q *Queries) GetReminderSettings(ctx context.Context, ownerID pgtype.Int4) (ReminderSetting, error) {
+	row := q.db.QueryRow(ctx, getReminderSettings, ownerID)
+	var i ReminderSetting
+	err := row.Scan(
+		&i.OwnerID,
+		&i.TimeZone,
+		&i.NotifyHour,
+		&i.Email,
+		&i.UpdatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const getSmartGroup = `-- name: GetSmartGroup :one
+SELECT id, owner_id, name, filter, created_at, tenant_id
+FROM smart_groups
+WHERE id = $1
+    AND owner_id IS NOT DISTINCT FROM $2::int
+`
+
+type GetSmartGroupParams struct {
+	ID      int32       `json:"id"`
+	OwnerID pgtype.Int4 `json:"owner_id"`
+}
+
+func (q *Queries) GetSmartGroup(ctx context.Context, arg GetSmartGroupParams) (SmartGroup, error) {
+	row := q.db.QueryRow(ctx, getSmartGroup, arg.ID, arg.OwnerID)
+	var i SmartGroup
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Name,
+		&i.Filter,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const getTag = `-- name: GetTag :one
+SELECT id, owner_id, name, color, created_at, tenant_id
+FROM tags
+WHERE id = $1
+    AND owner_id IS NOT DISTINCT FROM $2::int
+`
+
+type GetTagParams struct {
+	ID      int32       `json:"id"`
+	OwnerID pgtype.Int4 `json:"owner_id"`
+}
+
+func (q *Queries) GetTag(ctx context.Context, arg GetTagParams) (Tag, error) {
+	row := q.db.QueryRow(ctx, getTag, arg.ID, arg.OwnerID)
+	var i Tag
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Name,
+		&i.Color,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const getTeam = `-- name: GetTeam :one
+SELECT id, name, created_at, tenant_id
+FROM teams
+WHERE id = $1
+`
+
+func (q *Queries) GetTeam(ctx context.Context, id int32) (Team, error) {
+	row := q.db.QueryRow(ctx, getTeam, id)
+	var i Team
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const getTeamMember = `-- name: GetTeamMember :one
+SELECT team_id, user_id, role, created_at, tenant_id
+FROM team_members
+WHERE team_id = $1
+    AND user_id = $2
+`
+
+type GetTeamMemberParams struct {
+	TeamID int32 `json:"team_id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) GetTeamMember(ctx context.Context, arg GetTeamMemberParams) (TeamMember, error) {
+	row := q.db.QueryRow(ctx, getTeamMember, arg.TeamID, arg.UserID)
+	var i TeamMember
+	err := row.Scan(
+		&i.TeamID,
+		&i.UserID,
+		&i.Role,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const listAPIKeys = `-- name: ListAPIKeys :many
+SELECT id, name, prefix, key_hash, owner_id, scopes, expires_at, last_used_at, created_at, revoked_at, tenant_id
+FROM api_keys
+WHERE $1::int IS NULL
+    OR owner_id = $1
+ORDER BY id ASC
+`
+
+func (q *Queries) ListAPIKeys(ctx context.Context, ownerID pgtype.Int4) ([]ApiKey, error) {
+	rows, err := q.db.Query(ctx, listAPIKeys, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApiKey
+	for rows.Next() {
+		var i ApiKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Prefix,
+			&i.KeyHash,
+			&i.OwnerID,
+			&i.Scopes,
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+			&i.CreatedAt,
+			&i.RevokedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listContactDates = `-- name: ListContactDates :many
+SELECT c.id,
+    c.tenant_id,
+    c.owner_id,
+    c.birthday,
+    c.anniversaries,
+    s.time_zone,
+    s.notify_hour
+FROM contacts c
+    LEFT JOIN reminder_settings s ON s.tenant_id = c.tenant_id
+    AND s.owner_id = c.owner_id
+WHERE c.owner_id IS NOT NULL
+    AND (
+        c.birthday IS NOT NULL
+        OR c.anniversaries <> '[]'
+    )
+    AND c.id > $1::int
+ORDER BY c.id ASC
+LIMIT $2
+`
+
+type ListContactDatesParams struct {
+	AfterID  int32 `json:"after_id"`
+	PageSize int32 `json:"page_size"`
+}
+
+type ListContactDatesRow struct {
+	ID            int32       `json:"id"`
+	TenantID      int32       `json:"tenant_id"`
+	OwnerID       pgtype.Int4 `json:"owner_id"`
+	Birthday      pgtype.Date `json:"birthday"`
+	Anniversaries []byte      `json:"anniversaries"`
+	TimeZone      pgtype.Text `json:"time_zone"`
+	NotifyHour    pgtype.Int4 `json:"notify_hour"`
+}
+
+// A page of the contacts of every tenant with an owner and a birthday or
+// anniversaries, after the contact after_id, with the reminder settings of
+// their owners if they have any. Reminders belong to users, so contacts
+// created anonymously get none.
+func (q *Queries) ListContactDates(ctx context.Context, arg ListContactDatesParams) ([]ListContactDatesRow, error) {
+	rows, err := q.db.Query(ctx, listContactDates, arg.AfterID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListContactDatesRow
+	for rows.Next() {
+		var i ListContactDatesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.OwnerID,
+			&i.Birthday,
+			&i.Anniversaries,
+			&i.TimeZone,
+			&i.NotifyHour,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listContactShares = `-- name: ListContactShares :many
+SELECT id, contact_id, user_id, team_id, permission, created_at, tenant_id
+FROM contact_shares
+WHERE contact_id = $1
+ORDER BY id ASC
+`
+
+func (q *Queries) ListContactShares(ctx context.Context, contactID int32) ([]ContactShare, error) {
+	rows, err := q.db.Query(ctx, listContactShares, contactID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ContactShare
+	for rows.Next() {
+		var i ContactShare
+		if err := rows.Scan(
+			&i.ID,
+			&i.ContactID,
+			&i.UserID,
+			&i.TeamID,
+			&i.Permission,
+			&i.CreatedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listContactTags = `-- name: ListContactTags :many
+SELECT ct.contact_id,
+    t.id,
+    t.name,
+    t.color
+FROM contact_tags ct
+    JOIN tags t ON t.id = ct.tag_id
+WHERE ct.contact_id = ANY($1::int[])
+    AND t.owner_id IS NOT DISTINCT FROM $2::int
+ORDER BY t.name ASC
+`
+
+type ListContactTagsParams struct {
+	ContactIds []int32     `json:"contact_ids"`
+	OwnerID    pgtype.Int4 `json:"owner_id"`
+}
+
+type ListContactTagsRow struct {
+	ContactID int32  `json:"contact_id"`
+	ID        int32  `json:"id"`
+	Name      string `json:"name"`
+	Color     string `json:"color"`
+}
+
+func (q *Queries) ListContactTags(ctx context.Context, arg ListContactTagsParams) ([]ListContactTagsRow, error) {
+	rows, err := q.db.Query(ctx, listContactTags, arg.ContactIds, arg.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListContactTagsRow
+	for rows.Next() {
+		var i ListContactTagsRow
+		if err := rows.Scan(
+			&i.ContactID,
+			&i.ID,
+			&i.Name,
+			&i.Color,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listContactsPage = `-- name: ListContactsPage :many
+SELECT id, name, phone, owner_id, created_at, tenant_id, custom_fields, prefix, given_name, middle_name, family_name, suffix, nickname, company, job_title, birthday, anniversaries, notes, website, social_profiles
+FROM contacts c
+WHERE contact_access(c.id, c.owner_id, $1::int) IS NOT NULL
+    AND (
+        $2::text IS NULL
+        OR c.name ILIKE '%' || $2 || '%'
+        OR c.phone ILIKE '%' || $2 || '%'
+    )
+    AND (
+        $3::text IS NULL
+        OR (c.name, c.id) > ($3, $4::int)
+    )
+ORDER BY c.name ASC,
+    c.id ASC
+LIMIT $5
+`
+
+type ListContactsPageParams struct {
+	ViewerID  pgtype.Int4 `json:"viewer_id"`
+	Search    pgtype.Text `json:"search"`
+	AfterName pgtype.Text `json:"after_name"`
+	AfterID   pgtype.Int4 `json:"after_id"`
+	PageSize  int32       `json:"page_size"`
+}
+
+func (q *Queries) ListContactsPage(ctx context.Context, arg ListContactsPageParams) ([]Contact, error) {
+	rows, err := q.db.Query(ctx, listContactsPage,
+		arg.ViewerID,
+		arg.Search,
+		arg.AfterName,
+		arg.AfterID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Contact
+	for rows.Next() {
+		var i Contact
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Phone,
+			&i.OwnerID,
+			&i.CreatedAt,
+			&i.TenantID,
+			&i.CustomFields,
+			&i.Prefix,
+			&i.GivenName,
+			&i.MiddleName,
+			&i.FamilyName,
+			&i.Suffix,
+			&i.Nickname,
+			&i.Company,
+			&i.JobTitle,
+			&i.Birthday,
+			&i.Anniversaries,
+			&i.Notes,
+			&i.Website,
+			&i.SocialProfiles,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCustomFields = `-- name: ListCustomFields :many
+SELECT id, owner_id, name, type, required, pattern, options, created_at, tenant_id
+FROM custom_fields
+WHERE owner_id IS NOT DISTINCT FROM $1::int
+ORDER BY name ASC
+`
+
+func (q *Queries) ListCustomFields(ctx context.Context, ownerID pgtype.Int4) ([]CustomField, error) {
+	rows, err := q.db.Query(ctx, listCustomFields, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CustomField
+	for rows.Next() {
+		var i CustomField
+		if err := rows.Scan(
+			&i.ID,
+			&i.OwnerID,
+			&i.Name,
+			&i.Type,
+			&i.Required,
+			&i.Pattern,
+			&i.Options,
+			&i.CreatedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFavoriteContacts = `-- name: ListFavoriteContacts :many
+SELECT c.id, c.name, c.phone, c.owner_id, c.created_at, c.tenant_id, c.custom_fields, c.prefix, c.given_name, c.middle_name, c.family_name, c.suffix, c.nickname, c.company, c.job_title, c.birthday, c.anniversaries, c.notes, c.website, c.social_profiles
+FROM contact_favorites f
+    JOIN contacts c ON c.id = f.contact_id
+WHERE f.owner_id IS NOT DISTINCT FROM $1::int
+    AND contact_access(c.id, c.owner_id, $2::int) IS NOT NULL
+ORDER BY f.pinned DESC,
+    c.name ASC,
+    c.id ASC
+`
+
+type ListFavoriteContactsParams struct {
+	OwnerID  pgtype.Int4 `json:"owner_id"`
+	ViewerID pgtype.Int4 `json:"viewer_id"`
+}
+
+// The favorites of the owner that the viewer can see, pinned ones first.
+func (q *Queries) ListFavoriteContacts(ctx context.Context, arg ListFavoriteContactsParams) ([]Contact, error) {
+	rows, err := q.db.Query(ctx, listFavoriteContacts, arg.OwnerID, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Contact
+	for rows.Next() {
+		var i Contact
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Phone,
+			&i.OwnerID,
+			&i.CreatedAt,
+			&i.TenantID,
+			&i.CustomFields,
+			&i.Prefix,
+			&i.GivenName,
+			&i.MiddleName,
+			&i.FamilyName,
+			&i.Suffix,
+			&i.Nickname,
+			&i.Company,
+			&i.JobTitle,
+			&i.Birthday,
+			&i.Anniversaries,
+			&i.Notes,
+			&i.Website,
+			&i.SocialProfiles,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFavoriteFlags = `-- name: ListFavoriteFlags :many
+SELECT contact_id,
+    pinned
+FROM contact_favorites
+WHERE contact_id = ANY($1::int[])
+    AND owner_id IS NOT DISTINCT FROM $2::int
+`
+
+type ListFavoriteFlagsParams struct {
+	ContactIds []int32     `json:"contact_ids"`
+	OwnerID    pgtype.Int4 `json:"owner_id"`
+}
+
+type ListFavoriteFlagsRow struct {
+	ContactID int32 `json:"contact_id"`
+	Pinned    bool  `json:"pinned"`
+}
+
+// The contacts among contact_ids that are favorites of the owner.
+func (q *Queries) ListFavoriteFlags(ctx context.Context, arg ListFavoriteFlagsParams) ([]ListFavoriteFlagsRow, error) {
+	rows, err := q.db.Query(ctx, listFavoriteFlags, arg.ContactIds, arg.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFavoriteFlagsRow
+	for rows.Next() {
+		var i ListFavoriteFlagsRow
+		if err := rows.Scan(&i.ContactID, &i.Pinned); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInteractionStats = `-- name: ListInteractionStats :many
+SELECT contact_id,
+    MAX(occurred_at)::timestamptz AS last_contacted_at,
+    COUNT(*) AS interaction_count
+FROM interactions
+WHERE contact_id = ANY($1::int[])
+GROUP BY contact_id
+`
+
+type ListInteractionStatsRow struct {
+	ContactID        int32              `json:"contact_id"`
+	LastContactedAt  pgtype.Timestamptz `json:"last_contacted_at"`
+	InteractionCount int64              `json:"interaction_count"`
+}
+
+// The time of the last interaction and the number of interactions with
+// each of the contacts that have any.
+func (q *Queries) ListInteractionStats(ctx context.Context, contactIds []int32) ([]ListInteractionStatsRow, error) {
+	rows, err := q.db.Query(ctx, listInteractionStats, contactIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListInteractionStatsRow
+	for rows.Next() {
+		var i ListInteractionStatsRow
+		if err := rows.Scan(&i.ContactID, &i.LastContactedAt, &i.InteractionCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInteractions = `-- name: ListInteractions :many
+SELECT i.id, i.contact_id, i.owner_id, i.type, i.direction, i.occurred_at, i.duration_seconds, i.notes, i.created_at, i.tenant_id
+FROM interactions i
+    JOIN contacts c ON c.id = i.contact_id
+WHERE i.contact_id = $1
+    AND contact_access(c.id, c.owner_id, $2::int) IS NOT NULL
+    AND (
+        $3::timestamptz IS NULL
+        OR (i.occurred_at, i.id) < ($3, $4::int)
+    )
+ORDER BY i.occurred_at DESC,
+    i.id DESC
+LIMIT $5
+`
+
+type ListInteractionsParams struct {
+	ContactID int32              `json:"contact_id"`
+	ViewerID  pgtype.Int4        `json:"viewer_id"`
+	BeforeAt  pgtype.Timestamptz `json:"before_at"`
+	BeforeID  pgtype.Int4        `json:"before_id"`
+	PageSize  int32              `json:"page_size"`
+}
+
+// A page of the interactions with a contact the viewer can see, latest
+// first, after the interaction before_at and before_id if given.
+func (q *Queries) ListInteractions(ctx context.Context, arg ListInteractionsParams) ([]Interaction, error) {
+	rows, err := q.db.Query(ctx, listInteractions,
+		arg.ContactID,
+		arg.ViewerID,
+		arg.BeforeAt,
+		arg.BeforeID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Interaction
+	for rows.Next() {
+		var i Interaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.ContactID,
+			&i.OwnerID,
+			&i.Type,
+			&i.Direction,
+			&i.OccurredAt,
+			&i.DurationSeconds,
+			&i.Notes,
+			&i.CreatedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecentContacts = `-- name: ListRecentContacts :many
+SELECT c.id, c.name, c.phone, c.owner_id, c.created_at, c.tenant_id, c.custom_fields, c.prefix, c.given_name, c.middle_name, c.family_name, c.suffix, c.nickname, c.company, c.job_title, c.birthday, c.anniversaries, c.notes, c.website, c.social_profiles
+FROM contact_views v
+    JOIN contacts c ON c.id = v.contact_id
+WHERE v.owner_id IS NOT DISTINCT FROM $1::int
+    AND contact_access(c.id, c.owner_id, $2::int) IS NOT NULL
+ORDER BY v.viewed_at DESC, c.id DESC
+LIMIT $3
+`
+
+type ListRecentContactsParams struct {
+	OwnerID  pgtype.Int4 `json:"owner_id"`
+	ViewerID pgtype.Int4 `json:"viewer_id"`
+	PageSize int32       `json:"page_size"`
+}
+
+// The contacts the owner viewed that the viewer can still see, the most
+// recently viewed first.
+func (q *Queries) ListRecentContacts(ctx context.Context, arg ListRecentContactsParams) ([]Contact, error) {
+	rows, err := q.db.Query(ctx, listRecentContacts, arg.OwnerID, arg.ViewerID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Contact
+	for rows.Next() {
+		var i Contact
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Phone,
+			&i.OwnerID,
+			&i.CreatedAt,
+			&i.TenantID,
+			&i.CustomFields,
+			&i.Prefix,
+			&i.GivenName,
+			&i.MiddleName,
+			&i.FamilyName,
+			&i.Suffix,
+			&i.Nickname,
+			&i.Company,
+			&i.JobTitle,
+			&i.Birthday,
+			&i.Anniversaries,
+			&i.Notes,
+			&i.Website,
+			&i.SocialProfiles,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRelations = `-- name: ListRelations :many
+SELECT r.id, r.contact_id, r.related_id, r.type, r.created_at, r.tenant_id,
+    o.name AS other_name
+FROM contact_relations r
+    JOIN contacts o ON o.id = CASE
+        WHEN r.contact_id = $1::int THEN r.related_id
+        ELSE r.contact_id
+    END
+WHERE $1::int IN (r.contact_id, r.related_id)
+    AND contact_access(o.id, o.owner_id, $2::int) IS NOT NULL
+ORDER BY o.name ASC,
+    r.id ASC
+`
+
+type ListRelationsParams struct {
+	ContactID int32       `json:"contact_id"`
+	ViewerID  pgtype.Int4 `json:"viewer_id"`
+}
+
+type ListRelationsRow struct {
+	ID        int32              `json:"id"`
+	ContactID int32              `json:"contact_id"`
+	RelatedID int32              `json:"related_id"`
+	Type      string             `json:"type"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	TenantID  int32              `json:"tenant_id"`
+	OtherName string             `json:"other_name"`
+}
+
+// The relations of a contact with the contacts the viewer can see, by the
+// name of the other contact.
+func (q *Queries) ListRelations(ctx context.Context, arg ListRelationsParams) ([]ListRelationsRow, error) {
+	rows, err := q.db.Query(ctx, listRelations, arg.ContactID, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRelationsRow
+	for rows.Next() {
+		var i ListRelationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ContactID,
+			&i.RelatedID,
+			&i.Type,
+			&i.CreatedAt,
+			&i.TenantID,
+			&i.OtherName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRelationsAmong = `-- name: ListRelationsAmong :many
+SELECT id, contact_id, related_id, type, created_at, tenant_id
+FROM contact_relations
+WHERE contact_id = ANY($1::int[])
+    AND related_id = ANY($1::int[])
+ORDER BY id ASC
+`
+
+// The relations between the contacts.
+func (q *Queries) ListRelationsAmong(ctx context.Context, contactIds []int32) ([]ContactRelation, error) {
+	rows, err := q.db.Query(ctx, listRelationsAmong, contactIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ContactRelation
+	for rows.Next() {
+		var i ContactRelation
+		if err := rows.Scan(
+			&i.ID,
+			&i.ContactID,
+			&i.RelatedID,
+			&i.Type,
+			&i.CreatedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSmartGroups = `-- name: ListSmartGroups :many
+SELECT id, owner_id, name, filter, created_at, tenant_id
+FROM smart_groups
+WHERE owner_id IS NOT DISTINCT FROM $1::int
+ORDER BY name ASC
+`
+
+func (q *Queries) ListSmartGroups(ctx context.Context, ownerID pgtype.Int4) ([]SmartGroup, error) {
+	rows, err := q.db.Query(ctx, listSmartGroups, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SmartGroup
+	for rows.Next() {
+		var i SmartGroup
+		if err := rows.Scan(
+			&i.ID,
+			&i.OwnerID,
+			&i.Name,
+			&i.Filter,
+			&i.CreatedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTags = `-- name: ListTags :many
+SELECT id, owner_id, name, color, created_at, tenant_id
+FROM tags
+WHERE owner_id IS NOT DISTINCT FROM $1::int
+ORDER BY name ASC
+`
+
+func (q *Queries) ListTags(ctx context.Context, ownerID pgtype.Int4) ([]Tag, error) {
+	rows, err := q.db.Query(ctx, listTags, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Tag
+	for rows.Next() {
+		var i Tag
+		if err := rows.Scan(
+			&i.ID,
+			&i.OwnerID,
+			&i.Name,
+			&i.Color,
+			&i.CreatedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTeamMembers = `-- name: ListTeamMembers :many
+SELECT team_id, user_id, role, created_at, tenant_id
+FROM team_members
+WHERE team_id = $1
+ORDER BY user_id ASC
+`
+
+func (q *Queries) ListTeamMembers(ctx context.Context, teamID int32) ([]TeamMember, error) {
+	rows, err := q.db.Query(ctx, listTeamMembers, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TeamMember
+	for rows.Next() {
+		var i TeamMember
+		if err := rows.Scan(
+			&i.TeamID,
+			&i.UserID,
+			&i.Role,
+			&i.CreatedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTeamsForUser = `-- name: ListTeamsForUser :many
+SELECT t.id,
+    t.name,
+    t.created_at,
+    m.role
+FROM teams t
+    JOIN team_members m ON m.team_id = t.id
+WHERE m.user_id = $1
+ORDER BY t.name ASC,
+    t.id ASC
+`
+
+type ListTeamsForUserRow struct {
+	ID        int32              `json:"id"`
+	Name      string             `json:"name"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	Role      string             `json:"role"`
+}
+
+func (q *Queries) ListTeamsForUser(ctx context.Context, userID int32) ([]ListTeamsForUserRow, error) {
+	rows, err := q.db.Query(ctx, listTeamsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTeamsForUserRow
+	for rows.Next() {
+		var i ListTeamsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
 
-const createContact = `-- name: CreateContact :one
-INSERT INTO contacts (name, phone)
-VALUES ($1, $2)
-RETURNING id, name, phone, owner_id, created_at
+const listTenants = `-- name: ListTenants :many
+SELECT id, name, created_at
+FROM tenants
+ORDER BY id ASC
 `
 
-type CreateContactParams struct {
-	Name  string `json:"name"`
-	Phone string `json:"phone"`
+func (q *Queries) ListTenants(ctx context.Context) ([]Tenant, error) {
+	rows, err := q.db.Query(ctx, listTenants)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Tenant
+	for rows.Next() {
+		var i Tenant
+		if err := rows.Scan(&i.ID, &i.Name, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (q *Queries) CreateContact(ctx context.Context, arg CreateContactParams) (Contact, error) {
-	row := q.db.QueryRow(ctx, createContact, arg.Name, arg.Phone)
-	var i Contact
+const listUpcomingReminders = `-- name: ListUpcomingReminders :many
+SELECT r.id, r.contact_id, r.owner_id, r.kind, r.label, r.note, r.occurs_on, r.due_at, r.notified_at, r.snoozed_at, r.completed_at, r.created_at, r.tenant_id, r.attempts, r.next_attempt_at, r.delivered_to,
+    c.name AS contact_name
+FROM reminders r
+    JOIN contacts c ON c.id = r.contact_id
+WHERE r.owner_id IS NOT DISTINCT FROM $1::int
+    AND r.completed_at IS NULL
+    AND r.due_at < $2
+    AND contact_access(c.id, c.owner_id, $3::int) IS NOT NULL
+ORDER BY r.due_at ASC,
+    r.id ASC
+`
+
+type ListUpcomingRemindersParams struct {
+	OwnerID  pgtype.Int4        `json:"owner_id"`
+	Until    pgtype.Timestamptz `json:"until"`
+	ViewerID pgtype.Int4        `json:"viewer_id"`
+}
+
+type ListUpcomingRemindersRow struct {
+	ID            int32              `json:"id"`
+	ContactID     int32              `json:"contact_id"`
+	OwnerID       pgtype.Int4        `json:"owner_id"`
+	Kind          string             `json:"kind"`
+	Label         string             `json:"label"`
+	Note          string             `json:"note"`
+	OccursOn      pgtype.Date        `json:"occurs_on"`
+	DueAt         pgtype.Timestamptz `json:"due_at"`
+	NotifiedAt    pgtype.Timestamptz `json:"notified_at"`
+	SnoozedAt     pgtype.Timestamptz `json:"snoozed_at"`
+	CompletedAt   pgtype.Timestamptz `json:"completed_at"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	TenantID      int32              `json:"tenant_id"`
+	Attempts      int32              `json:"attempts"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	DeliveredTo   []string           `json:"delivered_to"`
+	ContactName   string             `json:"contact_name"`
+}
+
+// Open reminders of the owner that are due before until, overdue ones
+// included, on contacts the viewer can still see.
+func (q *Queries) ListUpcomingReminders(ctx context.Context, arg ListUpcomingRemindersParams) ([]ListUpcomingRemindersRow, error) {
+	rows, err := q.db.Query(ctx, listUpcomingReminders, arg.OwnerID, arg.Until, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUpcomingRemindersRow
+	for rows.Next() {
+		var i ListUpcomingRemindersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ContactID,
+			&i.OwnerID,
+			&i.Kind,
+			&i.Label,
+			&i.Note,
+			&i.OccursOn,
+			&i.DueAt,
+			&i.NotifiedAt,
+			&i.SnoozedAt,
+			&i.CompletedAt,
+			&i.CreatedAt,
+			&i.TenantID,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.DeliveredTo,
+			&i.ContactName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markReminderNotified = `-- name: MarkReminderNotified :exec
+UPDATE reminders
+SET notified_at = now(),
+    next_attempt_at = NULL,
+    delivered_to = $2
+WHERE id = $1
+`
+
+type MarkReminderNotifiedParams struct {
+	ID          int32    `json:"id"`
+	DeliveredTo []string `json:"delivered_to"`
+}
+
+// Ends the lease of a reminder that every notifier has sent.
+func (q *Queries) MarkReminderNotified(ctx context.Context, arg MarkReminderNotifiedParams) error {
+	_, err := q.db.Exec(ctx, markReminderNotified, arg.ID, arg.DeliveredTo)
+	return err
+}
+
+const recordContactView = `-- name: RecordContactView :exec
+INSERT INTO contact_views (contact_id, owner_id)
+VALUES ($1, $2) ON CONFLICT (tenant_id, owner_id, contact_id) DO
+UPDATE
+SET viewed_at = now()
+`
+
+type RecordContactViewParams struct {
+	ContactID int32       `json:"contact_id"`
+	OwnerID   pgtype.Int4 `json:"owner_id"`
+}
+
+func (q *Queries) RecordContactView(ctx context.Context, arg RecordContactViewParams) error {
+	_, err := q.db.Exec(ctx, recordContactView, arg.ContactID, arg.OwnerID)
+	return err
+}
+
+const retryReminder = `-- name: RetryReminder :exec
+UPDATE reminders
+SET next_attempt_at = $3,
+    delivered_to = $2
+WHERE id = $1
+`
+
+type RetryReminderParams struct {
+	ID          int32              `json:"id"`
+	DeliveredTo []string           `json:"delivered_to"`
+	RetryAt     pgtype.Timestamptz `json:"retry_at"`
+}
+
+// Makes a reminder that some notifiers failed to send due again at retry_at,
+// remembering the notifiers that did send it.
+func (q *Queries) RetryReminder(ctx context.Context, arg RetryReminderParams) error {
+	_, err := q.db.Exec(ctx, retryReminder, arg.ID, arg.DeliveredTo, arg.RetryAt)
+	return err
+}
+
+const revokeAPIKey = `-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = COALESCE(revoked_at, now())
+WHERE id = $1
+RETURNING id, name, prefix, key_hash, owner_id, scopes, expires_at, last_used_at, created_at, revoked_at, tenant_id
+`
+
+func (q *Queries) RevokeAPIKey(ctx context.Context, id int32) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, revokeAPIKey, id)
+	var i ApiKey
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
-		&i.Phone,
+		&i.Prefix,
+		&i.KeyHash,
 		&i.OwnerID,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
 		&i.CreatedAt,
+		&i.RevokedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
-const deleteContact = `-- name: DeleteContact :exec
-DELETE FROM contacts
-WHERE id = $1
+const shareContactWithTeam = `-- name: ShareContactWithTeam :one
+INSERT INTO contact_shares (contact_id, team_id, permission)
+VALUES ($1, $2, $3) ON CONFLICT (contact_id, team_id) DO
+UPDATE
+SET permission = EXCLUDED.permission
+RETURNING id, contact_id, user_id, team_id, permission, created_at, tenant_id
 `
 
-func (q *Queries) DeleteContact(ctx context.Context, id int32) error {
-	_, err := q.db.Exec(ctx, deleteContact, id)
-	return err
+type ShareContactWithTeamParams struct {
+	ContactID  int32       `json:"contact_id"`
+	TeamID     pgtype.Int4 `json:"team_id"`
+	Permission string      `json:"permission"`
 }
 
-const getContactByID = `-- name: GetContactByID :one
-SELECT id, name, phone, owner_id, created_at
-FROM contacts
+func (q *Queries) ShareContactWithTeam(ctx context.Context, arg ShareContactWithTeamParams) (ContactShare, error) {
+	row := q.db.QueryRow(ctx, shareContactWithTeam, arg.ContactID, arg.TeamID, arg.Permission)
+	var i ContactShare
+	err := row.Scan(
+		&i.ID,
+		&i.ContactID,
+		&i.UserID,
+		&i.TeamID,
+		&i.Permission,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const shareContactWithUser = `-- name: ShareContactWithUser :one
+INSERT INTO contact_shares (contact_id, user_id, permission)
+VALUES ($1, $2, $3) ON CONFLICT (contact_id, user_id) DO
+UPDATE
+SET permission = EXCLUDED.permission
+RETURNING id, contact_id, user_id, team_id, permission, created_at, tenant_id
+`
+
+type ShareContactWithUserParams struct {
+	ContactID  int32       `json:"contact_id"`
+	UserID     pgtype.Int4 `json:"user_id"`
+	Permission string      `json:"permission"`
+}
+
+func (q *Queries) ShareContactWithUser(ctx context.Context, arg ShareContactWithUserParams) (ContactShare, error) {
+	row := q.db.QueryRow(ctx, shareContactWithUser, arg.ContactID, arg.UserID, arg.Permission)
+	var i ContactShare
+	err := row.Scan(
+		&i.ID,
+		&i.ContactID,
+		&i.UserID,
+		&i.TeamID,
+		&i.Permission,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const snoozeReminder = `-- name: SnoozeReminder :one
+UPDATE reminders
+SET due_at = $2,
+    snoozed_at = now(),
+    notified_at = NULL,
+    attempts = 0,
+    next_attempt_at = NULL,
+    delivered_to = '{}'
 WHERE id = $1
+    AND owner_id IS NOT DISTINCT FROM $3::int
+    AND completed_at IS NULL
+RETURNING id, contact_id, owner_id, kind, label, note, occurs_on, due_at, notified_at, snoozed_at, completed_at, created_at, tenant_id, attempts, next_attempt_at, delivered_to
 `
 
-func (q *Queries) GetContactByID(ctx context.Context, id int32) (Contact, error) {
-	row := q.db.QueryRow(ctx, getContactByID, id)
-	var i Contact
+type SnoozeReminderParams struct {
+	ID      int32              `json:"id"`
+	Until   pgtype.Timestamptz `json:"until"`
+	OwnerID pgtype.Int4        `json:"owner_id"`
+}
+
+// Snoozing makes the reminder due again at until, even after its
+// notification was sent.
+func (q *Queries) SnoozeReminder(ctx context.Context, arg SnoozeReminderParams) (Reminder, error) {
+	row := q.db.QueryRow(ctx, snoozeReminder, arg.ID, arg.Until, arg.OwnerID)
+	var i Reminder
 	err := row.Scan(
 		&i.ID,
-		&i.Name,
-		&i.Phone,
+		&i.ContactID,
 		&i.OwnerID,
+		&i.Kind,
+		&i.Label,
+		&i.Note,
+		&i.OccursOn,
+		&i.DueAt,
+		&i.NotifiedAt,
+		&i.SnoozedAt,
+		&i.CompletedAt,
 		&i.CreatedAt,
+		&i.TenantID,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.DeliveredTo,
 	)
 	return i, err
 }
 
-const getContacts = `-- name: GetContacts :many
-SELECT id, name, phone, owner_id, created_at
+const tagContacts = `-- name: TagContacts :execrows
+INSERT INTO contact_tags (contact_id, tag_id)
+SELECT c.id,
+    $1::int
 FROM contacts c
-ORDER BY c.name ASC
+WHERE c.id = ANY($2::int[])
+    AND contact_access(c.id, c.owner_id, $3::int) IS NOT NULL ON CONFLICT DO NOTHING
 `
 
-func (q *Queries) GetContacts(ctx context.Context) ([]Contact, error) {
-	rows, err := q.db.Query(ctx, getContacts)
+type TagContactsParams struct {
+	TagID      int32       `json:"tag_id"`
+	ContactIds []int32     `json:"contact_ids"`
+	ViewerID   pgtype.Int4 `json:"viewer_id"`
+}
+
+// Contacts that viewer_id cannot see and those already tagged are skipped.
+func (q *Queries) TagContacts(ctx context.Context, arg TagContactsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, tagContacts, arg.TagID, arg.ContactIds, arg.ViewerID)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Contact
-	for rows.Next() {
-		var i Contact
-		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.Phone,
-			&i.OwnerID,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected(), nil
+}
+
+const takeRateLimitToken = `-- name: TakeRateLimitToken :one
+INSERT INTO rate_limits AS r (key, tokens, allowed, updated_at, full_at)
+VALUES (
+        $1,
+        $2::float8 - 1,
+        TRUE,
+        now(),
+        now() + make_interval(secs => 1 / $3::float8)
+    ) ON CONFLICT (key) DO
+UPDATE
+SET (tokens, allowed, updated_at, full_at) = (
+        SELECT taken.tokens,
+            taken.allowed,
+            now(),
+            now() + make_interval(
+                secs => ($2::float8 - taken.tokens) / $3::float8
+            )
+        FROM (
+                SELECT refill.tokens - CASE
+                        WHEN refill.tokens >= 1 THEN 1
+                        ELSE 0
+                    END AS tokens,
+                    refill.tokens >= 1 AS allowed
+                FROM (
+                        SELECT LEAST(
+                                $2::float8,
+                                r.tokens + GREATEST(EXTRACT(EPOCH FROM now() - r.updated_at)::float8, 0) * $3::float8
+                            ) AS tokens
+                    ) AS refill
+            ) AS taken
+    )
+RETURNING tokens,
+    allowed
+`
+
+type TakeRateLimitTokenParams struct {
+	Key   string  `json:"key"`
+	Burst float64 `json:"burst"`
+	Rate  float64 `json:"rate"`
+}
+
+type TakeRateLimitTokenRow struct {
+	Tokens  float64 `json:"tokens"`
+	Allowed bool    `json:"allowed"`
+}
+
+func (q *Queries) TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenParams) (TakeRateLimitTokenRow, error) {
+	row := q.db.QueryRow(ctx, takeRateLimitToken, arg.Key, arg.Burst, arg.Rate)
+	var i TakeRateLimitTokenRow
+	err := row.Scan(&i.Tokens, &i.Allowed)
+	return i, err
+}
+
+const touchAPIKey = `-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = now()
+WHERE id = $1
+    AND (
+        last_used_at IS NULL
+        OR last_used_at < now() - INTERVAL '1 minute'
+    )
+`
+
+// Writes are skipped while the recorded time is recent, so that busy keys do
+// not update their row on every request.
+func (q *Queries) TouchAPIKey(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, touchAPIKey, id)
+	return err
+}
+
+const untagContacts = `-- name: UntagContacts :execrows
+DELETE FROM contact_tags
+WHERE tag_id = $1
+    AND contact_id = ANY($2::int[])
+`
+
+type UntagContactsParams struct {
+	TagID      int32   `json:"tag_id"`
+	ContactIds []int32 `json:"contact_ids"`
+}
+
+func (q *Queries) UntagContacts(ctx context.Context, arg UntagContactsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, untagContacts, arg.TagID, arg.ContactIds)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected(), nil
 }
 
 const updateContact = `-- name: UpdateContact :one
 UPDATE contacts
 SET name = $2,
-    phone = $3
+    phone = $3,
+    prefix = $4,
+    given_name = $5,
+    middle_name = $6,
+    family_name = $7,
+    suffix = $8,
+    nickname = $9,
+    company = $10,
+    job_title = $11,
+    birthday = $12,
+    anniversaries = $13,
+    notes = $14,
+    website = $15,
+    social_profiles = $16,
+    custom_fields = COALESCE($17::jsonb, custom_fields)
 WHERE id = $1
-RETURNING id, name, phone, owner_id, created_at
+    AND contact_access(id, owner_id, $18::int) = 'write'
+RETURNING id, name, phone, owner_id, created_at, tenant_id, custom_fields, prefix, given_name, middle_name, family_name, suffix, nickname, company, job_title, birthday, anniversaries, notes, website, social_profiles
 `
 
 type UpdateContactParams struct {
-	ID    int32  `json:"id"`
-	Name  string `json:"name"`
-	Phone string `json:"phone"`
+	ID             int32       `json:"id"`
+	Name           string      `json:"name"`
+	Phone          string      `json:"phone"`
+	Prefix         string      `json:"prefix"`
+	GivenName      string      `json:"given_name"`
+	MiddleName     string      `json:"middle_name"`
+	FamilyName     string      `json:"family_name"`
+	Suffix         string      `json:"suffix"`
+	Nickname       string      `json:"nickname"`
+	Company        string      `json:"company"`
+	JobTitle       string      `json:"job_title"`
+	Birthday       pgtype.Date `json:"birthday"`
+	Anniversaries  []byte      `json:"anniversaries"`
+	Notes          string      `json:"notes"`
+	Website        string      `json:"website"`
+	SocialProfiles []byte      `json:"social_profiles"`
+	CustomFields   []byte      `json:"custom_fields"`
+	ViewerID       pgtype.Int4 `json:"viewer_id"`
 }
 
+// A NULL custom_fields keeps the current values.
 func (q *Queries) UpdateContact(ctx context.Context, arg UpdateContactParams) (Contact, error) {
-	row := q.db.QueryRow(ctx, updateContact, arg.ID, arg.Name, arg.Phone)
+	row := q.db.QueryRow(ctx, updateContact,
+		arg.ID,
+		arg.Name,
+		arg.Phone,
+		arg.Prefix,
+		arg.GivenName,
+		arg.MiddleName,
+		arg.FamilyName,
+		arg.Suffix,
+		arg.Nickname,
+		arg.Company,
+		arg.JobTitle,
+		arg.Birthday,
+		arg.Anniversaries,
+		arg.Notes,
+		arg.Website,
+		arg.SocialProfiles,
+		arg.CustomFields,
+		arg.ViewerID,
+	)
 	var i Contact
 	err := row.Scan(
 		&i.ID,
@@ -117,6 +2767,328 @@ func (q *Queries) UpdateContact(ctx context.Context, arg UpdateContactParams) (C
 		&i.Phone,
 		&i.OwnerID,
 		&i.CreatedAt,
+		&i.TenantID,
+		&i.CustomFields,
+		&i.Prefix,
+		&i.GivenName,
+		&i.MiddleName,
+		&i.FamilyName,
+		&i.Suffix,
+		&i.Nickname,
+		&i.Company,
+		&i.JobTitle,
+		&i.Birthday,
+		&i.Anniversaries,
+		&i.Notes,
+		&i.Website,
+		&i.SocialProfiles,
+	)
+	return i, err
+}
+
+const updateCustomField = `-- name: UpdateCustomField :one
+UPDATE custom_fields
+SET required = $2,
+    pattern = $3,
+    options = $4
+WHERE id = $1
+    AND owner_id IS NOT DISTINCT FROM $5::int
+RETURNING id, owner_id, name, type, required, pattern, options, created_at, tenant_id
+`
+
+type UpdateCustomFieldParams struct {
+	ID       int32       `json:"id"`
+	Required bool        `json:"required"`
+	Pattern  pgtype.Text `json:"pattern"`
+	Options  []string    `json:"options"`
+	OwnerID  pgtype.Int4 `json:"owner_id"`
+}
+
+// The name and type of a field are fixed, as contacts store values by name.
+func (q *Queries) UpdateCustomField(ctx context.Context, arg UpdateCustomFieldParams) (CustomField, error) {
+	row := q.db.QueryRow(ctx, updateCustomField,
+		arg.ID,
+		arg.Required,
+		arg.Pattern,
+		arg.Options,
+		arg.OwnerID,
+	)
+	var i CustomField
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Name,
+		&i.Type,
+		&i.Required,
+		&i.Pattern,
+		&i.Options,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const updateRelation = `-- name: UpdateRelation :one
+UPDATE contact_relations
+SET contact_id = $2,
+    related_id = $3,
+    type = $4
+WHERE id = $1
+RETURNING id, contact_id, related_id, type, created_at, tenant_id
+`
+
+type UpdateRelationParams struct {
+	ID        int32  `json:"id"`
+	ContactID int32  `json:"contact_id"`
+	RelatedID int32  `json:"related_id"`
+	Type      string `json:"type"`
+}
+
+func (q *Queries) UpdateRelation(ctx context.Context, arg UpdateRelationParams) (ContactRelation, error) {
+	row := q.db.QueryRow(ctx, updateRelation,
+		arg.ID,
+		arg.ContactID,
+		arg.RelatedID,
+		arg.Type,
+	)
+	var i ContactRelation
+	err := row.Scan(
+		&i.ID,
+		&i.ContactID,
+		&i.RelatedID,
+		&i.Type,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const updateSmartGroup = `-- name: UpdateSmartGroup :one
+UPDATE smart_groups
+SET name = $2,
+    filter = $3
+WHERE id = $1
+    AND owner_id IS NOT DISTINCT FROM $4::int
+RETURNING id, owner_id, name, filter, created_at, tenant_id
+`
+
+type UpdateSmartGroupParams struct {
+	ID      int32       `json:"id"`
+	Name    string      `json:"name"`
+	Filter  string      `json:"filter"`
+	OwnerID pgtype.Int4 `json:"owner_id"`
+}
+
+func (q *Queries) UpdateSmartGroup(ctx context.Context, arg UpdateSmartGroupParams) (SmartGroup, error) {
+	row := q.db.QueryRow(ctx, updateSmartGroup,
+		arg.ID,
+		arg.Name,
+		arg.Filter,
+		arg.OwnerID,
+	)
+	var i SmartGroup
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Name,
+		&i.Filter,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const updateTag = `-- name: UpdateTag :one
+UPDATE tags
+SET name = $2,
+    color = $3
+WHERE id = $1
+    AND owner_id IS NOT DISTINCT FROM $4::int
+RETURNING id, owner_id, name, color, created_at, tenant_id
+`
+
+type UpdateTagParams struct {
+	ID      int32       `json:"id"`
+	Name    string      `json:"name"`
+	Color   string      `json:"color"`
+	OwnerID pgtype.Int4 `json:"owner_id"`
+}
+
+func (q *Queries) UpdateTag(ctx context.Context, arg UpdateTagParams) (Tag, error) {
+	row := q.db.QueryRow(ctx, updateTag,
+		arg.ID,
+		arg.Name,
+		arg.Color,
+		arg.OwnerID,
+	)
+	var i Tag
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Name,
+		&i.Color,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const upsertContactAvatar = `-- name: UpsertContactAvatar :one
+INSERT INTO contact_avatars (
+        contact_id,
+        object_key,
+        content_type,
+        size_bytes,
+        etag,
+        checksum_sha256
+    )
+VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (contact_id) DO
+UPDATE
+SET object_key = EXCLUDED.object_key,
+    content_type = EXCLUDED.content_type,
+    size_bytes = EXCLUDED.size_bytes,
+    etag = EXCLUDED.etag,
+    checksum_sha256 = EXCLUDED.checksum_sha256,
+    uploaded_at = CURRENT_TIMESTAMP
+RETURNING contact_id, object_key, content_type, size_bytes, etag, checksum_sha256, uploaded_at, tenant_id
+`
+
+type UpsertContactAvatarParams struct {
+	ContactID      int32       `json:"contact_id"`
+	ObjectKey      string      `json:"object_key"`
+	ContentType    string      `json:"content_type"`
+	SizeBytes      int64       `json:"size_bytes"`
+	Etag           pgtype.Text `json:"etag"`
+	ChecksumSha256 pgtype.Text `json:"checksum_sha256"`
+}
+
+func (q *Queries) UpsertContactAvatar(ctx context.Context, arg UpsertContactAvatarParams) (ContactAvatar, error) {
+	row := q.db.QueryRow(ctx, upsertContactAvatar,
+		arg.ContactID,
+		arg.ObjectKey,
+		arg.ContentType,
+		arg.SizeBytes,
+		arg.Etag,
+		arg.ChecksumSha256,
+	)
+	var i ContactAvatar
+	err := row.Scan(
+		&i.ContactID,
+		&i.ObjectKey,
+		&i.ContentType,
+		&i.SizeBytes,
+		&i.Etag,
+		&i.ChecksumSha256,
+		&i.UploadedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const upsertFavorite = `-- name: UpsertFavorite :one
+INSERT INTO contact_favorites (contact_id, pinned, owner_id)
+VALUES ($1, $2, $3) ON CONFLICT (tenant_id, owner_id, contact_id) DO
+UPDATE
+SET pinned = EXCLUDED.pinned
+RETURNING owner_id, contact_id, pinned, created_at, tenant_id
+`
+
+type UpsertFavoriteParams struct {
+	ContactID int32       `json:"contact_id"`
+	Pinned    bool        `json:"pinned"`
+	OwnerID   pgtype.Int4 `json:"owner_id"`
+}
+
+func (q *Queries) UpsertFavorite(ctx context.Context, arg UpsertFavoriteParams) (ContactFavorite, error) {
+	row := q.db.QueryRow(ctx, upsertFavorite, arg.ContactID, arg.Pinned, arg.OwnerID)
+	var i ContactFavorite
+	err := row.Scan(
+		&i.OwnerID,
+		&i.ContactID,
+		&i.Pinned,
+		&i.CreatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const upsertReminderSettings = `-- name: UpsertReminderSettings :one
+WITH settings AS (
+    INSERT INTO reminder_settings (time_zone, notify_hour, email, owner_id)
+    VALUES ($1, $2, $3, $4) ON CONFLICT (tenant_id, owner_id) DO
+    UPDATE
+    SET time_zone = EXCLUDED.time_zone,
+        notify_hour = EXCLUDED.notify_hour,
+        email = EXCLUDED.email,
+        updated_at = now()
+    RETURNING owner_id, time_zone, notify_hour, email, updated_at, tenant_id
+),
+rescheduled AS (
+    UPDATE reminders r
+    SET due_at = (r.occurs_on + make_interval(hours => s.notify_hour)) AT TIME ZONE s.time_zone
+    FROM settings s
+    WHERE r.owner_id IS NOT DISTINCT FROM s.owner_id
+        AND r.occurs_on IS NOT NULL
+        AND r.notified_at IS NULL
+        AND r.snoozed_at IS NULL
+        AND r.completed_at IS NULL
+    RETURNING r.id
+)
+SELECT owner_id, time_zone, notify_hour, email, updated_at, tenant_id
+FROM settings
+`
+
+type UpsertReminderSettingsParams struct {
+	TimeZone   string      `json:"time_zone"`
+	NotifyHour int32       `json:"notify_hour"`
+	Email      string      `json:"email"`
+	OwnerID    pgtype.Int4 `json:"owner_id"`
+}
+
+// Birthday and anniversary reminders that are pending and not snoozed move
+// to the new hour and time zone.
+func (q *Queries) UpsertReminderSettings(ctx context.Context, arg UpsertReminderSettingsParams) (ReminderSetting, error) {
+	row := q.db.QueryRow(ctx, upsertReminderSettings,
+		arg.TimeZone,
+		arg.NotifyHour,
+		arg.Email,
+		arg.OwnerID,
+	)
+	var i ReminderSetting
+	err := row.Scan(
+		&i.OwnerID,
+		&i.TimeZone,
+		&i.NotifyHour,
+		&i.Email,
+		&i.UpdatedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const upsertTeamMember = `-- name: UpsertTeamMember :one
+INSERT INTO team_members (team_id, user_id, role)
+VALUES ($1, $2, $3) ON CONFLICT (team_id, user_id) DO
+UPDATE
+SET role = EXCLUDED.role
+RETURNING team_id, user_id, role, created_at, tenant_id
+`
+
+type UpsertTeamMemberParams struct {
+	TeamID int32  `json:"team_id"`
+	UserID int32  `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+func (q *Queries) UpsertTeamMember(ctx context.Context, arg UpsertTeamMemberParams) (TeamMember, error) {
+	row := q.db.QueryRow(ctx, upsertTeamMember, arg.TeamID, arg.UserID, arg.Role)
+	var i TeamMember
+	err := row.Scan(
+		&i.TeamID,
+		&i.UserID,
+		&i.Role,
+		&i.CreatedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
@@ -0,0 +1,21 @@
+package db
+
+import "strings"
+
+// UnnamedQuery is the name of statements that were not generated by sqlc,
+// such as migrations.
+const UnnamedQuery = "unnamed"
+
+// QueryName extracts the name from the "-- name: GetContact :one" header sqlc
+// puts in front of every generated statement.
+func QueryName(sql string) string {
+	rest, ok := strings.CutPrefix(sql, "-- name: ")
+	if !ok {
+		return UnnamedQuery
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	if name == "" {
+		return UnnamedQuery
+	}
+	return name
+}
@@ -0,0 +1,14 @@
+package db_test
+
+import (
+	"testing"
+
+	"contactsAI/contacts/internal/db"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryName(t *testing.T) {
+	assert.Equal(t, "GetContactByID", db.QueryName("-- name: GetContactByID :one\nSELECT 1"))
+	assert.Equal(t, db.UnnamedQuery, db.QueryName("SELECT pg_advisory_lock($1)"))
+}
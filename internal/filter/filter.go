@@ -0,0 +1,278 @@
+// Package filter parses the expressions that define smart groups and matches
+// contacts against them. An expression combines terms with AND, OR, NOT and
+// parentheses, e.g.
+//
+//	tag:vip AND (tag:customer OR name:"Kowalski") AND NOT phone:555
+//
+// AND binds tighter than OR and may be left out between terms. tag matches
+// tag names exactly, name and phone match substrings; both ignore case.
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MaxLength bounds the length of an expression.
+const MaxLength = 1000
+
+const (
+	FieldTag   = "tag"
+	FieldName  = "name"
+	FieldPhone = "phone"
+)
+
+// ErrSyntax is wrapped by the errors of Parse.
+var ErrSyntax = errors.New("invalid filter")
+
+// Contact is what expressions are evaluated against.
+type Contact struct {
+	Name  string
+	Phone string
+	Tags  []string
+}
+
+// Expr is a parsed expression.
+type Expr interface {
+	Match(contact Contact) bool
+}
+
+type andExpr struct{ left, right Expr }
+
+func (e andExpr) Match(contact Contact) bool { return e.left.Match(contact) && e.right.Match(contact) }
+
+type orExpr struct{ left, right Expr }
+
+func (e orExpr) Match(contact Contact) bool { return e.left.Match(contact) || e.right.Match(contact) }
+
+type notExpr struct{ expr Expr }
+
+func (e notExpr) Match(contact Contact) bool { return !e.expr.Match(contact) }
+
+type term struct{ field, value string }
+
+func (t term) Match(contact Contact) bool {
+	switch t.field {
+	case FieldTag:
+		return slices.ContainsFunc(contact.Tags, func(tag string) bool { return strings.EqualFold(tag, t.value) })
+	case FieldName:
+		return strings.Contains(strings.ToLower(contact.Name), t.value)
+	default:
+		return strings.Contains(strings.ToLower(contact.Phone), t.value)
+	}
+}
+
+// Parse parses expression, failing with ErrSyntax when it is malformed.
+func Parse(expression string) (Expr, error) {
+	if len(expression) > MaxLength {
+		return nil, fmt.Errorf("%w: longer than %d characters", ErrSyntax, MaxLength)
+	}
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, pos: 0}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, p.errorf("unexpected %s", p.tokens[p.pos].text)
+	}
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokenOpen tokenKind = iota
+	tokenClose
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenTerm
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// offset is the position of the token in the expression.
+	offset int
+	term   term
+}
+
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expression); {
+		switch c := expression[i]; {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(' || c == ')':
+			kind := tokenOpen
+			if c == ')' {
+				kind = tokenClose
+			}
+			tokens = append(tokens, token{kind: kind, text: string(c), offset: i, term: term{}})
+			i++
+		default:
+			tok, next, err := readWord(expression, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = next
+		}
+	}
+	return tokens, nil
+}
+
+// readWord reads an operator or a field:value term starting at start.
+func readWord(expression string, start int) (token, int, error) {
+	end := start
+	for end < len(expression) && !strings.ContainsRune(" \t\n():\"", rune(expression[end])) {
+		end++
+	}
+	word := expression[start:end]
+	if end == len(expression) || expression[end] != ':' {
+		kinds := map[string]tokenKind{"AND": tokenAnd, "OR": tokenOr, "NOT": tokenNot}
+		if kind, ok := kinds[strings.ToUpper(word)]; ok && word != "" {
+			return token{kind: kind, text: word, offset: start, term: term{}}, end, nil
+		}
+		return token{}, 0, fmt.Errorf("%w: expected field:value at position %d", ErrSyntax, start+1)
+	}
+
+	field := strings.ToLower(word)
+	if field != FieldTag && field != FieldName && field != FieldPhone {
+		return token{}, 0, fmt.Errorf("%w: unknown field %q at position %d, use tag, name or phone",
+			ErrSyntax, word, start+1)
+	}
+	value, next, err := readValue(expression, end+1)
+	if err != nil {
+		return token{}, 0, err
+	}
+	if value == "" {
+		return token{}, 0, fmt.Errorf("%w: missing value of %s at position %d", ErrSyntax, field, start+1)
+	}
+	if field != FieldTag {
+		value = strings.ToLower(value)
+	}
+	return token{
+		kind:   tokenTerm,
+		text:   expression[start:next],
+		offset: start,
+		term:   term{field: field, value: value},
+	}, next, nil
+}
+
+// readValue reads a bare or double-quoted value, in which \" and \\ are
+// escapes.
+func readValue(expression string, start int) (string, int, error) {
+	if start >= len(expression) || expression[start] != '"' {
+		end := start
+		for end < len(expression) && !strings.ContainsRune(" \t\n()\"", rune(expression[end])) {
+			end++
+		}
+		return expression[start:end], end, nil
+	}
+	var value strings.Builder
+	for i := start + 1; i < len(expression); i++ {
+		switch c := expression[i]; {
+		case c == '"':
+			return value.String(), i + 1, nil
+		case c == '\\' && i+1 < len(expression):
+			i++
+			value.WriteByte(expression[i])
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("%w: unterminated quote at position %d", ErrSyntax, start+1)
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	if p.pos >= len(p.tokens) {
+		return fmt.Errorf("%w: %s at the end", ErrSyntax, fmt.Sprintf(format, args...))
+	}
+	return fmt.Errorf("%w: %s at position %d", ErrSyntax, fmt.Sprintf(format, args...), p.tokens[p.pos].offset+1)
+}
+
+func (p *parser) peek(kind tokenKind) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == kind
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek(tokenOr) {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseAnd also joins terms that follow each other without an operator.
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek(tokenAnd) || p.peek(tokenNot) || p.peek(tokenOpen) || p.peek(tokenTerm) {
+		if p.peek(tokenAnd) {
+			p.pos++
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if p.peek(tokenNot) {
+		p.pos++
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{expr: expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	switch {
+	case p.peek(tokenTerm):
+		p.pos++
+		return p.tokens[p.pos-1].term, nil
+	case p.peek(tokenOpen):
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peek(tokenClose) {
+			return nil, p.errorf("expected )")
+		}
+		p.pos++
+		return expr, nil
+	case p.pos >= len(p.tokens):
+		return nil, p.errorf("expected a term")
+	default:
+		return nil, p.errorf("unexpected %s", p.tokens[p.pos].text)
+	}
+}
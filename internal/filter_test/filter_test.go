@@ -0,0 +1,58 @@
+package filter_test
+
+import (
+	"testing"
+
+	"contactsAI/contacts/internal/filter"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	vip := filter.Contact{Name: "Jan Kowalski", Phone: "123-456-789", Tags: []string{"VIP", "Customer"}}
+	family := filter.Contact{Name: "Anna Nowak", Phone: "987-654-321", Tags: []string{"Family"}}
+	untagged := filter.Contact{Name: "Piotr Wiśniewski", Phone: "111-222-333", Tags: nil}
+
+	tests := []struct {
+		expression string
+		matches    []bool // vip, family, untagged
+	}{
+		{"tag:vip", []bool{true, false, false}},
+		{"tag:VIP tag:customer", []bool{true, false, false}},
+		{"tag:vip OR tag:family", []bool{true, true, false}},
+		{"NOT tag:family", []bool{true, false, true}},
+		{"name:kowal", []bool{true, false, false}},
+		{`name:"anna now"`, []bool{false, true, false}},
+		{"phone:111 or (tag:family and not name:jan)", []bool{false, true, true}},
+		{"tag:vip AND tag:family OR phone:111", []bool{false, false, true}},
+		{"tag:vip AND (tag:family OR phone:123)", []bool{true, false, false}},
+		{"NOT (tag:vip OR tag:family)", []bool{false, false, true}},
+		{"tag:Cust", []bool{false, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			expr, err := filter.Parse(tt.expression)
+			require.NoError(t, err)
+			assert.Equal(t, tt.matches, []bool{expr.Match(vip), expr.Match(family), expr.Match(untagged)})
+		})
+	}
+}
+
+func TestParseRejectsMalformedExpressions(t *testing.T) {
+	for _, expression := range []string{
+		"",
+		"vip",
+		"email:a@example.com",
+		"tag:",
+		"tag:vip AND",
+		"(tag:vip",
+		"tag:vip)",
+		"OR tag:vip",
+		`name:"unterminated`,
+		"tag:" + string(make([]byte, filter.MaxLength)),
+	} {
+		_, err := filter.Parse(expression)
+		assert.ErrorIs(t, err, filter.ErrSyntax, expression)
+	}
+}
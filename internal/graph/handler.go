@@ -0,0 +1,62 @@
+package graph
+
+import (
+	"net/http"
+
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+type Request struct {
+	Query         string         `json:"query"         binding:"required"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+func RegisterRoutes(router *gin.RouterGroup, env *config.Env) {
+	schema, err := NewSchema(env)
+	if err != nil {
+		panic(err)
+	}
+	router.POST("/graphql", func(c *gin.Context) { Handler(c, env, schema) })
+}
+
+// Handler godoc
+//
+//	@Summary		GraphQL endpoint
+//	@Description	Execute a GraphQL query or mutation against contacts
+//	@Tags			graphql
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		Request	true	"GraphQL request"
+//	@Success		200		{object}	map[string]any
+//	@Failure		400		{object}	map[string]any
+//	@Router			/graphql [post]
+func Handler(c *gin.Context, env *config.Env, schema graphql.Schema) {
+	var req Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, handlers.NewErrorResponse(c, err.Error()))
+		return
+	}
+
+	if err := CheckLimits(req.Query, req.Variables); err != nil {
+		//nolint:exhaustruct // no data is produced for rejected queries
+		c.JSON(http.StatusBadRequest, graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+		return
+	}
+
+	ctx := withLoaders(c.Request.Context(), NewLoaders(env))
+	result := graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  req.Query,
+		RootObject:     nil,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        ctx,
+	})
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,134 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+const (
+	MaxQueryDepth      = 8
+	MaxQueryComplexity = 1000
+)
+
+var errFragmentCycle = errors.New("fragment cycle detected")
+
+// queryCost walks a parsed operation and reports its selection depth and an
+// estimated complexity, where fields below a paginated field are multiplied
+// by the requested page size.
+type queryCost struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+	visiting  map[string]bool
+}
+
+// CheckLimits rejects queries that are nested deeper than MaxQueryDepth or
+// whose estimated complexity exceeds MaxQueryComplexity. Syntax errors are left
+// for the executor to report.
+func CheckLimits(query string, variables map[string]any) error {
+	//nolint:exhaustruct // only the source is required
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return nil //nolint:nilerr // the executor reports syntax errors with locations
+	}
+
+	cost := queryCost{
+		fragments: map[string]*ast.FragmentDefinition{},
+		variables: variables,
+		visiting:  map[string]bool{},
+	}
+	for _, def := range doc.Definitions {
+		if fragment, ok := def.(*ast.FragmentDefinition); ok {
+			cost.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	for _, def := range doc.Definitions {
+		operation, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		depth, complexity, walkErr := cost.selectionSet(operation.SelectionSet)
+		if walkErr != nil {
+			return walkErr
+		}
+		if depth > MaxQueryDepth {
+			return fmt.Errorf("query depth %d exceeds the limit of %d", depth, MaxQueryDepth)
+		}
+		if complexity > MaxQueryComplexity {
+			return fmt.Errorf("query complexity %d exceeds the limit of %d", complexity, MaxQueryComplexity)
+		}
+	}
+	return nil
+}
+
+func (q *queryCost) selectionSet(set *ast.SelectionSet) (int, int, error) {
+	if set == nil {
+		return 0, 0, nil
+	}
+	maxDepth, total := 0, 0
+	for _, selection := range set.Selections {
+		depth, complexity, err := q.selection(selection)
+		if err != nil {
+			return 0, 0, err
+		}
+		maxDepth = max(maxDepth, depth)
+		total += complexity
+	}
+	return maxDepth, total, nil
+}
+
+func (q *queryCost) selection(selection ast.Selection) (int, int, error) {
+	switch node := selection.(type) {
+	case *ast.Field:
+		depth, complexity, err := q.selectionSet(node.SelectionSet)
+		if err != nil {
+			return 0, 0, err
+		}
+		return depth + 1, 1 + q.pageSize(node)*complexity, nil
+	case *ast.InlineFragment:
+		return q.selectionSet(node.SelectionSet)
+	case *ast.FragmentSpread:
+		name := node.Name.Value
+		fragment, ok := q.fragments[name]
+		if !ok {
+			return 0, 0, nil
+		}
+		if q.visiting[name] {
+			return 0, 0, errFragmentCycle
+		}
+		q.visiting[name] = true
+		defer delete(q.visiting, name)
+		return q.selectionSet(fragment.SelectionSet)
+	default:
+		return 0, 0, nil
+	}
+}
+
+// pageSize returns the multiplier applied to a field's children: the value of
+// its "first" argument, or 1 for fields that are not paginated.
+func (q *queryCost) pageSize(field *ast.Field) int {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != "first" {
+			continue
+		}
+		switch value := arg.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(value.Value); err == nil {
+				return clampPageSize(n)
+			}
+		case *ast.Variable:
+			if n, ok := q.variables[value.Name.Value].(float64); ok {
+				return clampPageSize(int(n))
+			}
+		}
+		return DefaultPageSize
+	}
+	if field.Name.Value == "contacts" {
+		return DefaultPageSize
+	}
+	return 1
+}
@@ -0,0 +1,135 @@
+package graph
+
+import (
+	"context"
+	"sync"
+
+	"contactsAI/contacts/internal/access"
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/db"
+)
+
+type batchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// loader collects keys requested while a selection set is being resolved and
+// fetches them with a single batch call once the first deferred value is read.
+type loader[K comparable, V any] struct {
+	mu      sync.Mutex
+	fetch   batchFunc[K, V]
+	pending []K
+	cache   map[K]V
+	errs    map[K]error
+}
+
+func newLoader[K comparable, V any](fetch batchFunc[K, V]) *loader[K, V] {
+	return &loader[K, V]{
+		mu:      sync.Mutex{},
+		fetch:   fetch,
+		pending: nil,
+		cache:   map[K]V{},
+		errs:    map[K]error{},
+	}
+}
+
+// Load queues key for the next batch and returns a thunk resolving to its value.
+// The boolean result is false when the batch did not return the key.
+func (l *loader[K, V]) Load(ctx context.Context, key K) func() (V, bool, error) {
+	l.mu.Lock()
+	if _, cached := l.cache[key]; !cached {
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, bool, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		l.dispatch(ctx)
+		if err, failed := l.errs[key]; failed {
+			var zero V
+			return zero, false, err
+		}
+		value, ok := l.cache[key]
+		return value, ok, nil
+	}
+}
+
+// dispatch must be called with l.mu held.
+func (l *loader[K, V]) dispatch(ctx context.Context) {
+	if len(l.pending) == 0 {
+		return
+	}
+	keys := uniqueKeys(l.pending)
+	l.pending = nil
+
+	values, err := l.fetch(ctx, keys)
+	for _, key := range keys {
+		if err != nil {
+			l.errs[key] = err
+			continue
+		}
+		if value, ok := values[key]; ok {
+			l.cache[key] = value
+		}
+	}
+}
+
+func uniqueKeys[K comparable](keys []K) []K {
+	seen := make(map[K]struct{}, len(keys))
+	unique := make([]K, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		unique = append(unique, key)
+	}
+	return unique
+}
+
+// Loaders holds the per-request batch loaders used by resolvers.
+type Loaders struct {
+	ContactByID       *loader[int32, db.Contact]
+	ContactCountOwner *loader[int32, int64]
+}
+
+func NewLoaders(env *config.Env) *Loaders {
+	return &Loaders{
+		ContactByID: newLoader(func(ctx context.Context, ids []int32) (map[int32]db.Contact, error) {
+			contacts, err := env.GetContactsByIDs(ctx, db.GetContactsByIDsParams{Ids: ids, ViewerID: access.Viewer(ctx)})
+			if err != nil {
+				return nil, err
+			}
+			result := make(map[int32]db.Contact, len(contacts))
+			for _, contact := range contacts {
+				result[contact.ID] = contact
+			}
+			return result, nil
+		}),
+		ContactCountOwner: newLoader(func(ctx context.Context, ownerIDs []int32) (map[int32]int64, error) {
+			rows, err := env.CountContactsByOwnerIDs(ctx, db.CountContactsByOwnerIDsParams{
+				OwnerIds: ownerIDs,
+				ViewerID: access.Viewer(ctx),
+			})
+			if err != nil {
+				return nil, err
+			}
+			result := make(map[int32]int64, len(rows))
+			for _, row := range rows {
+				result[row.OwnerID.Int32] = row.ContactCount
+			}
+			return result, nil
+		}),
+	}
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, loaders *Loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, loaders)
+}
+
+func loadersFrom(ctx context.Context) *Loaders {
+	loaders, _ := ctx.Value(loadersKey{}).(*Loaders)
+	return loaders
+}
@@ -0,0 +1,430 @@
+package graph
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"contactsAI/contacts/internal/access"
+	"contactsAI/contacts/internal/apikey"
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/db"
+	"contactsAI/contacts/internal/handlers"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/graphql-go/graphql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var errContactNotFound = errors.New("contact not found")
+
+// notEditable tells why a write to contact id changed nothing: the caller
+// does not see the contact, or it is shared with them read-only.
+func notEditable(ctx context.Context, env *config.Env, id int32) error {
+	permission, err := env.GetContactAccess(ctx, db.GetContactAccessParams{ID: id, ViewerID: access.Viewer(ctx)})
+	switch {
+	case errors.Is(err, pgx.ErrNoRows) || (err == nil && !permission.Valid):
+		return errContactNotFound
+	case err != nil:
+		return err
+	default:
+		return fmt.Errorf("%w: contact is shared read-only", access.ErrForbidden)
+	}
+}
+
+type contactEdge struct {
+	Cursor string
+	Node   db.Contact
+}
+
+type contactConnection struct {
+	Edges       []contactEdge
+	HasNextPage bool
+}
+
+// NewSchema builds the GraphQL schema for contacts. Resolvers expect the
+// request context to carry Loaders (see Handler).
+func NewSchema(env *config.Env) (graphql.Schema, error) {
+	owner := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Owner",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					ownerID, _ := p.Source.(int32)
+					return ownerID, nil
+				},
+			},
+			"contactCount": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					ownerID, _ := p.Source.(int32)
+					thunk := loadersFrom(p.Context).ContactCountOwner.Load(p.Context, ownerID)
+					return func() (any, error) {
+						count, _, err := thunk()
+						return count, err
+					}, nil
+				},
+			},
+		},
+	})
+
+	contact := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Contact",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Int),
+				Resolve: contactField(func(c db.Contact) any { return c.ID }),
+			},
+			"name": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.String),
+				Resolve: contactField(func(c db.Contact) any { return c.Name }),
+			},
+			"phone": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.String),
+				Resolve: contactField(func(c db.Contact) any { return c.Phone }),
+			},
+			"createdAt": &graphql.Field{
+				Type: graphql.DateTime,
+				Resolve: contactField(func(c db.Contact) any {
+					if !c.CreatedAt.Valid {
+						return nil
+					}
+					return c.CreatedAt.Time
+				}),
+			},
+			"avatarUrl": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: contactField(func(c db.Contact) any {
+					return fmt.Sprintf("/api/contacts/%d/avatar", c.ID)
+				}),
+			},
+			"owner": &graphql.Field{
+				Type: owner,
+				Resolve: contactField(func(c db.Contact) any {
+					if !c.OwnerID.Valid {
+						return nil
+					}
+					return c.OwnerID.Int32
+				}),
+			},
+		},
+	})
+
+	pageInfo := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					conn, _ := p.Source.(contactConnection)
+					return conn.HasNextPage, nil
+				},
+			},
+			"endCursor": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					conn, _ := p.Source.(contactConnection)
+					if len(conn.Edges) == 0 {
+						return nil, nil
+					}
+					return conn.Edges[len(conn.Edges)-1].Cursor, nil
+				},
+			},
+		},
+	})
+
+	edge := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ContactEdge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					e, _ := p.Source.(contactEdge)
+					return e.Cursor, nil
+				},
+			},
+			"node": &graphql.Field{
+				Type: graphql.NewNonNull(contact),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					e, _ := p.Source.(contactEdge)
+					return e.Node, nil
+				},
+			},
+		},
+	})
+
+	connection := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ContactConnection",
+		Fields: graphql.Fields{
+			"edges": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(edge))),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					conn, _ := p.Source.(contactConnection)
+					return conn.Edges, nil
+				},
+			},
+			"pageInfo": &graphql.Field{
+				Type: graphql.NewNonNull(pageInfo),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source, nil
+				},
+			},
+		},
+	})
+
+	contactInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "ContactInput",
+		Description: "The name and phone of a contact. The profile and custom fields are only set over REST: " +
+			"updates keep them, and creating fails while the owner has required custom fields.",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"phone": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"contact": &graphql.Field{
+				Type: contact,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					id, _ := p.Args["id"].(int)
+					thunk := loadersFrom(p.Context).ContactByID.Load(p.Context, int32(id)) //nolint:gosec // GraphQL Int is 32-bit
+					return func() (any, error) {
+						c, ok, err := thunk()
+						if err != nil || !ok {
+							return nil, err
+						}
+						return c, nil
+					}, nil
+				},
+			},
+			"contacts": &graphql.Field{
+				Type: graphql.NewNonNull(connection),
+				Args: graphql.FieldConfigArgument{
+					"first":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: DefaultPageSize},
+					"after":  &graphql.ArgumentConfig{Type: graphql.String},
+					"search": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return resolveContacts(p, env)
+				},
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createContact": &graphql.Field{
+				Type: graphql.NewNonNull(contact),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(contactInput)},
+				},
+				Resolve: requireScope(apikey.ScopeContactsWrite, func(p graphql.ResolveParams) (any, error) {
+					input, _ := p.Args["input"].(map[string]any)
+					body := handlers.CreateContactBody{Name: stringArg(input, "name"), Phone: stringArg(input, "phone")}
+					if err := binding.Validator.ValidateStruct(&body); err != nil {
+						return nil, err
+					}
+					owner := access.User(p.Context)
+					params, err := handlers.NewCreateContactParams(
+						body.Name, body.Phone, owner, handlers.ContactProfile{}, nil)
+					if err != nil {
+						return nil, err
+					}
+					// The custom fields are checked in the transaction that
+					// stores the contact, as in the REST API.
+					var created db.Contact
+					err = env.Tenant.WithTx(p.Context, func(ctx context.Context) error {
+						customFields, checkErr := handlers.CheckCustomFields(ctx, env.Queries, owner, nil)
+						if checkErr != nil {
+							return checkErr
+						}
+						params.CustomFields = customFields
+						var createErr error
+						created, createErr = env.CreateContact(ctx, params)
+						return createErr
+					})
+					if err != nil {
+						return nil, err
+					}
+					return created, nil
+				}),
+			},
+			"updateContact": &graphql.Field{
+				Type: graphql.NewNonNull(contact),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(contactInput)},
+				},
+				Resolve: requireScope(apikey.ScopeContactsWrite, func(p graphql.ResolveParams) (any, error) {
+					id, _ := p.Args["id"].(int)
+					input, _ := p.Args["input"].(map[string]any)
+					body := handlers.UpdateContactBody{Name: stringArg(input, "name"), Phone: stringArg(input, "phone")}
+					if err := binding.Validator.ValidateStruct(&body); err != nil {
+						return nil, err
+					}
+					// The input has no profile or custom fields, so the current
+					// ones are kept. They are read in the transaction that writes
+					// them back, so that concurrent changes are not overwritten.
+					viewer := access.Viewer(p.Context)
+					var updated db.Contact
+					err := env.Tenant.WithTx(p.Context, func(ctx context.Context) error {
+						current, getErr := env.GetContactByID(ctx, db.GetContactByIDParams{
+							ID:       int32(id), //nolint:gosec // GraphQL Int is 32-bit
+							ViewerID: viewer,
+						})
+						if getErr != nil {
+							return getErr
+						}
+						params, paramsErr := handlers.NewUpdateContactParams(
+							current.ID, body.Name, body.Phone, handlers.ProfileOf(current), nil, viewer)
+						if paramsErr != nil {
+							return paramsErr
+						}
+						var updateErr error
+						updated, updateErr = env.UpdateContact(ctx, params)
+						if errors.Is(updateErr, pgx.ErrNoRows) {
+							return notEditable(ctx, env, current.ID)
+						}
+						return updateErr
+					})
+					if errors.Is(err, pgx.ErrNoRows) {
+						return nil, errContactNotFound
+					}
+					if err != nil {
+						return nil, err
+					}
+					return updated, nil
+				}),
+			},
+			"deleteContact": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: requireScope(apikey.ScopeContactsWrite, func(p graphql.ResolveParams) (any, error) {
+					id, _ := p.Args["id"].(int)
+					deleted, err := env.DeleteContact(p.Context, db.DeleteContactParams{
+						ID:       int32(id), //nolint:gosec // GraphQL Int is 32-bit
+						ViewerID: access.Viewer(p.Context),
+					})
+					if err != nil {
+						return false, err
+					}
+					if deleted == 0 {
+						return false, notEditable(p.Context, env, int32(id)) //nolint:gosec // GraphQL Int is 32-bit
+					}
+					return true, nil
+				}),
+			},
+		},
+	})
+
+	//nolint:exhaustruct // subscriptions and extra types are not used
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+// requireScope rejects mutations by API keys without scope. The route itself
+// only requires read access, since queries and mutations share it.
+func requireScope(scope string, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		if err := apikey.CheckScope(p.Context, scope); err != nil {
+			return nil, err
+		}
+		return resolve(p)
+	}
+}
+
+func contactField(get func(db.Contact) any) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		c, ok := p.Source.(db.Contact)
+		if !ok {
+			return nil, nil
+		}
+		return get(c), nil
+	}
+}
+
+func resolveContacts(p graphql.ResolveParams, env *config.Env) (any, error) {
+	first, _ := p.Args["first"].(int)
+	pageSize := clampPageSize(first)
+
+	//nolint:exhaustruct // cursor fields are optional
+	params := db.ListContactsPageParams{
+		ViewerID: access.Viewer(p.Context),
+		PageSize: int32(pageSize + 1), //nolint:gosec // bounded by MaxPageSize
+	}
+	if search, ok := p.Args["search"].(string); ok && search != "" {
+		params.Search = pgtype.Text{String: search, Valid: true}
+	}
+	if after, ok := p.Args["after"].(string); ok && after != "" {
+		name, id, err := decodeCursor(after)
+		if err != nil {
+			return nil, err
+		}
+		params.AfterName = pgtype.Text{String: name, Valid: true}
+		params.AfterID = pgtype.Int4{Int32: id, Valid: true}
+	}
+
+	contacts, err := env.ListContactsPage(p.Context, params)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := contactConnection{Edges: make([]contactEdge, 0, len(contacts)), HasNextPage: len(contacts) > pageSize}
+	if conn.HasNextPage {
+		contacts = contacts[:pageSize]
+	}
+	for _, c := range contacts {
+		conn.Edges = append(conn.Edges, contactEdge{Cursor: encodeCursor(c), Node: c})
+	}
+	return conn, nil
+}
+
+func clampPageSize(n int) int {
+	if n <= 0 {
+		return DefaultPageSize
+	}
+	return min(n, MaxPageSize)
+}
+
+// Cursors encode the (name, id) keyset used to order contacts.
+func encodeCursor(c db.Contact) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(int(c.ID)) + ":" + c.Name))
+}
+
+func decodeCursor(cursor string) (string, int32, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, errors.New("invalid cursor")
+	}
+	idPart, name, found := strings.Cut(string(raw), ":")
+	if !found {
+		return "", 0, errors.New("invalid cursor")
+	}
+	id, err := strconv.ParseInt(idPart, 10, 32)
+	if err != nil {
+		return "", 0, errors.New("invalid cursor")
+	}
+	return name, int32(id), nil
+}
+
+func stringArg(args map[string]any, name string) string {
+	value, _ := args[name].(string)
+	return value
+}
@@ -0,0 +1,37 @@
+package graph_test
+
+import (
+	"strings"
+	"testing"
+
+	"contactsAI/contacts/internal/graph"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckLimitsAcceptsRegularQuery(t *testing.T) {
+	query := `query { contacts(first: 10) { edges { node { id name owner { id contactCount } } } } }`
+	require.NoError(t, graph.CheckLimits(query, nil))
+}
+
+func TestCheckLimitsRejectsDeepQuery(t *testing.T) {
+	query := "query { contact(id: 1) " + strings.Repeat("{ owner ", graph.MaxQueryDepth) +
+		"{ id }" + strings.Repeat("}", graph.MaxQueryDepth) + " }"
+	err := graph.CheckLimits(query, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "depth")
+}
+
+func TestCheckLimitsRejectsExpensiveQuery(t *testing.T) {
+	query := `query($n: Int) { contacts(first: $n) { edges { node { id name phone avatarUrl createdAt
+		owner { id contactCount } } } } }`
+	err := graph.CheckLimits(query, map[string]any{"n": float64(graph.MaxPageSize)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "complexity")
+}
+
+func TestCheckLimitsDetectsFragmentCycles(t *testing.T) {
+	query := `query { contact(id: 1) { ...A } } fragment A on Contact { owner { ...B } } fragment B on Owner { ...A }`
+	require.Error(t, graph.CheckLimits(query, nil))
+}
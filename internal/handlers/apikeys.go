@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"contactsAI/contacts/internal/apikey"
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/db"
+	"contactsAI/contacts/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type CreateAPIKeyBody struct {
+	Name    string   `json:"name"     binding:"required,max=100" example:"CRM sync"`
+	OwnerID int32    `json:"owner_id" binding:"required,min=1"   example:"1"`
+	Scopes  []string `json:"scopes"   binding:"required"         example:"contacts:read"`
+	// ExpiresAt is optional; keys without it do not expire.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type APIKeyResponse struct {
+	ID         int32      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	OwnerID    int32      `json:"owner_id"`
+	TenantID   int32      `json:"tenant_id"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+
+	// Secret is only returned when the key is created.
+	Secret string `json:"secret"`
+}
+
+func toAPIKeyResponse(key db.ApiKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		OwnerID:    key.OwnerID,
+		TenantID:   key.TenantID,
+		Scopes:     key.Scopes,
+		ExpiresAt:  optionalTime(key.ExpiresAt),
+		LastUsedAt: optionalTime(key.LastUsedAt),
+		CreatedAt:  key.CreatedAt.Time,
+		RevokedAt:  optionalTime(key.RevokedAt),
+	}
+}
+
+func optionalTime(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// CreateAPIKey godoc
+//
+//	@Summary		Create an API key
+//	@Description	Create an API key in the tenant of the caller. The secret is only returned in this response.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			key	body		CreateAPIKeyBody	true	"Key details"
+//	@Success		201	{object}	CreatedAPIKeyResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/admin/api-keys [post]
+func CreateAPIKey(c *gin.Context, env *config.Env) {
+	var body CreateAPIKeyBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+	if err := apikey.ValidateScopes(body.Scopes); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+	var expiresAt pgtype.Timestamptz
+	if body.ExpiresAt != nil {
+		if !body.ExpiresAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, NewErrorResponse(c, "expires_at must be in the future"))
+			return
+		}
+		expiresAt = pgtype.Timestamptz{Time: *body.ExpiresAt, Valid: true}
+	}
+
+	// Authenticate always stores a tenant, keys are created in the same one.
+	tenantID, _ := tenant.FromContext(c)
+	key := apikey.Generate()
+	created, err := env.CreateAPIKey(c, db.CreateAPIKeyParams{
+		Name:      body.Name,
+		Prefix:    key.Prefix,
+		KeyHash:   key.Hash,
+		OwnerID:   body.OwnerID,
+		Scopes:    body.Scopes,
+		ExpiresAt: expiresAt,
+		TenantID:  tenantID,
+	})
+	if err != nil {
+		logError(c, "Failed to create API key", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to create API key"))
+		return
+	}
+	c.JSON(http.StatusCreated, CreatedAPIKeyResponse{APIKeyResponse: toAPIKeyResponse(created), Secret: key.Secret})
+}
+
+// ListAPIKeys godoc
+//
+//	@Summary		List API keys
+//	@Description	List the API keys of the tenant, including revoked and expired ones, without their secrets
+//	@Tags			admin
+//	@Produce		json
+//	@Param			owner_id	query		int	false	"Only keys of this owner"
+//	@Success		200			{array}		APIKeyResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/admin/api-keys [get]
+func ListAPIKeys(c *gin.Context, env *config.Env) {
+	var ownerID pgtype.Int4
+	if c.Query("owner_id") != "" {
+		id, err := getIntFromQuery(c, "owner_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid owner ID"))
+			return
+		}
+		ownerID = pgtype.Int4{Int32: id, Valid: true}
+	}
+
+	keys, err := env.Queries.ListAPIKeys(c, ownerID)
+	if err != nil {
+		logError(c, "Failed to list API keys", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to list API keys"))
+		return
+	}
+	dtos := make([]APIKeyResponse, len(keys))
+	for i, key := range keys {
+		dtos[i] = toAPIKeyResponse(key)
+	}
+	c.JSON(http.StatusOK, dtos)
+}
+
+// RevokeAPIKey godoc
+//
+//	@Summary		Revoke an API key
+//	@Description	Revoke an API key so that it is rejected from now on
+//	@Tags			admin
+//	@Produce		json
+//	@Param			id	path		int	true	"API key ID"
+//	@Success		200	{object}	APIKeyResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/admin/api-keys/{id} [delete]
+func RevokeAPIKey(c *gin.Context, env *config.Env) {
+	id, err := getIntFromPath(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid API key ID"))
+		return
+	}
+
+	key, err := env.Queries.RevokeAPIKey(c, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, NewErrorResponse(c, "API key not found"))
+			return
+		}
+		logError(c, "Failed to revoke API key", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to revoke API key"))
+		return
+	}
+	c.JSON(http.StatusOK, toAPIKeyResponse(key))
+}
@@ -0,0 +1,592 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"contactsAI/contacts/internal/access"
+	"contactsAI/contacts/internal/bucket"
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/db"
+	"contactsAI/contacts/internal/imaging"
+	"contactsAI/contacts/internal/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	BytesPerKB = 1024
+	KBPerMB    = 1024
+	MaxMBSize  = 10
+)
+
+const maxAvatarSize = int64(MaxMBSize * KBPerMB * BytesPerKB) // 10 MiB
+
+// maxMultipartOverhead bounds the multipart headers and boundaries around the avatar part.
+const maxMultipartOverhead = 64 * BytesPerKB
+
+var errAvatarTooLarge = errors.New("avatar exceeds the maximum size")
+
+const (
+	avatarUploadURLTTL   = 15 * time.Minute
+	avatarDownloadURLTTL = 5 * time.Minute
+)
+
+// UploadContactAvatar godoc
+//
+//	@Summary		Upload contact avatar
+//	@Description	Upload an avatar image for a contact by ID. Files are limited to 10 MB and 16 megapixels.
+//	@Tags			contacts
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			id		path		int		true	"Contact ID"
+//	@Param			avatar	formData	file	true	"Avatar file"
+//	@Success		200		{object}	map[string]string
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/contacts/{id}/avatar [put]
+func UploadContactAvatar(c *gin.Context, env *config.Env) {
+	contactID, ok := requireEditableContact(c, env)
+	if !ok {
+		return
+	}
+
+	// The body is streamed into the decoder and never held as a whole: it is
+	// capped at the avatar size limit, and imaging.ProcessAvatar buffers only
+	// the image header and rejects images of too many pixels before decoding.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAvatarSize+maxMultipartOverhead)
+	part, err := nextAvatarPart(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid avatar file provided"))
+		return
+	}
+	defer part.Close()
+
+	buffered := bufio.NewReader(part)
+	if _, err = buffered.Peek(1); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Avatar file is empty"))
+		return
+	}
+
+	// The declared Content-Type is ignored: the format is sniffed and the image
+	// re-encoded before anything is stored.
+	processed, err := imaging.ProcessAvatar(newChecksumReader(buffered, maxAvatarSize))
+	if err != nil {
+		writeAvatarProcessingError(c, env, err)
+		return
+	}
+
+	if _, err = storeAvatar(c, env, contactID, avatarObjectKey(contactID), processed); err != nil {
+		logError(c, "Failed to store avatar", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Could not upload avatar"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Avatar uploaded"})
+}
+
+// nextAvatarPart reads the multipart body up to the "avatar" file part without
+// buffering it, unlike c.FormFile which parses the whole form first.
+func nextAvatarPart(c *gin.Context) (*multipart.Part, error) {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, partErr := reader.NextPart()
+		if partErr != nil {
+			return nil, partErr
+		}
+		if part.FormName() == "avatar" && part.FileName() != "" {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+// DownloadContactAvatar godoc
+//
+//	@Summary		Download contact's avatar
+//	@Description	Streams a contact's avatar by contact ID. Supports conditional and range requests.
+//	@Tags			contacts
+//	@Produce		octet-stream
+//	@Param			id					path		int		true	"Contact ID"
+//	@Param			size				query		int		false	"Thumbnail size (64, 256 or 512)"
+//	@Param			If-None-Match		header		string	false	"ETag of a cached copy"
+//	@Param			If-Modified-Since	header		string	false	"Last-Modified of a cached copy"
+//	@Param			Range				header		string	false	"Byte range, e.g. bytes=0-1023"
+//	@Success		200					{file}		file	"The avatar file stream"
+//	@Success		206					{file}		file	"Partial avatar content"
+//	@Success		304					{string}	string	"Not Modified"
+//	@Failure		400					{object}	ErrorResponse
+//	@Failure		404					{object}	ErrorResponse
+//	@Failure		416					{object}	ErrorResponse
+//	@Failure		500					{object}	ErrorResponse
+//	@Router			/contacts/{id}/avatar [get]
+//	@Router			/contacts/{id}/avatar [head]
+func DownloadContactAvatar(c *gin.Context, env *config.Env) {
+	avatar, ok := requireAvatar(c, env)
+	if !ok {
+		return
+	}
+	key, ok := avatarKeyForSize(c, avatar)
+	if !ok {
+		return
+	}
+
+	opts := avatarGetOptions(c.Request)
+	if c.Request.Method == http.MethodHead {
+		info, err := env.Bucket.Head(c.Request.Context(), key, opts)
+		if err != nil {
+			writeAvatarObjectError(c, env, key, err)
+			return
+		}
+		setAvatarHeaders(c, env, info)
+		c.Status(http.StatusOK)
+		return
+	}
+
+	s3Object, err := env.Bucket.GetStream(c.Request.Context(), key, opts)
+	if err != nil {
+		writeAvatarObjectError(c, env, key, err)
+		return
+	}
+	defer s3Object.Body.Close()
+
+	setAvatarHeaders(c, env, &s3Object.ObjectInfo)
+	status := http.StatusOK
+	if s3Object.ContentRange != "" {
+		c.Header("Content-Range", s3Object.ContentRange)
+		status = http.StatusPartialContent
+	}
+	c.Status(status)
+
+	_, err = io.Copy(c.Writer, s3Object.Body)
+	if err != nil {
+		logError(c, "Error streaming file to client", err)
+	}
+}
+
+// avatarGetOptions forwards the request's cache validators and range to storage.
+// If-Modified-Since is ignored when If-None-Match is present, as required by RFC 9110.
+func avatarGetOptions(req *http.Request) bucket.GetOptions {
+	//nolint:exhaustruct // fields are filled from optional headers
+	opts := bucket.GetOptions{
+		IfNoneMatch: req.Header.Get("If-None-Match"),
+		Range:       req.Header.Get("Range"),
+	}
+	if opts.IfNoneMatch == "" {
+		if since, err := http.ParseTime(req.Header.Get("If-Modified-Since")); err == nil {
+			opts.IfModifiedSince = since
+		}
+	}
+	return opts
+}
+
+func setAvatarHeaders(c *gin.Context, env *config.Env, info *bucket.ObjectInfo) {
+	if info.ContentType != "" {
+		c.Header("Content-Type", info.ContentType)
+	} else {
+		c.Header("Content-Type", "application/octet-stream")
+	}
+	c.Header("Content-Length", strconv.FormatInt(info.Size, 10))
+	if info.ETag != "" {
+		c.Header("ETag", info.ETag)
+	}
+	if !info.LastModified.IsZero() {
+		c.Header("Last-Modified", info.LastModified.UTC().Format(http.TimeFormat))
+	}
+	c.Header("Cache-Control", env.AvatarCacheControl)
+	c.Header("Accept-Ranges", "bytes")
+	c.Header("Content-Disposition", "inline")
+}
+
+func writeAvatarObjectError(c *gin.Context, env *config.Env, key string, err error) {
+	switch {
+	case errors.Is(err, bucket.ErrNotModified):
+		writeNotModified(c, env, key)
+	case errors.Is(err, bucket.ErrInvalidRange):
+		c.JSON(http.StatusRequestedRangeNotSatisfiable, NewErrorResponse(c, "Requested range not satisfiable"))
+	case errors.Is(err, bucket.ErrNotFound):
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Avatar not found."))
+	default:
+		logError(c, "Failed to fetch avatar", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Could not fetch avatar"))
+	}
+}
+
+// writeNotModified answers a matching conditional request. RFC 9110 requires
+// a 304 to carry the validators a 200 would, so they are read from storage.
+func writeNotModified(c *gin.Context, env *config.Env, key string) {
+	//nolint:exhaustruct // unconditional
+	info, err := env.Bucket.Head(c.Request.Context(), key, bucket.GetOptions{})
+	if err == nil {
+		if info.ETag != "" {
+			c.Header("ETag", info.ETag)
+		}
+		if !info.LastModified.IsZero() {
+			c.Header("Last-Modified", info.LastModified.UTC().Format(http.TimeFormat))
+		}
+	}
+	c.Header("Cache-Control", env.AvatarCacheControl)
+	c.Status(http.StatusNotModified)
+}
+
+// CreateAvatarUploadURL godoc
+//
+//	@Summary		Create avatar upload URL
+//	@Description	Returns a short-lived presigned PUT URL for uploading a contact's avatar directly to storage
+//	@Tags			contacts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int					true	"Contact ID"
+//	@Param			upload	body		AvatarUploadURLBody	true	"Avatar content type and size"
+//	@Success		200		{object}	PresignedURLResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Failure		501		{object}	ErrorResponse
+//	@Router			/contacts/{id}/avatar/upload-url [post]
+func CreateAvatarUploadURL(c *gin.Context, env *config.Env) {
+	contactID, ok := requireEditableContact(c, env)
+	if !ok {
+		return
+	}
+
+	var json AvatarUploadURLBody
+	if err := c.ShouldBindJSON(&json); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+	if !imaging.IsSupportedContentType(json.ContentType) {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Unsupported avatar content type"))
+		return
+	}
+	if json.Size > maxAvatarSize {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, fmt.Sprintf("Avatar size cannot exceed %dMB", MaxMBSize)))
+		return
+	}
+
+	key := avatarObjectKey(contactID)
+	req, err := env.Bucket.PresignPut(c.Request.Context(), key, json.ContentType, json.Size, avatarUploadURLTTL)
+	if errors.Is(err, bucket.ErrPresignNotSupported) {
+		c.JSON(http.StatusNotImplemented, NewErrorResponse(c, "Direct uploads are not supported by the storage backend"))
+		return
+	}
+	if err != nil {
+		logError(c, "Failed to presign avatar upload", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Could not create upload URL"))
+		return
+	}
+	c.JSON(http.StatusOK, toPresignedURLResponse(req, key))
+}
+
+// CompleteAvatarUpload godoc
+//
+//	@Summary		Complete avatar upload
+//	@Description	Verifies an avatar uploaded through a presigned URL and records it for the contact
+//	@Tags			contacts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int							true	"Contact ID"
+//	@Param			upload	body		CompleteAvatarUploadBody	true	"Uploaded object key"
+//	@Success		200		{object}	AvatarResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/contacts/{id}/avatar/complete [post]
+func CompleteAvatarUpload(c *gin.Context, env *config.Env) {
+	contactID, ok := requireEditableContact(c, env)
+	if !ok {
+		return
+	}
+
+	var json CompleteAvatarUploadBody
+	if err := c.ShouldBindJSON(&json); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+	if !strings.HasPrefix(json.ObjectKey, avatarKeyPrefix(contactID)) {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Object key does not belong to this contact"))
+		return
+	}
+
+	//nolint:exhaustruct // no preconditions
+	info, err := env.Bucket.Head(c.Request.Context(), json.ObjectKey, bucket.GetOptions{})
+	if err != nil {
+		if errors.Is(err, bucket.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Avatar has not been uploaded"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Could not verify avatar"))
+		return
+	}
+	if info.Size == 0 || info.Size > maxAvatarSize || !imaging.IsSupportedContentType(info.ContentType) {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Uploaded avatar does not match the upload constraints"))
+		return
+	}
+
+	//nolint:exhaustruct // the whole object is processed
+	object, err := env.Bucket.GetStream(c.Request.Context(), info.Key, bucket.GetOptions{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Could not read uploaded avatar"))
+		return
+	}
+	defer object.Body.Close()
+
+	processed, err := imaging.ProcessAvatar(newChecksumReader(object.Body, maxAvatarSize))
+	if err != nil {
+		writeAvatarProcessingError(c, env, err)
+		return
+	}
+
+	// The sanitized image replaces the client's upload under the same key.
+	avatar, err := storeAvatar(c, env, contactID, info.Key, processed)
+	if err != nil {
+		logError(c, "Failed to store avatar", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Could not record avatar"))
+		return
+	}
+	c.JSON(http.StatusOK, toAvatarResponse(avatar))
+}
+
+// GetAvatarURL godoc
+//
+//	@Summary		Get avatar download URL
+//	@Description	Returns a short-lived presigned GET URL for a contact's avatar
+//	@Tags			contacts
+//	@Produce		json
+//	@Param			id		path		int	true	"Contact ID"
+//	@Param			size	query		int	false	"Thumbnail size (64, 256 or 512)"
+//	@Success		200		{object}	PresignedURLResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Failure		501		{object}	ErrorResponse
+//	@Router			/contacts/{id}/avatar/url [get]
+func GetAvatarURL(c *gin.Context, env *config.Env) {
+	avatar, ok := requireAvatar(c, env)
+	if !ok {
+		return
+	}
+	key, ok := avatarKeyForSize(c, avatar)
+	if !ok {
+		return
+	}
+
+	req, err := env.Bucket.PresignGet(c.Request.Context(), key, avatarDownloadURLTTL)
+	if errors.Is(err, bucket.ErrPresignNotSupported) {
+		c.JSON(http.StatusNotImplemented, NewErrorResponse(c, "Direct downloads are not supported by the storage backend"))
+		return
+	}
+	if err != nil {
+		logError(c, "Failed to presign avatar download", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Could not create download URL"))
+		return
+	}
+	c.JSON(http.StatusOK, toPresignedURLResponse(req, ""))
+}
+
+// requireEditableContact parses the contact ID from the path and checks the
+// caller may change the contact. It writes the error response itself and
+// returns false when the request cannot proceed.
+func requireEditableContact(c *gin.Context, env *config.Env) (int32, bool) {
+	contactID, err := getIntFromPath(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid contact ID"))
+		return 0, false
+	}
+	permission, err := env.GetContactAccess(c, db.GetContactAccessParams{
+		ID:       contactID,
+		ViewerID: access.Viewer(c.Request.Context()),
+	})
+	switch {
+	case errors.Is(err, pgx.ErrNoRows) || (err == nil && !permission.Valid):
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Contact not found"))
+		return 0, false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, err.Error()))
+		return 0, false
+	case !access.CanEdit(permission):
+		c.JSON(http.StatusForbidden, NewErrorResponse(c, "Contact is shared read-only"))
+		return 0, false
+	}
+	return contactID, true
+}
+
+func requireAvatar(c *gin.Context, env *config.Env) (db.ContactAvatar, bool) {
+	contactID, err := getIntFromPath(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid contact ID"))
+		return db.ContactAvatar{}, false
+	}
+	avatar, err := env.GetContactAvatar(c, db.GetContactAvatarParams{
+		ContactID: contactID,
+		ViewerID:  access.Viewer(c.Request.Context()),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, NewErrorResponse(c, "Avatar not found"))
+			return db.ContactAvatar{}, false
+		}
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, err.Error()))
+		return db.ContactAvatar{}, false
+	}
+	return avatar, true
+}
+
+func avatarKeyPrefix(contactID int32) string {
+	return fmt.Sprintf("avatars/%d/", contactID)
+}
+
+// avatarObjectKey returns a fresh key for every upload so that cached
+// responses for a previous avatar are never served for a new one.
+func avatarObjectKey(contactID int32) string {
+	return avatarKeyPrefix(contactID) + strings.ToLower(rand.Text())
+}
+
+// avatarVariantKey returns the key of the thumbnail stored next to the original.
+func avatarVariantKey(key string, size int) string {
+	return fmt.Sprintf("%s_%d", key, size)
+}
+
+// avatarKeyForSize resolves the optional ?size= query parameter to an object key.
+func avatarKeyForSize(c *gin.Context, avatar db.ContactAvatar) (string, bool) {
+	sizeParam := c.Query("size")
+	if sizeParam == "" {
+		return avatar.ObjectKey, true
+	}
+	size, err := strconv.Atoi(sizeParam)
+	if err != nil || !imaging.IsVariantSize(size) {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, fmt.Sprintf("Avatar size must be one of %v", imaging.VariantSizes())))
+		return "", false
+	}
+	return avatarVariantKey(avatar.ObjectKey, size), true
+}
+
+// storeAvatar uploads the processed original under key, its thumbnails next to
+// it, and records the original for the contact. The objects of the avatar it
+// replaces are deleted afterwards.
+func storeAvatar(
+	ctx context.Context, env *config.Env, contactID int32, key string, avatar *imaging.Avatar,
+) (db.ContactAvatar, error) {
+	previous, err := env.GetContactAvatar(ctx, db.GetContactAvatarParams{
+		ContactID: contactID,
+		ViewerID:  access.Viewer(ctx),
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return db.ContactAvatar{}, err
+	}
+
+	for size, variant := range avatar.Variants {
+		err := env.Bucket.Upload(ctx, avatarVariantKey(key, size), bytes.NewReader(variant.Data),
+			int64(len(variant.Data)), variant.ContentType)
+		if err != nil {
+			return db.ContactAvatar{}, err
+		}
+	}
+
+	size := int64(len(avatar.Original.Data))
+	original := newChecksumReader(bytes.NewReader(avatar.Original.Data), size)
+	if err := env.Bucket.Upload(ctx, key, original, size, avatar.Original.ContentType); err != nil {
+		return db.ContactAvatar{}, err
+	}
+
+	//nolint:exhaustruct // etag is not returned by PutObject through Upload
+	stored, err := env.UpsertContactAvatar(ctx, db.UpsertContactAvatarParams{
+		ContactID:      contactID,
+		ObjectKey:      key,
+		ContentType:    avatar.Original.ContentType,
+		SizeBytes:      size,
+		ChecksumSha256: pgtype.Text{String: original.Checksum(), Valid: true},
+	})
+	if err != nil {
+		return db.ContactAvatar{}, err
+	}
+	if previous.ObjectKey != "" && previous.ObjectKey != key {
+		deleteAvatarObjects(ctx, env, previous.ObjectKey)
+	}
+	return stored, nil
+}
+
+// deleteAvatarObjects removes an original and its thumbnails. The avatar is
+// already replaced in the database, so failures only leave objects behind and
+// are logged rather than returned.
+func deleteAvatarObjects(ctx context.Context, env *config.Env, key string) {
+	keys := []string{key}
+	for _, size := range imaging.VariantSizes() {
+		keys = append(keys, avatarVariantKey(key, size))
+	}
+	for _, object := range keys {
+		if err := env.Bucket.Delete(ctx, object); err != nil {
+			logging.FromContext(ctx).WarnContext(ctx, "Failed to delete replaced avatar", "key", object, "error", err)
+		}
+	}
+}
+
+func writeAvatarProcessingError(c *gin.Context, env *config.Env, err error) {
+	switch {
+	case errors.Is(err, errAvatarTooLarge):
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, fmt.Sprintf("Avatar size cannot exceed %dMB", MaxMBSize)))
+	case errors.Is(err, imaging.ErrUnsupportedFormat):
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Avatar must be a JPEG, PNG, WebP or GIF image"))
+	case errors.Is(err, imaging.ErrImageTooLarge):
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c,
+			fmt.Sprintf("Avatar dimensions cannot exceed %dx%d", imaging.MaxDimension, imaging.MaxDimension)))
+	case errors.Is(err, imaging.ErrInvalidImage):
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Avatar image is malformed"))
+	default:
+		logError(c, "Failed to process avatar", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to process avatar file"))
+	}
+}
+
+// checksumReader hashes and counts the bytes read through it, failing with
+// errAvatarTooLarge as soon as more than limit bytes have been read.
+type checksumReader struct {
+	reader io.Reader
+	hash   hash.Hash
+	size   int64
+	limit  int64
+}
+
+func newChecksumReader(reader io.Reader, limit int64) *checksumReader {
+	return &checksumReader{reader: reader, hash: sha256.New(), size: 0, limit: limit}
+}
+
+func (r *checksumReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.size += int64(n)
+	if r.size > r.limit {
+		return n, errAvatarTooLarge
+	}
+	r.hash.Write(p[:n])
+	return n, err
+}
+
+func (r *checksumReader) Size() int64 {
+	return r.size
+}
+
+// Checksum returns the hex encoded SHA-256 of everything read so far.
+func (r *checksumReader) Checksum() string {
+	return hex.EncodeToString(r.hash.Sum(nil))
+}
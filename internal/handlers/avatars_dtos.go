@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"time"
+
+	"contactsAI/contacts/internal/bucket"
+	"contactsAI/contacts/internal/db"
+)
+
+type AvatarUploadURLBody struct {
+	ContentType string `json:"content_type" binding:"required"`
+	Size        int64  `json:"size"         binding:"required,gt=0"`
+}
+
+type CompleteAvatarUploadBody struct {
+	ObjectKey string `json:"object_key" binding:"required"`
+}
+
+type PresignedURLResponse struct {
+	URL       string            `json:"url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	ObjectKey string            `json:"object_key,omitempty"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+type AvatarResponse struct {
+	ContactID   int32      `json:"contact_id"`
+	ContentType string     `json:"content_type"`
+	Size        int64      `json:"size"`
+	Checksum    string     `json:"checksum_sha256,omitempty"`
+	UploadedAt  *time.Time `json:"uploaded_at,omitempty"`
+}
+
+func toPresignedURLResponse(req *bucket.PresignedRequest, objectKey string) PresignedURLResponse {
+	headers := map[string]string{}
+	for name := range req.Headers {
+		// Host is set by the HTTP client from the URL itself.
+		if name == "Host" {
+			continue
+		}
+		headers[name] = req.Headers.Get(name)
+	}
+	if len(headers) == 0 {
+		headers = nil
+	}
+	return PresignedURLResponse{
+		URL:       req.URL,
+		Method:    req.Method,
+		Headers:   headers,
+		ObjectKey: objectKey,
+		ExpiresAt: req.ExpiresAt,
+	}
+}
+
+func toAvatarResponse(avatar db.ContactAvatar) AvatarResponse {
+	var uploadedAt *time.Time
+	if avatar.UploadedAt.Valid {
+		uploadedAt = &avatar.UploadedAt.Time
+	}
+	return AvatarResponse{
+		ContactID:   avatar.ContactID,
+		ContentType: avatar.ContentType,
+		Size:        avatar.SizeBytes,
+		Checksum:    avatar.ChecksumSha256.String,
+		UploadedAt:  uploadedAt,
+	}
+}
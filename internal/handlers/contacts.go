@@ -1,17 +1,20 @@
 package handlers
 
 import (
+	"context"
 	"errors"
-	"fmt"
-	"io"
 	"net/http"
+	"slices"
 	"strconv"
 
+	"contactsAI/contacts/internal/access"
 	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/customfields"
 	"contactsAI/contacts/internal/db"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func RegisterContactsRoutes(router *gin.RouterGroup, env *config.Env) {
@@ -22,18 +25,28 @@ func RegisterContactsRoutes(router *gin.RouterGroup, env *config.Env) {
 	apiGroup.PUT("/:id", func(c *gin.Context) { UpdateContact(c, env) })
 	apiGroup.PUT("/:id/avatar", func(c *gin.Context) { UploadContactAvatar(c, env) })
 	apiGroup.GET("/:id/avatar", func(c *gin.Context) { DownloadContactAvatar(c, env) })
+	apiGroup.HEAD("/:id/avatar", func(c *gin.Context) { DownloadContactAvatar(c, env) })
+	apiGroup.POST("/:id/avatar/upload-url", func(c *gin.Context) { CreateAvatarUploadURL(c, env) })
+	apiGroup.POST("/:id/avatar/complete", func(c *gin.Context) { CompleteAvatarUpload(c, env) })
+	apiGroup.GET("/:id/avatar/url", func(c *gin.Context) { GetAvatarURL(c, env) })
 	apiGroup.DELETE("/:id", func(c *gin.Context) { DeleteContact(c, env) })
 }
 
 type CreateContactBody struct {
-	Name  string `json:"name"  binding:"required"`
-	Phone string `json:"phone" binding:"required,phonenumber"`
+	// Name is the display name. Left out, it is derived from the name parts,
+	// the nickname or the company.
+	Name  string `json:"name,omitempty" binding:"max=100"`
+	Phone string `json:"phone"          binding:"required,phonenumber"`
+	ContactProfile
+	// CustomFields are validated against the custom fields of the caller.
+	CustomFields map[string]any `json:"custom_fields,omitempty"`
 }
 
 // CreateContact godoc
 //
 //	@Summary		Create new contact
-//	@Description	Create a new contact in the system
+//	@Description	Create a new contact in the system. Without a name, it is derived from the name parts,
+//	@Description	the nickname or the company.
 //	@Tags			contacts
 //	@Accept			json
 //	@Produce		json
@@ -45,17 +58,37 @@ type CreateContactBody struct {
 func CreateContact(c *gin.Context, env *config.Env) {
 	var json CreateContactBody
 	if err := c.ShouldBindJSON(&json); err != nil {
-		c.JSON(http.StatusBadRequest, NewErrorResponse(err.Error()))
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
 		return
 	}
 
-	contact := db.CreateContactParams{
-		Name:  json.Name,
-		Phone: json.Phone,
+	ctx := c.Request.Context()
+	owner := access.User(ctx)
+	contact, err := NewCreateContactParams(json.Name, json.Phone, owner, json.ContactProfile, nil)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+	// The custom fields are checked against the definitions in the transaction
+	// that stores them.
+	var createdContact db.Contact
+	err = env.Tenant.WithTx(ctx, func(ctx context.Context) error {
+		customFields, checkErr := CheckCustomFields(ctx, env.Queries, owner, json.CustomFields)
+		if checkErr != nil {
+			return checkErr
+		}
+		contact.CustomFields = customFields
+		var createErr error
+		createdContact, createErr = env.CreateContact(ctx, contact)
+		return createErr
+	})
+	if errors.Is(err, customfields.ErrInvalid) {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
 	}
-	createdContact, err := env.CreateContact(c, contact)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, NewErrorResponse("Failed to create contact"))
+		logError(c, "Failed to create contact", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to create contact"))
 		return
 	}
 	dto := toContactResponse(createdContact)
@@ -65,26 +98,74 @@ func CreateContact(c *gin.Context, env *config.Env) {
 // GetContacts godoc
 //
 //	@Summary		Get all contacts
-//	@Description	Retrieve all contacts from the database
+//	@Description	Retrieve all contacts visible to the caller, optionally only those with the caller's tags
+//	@Description	or custom field values. Custom fields are filtered and sorted by the caller's definitions.
+//	@Description	Sorted by last_contacted_at, contacts never contacted come first, so neglected contacts lead.
+//	@Description	With favorites_first, the caller's pinned contacts and then their other favorites come first.
 //	@Tags			contacts
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{array}		ContactResponse
-//	@Failure		500	{object}	ErrorResponse
+//	@Param			tag				query		[]string	false	"Tag names"	collectionFormat(multi)
+//	@Param			match			query		string		false	"Whether contacts need any or all of the tags"	Enums(any, all)
+//	@Param			field			query		object		false	"Custom field values, e.g. field[language]=pl"
+//	@Param			sort			query		string		false	"name, last_contacted_at, interaction_count, or field.<name> for a custom field"
+//	@Param			order			query		string		false	"Sort order"	Enums(asc, desc)
+//	@Param			favorites_first	query		bool		false	"Pinned contacts, then other favorites first"
+//	@Success		200				{array}		ContactResponse
+//	@Failure		400				{object}	ErrorResponse
+//	@Failure		500				{object}	ErrorResponse
 //	@Router			/contacts [get]
 func GetContacts(c *gin.Context, env *config.Env) {
-	contacts, err := env.Queries.GetContacts(c)
+	ctx := c.Request.Context()
+	params := db.GetContactsParams{
+		ViewerID:        access.Viewer(ctx),
+		Tags:            nil,
+		TagOwnerID:      access.User(ctx),
+		MatchAll:        false,
+		CustomFields:    nil,
+		FavoritesFirst:  false,
+		FavoriteOwnerID: access.User(ctx),
+		SortDesc:        false,
+		SortBy:          pgtype.Text{},
+		SortField:       pgtype.Text{},
+	}
+	if tags := c.QueryArray("tag"); len(tags) > 0 {
+		slices.Sort(tags)
+		params.Tags = slices.Compact(tags)
+	}
+	switch c.DefaultQuery("match", "any") {
+	case "any":
+	case "all":
+		params.MatchAll = true
+	default:
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "match must be any or all"))
+		return
+	}
+	favoritesFirst, err := strconv.ParseBool(c.DefaultQuery("favorites_first", "false"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, NewErrorResponse(err.Error()))
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "favorites_first must be true or false"))
 		return
 	}
-	if contacts == nil {
-		contacts = []db.Contact{}
+	params.FavoritesFirst = favoritesFirst
+	if err = bindContactSort(c, &params); err != nil {
+		writeCustomFieldsError(c, err)
+		return
+	}
+	if err = bindCustomFieldQuery(c, env, &params); err != nil {
+		writeCustomFieldsError(c, err)
+		return
 	}
 
-	dtos := make([]ContactResponse, len(contacts))
-	for i, v := range contacts {
-		dtos[i] = toContactResponse(v)
+	contacts, err := env.Queries.GetContacts(c, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, err.Error()))
+		return
+	}
+	dtos, err := toContactResponses(c, env, contacts)
+	if err != nil {
+		logError(c, "Failed to load contact details", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to load contact details"))
+		return
 	}
 	c.JSON(http.StatusOK, dtos)
 }
@@ -92,7 +173,7 @@ func GetContacts(c *gin.Context, env *config.Env) {
 // GetContactByID godoc
 //
 //	@Summary		Get contact by ID
-//	@Description	Retrieve a single contact by its ID
+//	@Description	Retrieve a single contact by its ID. The contact is recorded as recently viewed by the caller.
 //	@Tags			contacts
 //	@Accept			json
 //	@Produce		json
@@ -105,34 +186,58 @@ func GetContacts(c *gin.Context, env *config.Env) {
 func GetContactByID(c *gin.Context, env *config.Env) {
 	contactID, err := getIntFromPath(c, "id")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, NewErrorResponse("Invalid contact ID"))
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid contact ID"))
 		return
 	}
 
-	contact, err := env.GetContactByID(c, contactID)
+	contact, err := env.GetContactByID(c, db.GetContactByIDParams{
+		ID:       contactID,
+		ViewerID: access.Viewer(c.Request.Context()),
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, NewErrorResponse("Contact not found"))
+			c.JSON(http.StatusNotFound, NewErrorResponse(c, "Contact not found"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, NewErrorResponse(err.Error()))
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, err.Error()))
 		return
 	}
+	// Views are recorded for users only, and the contact is served even if
+	// the view is not recorded.
+	if user := access.User(c.Request.Context()); user.Valid {
+		if err = env.RecordContactView(c, db.RecordContactViewParams{
+			ContactID: contact.ID,
+			OwnerID:   user,
+		}); err != nil {
+			logError(c, "Failed to record contact view", err)
+		}
+	}
 
-	dto := toContactResponse(contact)
-
-	c.JSON(http.StatusOK, dto)
+	dtos, err := toContactResponses(c, env, []db.Contact{contact})
+	if err != nil {
+		logError(c, "Failed to load contact details", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to load contact details"))
+		return
+	}
+	c.JSON(http.StatusOK, dtos[0])
 }
 
+// UpdateContactBody replaces the name, phone and profile of a contact.
 type UpdateContactBody struct {
-	Name  string `json:"name"  binding:"required"`
-	Phone string `json:"phone" binding:"required,phonenumber"`
+	// Name is the display name. Left out, it is derived from the name parts,
+	// the nickname or the company.
+	Name  string `json:"name,omitempty" binding:"max=100"`
+	Phone string `json:"phone"          binding:"required,phonenumber"`
+	ContactProfile
+	// CustomFields replace the custom field values of the contact, validated
+	// against the custom fields of its owner. Left out, they are kept.
+	CustomFields map[string]any `json:"custom_fields,omitempty"`
 }
 
 // UpdateContact godoc
 //
 //	@Summary		Update contact
-//	@Description	Update an existing contact by ID
+//	@Description	Replace the details of an existing contact by ID. Custom fields are kept when left out.
 //	@Tags			contacts
 //	@Accept			json
 //	@Produce		json
@@ -140,134 +245,69 @@ type UpdateContactBody struct {
 //	@Param			contact	body		UpdateContactBody	true	"Updated contact details"
 //	@Success		200		{object}	ContactResponse
 //	@Failure		400		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
 //	@Failure		500		{object}	ErrorResponse
 //	@Router			/contacts/{id} [put]
 func UpdateContact(c *gin.Context, env *config.Env) {
 	contactID, parseErr := getIntFromPath(c, "id")
 	if parseErr != nil {
-		c.JSON(http.StatusBadRequest, NewErrorResponse("Invalid contact ID"))
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid contact ID"))
 		return
 	}
 
 	var json UpdateContactBody
 	if bindErr := c.BindJSON(&json); bindErr != nil {
-		c.JSON(http.StatusBadRequest, NewErrorResponse(bindErr.Error()))
-		return
-	}
-	contactParams := db.UpdateContactParams{
-		ID:    contactID,
-		Name:  json.Name,
-		Phone: json.Phone,
-	}
-
-	contact, updateErr := env.UpdateContact(c, contactParams)
-	if updateErr != nil {
-		c.JSON(http.StatusInternalServerError, NewErrorResponse("Updating contact failed."))
-		return
-	}
-	dto := toContactResponse(contact)
-	c.JSON(http.StatusOK, dto)
-}
-
-const (
-	BytesPerKB = 1024
-	KBPerMB    = 1024
-	MaxMBSize  = 10
-)
-
-const maxAvatarSize = int64(MaxMBSize * KBPerMB * BytesPerKB) // 10 MiB
-
-// UploadContactAvatar godoc
-//
-//	@Summary		Upload contact avatar
-//	@Description	Upload an avatar image for a contact by ID
-//	@Tags			contacts
-//	@Accept			multipart/form-data
-//	@Produce		json
-//	@Param			id		path		int		true	"Contact ID"
-//	@Param			avatar	formData	file	true	"Avatar file"
-//	@Success		200		{object}	map[string]string
-//	@Failure		400		{object}	ErrorResponse
-//	@Failure		500		{object}	ErrorResponse
-//	@Router			/contacts/{id}/avatar [put]
-func UploadContactAvatar(c *gin.Context, env *config.Env) {
-	avatar, err := c.FormFile("avatar")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, NewErrorResponse("Invalid avatar file provided"))
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, bindErr.Error()))
 		return
 	}
-	if avatar.Size == 0 {
-		c.JSON(http.StatusBadRequest, NewErrorResponse("Avatar file is empty"))
-		return
-	}
-	if avatar.Size > maxAvatarSize {
-		c.JSON(http.StatusBadRequest, NewErrorResponse(fmt.Sprintf("Avatar size cannot exceed %dMB", MaxMBSize)))
+	ctx := c.Request.Context()
+	viewer := access.Viewer(ctx)
+	contactParams, paramsErr := NewUpdateContactParams(
+		contactID, json.Name, json.Phone, json.ContactProfile, nil, viewer)
+	if paramsErr != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, paramsErr.Error()))
 		return
 	}
 
-	f, err := avatar.Open()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, NewErrorResponse("Failed to process avatar file"))
+	// Reading the owner, checking the custom fields against their definitions
+	// and storing them happen in one transaction.
+	var contact db.Contact
+	updateErr := env.Tenant.WithTx(ctx, func(ctx context.Context) error {
+		if json.CustomFields != nil {
+			current, err := env.GetContactByID(ctx, db.GetContactByIDParams{ID: contactID, ViewerID: viewer})
+			if err != nil {
+				return err
+			}
+			customFields, err := CheckCustomFields(ctx, env.Queries, current.OwnerID, json.CustomFields)
+			if err != nil {
+				return err
+			}
+			contactParams.CustomFields = customFields
+		}
+		var err error
+		contact, err = env.UpdateContact(ctx, contactParams)
+		return err
+	})
+	switch {
+	case errors.Is(updateErr, pgx.ErrNoRows):
+		writeNotEditable(c, env, contactID)
 		return
-	}
-	defer f.Close()
-
-	data, err := io.ReadAll(f)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, NewErrorResponse("Failed to read avatar file"))
+	case errors.Is(updateErr, customfields.ErrInvalid):
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, updateErr.Error()))
 		return
-	}
-
-	key := "test" // TODO: make unique per contact
-	contentType := avatar.Header.Get("Content-Type")
-	if contentType == "" {
-		contentType = "application/octet-stream"
-	}
-
-	if err = env.Bucket.Upload(c.Request.Context(), key, data, contentType); err != nil {
-		c.JSON(http.StatusInternalServerError, NewErrorResponse("Could not upload avatar"))
+	case updateErr != nil:
+		logError(c, "Failed to update contact", updateErr)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Updating contact failed."))
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Avatar uploaded"})
-}
-
-// DownloadContactAvatar godoc
-//
-//	@Summary		Download contact's avatar
-//	@Description	Streams a contact's avatar by contact ID
-//	@Tags			contacts
-//	@Produce		octet-stream
-//	@Param			id	path		int		true	"Contact ID"
-//	@Success		200	{file}		file	"The avatar file stream"
-//	@Failure		404	{object}	ErrorResponse
-//	@Failure		500	{object}	ErrorResponse
-//	@Router			/contacts/{id}/avatar [get]
-func DownloadContactAvatar(c *gin.Context, env *config.Env) {
-	objectKey := c.Param("id")
-
-	s3Object, err := env.Bucket.GetStream(c.Request.Context(), objectKey)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Avatar not found."})
+	dtos, tagsErr := toContactResponses(c, env, []db.Contact{contact})
+	if tagsErr != nil {
+		logError(c, "Failed to load contact details", tagsErr)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to load contact details"))
 		return
 	}
-	defer s3Object.Body.Close()
-
-	if s3Object.ContentType != nil {
-		c.Header("Content-Type", *s3Object.ContentType)
-	} else {
-		c.Header("Content-Type", "application/octet-stream")
-	}
-
-	if s3Object.ContentLength != nil {
-		c.Header("Content-Length", strconv.FormatInt(*s3Object.ContentLength, 10))
-	}
-
-	c.Header("Content-Disposition", "inline")
-
-	_, err = io.Copy(c.Writer, s3Object.Body)
-	if err != nil {
-		env.Logger.Error("Error streaming file to client", "error", err)
-	}
+	c.JSON(http.StatusOK, dtos[0])
 }
 
 // DeleteContact godoc
@@ -280,23 +320,44 @@ func DownloadContactAvatar(c *gin.Context, env *config.Env) {
 //	@Param			id	path		int		true	"Contact ID"
 //	@Success		204	{string}	string	"No Content"
 //	@Failure		400	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
 //	@Failure		404	{object}	ErrorResponse
 //	@Failure		500	{object}	ErrorResponse
 //	@Router			/contacts/{id} [delete]
 func DeleteContact(c *gin.Context, env *config.Env) {
 	id, err := getIntFromPath(c, "id")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, NewErrorResponse("Invalid contact ID"))
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid contact ID"))
 		return
 	}
 
-	if err = env.DeleteContact(c, id); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, NewErrorResponse("Contact not found"))
-			return
-		}
-		c.JSON(http.StatusInternalServerError, NewErrorResponse(err.Error()))
+	deleted, err := env.DeleteContact(c, db.DeleteContactParams{ID: id, ViewerID: access.Viewer(c.Request.Context())})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, err.Error()))
+		return
+	}
+	if deleted == 0 {
+		writeNotEditable(c, env, id)
 		return
 	}
 	c.JSON(http.StatusNoContent, nil)
 }
+
+// writeNotEditable explains why a change of a contact matched no row: it
+// does not exist or is not visible to the caller (404), or is only shared
+// for reading (403).
+func writeNotEditable(c *gin.Context, env *config.Env, contactID int32) {
+	permission, err := env.GetContactAccess(c, db.GetContactAccessParams{
+		ID:       contactID,
+		ViewerID: access.Viewer(c.Request.Context()),
+	})
+	switch {
+	case errors.Is(err, pgx.ErrNoRows) || (err == nil && !permission.Valid):
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Contact not found"))
+	case err != nil:
+		logError(c, "Failed to check contact access", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to check contact access"))
+	default:
+		c.JSON(http.StatusForbidden, NewErrorResponse(c, "Contact is shared read-only"))
+	}
+}
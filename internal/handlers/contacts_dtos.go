@@ -1,23 +1,233 @@
 package handlers
 
-import "contactsAI/contacts/internal/db"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"contactsAI/contacts/internal/db"
+	"contactsAI/contacts/internal/vcard"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var errNoName = errors.New("name is required without given_name, family_name, nickname or company")
+
+// ContactProfile holds the details of a contact besides its name and phone,
+// following vCard: the name parts are N, then NICKNAME, ORG, TITLE, BDAY,
+// ANNIVERSARY, NOTE and URL. Dates are written as YYYY-MM-DD, or as --MM-DD
+// when the year is unknown.
+type ContactProfile struct {
+	Prefix     string `json:"prefix,omitempty"      binding:"max=50"                             example:"dr"`
+	GivenName  string `json:"given_name,omitempty"  binding:"max=100"                            example:"Jan"`
+	MiddleName string `json:"middle_name,omitempty" binding:"max=100"`
+	FamilyName string `json:"family_name,omitempty" binding:"max=100"                            example:"Kowalski"`
+	Suffix     string `json:"suffix,omitempty"      binding:"max=50"`
+	Nickname   string `json:"nickname,omitempty"    binding:"max=100"`
+	Company    string `json:"company,omitempty"     binding:"max=200"                            example:"ACME"`
+	JobTitle   string `json:"job_title,omitempty"   binding:"max=200"                            example:"Sales manager"`
+	Birthday   string `json:"birthday,omitempty"    binding:"omitempty,vcarddate"               example:"1980-04-23"`
+	// Anniversaries are dates to remember besides the birthday.
+	Anniversaries []Anniversary `json:"anniversaries,omitempty" binding:"max=20,dive"`
+	Notes         string        `json:"notes,omitempty"         binding:"max=10000"`
+	Website       string        `json:"website,omitempty"       binding:"omitempty,max=500,http_url"`
+	// SocialProfiles maps lower case service names, e.g. linkedin, to profile
+	// URLs.
+	SocialProfiles map[string]string `json:"social_profiles,omitempty" binding:"max=20,socialprofiles"`
+}
+
+type Anniversary struct {
+	Label string `json:"label" binding:"required,max=50"    example:"wedding"`
+	Date  string `json:"date"  binding:"required,vcarddate" example:"2010-06-12"`
+}
 
 type ContactResponse struct {
 	ID      int32  `json:"id"`
 	Name    string `json:"name"`
 	Phone   string `json:"phone"`
 	OwnerID *int32 `json:"owner_id,omitempty"`
+	ContactProfile
+	// Tags are the caller's own tags on the contact.
+	Tags []TagResponse `json:"tags"`
+	// CustomFields holds the values of the owner's custom fields.
+	CustomFields map[string]any `json:"custom_fields"`
+	// LastContactedAt is when the latest interaction with the contact
+	// occurred, left out when there is none.
+	LastContactedAt  *time.Time `json:"last_contacted_at,omitempty"`
+	InteractionCount int64      `json:"interaction_count"`
+	// Favorite and Pinned tell whether the contact is one of the caller's
+	// favorites, and pinned above the others.
+	Favorite bool `json:"favorite"`
+	Pinned   bool `json:"pinned"`
 }
 
 func toContactResponse(contact db.Contact) ContactResponse {
+	customFields := map[string]any{}
+	if len(contact.CustomFields) > 0 {
+		// The column only holds JSON objects.
+		_ = json.Unmarshal(contact.CustomFields, &customFields)
+	}
 	var ownerID *int32
 	if contact.OwnerID.Valid {
 		ownerID = &contact.OwnerID.Int32
 	}
 	return ContactResponse{
-		ID:      contact.ID,
-		Name:    contact.Name,
-		Phone:   contact.Phone,
-		OwnerID: ownerID,
+		ID:               contact.ID,
+		Name:             contact.Name,
+		Phone:            contact.Phone,
+		OwnerID:          ownerID,
+		ContactProfile:   ProfileOf(contact),
+		Tags:             []TagResponse{},
+		CustomFields:     customFields,
+		LastContactedAt:  nil,
+		InteractionCount: 0,
+		Favorite:         false,
+		Pinned:           false,
+	}
+}
+
+// ProfileOf returns the profile stored for contact.
+func ProfileOf(contact db.Contact) ContactProfile {
+	profile := ContactProfile{
+		Prefix:         contact.Prefix,
+		GivenName:      contact.GivenName,
+		MiddleName:     contact.MiddleName,
+		FamilyName:     contact.FamilyName,
+		Suffix:         contact.Suffix,
+		Nickname:       contact.Nickname,
+		Company:        contact.Company,
+		JobTitle:       contact.JobTitle,
+		Birthday:       "",
+		Anniversaries:  nil,
+		Notes:          contact.Notes,
+		Website:        contact.Website,
+		SocialProfiles: nil,
+	}
+	if contact.Birthday.Valid {
+		profile.Birthday = vcard.FormatDate(contact.Birthday.Time)
+	}
+	// The columns only hold what profileColumns encodes.
+	_ = json.Unmarshal(contact.Anniversaries, &profile.Anniversaries)
+	_ = json.Unmarshal(contact.SocialProfiles, &profile.SocialProfiles)
+	return profile
+}
+
+// profileColumns holds the columns of a ContactProfile that are not stored
+// as they are.
+type profileColumns struct {
+	birthday       pgtype.Date
+	anniversaries  []byte
+	socialProfiles []byte
+}
+
+func (p ContactProfile) columns() (profileColumns, error) {
+	var columns profileColumns
+	if p.Birthday != "" {
+		birthday, err := vcard.ParseDate(p.Birthday)
+		if err != nil {
+			return columns, fmt.Errorf("birthday: %w", err)
+		}
+		columns.birthday = pgtype.Date{Time: birthday, InfinityModifier: pgtype.Finite, Valid: true}
+	}
+	anniversaries := p.Anniversaries
+	if anniversaries == nil {
+		anniversaries = []Anniversary{}
+	}
+	socialProfiles := p.SocialProfiles
+	if socialProfiles == nil {
+		socialProfiles = map[string]string{}
+	}
+	var err error
+	if columns.anniversaries, err = json.Marshal(anniversaries); err != nil {
+		return columns, fmt.Errorf("encoding anniversaries: %w", err)
+	}
+	if columns.socialProfiles, err = json.Marshal(socialProfiles); err != nil {
+		return columns, fmt.Errorf("encoding social profiles: %w", err)
+	}
+	return columns, nil
+}
+
+// displayName returns name, or derives it from the profile when empty.
+func (p ContactProfile) displayName(name string) (string, error) {
+	if name != "" {
+		return name, nil
+	}
+	name = vcard.FormattedName(vcard.Name{
+		Prefix: p.Prefix,
+		Given:  p.GivenName,
+		Middle: p.MiddleName,
+		Family: p.FamilyName,
+		Suffix: p.Suffix,
+	}, p.Nickname, p.Company)
+	if name == "" {
+		return "", errNoName
+	}
+	return name, nil
+}
+
+// NewCreateContactParams builds the parameters to create a contact, deriving
+// its name from the profile when empty. It fails with errNoName when there is
+// nothing to derive the name from.
+func NewCreateContactParams(
+	name, phone string, owner pgtype.Int4, profile ContactProfile, customFields []byte,
+) (db.CreateContactParams, error) {
+	name, err := profile.displayName(name)
+	if err != nil {
+		return db.CreateContactParams{}, err
+	}
+	columns, err := profile.columns()
+	if err != nil {
+		return db.CreateContactParams{}, err
+	}
+	return db.CreateContactParams{
+		Name:           name,
+		Phone:          phone,
+		OwnerID:        owner,
+		Prefix:         profile.Prefix,
+		GivenName:      profile.GivenName,
+		MiddleName:     profile.MiddleName,
+		FamilyName:     profile.FamilyName,
+		Suffix:         profile.Suffix,
+		Nickname:       profile.Nickname,
+		Company:        profile.Company,
+		JobTitle:       profile.JobTitle,
+		Birthday:       columns.birthday,
+		Anniversaries:  columns.anniversaries,
+		Notes:          profile.Notes,
+		Website:        profile.Website,
+		SocialProfiles: columns.socialProfiles,
+		CustomFields:   customFields,
+	}, nil
+}
+
+// NewUpdateContactParams builds the parameters to replace a contact like
+// NewCreateContactParams. Nil customFields keep the current values.
+func NewUpdateContactParams(
+	id int32, name, phone string, profile ContactProfile, customFields []byte, viewer pgtype.Int4,
+) (db.UpdateContactParams, error) {
+	create, err := NewCreateContactParams(name, phone, pgtype.Int4{}, profile, customFields)
+	if err != nil {
+		return db.UpdateContactParams{}, err
 	}
+	return db.UpdateContactParams{
+		ID:             id,
+		Name:           create.Name,
+		Phone:          create.Phone,
+		Prefix:         create.Prefix,
+		GivenName:      create.GivenName,
+		MiddleName:     create.MiddleName,
+		FamilyName:     create.FamilyName,
+		Suffix:         create.Suffix,
+		Nickname:       create.Nickname,
+		Company:        create.Company,
+		JobTitle:       create.JobTitle,
+		Birthday:       create.Birthday,
+		Anniversaries:  create.Anniversaries,
+		Notes:          create.Notes,
+		Website:        create.Website,
+		SocialProfiles: create.SocialProfiles,
+		CustomFields:   create.CustomFields,
+		ViewerID:       viewer,
+	}, nil
 }
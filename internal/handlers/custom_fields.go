@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"contactsAI/contacts/internal/access"
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/customfields"
+	"contactsAI/contacts/internal/db"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func RegisterCustomFieldsRoutes(router *gin.RouterGroup, env *config.Env) {
+	fields := router.Group("/custom-fields")
+	fields.GET("/", func(c *gin.Context) { GetCustomFields(c, env) })
+	fields.POST("/", func(c *gin.Context) { CreateCustomField(c, env) })
+	fields.PUT("/:id", func(c *gin.Context) { UpdateCustomField(c, env) })
+	fields.DELETE("/:id", func(c *gin.Context) { DeleteCustomField(c, env) })
+}
+
+// GetCustomFields godoc
+//
+//	@Summary		List custom fields
+//	@Description	List the custom fields the caller defined for their contacts
+//	@Tags			custom-fields
+//	@Produce		json
+//	@Success		200	{array}		CustomFieldResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/custom-fields [get]
+func GetCustomFields(c *gin.Context, env *config.Env) {
+	fields, err := env.ListCustomFields(c, access.User(c.Request.Context()))
+	if err != nil {
+		logError(c, "Failed to list custom fields", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to list custom fields"))
+		return
+	}
+	dtos := make([]CustomFieldResponse, len(fields))
+	for i, field := range fields {
+		dtos[i] = toCustomFieldResponse(field)
+	}
+	c.JSON(http.StatusOK, dtos)
+}
+
+// CreateCustomField godoc
+//
+//	@Summary		Create a custom field
+//	@Description	Define a custom field for the caller's contacts. Field names are unique per owner.
+//	@Tags			custom-fields
+//	@Accept			json
+//	@Produce		json
+//	@Param			field	body		CustomFieldBody	true	"Field definition"
+//	@Success		201		{object}	CustomFieldResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/custom-fields [post]
+func CreateCustomField(c *gin.Context, env *config.Env) {
+	var body CustomFieldBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+	err := customfields.ValidateDefinition(customfields.Definition{
+		Name:     body.Name,
+		Type:     body.Type,
+		Required: body.Required,
+		Pattern:  body.Pattern,
+		Options:  body.Options,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+
+	field, err := env.CreateCustomField(c, db.CreateCustomFieldParams{
+		Name:     body.Name,
+		Type:     string(body.Type),
+		Required: body.Required,
+		Pattern:  toPattern(body.Pattern),
+		Options:  append([]string{}, body.Options...),
+		OwnerID:  access.User(c.Request.Context()),
+	})
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, NewErrorResponse(c, "A custom field with this name already exists"))
+		return
+	}
+	if err != nil {
+		logError(c, "Failed to create custom field", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to create custom field"))
+		return
+	}
+	c.JSON(http.StatusCreated, toCustomFieldResponse(field))
+}
+
+// UpdateCustomField godoc
+//
+//	@Summary		Update a custom field
+//	@Description	Change whether a field is required, its pattern or its options. Stored values are not revalidated.
+//	@Tags			custom-fields
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int						true	"Custom field ID"
+//	@Param			field	body		UpdateCustomFieldBody	true	"Field definition"
+//	@Success		200		{object}	CustomFieldResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/custom-fields/{id} [put]
+func UpdateCustomField(c *gin.Context, env *config.Env) {
+	id, err := getIntFromPath(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid custom field ID"))
+		return
+	}
+	var body UpdateCustomFieldBody
+	if err = c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+
+	owner := access.User(c.Request.Context())
+	current, err := env.GetCustomField(c, db.GetCustomFieldParams{ID: id, OwnerID: owner})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Custom field not found"))
+		return
+	}
+	if err != nil {
+		logError(c, "Failed to get custom field", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to get custom field"))
+		return
+	}
+	err = customfields.ValidateDefinition(customfields.Definition{
+		Name:     current.Name,
+		Type:     customfields.Type(current.Type),
+		Required: body.Required,
+		Pattern:  body.Pattern,
+		Options:  body.Options,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+
+	field, err := env.UpdateCustomField(c, db.UpdateCustomFieldParams{
+		ID:       id,
+		Required: body.Required,
+		Pattern:  toPattern(body.Pattern),
+		Options:  append([]string{}, body.Options...),
+		OwnerID:  owner,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Custom field not found"))
+		return
+	}
+	if err != nil {
+		logError(c, "Failed to update custom field", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to update custom field"))
+		return
+	}
+	c.JSON(http.StatusOK, toCustomFieldResponse(field))
+}
+
+// DeleteCustomField godoc
+//
+//	@Summary		Delete a custom field
+//	@Description	Delete one of the caller's custom fields and its values on their contacts
+//	@Tags			custom-fields
+//	@Param			id	path		int		true	"Custom field ID"
+//	@Success		204	{string}	string	"No Content"
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/custom-fields/{id} [delete]
+func DeleteCustomField(c *gin.Context, env *config.Env) {
+	id, err := getIntFromPath(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid custom field ID"))
+		return
+	}
+	deleted, err := env.DeleteCustomField(c, db.DeleteCustomFieldParams{
+		ID:      id,
+		OwnerID: access.User(c.Request.Context()),
+	})
+	if err != nil {
+		logError(c, "Failed to delete custom field", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to delete custom field"))
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Custom field not found"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// CheckCustomFields validates the custom field values of a contact of owner
+// against the owner's definitions and encodes them for storage. Invalid
+// values fail with customfields.ErrInvalid.
+func CheckCustomFields(
+	ctx context.Context, queries db.Querier, owner pgtype.Int4, values map[string]any,
+) ([]byte, error) {
+	defs, err := customFieldDefinitions(ctx, queries, owner)
+	if err != nil {
+		return nil, err
+	}
+	valid, err := customfields.Validate(defs, values)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(valid)
+}
+
+func customFieldDefinitions(
+	ctx context.Context, queries db.Querier, owner pgtype.Int4,
+) ([]customfields.Definition, error) {
+	fields, err := queries.ListCustomFields(ctx, owner)
+	if err != nil {
+		return nil, fmt.Errorf("listing custom fields: %w", err)
+	}
+	defs := make([]customfields.Definition, len(fields))
+	for i, field := range fields {
+		if defs[i], err = toDefinition(field); err != nil {
+			return nil, err
+		}
+	}
+	return defs, nil
+}
+
+// bindContactSort adds the sort (sort=name, last_contacted_at,
+// interaction_count or field.<name>) and order (asc or desc) of the request
+// to params. A custom field to sort by is checked by bindCustomFieldQuery.
+func bindContactSort(c *gin.Context, params *db.GetContactsParams) error {
+	switch c.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		params.SortDesc = true
+	default:
+		return fmt.Errorf("%w: order must be asc or desc", customfields.ErrInvalid)
+	}
+	sort := c.DefaultQuery("sort", "name")
+	switch sort {
+	case "name":
+	case sortLastContactedAt, sortInteractionCount:
+		params.SortBy = pgtype.Text{String: sort, Valid: true}
+	default:
+		sortField, sortsByField := strings.CutPrefix(sort, "field.")
+		if !sortsByField {
+			return fmt.Errorf("%w: sort must be name, %s, %s or field.<name>",
+				customfields.ErrInvalid, sortLastContactedAt, sortInteractionCount)
+		}
+		params.SortField = pgtype.Text{String: sortField, Valid: true}
+	}
+	return nil
+}
+
+// bindCustomFieldQuery adds the custom field filters (field[name]=value) of
+// the request to params and checks the custom field they are sorted by.
+// Values are converted with the caller's definitions.
+func bindCustomFieldQuery(c *gin.Context, env *config.Env, params *db.GetContactsParams) error {
+	filters := c.QueryMap("field")
+	if len(filters) == 0 && !params.SortField.Valid {
+		return nil
+	}
+
+	defs, err := customFieldDefinitions(c, env.Queries, access.User(c.Request.Context()))
+	if err != nil {
+		return err
+	}
+	find := func(name string) (customfields.Definition, error) {
+		i := slices.IndexFunc(defs, func(def customfields.Definition) bool { return def.Name == name })
+		if i < 0 {
+			return customfields.Definition{}, fmt.Errorf("%w: %q is not defined", customfields.ErrInvalid, name)
+		}
+		return defs[i], nil
+	}
+
+	if params.SortField.Valid {
+		if _, err = find(params.SortField.String); err != nil {
+			return err
+		}
+	}
+	if len(filters) > 0 {
+		values := make(map[string]any, len(filters))
+		for name, raw := range filters {
+			def, findErr := find(name)
+			if findErr != nil {
+				return findErr
+			}
+			if values[name], err = customfields.ParseValue(def, raw); err != nil {
+				return err
+			}
+		}
+		if params.CustomFields, err = json.Marshal(values); err != nil {
+			return fmt.Errorf("encoding custom field filters: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeCustomFieldsError reports an error of CheckCustomFields.
+func writeCustomFieldsError(c *gin.Context, err error) {
+	if errors.Is(err, customfields.ErrInvalid) {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+	logError(c, "Failed to check custom fields", err)
+	c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to check custom fields"))
+}
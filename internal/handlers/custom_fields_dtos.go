@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"time"
+
+	"contactsAI/contacts/internal/customfields"
+	"contactsAI/contacts/internal/db"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type CustomFieldBody struct {
+	// Name keys the values of the field on contacts.
+	Name string            `json:"name" binding:"required,max=50"                                example:"customer_number"`
+	Type customfields.Type `json:"type" binding:"required,oneof=text number date bool enum url" example:"text"`
+
+	UpdateCustomFieldBody
+}
+
+// UpdateCustomFieldBody holds what can change about a field. Its name and
+// type are fixed once contacts store values for it.
+type UpdateCustomFieldBody struct {
+	// Required fields need a value on every contact created or updated with
+	// custom fields.
+	Required bool `json:"required"`
+	// Pattern is a regular expression that text and url values must match.
+	Pattern string `json:"pattern,omitempty" binding:"max=200"                            example:"^C-[0-9]+$"`
+	// Options are the values allowed for an enum field.
+	Options []string `json:"options,omitempty" binding:"max=100,dive,required,max=100"`
+}
+
+type CustomFieldResponse struct {
+	ID        int32             `json:"id"`
+	Name      string            `json:"name"`
+	Type      customfields.Type `json:"type"`
+	Required  bool              `json:"required"`
+	Pattern   string            `json:"pattern,omitempty"`
+	Options   []string          `json:"options,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+func toCustomFieldResponse(field db.CustomField) CustomFieldResponse {
+	return CustomFieldResponse{
+		ID:        field.ID,
+		Name:      field.Name,
+		Type:      customfields.Type(field.Type),
+		Required:  field.Required,
+		Pattern:   field.Pattern.String,
+		Options:   field.Options,
+		CreatedAt: field.CreatedAt.Time,
+	}
+}
+
+func toDefinition(field db.CustomField) (customfields.Definition, error) {
+	//nolint:exhaustruct // the pattern is compiled by customfields.Compile
+	return customfields.Compile(customfields.Definition{
+		Name:     field.Name,
+		Type:     customfields.Type(field.Type),
+		Required: field.Required,
+		Pattern:  field.Pattern.String,
+		Options:  field.Options,
+	})
+}
+
+func toPattern(pattern string) pgtype.Text {
+	return pgtype.Text{String: pattern, Valid: pattern != ""}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"contactsAI/contacts/internal/access"
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/db"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultRecentLimit = 20
+	maxRecentLimit     = 100
+)
+
+func RegisterFavoritesRoutes(router *gin.RouterGroup, env *config.Env) {
+	router.GET("/contacts/favorites", func(c *gin.Context) { GetFavoriteContacts(c, env) })
+	router.GET("/contacts/recent", func(c *gin.Context) { GetRecentContacts(c, env) })
+	router.PUT("/contacts/:id/favorite", func(c *gin.Context) { FavoriteContact(c, env) })
+	router.DELETE("/contacts/:id/favorite", func(c *gin.Context) { UnfavoriteContact(c, env) })
+}
+
+// FavoriteBody is optional; without it the contact is a favorite that is not
+// pinned.
+type FavoriteBody struct {
+	Pinned bool `json:"pinned" example:"true"`
+}
+
+type FavoriteResponse struct {
+	ContactID int32 `json:"contact_id"`
+	Pinned    bool  `json:"pinned"`
+}
+
+// GetFavoriteContacts godoc
+//
+//	@Summary		List favorite contacts
+//	@Description	List the caller's favorite contacts, pinned ones first, then by name.
+//	@Tags			favorites
+//	@Produce		json
+//	@Success		200	{array}		ContactResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/contacts/favorites [get]
+func GetFavoriteContacts(c *gin.Context, env *config.Env) {
+	if _, ok := requireUser(c); !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	contacts, err := env.ListFavoriteContacts(c, db.ListFavoriteContactsParams{
+		OwnerID:  access.User(ctx),
+		ViewerID: access.Viewer(ctx),
+	})
+	if err != nil {
+		logError(c, "Failed to list favorites", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to list favorites"))
+		return
+	}
+	dtos, err := toContactResponses(c, env, contacts)
+	if err != nil {
+		logError(c, "Failed to load contact details", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to load contact details"))
+		return
+	}
+	c.JSON(http.StatusOK, dtos)
+}
+
+// GetRecentContacts godoc
+//
+//	@Summary		List recently viewed contacts
+//	@Description	List the contacts the caller opened most recently, the latest first, each once.
+//	@Tags			favorites
+//	@Produce		json
+//	@Param			limit	query		int	false	"Number of contacts, 1 to 100"	default(20)
+//	@Success		200		{array}		ContactResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/contacts/recent [get]
+func GetRecentContacts(c *gin.Context, env *config.Env) {
+	if _, ok := requireUser(c); !ok {
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultRecentLimit)))
+	if err != nil || limit < 1 || limit > maxRecentLimit {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "limit must be between 1 and 100"))
+		return
+	}
+	ctx := c.Request.Context()
+	contacts, err := env.ListRecentContacts(c, db.ListRecentContactsParams{
+		OwnerID:  access.User(ctx),
+		ViewerID: access.Viewer(ctx),
+		PageSize: int32(limit), //nolint:gosec // bounded by maxRecentLimit
+	})
+	if err != nil {
+		logError(c, "Failed to list recent contacts", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to list recent contacts"))
+		return
+	}
+	dtos, err := toContactResponses(c, env, contacts)
+	if err != nil {
+		logError(c, "Failed to load contact details", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to load contact details"))
+		return
+	}
+	c.JSON(http.StatusOK, dtos)
+}
+
+// FavoriteContact godoc
+//
+//	@Summary		Favorite a contact
+//	@Description	Add a contact the caller can see to their favorites, or pin or unpin a favorite.
+//	@Tags			favorites
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int				true	"Contact ID"
+//	@Param			favorite	body		FavoriteBody	false	"Whether the contact is pinned"
+//	@Success		200			{object}	FavoriteResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/contacts/{id}/favorite [put]
+func FavoriteContact(c *gin.Context, env *config.Env) {
+	if _, ok := requireUser(c); !ok {
+		return
+	}
+	var body FavoriteBody
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+	contact, ok := requireVisibleContact(c, env)
+	if !ok {
+		return
+	}
+	favorite, err := env.UpsertFavorite(c, db.UpsertFavoriteParams{
+		ContactID: contact.ID,
+		Pinned:    body.Pinned,
+		OwnerID:   access.User(c.Request.Context()),
+	})
+	if err != nil {
+		logError(c, "Failed to favorite contact", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to favorite contact"))
+		return
+	}
+	c.JSON(http.StatusOK, FavoriteResponse{ContactID: favorite.ContactID, Pinned: favorite.Pinned})
+}
+
+// UnfavoriteContact godoc
+//
+//	@Summary		Unfavorite a contact
+//	@Description	Remove a contact from the caller's favorites
+//	@Tags			favorites
+//	@Param			id	path		int		true	"Contact ID"
+//	@Success		204	{string}	string	"No Content"
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/contacts/{id}/favorite [delete]
+func UnfavoriteContact(c *gin.Context, env *config.Env) {
+	if _, ok := requireUser(c); !ok {
+		return
+	}
+	contactID, err := getIntFromPath(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid contact ID"))
+		return
+	}
+	deleted, err := env.DeleteFavorite(c, db.DeleteFavoriteParams{
+		ContactID: contactID,
+		OwnerID:   access.User(c.Request.Context()),
+	})
+	if err != nil {
+		logError(c, "Failed to unfavorite contact", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to unfavorite contact"))
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Favorite not found"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
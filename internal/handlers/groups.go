@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"contactsAI/contacts/internal/access"
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/db"
+	"contactsAI/contacts/internal/filter"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	defaultSmartGroupLimit = 50
+	maxSmartGroupLimit     = 100
+	smartGroupBatchSize    = 200
+	maxSmartGroupScan      = 5000
+)
+
+func RegisterSmartGroupsRoutes(router *gin.RouterGroup, env *config.Env) {
+	groups := router.Group("/groups")
+	groups.GET("/", func(c *gin.Context) { GetSmartGroups(c, env) })
+	groups.POST("/", func(c *gin.Context) { CreateSmartGroup(c, env) })
+	groups.GET("/:id", func(c *gin.Context) { GetSmartGroup(c, env) })
+	groups.PUT("/:id", func(c *gin.Context) { UpdateSmartGroup(c, env) })
+	groups.DELETE("/:id", func(c *gin.Context) { DeleteSmartGroup(c, env) })
+	groups.GET("/:id/contacts", func(c *gin.Context) { GetSmartGroupContacts(c, env) })
+}
+
+// GetSmartGroups godoc
+//
+//	@Summary		List smart groups
+//	@Description	List the caller's smart groups
+//	@Tags			groups
+//	@Produce		json
+//	@Success		200	{array}		SmartGroupResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/groups [get]
+func GetSmartGroups(c *gin.Context, env *config.Env) {
+	groups, err := env.ListSmartGroups(c, access.User(c.Request.Context()))
+	if err != nil {
+		logError(c, "Failed to list smart groups", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to list smart groups"))
+		return
+	}
+	dtos := make([]SmartGroupResponse, len(groups))
+	for i, group := range groups {
+		dtos[i] = toSmartGroupResponse(group)
+	}
+	c.JSON(http.StatusOK, dtos)
+}
+
+// CreateSmartGroup godoc
+//
+//	@Summary		Create a smart group
+//	@Description	Save a filter over the caller's contacts, e.g. tag:customer AND (tag:vip OR name:kowalski)
+//	@Tags			groups
+//	@Accept			json
+//	@Produce		json
+//	@Param			group	body		SmartGroupBody	true	"Smart group details"
+//	@Success		201		{object}	SmartGroupResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/groups [post]
+func CreateSmartGroup(c *gin.Context, env *config.Env) {
+	body, ok := bindSmartGroup(c)
+	if !ok {
+		return
+	}
+	group, err := env.CreateSmartGroup(c, db.CreateSmartGroupParams{
+		Name:    body.Name,
+		Filter:  body.Filter,
+		OwnerID: access.User(c.Request.Context()),
+	})
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, NewErrorResponse(c, "A smart group with this name already exists"))
+		return
+	}
+	if err != nil {
+		logError(c, "Failed to create smart group", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to create smart group"))
+		return
+	}
+	c.JSON(http.StatusCreated, toSmartGroupResponse(group))
+}
+
+// GetSmartGroup godoc
+//
+//	@Summary		Get a smart group
+//	@Description	Retrieve one of the caller's smart groups
+//	@Tags			groups
+//	@Produce		json
+//	@Param			id	path		int	true	"Smart group ID"
+//	@Success		200	{object}	SmartGroupResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/groups/{id} [get]
+func GetSmartGroup(c *gin.Context, env *config.Env) {
+	group, ok := requireSmartGroup(c, env)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, toSmartGroupResponse(group))
+}
+
+// UpdateSmartGroup godoc
+//
+//	@Summary		Update a smart group
+//	@Description	Rename one of the caller's smart groups or change its filter
+//	@Tags			groups
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int				true	"Smart group ID"
+//	@Param			group	body		SmartGroupBody	true	"Smart group details"
+//	@Success		200		{object}	SmartGroupResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/groups/{id} [put]
+func UpdateSmartGroup(c *gin.Context, env *config.Env) {
+	id, err := getIntFromPath(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid smart group ID"))
+		return
+	}
+	body, ok := bindSmartGroup(c)
+	if !ok {
+		return
+	}
+
+	group, err := env.UpdateSmartGroup(c, db.UpdateSmartGroupParams{
+		ID:      id,
+		Name:    body.Name,
+		Filter:  body.Filter,
+		OwnerID: access.User(c.Request.Context()),
+	})
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Smart group not found"))
+	case isUniqueViolation(err):
+		c.JSON(http.StatusConflict, NewErrorResponse(c, "A smart group with this name already exists"))
+	case err != nil:
+		logError(c, "Failed to update smart group", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to update smart group"))
+	default:
+		c.JSON(http.StatusOK, toSmartGroupResponse(group))
+	}
+}
+
+// DeleteSmartGroup godoc
+//
+//	@Summary		Delete a smart group
+//	@Description	Delete one of the caller's smart groups. Its contacts are not affected.
+//	@Tags			groups
+//	@Param			id	path		int		true	"Smart group ID"
+//	@Success		204	{string}	string	"No Content"
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/groups/{id} [delete]
+func DeleteSmartGroup(c *gin.Context, env *config.Env) {
+	id, err := getIntFromPath(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid smart group ID"))
+		return
+	}
+	deleted, err := env.DeleteSmartGroup(c, db.DeleteSmartGroupParams{
+		ID:      id,
+		OwnerID: access.User(c.Request.Context()),
+	})
+	if err != nil {
+		logError(c, "Failed to delete smart group", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to delete smart group"))
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Smart group not found"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetSmartGroupContacts godoc
+//
+//	@Summary		List the contacts of a smart group
+//	@Description	Evaluate a smart group's filter against the contacts visible to the caller and their tags, by name.
+//	@Description	Pass the next_cursor of a page as after to get the next one. A page can hold fewer contacts than
+//	@Description	the limit, or none, and still have a next_cursor when few contacts match.
+//	@Tags			groups
+//	@Produce		json
+//	@Param			id		path		int		true	"Smart group ID"
+//	@Param			limit	query		int		false	"Page size, 1 to 100"	default(50)
+//	@Param			after	query		string	false	"Cursor of the previous page"
+//	@Success		200		{object}	SmartGroupPage
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/groups/{id}/contacts [get]
+func GetSmartGroupContacts(c *gin.Context, env *config.Env) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultSmartGroupLimit)))
+	if err != nil || limit < 1 || limit > maxSmartGroupLimit {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "limit must be between 1 and 100"))
+		return
+	}
+	params := db.ListContactsPageParams{
+		ViewerID:  access.Viewer(c.Request.Context()),
+		Search:    pgtype.Text{},
+		AfterName: pgtype.Text{},
+		AfterID:   pgtype.Int4{},
+		PageSize:  smartGroupBatchSize,
+	}
+	if after := c.Query("after"); after != "" {
+		name, id, decodeErr := decodeContactCursor(after)
+		if decodeErr != nil {
+			c.JSON(http.StatusBadRequest, NewErrorResponse(c, decodeErr.Error()))
+			return
+		}
+		params.AfterName = pgtype.Text{String: name, Valid: true}
+		params.AfterID = pgtype.Int4{Int32: id, Valid: true}
+	}
+	group, ok := requireSmartGroup(c, env)
+	if !ok {
+		return
+	}
+	expr, err := filter.Parse(group.Filter)
+	if err != nil {
+		// Filters are validated when saved.
+		logError(c, "Stored smart group filter is invalid", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to evaluate smart group"))
+		return
+	}
+
+	// Filters are evaluated in Go, so the visible contacts are scanned batch
+	// by batch until the page is full or maxSmartGroupScan contacts were
+	// read, whichever comes first.
+	page := SmartGroupPage{Contacts: make([]ContactResponse, 0, limit), NextCursor: ""}
+	var last db.Contact
+	for scanned := 0; scanned < maxSmartGroupScan; {
+		contacts, err := env.ListContactsPage(c, params)
+		if err != nil {
+			logError(c, "Failed to list contacts", err)
+			c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to list contacts"))
+			return
+		}
+		dtos, err := toContactResponses(c, env, contacts)
+		if err != nil {
+			logError(c, "Failed to load contact details", err)
+			c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to load contact details"))
+			return
+		}
+		for i, dto := range dtos {
+			if !expr.Match(toFilterContact(dto)) {
+				continue
+			}
+			if len(page.Contacts) == limit {
+				page.NextCursor = encodeContactCursor(last)
+				c.JSON(http.StatusOK, page)
+				return
+			}
+			page.Contacts = append(page.Contacts, dto)
+			last = contacts[i]
+		}
+		if len(contacts) < int(params.PageSize) {
+			c.JSON(http.StatusOK, page)
+			return
+		}
+		scanned += len(contacts)
+		last = contacts[len(contacts)-1]
+		params.AfterName = pgtype.Text{String: last.Name, Valid: true}
+		params.AfterID = pgtype.Int4{Int32: last.ID, Valid: true}
+	}
+	page.NextCursor = encodeContactCursor(last)
+	c.JSON(http.StatusOK, page)
+}
+
+func toFilterContact(dto ContactResponse) filter.Contact {
+	tags := make([]string, len(dto.Tags))
+	for i, tag := range dto.Tags {
+		tags[i] = tag.Name
+	}
+	return filter.Contact{Name: dto.Name, Phone: dto.Phone, Tags: tags}
+}
+
+func bindSmartGroup(c *gin.Context) (SmartGroupBody, bool) {
+	var body SmartGroupBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return body, false
+	}
+	if _, err := filter.Parse(body.Filter); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return body, false
+	}
+	return body, true
+}
+
+func requireSmartGroup(c *gin.Context, env *config.Env) (db.SmartGroup, bool) {
+	id, err := getIntFromPath(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid smart group ID"))
+		return db.SmartGroup{}, false
+	}
+	group, err := env.GetSmartGroup(c, db.GetSmartGroupParams{ID: id, OwnerID: access.User(c.Request.Context())})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Smart group not found"))
+		return db.SmartGroup{}, false
+	}
+	if err != nil {
+		logError(c, "Failed to get smart group", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to get smart group"))
+		return db.SmartGroup{}, false
+	}
+	return group, true
+}
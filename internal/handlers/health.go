@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/health"
+	"contactsAI/contacts/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterHealthRoutes(router *gin.Engine, env *config.Env) {
+	router.GET("/healthz", Liveness)
+	router.HEAD("/healthz", Liveness)
+	router.GET("/readyz", func(c *gin.Context) { Readiness(c, env) })
+	router.HEAD("/readyz", func(c *gin.Context) { Readiness(c, env) })
+}
+
+type LivenessResponse struct {
+	Status string `json:"status"`
+}
+
+// Liveness godoc
+//
+//	@Summary		Liveness probe
+//	@Description	Report that the process is running
+//	@Tags			health
+//	@Produce		json
+//	@Success		200	{object}	LivenessResponse
+//	@Router			/healthz [get]
+func Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, LivenessResponse{Status: health.StatusOK})
+}
+
+// Readiness godoc
+//
+//	@Summary		Readiness probe
+//	@Description	Check every dependency and report its status and latency
+//	@Tags			health
+//	@Produce		json
+//	@Success		200	{object}	health.Report
+//	@Failure		503	{object}	health.Report
+//	@Router			/readyz [get]
+func Readiness(c *gin.Context, env *config.Env) {
+	ctx := c.Request.Context()
+	report := env.Health.Check(ctx)
+	for name, dependency := range report.Dependencies {
+		if dependency.Error != "" {
+			logging.FromContext(ctx).WarnContext(ctx, "Dependency is unavailable",
+				"dependency", name, "error", dependency.Error)
+		}
+	}
+	status := http.StatusOK
+	if report.Status != health.StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"contactsAI/contacts/internal/access"
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/db"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	defaultInteractionsLimit = 20
+	maxInteractionsLimit     = 100
+	// maxClockSkew is how far in the future an interaction may be recorded,
+	// for clients whose clocks run ahead.
+	maxClockSkew = time.Minute
+
+	directionOutbound = "outbound"
+
+	// Contacts are sorted by their interactions with these sort parameters.
+	sortLastContactedAt  = "last_contacted_at"
+	sortInteractionCount = "interaction_count"
+)
+
+func RegisterInteractionsRoutes(router *gin.RouterGroup, env *config.Env) {
+	router.GET("/contacts/:id/interactions", func(c *gin.Context) { GetInteractions(c, env) })
+	router.POST("/contacts/:id/interactions", func(c *gin.Context) { CreateInteraction(c, env) })
+}
+
+// requireVisibleContact returns the contact of the id path parameter, or
+// writes an error response when it is invalid or not visible to the caller.
+func requireVisibleContact(c *gin.Context, env *config.Env) (db.Contact, bool) {
+	contactID, err := getIntFromPath(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid contact ID"))
+		return db.Contact{}, false
+	}
+	contact, err := env.GetContactByID(c, db.GetContactByIDParams{
+		ID:       contactID,
+		ViewerID: access.Viewer(c.Request.Context()),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Contact not found"))
+		return db.Contact{}, false
+	}
+	if err != nil {
+		logError(c, "Failed to get contact", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to get contact"))
+		return db.Contact{}, false
+	}
+	return contact, true
+}
+
+// GetInteractions godoc
+//
+//	@Summary		List interactions with a contact
+//	@Description	List a page of the calls, messages, emails and meetings with a contact, the latest first.
+//	@Description	Pass the next_cursor of a page as before to get the next one.
+//	@Tags			interactions
+//	@Produce		json
+//	@Param			id		path		int		true	"Contact ID"
+//	@Param			limit	query		int		false	"Page size, 1 to 100"	default(20)
+//	@Param			before	query		string	false	"Cursor of the previous page"
+//	@Success		200		{object}	InteractionPage
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/contacts/{id}/interactions [get]
+func GetInteractions(c *gin.Context, env *config.Env) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultInteractionsLimit)))
+	if err != nil || limit < 1 || limit > maxInteractionsLimit {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "limit must be between 1 and 100"))
+		return
+	}
+	contact, ok := requireVisibleContact(c, env)
+	if !ok {
+		return
+	}
+
+	params := db.ListInteractionsParams{
+		ContactID: contact.ID,
+		ViewerID:  access.Viewer(c.Request.Context()),
+		BeforeAt:  pgtype.Timestamptz{},
+		BeforeID:  pgtype.Int4{},
+		PageSize:  int32(limit + 1), //nolint:gosec // bounded by maxInteractionsLimit
+	}
+	if before := c.Query("before"); before != "" {
+		at, id, decodeErr := decodeInteractionCursor(before)
+		if decodeErr != nil {
+			c.JSON(http.StatusBadRequest, NewErrorResponse(c, decodeErr.Error()))
+			return
+		}
+		params.BeforeAt = pgtype.Timestamptz{Time: at, InfinityModifier: pgtype.Finite, Valid: true}
+		params.BeforeID = pgtype.Int4{Int32: id, Valid: true}
+	}
+	interactions, err := env.ListInteractions(c, params)
+	if err != nil {
+		logError(c, "Failed to list interactions", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to list interactions"))
+		return
+	}
+
+	page := InteractionPage{Interactions: make([]InteractionResponse, 0, limit), NextCursor: ""}
+	if len(interactions) > limit {
+		interactions = interactions[:limit]
+		page.NextCursor = encodeInteractionCursor(interactions[limit-1])
+	}
+	for _, interaction := range interactions {
+		page.Interactions = append(page.Interactions, toInteractionResponse(interaction))
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// CreateInteraction godoc
+//
+//	@Summary		Record an interaction with a contact
+//	@Description	Record a call, message, email or meeting with a contact the caller can edit. Interactions are
+//	@Description	listed to everyone who sees the contact, so read-only shares cannot record them.
+//	@Tags			interactions
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int				true	"Contact ID"
+//	@Param			interaction	body		InteractionBody	true	"What happened and when"
+//	@Success		201			{object}	InteractionResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/contacts/{id}/interactions [post]
+func CreateInteraction(c *gin.Context, env *config.Env) {
+	var body InteractionBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+	now := time.Now()
+	occurredAt := now
+	if body.OccurredAt != nil {
+		if body.OccurredAt.After(now.Add(maxClockSkew)) {
+			c.JSON(http.StatusBadRequest, NewErrorResponse(c, "occurred_at cannot be in the future"))
+			return
+		}
+		occurredAt = *body.OccurredAt
+	}
+	direction := body.Direction
+	if direction == "" {
+		direction = directionOutbound
+	}
+	duration := pgtype.Int4{}
+	if body.DurationSeconds != nil {
+		duration = pgtype.Int4{Int32: *body.DurationSeconds, Valid: true}
+	}
+
+	contactID, ok := requireEditableContact(c, env)
+	if !ok {
+		return
+	}
+	interaction, err := env.CreateInteraction(c, db.CreateInteractionParams{
+		ContactID:       contactID,
+		OwnerID:         access.User(c.Request.Context()),
+		Type:            body.Type,
+		Direction:       direction,
+		OccurredAt:      pgtype.Timestamptz{Time: occurredAt, InfinityModifier: pgtype.Finite, Valid: true},
+		DurationSeconds: duration,
+		Notes:           body.Notes,
+	})
+	if err != nil {
+		logError(c, "Failed to create interaction", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to create interaction"))
+		return
+	}
+	c.JSON(http.StatusCreated, toInteractionResponse(interaction))
+}
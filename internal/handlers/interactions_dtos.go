@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"contactsAI/contacts/internal/db"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+type InteractionBody struct {
+	// Type is call, message, email, meeting or other.
+	Type string `json:"type" binding:"required,oneof=call message email meeting other" example:"call"`
+	// Direction is inbound when the contact reached out, outbound by default.
+	Direction string `json:"direction,omitempty" binding:"omitempty,oneof=inbound outbound" example:"outbound"`
+	// OccurredAt is when the interaction started, now by default. It cannot be
+	// in the future.
+	OccurredAt      *time.Time `json:"occurred_at,omitempty"`
+	DurationSeconds *int32     `json:"duration_seconds,omitempty" binding:"omitempty,min=0,max=86400" example:"300"`
+	Notes           string     `json:"notes,omitempty"            binding:"max=2000"`
+}
+
+type InteractionResponse struct {
+	ID        int32 `json:"id"`
+	ContactID int32 `json:"contact_id"`
+	// OwnerID is the user who recorded the interaction.
+	OwnerID         *int32    `json:"owner_id,omitempty"`
+	Type            string    `json:"type"                       example:"call"`
+	Direction       string    `json:"direction"                  example:"outbound"`
+	OccurredAt      time.Time `json:"occurred_at"`
+	DurationSeconds *int32    `json:"duration_seconds,omitempty" example:"300"`
+	Notes           string    `json:"notes,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type InteractionPage struct {
+	// Interactions are the latest first.
+	Interactions []InteractionResponse `json:"interactions"`
+	// NextCursor is the before parameter of the next page, left out on the
+	// last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func toInteractionResponse(interaction db.Interaction) InteractionResponse {
+	response := InteractionResponse{
+		ID:              interaction.ID,
+		ContactID:       interaction.ContactID,
+		OwnerID:         nil,
+		Type:            interaction.Type,
+		Direction:       interaction.Direction,
+		OccurredAt:      interaction.OccurredAt.Time,
+		DurationSeconds: nil,
+		Notes:           interaction.Notes,
+		CreatedAt:       interaction.CreatedAt.Time,
+	}
+	if interaction.OwnerID.Valid {
+		response.OwnerID = &interaction.OwnerID.Int32
+	}
+	if interaction.DurationSeconds.Valid {
+		response.DurationSeconds = &interaction.DurationSeconds.Int32
+	}
+	return response
+}
+
+// Cursors encode the (occurred_at, id) keyset used to order interactions.
+func encodeInteractionCursor(interaction db.Interaction) string {
+	raw := strconv.Itoa(int(interaction.ID)) + ":" + interaction.OccurredAt.Time.Format(time.RFC3339Nano)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeInteractionCursor(cursor string) (time.Time, int32, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, errInvalidCursor
+	}
+	idPart, occurredAt, found := strings.Cut(string(raw), ":")
+	if !found {
+		return time.Time{}, 0, errInvalidCursor
+	}
+	id, err := strconv.ParseInt(idPart, 10, 32)
+	if err != nil {
+		return time.Time{}, 0, errInvalidCursor
+	}
+	at, err := time.Parse(time.RFC3339Nano, occurredAt)
+	if err != nil {
+		return time.Time{}, 0, errInvalidCursor
+	}
+	return at, int32(id), nil
+}
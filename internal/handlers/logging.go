@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAdminRoutes(router *gin.RouterGroup, env *config.Env) {
+	admin := router.Group("/admin")
+	admin.GET("/log-level", func(c *gin.Context) { GetLogLevel(c, env) })
+	admin.PUT("/log-level", func(c *gin.Context) { SetLogLevel(c, env) })
+	admin.POST("/api-keys", func(c *gin.Context) { CreateAPIKey(c, env) })
+	admin.GET("/api-keys", func(c *gin.Context) { ListAPIKeys(c, env) })
+	admin.DELETE("/api-keys/:id", func(c *gin.Context) { RevokeAPIKey(c, env) })
+}
+
+type LogLevelBody struct {
+	// Level is one of debug, info, warn or error, optionally with an offset
+	// such as "info+2".
+	Level string `json:"level" binding:"required" example:"debug"`
+}
+
+// GetLogLevel godoc
+//
+//	@Summary		Get the log level
+//	@Description	Return the current minimum level of the service logger
+//	@Tags			admin
+//	@Produce		json
+//	@Success		200	{object}	LogLevelBody
+//	@Router			/admin/log-level [get]
+func GetLogLevel(c *gin.Context, env *config.Env) {
+	c.JSON(http.StatusOK, LogLevelBody{Level: env.LogLevel.Level().String()})
+}
+
+// SetLogLevel godoc
+//
+//	@Summary		Set the log level
+//	@Description	Change the minimum level of the service logger without a restart
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		LogLevelBody	true	"New log level"
+//	@Success		200		{object}	LogLevelBody
+//	@Failure		400		{object}	ErrorResponse
+//	@Router			/admin/log-level [put]
+func SetLogLevel(c *gin.Context, env *config.Env) {
+	var body LogLevelBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+	if err := env.LogLevel.UnmarshalText([]byte(body.Level)); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid log level"))
+		return
+	}
+	// Logged as a warning so that the change is visible at every level.
+	ctx := c.Request.Context()
+	logging.FromContext(ctx).WarnContext(ctx, "Log level changed", "level", env.LogLevel.Level())
+	c.JSON(http.StatusOK, LogLevelBody{Level: env.LogLevel.Level().String()})
+}
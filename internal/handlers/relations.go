@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"contactsAI/contacts/internal/access"
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/db"
+	"contactsAI/contacts/internal/relations"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+func RegisterRelationsRoutes(router *gin.RouterGroup, env *config.Env) {
+	router.GET("/contacts/:id/relations", func(c *gin.Context) { GetRelations(c, env) })
+	router.POST("/contacts/:id/relations", func(c *gin.Context) { CreateRelation(c, env) })
+	router.GET("/contacts/:id/relations/graph", func(c *gin.Context) { GetRelationGraph(c, env) })
+	router.PUT("/contacts/:id/relations/:relationID", func(c *gin.Context) { UpdateRelation(c, env) })
+	router.DELETE("/contacts/:id/relations/:relationID", func(c *gin.Context) { DeleteRelation(c, env) })
+}
+
+// GetRelations godoc
+//
+//	@Summary		List relations of a contact
+//	@Description	List the relations of a contact with the contacts the caller can see, read from the contact:
+//	@Description	type is what the related contact is to it.
+//	@Tags			relations
+//	@Produce		json
+//	@Param			id	path		int	true	"Contact ID"
+//	@Success		200	{array}		RelationResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/contacts/{id}/relations [get]
+func GetRelations(c *gin.Context, env *config.Env) {
+	contact, ok := requireVisibleContact(c, env)
+	if !ok {
+		return
+	}
+	rows, err := env.ListRelations(c, db.ListRelationsParams{
+		ContactID: contact.ID,
+		ViewerID:  access.Viewer(c.Request.Context()),
+	})
+	if err != nil {
+		logError(c, "Failed to list relations", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to list relations"))
+		return
+	}
+	dtos := make([]RelationResponse, len(rows))
+	for i, row := range rows {
+		dtos[i] = toListedRelationResponse(row, contact.ID)
+	}
+	c.JSON(http.StatusOK, dtos)
+}
+
+// CreateRelation godoc
+//
+//	@Summary		Relate two contacts
+//	@Description	Record that the related contact is the type of the contact, e.g. its assistant. The contact
+//	@Description	then shows up as the inverse type of the related contact, e.g. its executive.
+//	@Tags			relations
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int				true	"Contact ID"
+//	@Param			relation	body		RelationBody	true	"Related contact and type"
+//	@Success		201			{object}	RelationResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		409			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/contacts/{id}/relations [post]
+func CreateRelation(c *gin.Context, env *config.Env) {
+	contactID, ok := requireEditableContact(c, env)
+	if !ok {
+		return
+	}
+	relation, related, ok := bindRelation(c, env, contactID)
+	if !ok {
+		return
+	}
+	created, err := env.CreateRelation(c, db.CreateRelationParams{
+		ContactID: relation.Contact,
+		RelatedID: relation.Related,
+		Type:      string(relation.Type),
+	})
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, NewErrorResponse(c, "Relation already exists"))
+		return
+	}
+	if err != nil {
+		logError(c, "Failed to create relation", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to create relation"))
+		return
+	}
+	c.JSON(http.StatusCreated, toRelationResponse(created, contactID, related.Name))
+}
+
+// UpdateRelation godoc
+//
+//	@Summary		Update a relation
+//	@Description	Replace the related contact and the type of a relation of the contact
+//	@Tags			relations
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int				true	"Contact ID"
+//	@Param			relationID	path		int				true	"Relation ID"
+//	@Param			relation	body		RelationBody	true	"Related contact and type"
+//	@Success		200			{object}	RelationResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		409			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/contacts/{id}/relations/{relationID} [put]
+func UpdateRelation(c *gin.Context, env *config.Env) {
+	contactID, existing, ok := requireRelation(c, env)
+	if !ok {
+		return
+	}
+	relation, related, ok := bindRelation(c, env, contactID)
+	if !ok {
+		return
+	}
+	updated, err := env.UpdateRelation(c, db.UpdateRelationParams{
+		ID:        existing.ID,
+		ContactID: relation.Contact,
+		RelatedID: relation.Related,
+		Type:      string(relation.Type),
+	})
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, NewErrorResponse(c, "Relation already exists"))
+		return
+	}
+	if err != nil {
+		logError(c, "Failed to update relation", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to update relation"))
+		return
+	}
+	c.JSON(http.StatusOK, toRelationResponse(updated, contactID, related.Name))
+}
+
+// DeleteRelation godoc
+//
+//	@Summary		Delete a relation
+//	@Description	Delete a relation of the contact, for both contacts
+//	@Tags			relations
+//	@Produce		json
+//	@Param			id			path		int		true	"Contact ID"
+//	@Param			relationID	path		int		true	"Relation ID"
+//	@Success		204			{string}	string	"No Content"
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/contacts/{id}/relations/{relationID} [delete]
+func DeleteRelation(c *gin.Context, env *config.Env) {
+	_, relation, ok := requireRelation(c, env)
+	if !ok {
+		return
+	}
+	if _, err := env.DeleteRelation(c, relation.ID); err != nil {
+		logError(c, "Failed to delete relation", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to delete relation"))
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetRelationGraph godoc
+//
+//	@Summary		Get the neighbourhood of a contact
+//	@Description	List the contacts related to a contact through at most depth relations, with the relations
+//	@Description	between them. Paths only lead through contacts the caller can see.
+//	@Tags			relations
+//	@Produce		json
+//	@Param			id		path		int	true	"Contact ID"
+//	@Param			depth	query		int	false	"Number of relations, 1 to 4"	default(1)
+//	@Success		200		{object}	RelationGraphResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/contacts/{id}/relations/graph [get]
+func GetRelationGraph(c *gin.Context, env *config.Env) {
+	depth, err := strconv.Atoi(c.DefaultQuery("depth", "1"))
+	if err != nil || depth < 1 || depth > relations.MaxDepth {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "depth must be between 1 and 4"))
+		return
+	}
+	contact, ok := requireVisibleContact(c, env)
+	if !ok {
+		return
+	}
+
+	nodes, err := env.GetRelationGraph(c, db.GetRelationGraphParams{
+		ContactID: contact.ID,
+		Depth:     int32(depth), //nolint:gosec // bounded by relations.MaxDepth
+		ViewerID:  access.Viewer(c.Request.Context()),
+	})
+	if err != nil {
+		logError(c, "Failed to get relation graph", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to get relation graph"))
+		return
+	}
+	graph := RelationGraphResponse{
+		Nodes: make([]RelationGraphNode, len(nodes)),
+		Edges: []RelationGraphEdge{},
+	}
+	ids := make([]int32, len(nodes))
+	for i, node := range nodes {
+		graph.Nodes[i] = RelationGraphNode{ID: node.ID, Name: node.Name, Depth: node.Depth}
+		ids[i] = node.ID
+	}
+	edges, err := env.ListRelationsAmong(c, ids)
+	if err != nil {
+		logError(c, "Failed to get relation graph", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to get relation graph"))
+		return
+	}
+	for _, edge := range edges {
+		graph.Edges = append(graph.Edges, RelationGraphEdge{
+			ID:        edge.ID,
+			ContactID: edge.ContactID,
+			RelatedID: edge.RelatedID,
+			Type:      edge.Type,
+		})
+	}
+	c.JSON(http.StatusOK, graph)
+}
+
+// bindRelation binds the relation of the request to the contact contactID
+// and returns it in canonical form with the related contact, or writes an
+// error response when it is invalid or the related contact is not visible
+// to the caller.
+func bindRelation(c *gin.Context, env *config.Env, contactID int32) (relations.Relation, db.Contact, bool) {
+	var body RelationBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return relations.Relation{}, db.Contact{}, false
+	}
+	relation, err := relations.Relation{
+		Contact: contactID,
+		Related: body.RelatedID,
+		Type:    relations.Type(body.Type),
+	}.Canonical()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return relations.Relation{}, db.Contact{}, false
+	}
+
+	related, err := env.GetContactByID(c, db.GetContactByIDParams{
+		ID:       body.RelatedID,
+		ViewerID: access.Viewer(c.Request.Context()),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Related contact not found"))
+		return relations.Relation{}, db.Contact{}, false
+	}
+	if err != nil {
+		logError(c, "Failed to get contact", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to get contact"))
+		return relations.Relation{}, db.Contact{}, false
+	}
+	return relation, related, true
+}
+
+// requireRelation returns the contact ID and the relation of the path, or
+// writes an error response when either is invalid, the caller may not change
+// the contact or the other contact of the relation is not visible to them.
+func requireRelation(c *gin.Context, env *config.Env) (int32, db.ContactRelation, bool) {
+	contactID, ok := requireEditableContact(c, env)
+	if !ok {
+		return 0, db.ContactRelation{}, false
+	}
+	relationID, err := getIntFromPath(c, "relationID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid relation ID"))
+		return 0, db.ContactRelation{}, false
+	}
+
+	relation, err := env.GetRelation(c, db.GetRelationParams{ID: relationID, ContactID: contactID})
+	if err == nil {
+		_, err = env.GetContactByID(c, db.GetContactByIDParams{
+			ID:       toRelation(relation).From(contactID).Related,
+			ViewerID: access.Viewer(c.Request.Context()),
+		})
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Relation not found"))
+		return 0, db.ContactRelation{}, false
+	}
+	if err != nil {
+		logError(c, "Failed to get relation", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to get relation"))
+		return 0, db.ContactRelation{}, false
+	}
+	return contactID, relation, true
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"time"
+
+	"contactsAI/contacts/internal/db"
+	"contactsAI/contacts/internal/relations"
+)
+
+// RelationBody says that the related contact is the type of the contact in
+// the path, e.g. its assistant.
+type RelationBody struct {
+	RelatedID int32 `json:"related_id" binding:"required" example:"1"`
+	// Type is spouse, colleague, parent, child, manager, report, assistant or
+	// executive.
+	Type string `json:"type" binding:"required" example:"assistant"`
+}
+
+// RelationResponse is a relation read from the contact in the path.
+type RelationResponse struct {
+	ID          int32     `json:"id"`
+	ContactID   int32     `json:"contact_id"`
+	RelatedID   int32     `json:"related_id"`
+	RelatedName string    `json:"related_name,omitempty"`
+	Type        string    `json:"type"                   example:"assistant"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type RelationGraphNode struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+	// Depth is the number of relations on the shortest path from the contact.
+	Depth int32 `json:"depth"`
+}
+
+// RelationGraphEdge is a relation as stored: related_id is the type of
+// contact_id.
+type RelationGraphEdge struct {
+	ID        int32  `json:"id"`
+	ContactID int32  `json:"contact_id"`
+	RelatedID int32  `json:"related_id"`
+	Type      string `json:"type"`
+}
+
+type RelationGraphResponse struct {
+	Nodes []RelationGraphNode `json:"nodes"`
+	Edges []RelationGraphEdge `json:"edges"`
+}
+
+func toRelation(relation db.ContactRelation) relations.Relation {
+	return relations.Relation{
+		Contact: relation.ContactID,
+		Related: relation.RelatedID,
+		Type:    relations.Type(relation.Type),
+	}
+}
+
+// toRelationResponse reads relation from the contact contactID.
+func toRelationResponse(relation db.ContactRelation, contactID int32, relatedName string) RelationResponse {
+	from := toRelation(relation).From(contactID)
+	return RelationResponse{
+		ID:          relation.ID,
+		ContactID:   from.Contact,
+		RelatedID:   from.Related,
+		RelatedName: relatedName,
+		Type:        string(from.Type),
+		CreatedAt:   relation.CreatedAt.Time,
+	}
+}
+
+func toListedRelationResponse(row db.ListRelationsRow, contactID int32) RelationResponse {
+	return toRelationResponse(db.ContactRelation{
+		ID:        row.ID,
+		ContactID: row.ContactID,
+		RelatedID: row.RelatedID,
+		Type:      row.Type,
+		CreatedAt: row.CreatedAt,
+		TenantID:  row.TenantID,
+	}, contactID, row.OtherName)
+}
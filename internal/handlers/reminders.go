@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"contactsAI/contacts/internal/access"
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/db"
+	"contactsAI/contacts/internal/reminders"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	defaultUpcomingDays = 30
+	maxUpcomingDays     = 366
+)
+
+func RegisterRemindersRoutes(router *gin.RouterGroup, env *config.Env) {
+	group := router.Group("/reminders")
+	group.GET("/upcoming", func(c *gin.Context) { GetUpcomingReminders(c, env) })
+	group.GET("/settings", func(c *gin.Context) { GetReminderSettings(c, env) })
+	group.PUT("/settings", func(c *gin.Context) { UpdateReminderSettings(c, env) })
+	group.POST("/:id/snooze", func(c *gin.Context) { SnoozeReminder(c, env) })
+	group.POST("/:id/complete", func(c *gin.Context) { CompleteReminder(c, env) })
+
+	router.POST("/contacts/:id/reminders", func(c *gin.Context) { CreateFollowUp(c, env) })
+}
+
+// GetUpcomingReminders godoc
+//
+//	@Summary		List upcoming reminders
+//	@Description	List the caller's open reminders that are due within the next days, overdue ones first.
+//	@Description	Birthdays and anniversaries are listed once they are within the horizon of the scheduler.
+//	@Tags			reminders
+//	@Produce		json
+//	@Param			days	query		int	false	"Days ahead, 1 to 366"	default(30)
+//	@Success		200		{array}		ReminderResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/reminders/upcoming [get]
+func GetUpcomingReminders(c *gin.Context, env *config.Env) {
+	if _, ok := requireUser(c); !ok {
+		return
+	}
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultUpcomingDays)))
+	if err != nil || days < 1 || days > maxUpcomingDays {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "days must be between 1 and 366"))
+		return
+	}
+	ctx := c.Request.Context()
+	until := time.Now().AddDate(0, 0, days)
+	rows, err := env.ListUpcomingReminders(c, db.ListUpcomingRemindersParams{
+		OwnerID:  access.User(ctx),
+		Until:    pgtype.Timestamptz{Time: until, InfinityModifier: pgtype.Finite, Valid: true},
+		ViewerID: access.Viewer(ctx),
+	})
+	if err != nil {
+		logError(c, "Failed to list reminders", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to list reminders"))
+		return
+	}
+	dtos := make([]ReminderResponse, len(rows))
+	for i, row := range rows {
+		dtos[i] = toUpcomingReminderResponse(row)
+	}
+	c.JSON(http.StatusOK, dtos)
+}
+
+// CreateFollowUp godoc
+//
+//	@Summary		Create a follow-up reminder
+//	@Description	Remind the caller of a contact at a time, or in a number of days at their notify hour
+//	@Tags			reminders
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int				true	"Contact ID"
+//	@Param			reminder	body		FollowUpBody	true	"When and why"
+//	@Success		201			{object}	ReminderResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/contacts/{id}/reminders [post]
+func CreateFollowUp(c *gin.Context, env *config.Env) {
+	if _, ok := requireUser(c); !ok {
+		return
+	}
+	contactID, err := getIntFromPath(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid contact ID"))
+		return
+	}
+	var body FollowUpBody
+	if err = c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+	if (body.DueAt == nil) == (body.DueInDays == 0) {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Give either due_at or due_in_days"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	contact, err := env.GetContactByID(c, db.GetContactByIDParams{ID: contactID, ViewerID: access.Viewer(ctx)})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Contact not found"))
+		return
+	}
+	if err != nil {
+		logError(c, "Failed to get contact", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to get contact"))
+		return
+	}
+
+	dueAt := body.DueAt
+	if dueAt == nil {
+		settings, settingsErr := reminderSettings(c, env)
+		if settingsErr != nil {
+			logError(c, "Failed to get reminder settings", settingsErr)
+			c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to get reminder settings"))
+			return
+		}
+		loc, locErr := reminders.LoadLocation(settings.TimeZone)
+		if locErr != nil {
+			loc = time.UTC
+		}
+		day := reminders.Today(time.Now(), loc).AddDate(0, 0, body.DueInDays)
+		due := reminders.DueAt(day, int(settings.NotifyHour), loc)
+		dueAt = &due
+	}
+
+	reminder, err := env.CreateFollowUp(c, db.CreateFollowUpParams{
+		ContactID: contactID,
+		Note:      body.Note,
+		DueAt:     pgtype.Timestamptz{Time: *dueAt, InfinityModifier: pgtype.Finite, Valid: true},
+		OwnerID:   access.User(ctx),
+	})
+	if err != nil {
+		logError(c, "Failed to create reminder", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to create reminder"))
+		return
+	}
+	c.JSON(http.StatusCreated, toReminderResponse(reminder, contact.Name))
+}
+
+// SnoozeReminder godoc
+//
+//	@Summary		Snooze a reminder
+//	@Description	Make one of the caller's open reminders due again later, notifying it again then
+//	@Tags			reminders
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int					true	"Reminder ID"
+//	@Param			snooze	body		SnoozeReminderBody	true	"Until when"
+//	@Success		200		{object}	ReminderResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/reminders/{id}/snooze [post]
+func SnoozeReminder(c *gin.Context, env *config.Env) {
+	if _, ok := requireUser(c); !ok {
+		return
+	}
+	id, err := getIntFromPath(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid reminder ID"))
+		return
+	}
+	var body SnoozeReminderBody
+	if err = c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+	if (body.Until == nil) == (body.Minutes == 0) {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Give either until or minutes"))
+		return
+	}
+	until := time.Now().Add(time.Duration(body.Minutes) * time.Minute)
+	if body.Until != nil {
+		if !body.Until.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, NewErrorResponse(c, "until must be in the future"))
+			return
+		}
+		until = *body.Until
+	}
+
+	reminder, err := env.SnoozeReminder(c, db.SnoozeReminderParams{
+		ID:      id,
+		Until:   pgtype.Timestamptz{Time: until, InfinityModifier: pgtype.Finite, Valid: true},
+		OwnerID: access.User(c.Request.Context()),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Open reminder not found"))
+		return
+	}
+	if err != nil {
+		logError(c, "Failed to snooze reminder", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to snooze reminder"))
+		return
+	}
+	c.JSON(http.StatusOK, toReminderResponse(reminder, ""))
+}
+
+// CompleteReminder godoc
+//
+//	@Summary		Complete a reminder
+//	@Description	Mark one of the caller's reminders done, so that it is neither listed nor notified
+//	@Tags			reminders
+//	@Produce		json
+//	@Param			id	path		int	true	"Reminder ID"
+//	@Success		200	{object}	ReminderResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/reminders/{id}/complete [post]
+func CompleteReminder(c *gin.Context, env *config.Env) {
+	if _, ok := requireUser(c); !ok {
+		return
+	}
+	id, err := getIntFromPath(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid reminder ID"))
+		return
+	}
+	reminder, err := env.CompleteReminder(c, db.CompleteReminderParams{
+		ID:      id,
+		OwnerID: access.User(c.Request.Context()),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Reminder not found"))
+		return
+	}
+	if err != nil {
+		logError(c, "Failed to complete reminder", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to complete reminder"))
+		return
+	}
+	c.JSON(http.StatusOK, toReminderResponse(reminder, ""))
+}
+
+// GetReminderSettings godoc
+//
+//	@Summary		Get reminder settings
+//	@Description	Get the caller's time zone, notify hour and notification email, or the defaults
+//	@Tags			reminders
+//	@Produce		json
+//	@Success		200	{object}	ReminderSettingsResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/reminders/settings [get]
+func GetReminderSettings(c *gin.Context, env *config.Env) {
+	if _, ok := requireUser(c); !ok {
+		return
+	}
+	settings, err := reminderSettings(c, env)
+	if err != nil {
+		logError(c, "Failed to get reminder settings", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to get reminder settings"))
+		return
+	}
+	c.JSON(http.StatusOK, toReminderSettingsResponse(settings))
+}
+
+// UpdateReminderSettings godoc
+//
+//	@Summary		Update reminder settings
+//	@Description	Set the caller's time zone, notify hour and notification email. Pending birthday and
+//	@Description	anniversary reminders move to the new hour and time zone.
+//	@Tags			reminders
+//	@Accept			json
+//	@Produce		json
+//	@Param			settings	body		ReminderSettingsBody	true	"Settings"
+//	@Success		200			{object}	ReminderSettingsResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/reminders/settings [put]
+func UpdateReminderSettings(c *gin.Context, env *config.Env) {
+	if _, ok := requireUser(c); !ok {
+		return
+	}
+	var body ReminderSettingsBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+	// The name is stored as given, so that PostgreSQL knows it as well.
+	if _, err := reminders.LoadLocation(body.TimeZone); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+	settings, err := env.UpsertReminderSettings(c, db.UpsertReminderSettingsParams{
+		TimeZone:   body.TimeZone,
+		NotifyHour: *body.NotifyHour,
+		Email:      body.Email,
+		OwnerID:    access.User(c.Request.Context()),
+	})
+	if err != nil {
+		logError(c, "Failed to update reminder settings", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to update reminder settings"))
+		return
+	}
+	c.JSON(http.StatusOK, toReminderSettingsResponse(settings))
+}
+
+// reminderSettings are the caller's reminder settings, or the defaults if
+// they have none.
+func reminderSettings(c *gin.Context, env *config.Env) (db.ReminderSetting, error) {
+	owner := access.User(c.Request.Context())
+	settings, err := env.GetReminderSettings(c, owner)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.ReminderSetting{
+			OwnerID:    owner,
+			TimeZone:   reminders.DefaultTimeZone,
+			NotifyHour: reminders.DefaultNotifyHour,
+			Email:      "",
+			UpdatedAt:  pgtype.Timestamptz{},
+			TenantID:   0,
+		}, nil
+	}
+	return settings, err
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"time"
+
+	"contactsAI/contacts/internal/db"
+)
+
+type ReminderSettingsBody struct {
+	// TimeZone is an IANA time zone name.
+	TimeZone string `json:"time_zone" binding:"required,max=64" example:"Europe/Warsaw"`
+	// NotifyHour is the local hour at which reminders for a date are due.
+	NotifyHour *int32 `json:"notify_hour" binding:"required,min=0,max=23" example:"9"`
+	// Email receives notifications when the smtp notifier is enabled.
+	Email string `json:"email,omitempty" binding:"omitempty,email,max=254" example:"jan@example.com"`
+}
+
+type ReminderSettingsResponse struct {
+	TimeZone   string `json:"time_zone"`
+	NotifyHour int32  `json:"notify_hour"`
+	Email      string `json:"email,omitempty"`
+}
+
+type FollowUpBody struct {
+	Note string `json:"note" binding:"max=500" example:"Call back about the offer"`
+	// DueAt is when the reminder is due. Give either it or DueInDays.
+	DueAt *time.Time `json:"due_at,omitempty"`
+	// DueInDays makes the reminder due that many days from today, at the
+	// notify hour of the caller's time zone.
+	DueInDays int `json:"due_in_days,omitempty" binding:"omitempty,min=1,max=3650" example:"14"`
+}
+
+type SnoozeReminderBody struct {
+	// Until is when the reminder is due again. Give either it or Minutes.
+	Until *time.Time `json:"until,omitempty"`
+	// Minutes snoozes the reminder for that long from now.
+	Minutes int `json:"minutes,omitempty" binding:"omitempty,min=1,max=525600" example:"60"`
+}
+
+type ReminderResponse struct {
+	ID        int32 `json:"id"`
+	ContactID int32 `json:"contact_id"`
+	// ContactName is left out by snooze and complete.
+	ContactName string `json:"contact_name,omitempty"`
+	// Kind is follow_up, birthday or anniversary.
+	Kind  string `json:"kind"            example:"follow_up"`
+	Label string `json:"label,omitempty" example:"wedding"`
+	Note  string `json:"note,omitempty"`
+	// OccursOn is the day of a birthday or anniversary.
+	OccursOn    string     `json:"occurs_on,omitempty" example:"2026-04-23"`
+	DueAt       time.Time  `json:"due_at"`
+	NotifiedAt  *time.Time `json:"notified_at,omitempty"`
+	SnoozedAt   *time.Time `json:"snoozed_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func toReminderResponse(reminder db.Reminder, contactName string) ReminderResponse {
+	response := ReminderResponse{
+		ID:          reminder.ID,
+		ContactID:   reminder.ContactID,
+		ContactName: contactName,
+		Kind:        reminder.Kind,
+		Label:       reminder.Label,
+		Note:        reminder.Note,
+		OccursOn:    "",
+		DueAt:       reminder.DueAt.Time,
+		NotifiedAt:  optionalTime(reminder.NotifiedAt),
+		SnoozedAt:   optionalTime(reminder.SnoozedAt),
+		CompletedAt: optionalTime(reminder.CompletedAt),
+		CreatedAt:   reminder.CreatedAt.Time,
+	}
+	if reminder.OccursOn.Valid {
+		response.OccursOn = reminder.OccursOn.Time.Format(time.DateOnly)
+	}
+	return response
+}
+
+func toUpcomingReminderResponse(row db.ListUpcomingRemindersRow) ReminderResponse {
+	return toReminderResponse(db.Reminder{
+		ID:            row.ID,
+		ContactID:     row.ContactID,
+		OwnerID:       row.OwnerID,
+		Kind:          row.Kind,
+		Label:         row.Label,
+		Note:          row.Note,
+		OccursOn:      row.OccursOn,
+		DueAt:         row.DueAt,
+		NotifiedAt:    row.NotifiedAt,
+		SnoozedAt:     row.SnoozedAt,
+		CompletedAt:   row.CompletedAt,
+		CreatedAt:     row.CreatedAt,
+		TenantID:      row.TenantID,
+		Attempts:      row.Attempts,
+		NextAttemptAt: row.NextAttemptAt,
+		DeliveredTo:   row.DeliveredTo,
+	}, row.ContactName)
+}
+
+func toReminderSettingsResponse(settings db.ReminderSetting) ReminderSettingsResponse {
+	return ReminderSettingsResponse{
+		TimeZone:   settings.TimeZone,
+		NotifyHour: settings.NotifyHour,
+		Email:      settings.Email,
+	}
+}
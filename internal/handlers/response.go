@@ -1,9 +1,25 @@
 package handlers
 
+import (
+	"contactsAI/contacts/internal/logging"
+	"contactsAI/contacts/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+)
+
 type ErrorResponse struct {
 	Message string `json:"message"`
+	// TraceID identifies the request's trace, so that a reported error can be
+	// looked up in the tracing backend.
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+func NewErrorResponse(c *gin.Context, message string) ErrorResponse {
+	return ErrorResponse{Message: message, TraceID: tracing.TraceID(c.Request.Context())}
 }
 
-func NewErrorResponse(message string) ErrorResponse {
-	return ErrorResponse{Message: message}
+// logError logs err with the logger of the request.
+func logError(c *gin.Context, message string, err error) {
+	ctx := c.Request.Context()
+	logging.FromContext(ctx).ErrorContext(ctx, message, "error", err)
 }
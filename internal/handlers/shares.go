@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"contactsAI/contacts/internal/access"
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/db"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func RegisterSharesRoutes(router *gin.RouterGroup, env *config.Env) {
+	shares := router.Group("/contacts/:id/shares")
+	shares.GET("/", func(c *gin.Context) { GetContactShares(c, env) })
+	shares.POST("/", func(c *gin.Context) { ShareContact(c, env) })
+	shares.DELETE("/:shareID", func(c *gin.Context) { UnshareContact(c, env) })
+}
+
+// GetContactShares godoc
+//
+//	@Summary		List the shares of a contact
+//	@Description	List the users and teams a contact is shared with. Only the owner of the contact can see them.
+//	@Tags			sharing
+//	@Produce		json
+//	@Param			id	path		int	true	"Contact ID"
+//	@Success		200	{array}		ShareResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/contacts/{id}/shares [get]
+func GetContactShares(c *gin.Context, env *config.Env) {
+	contactID, ok := requireOwnedContact(c, env)
+	if !ok {
+		return
+	}
+	shares, err := env.ListContactShares(c, contactID)
+	if err != nil {
+		logError(c, "Failed to list contact shares", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to list contact shares"))
+		return
+	}
+	dtos := make([]ShareResponse, len(shares))
+	for i, share := range shares {
+		dtos[i] = toShareResponse(share)
+	}
+	c.JSON(http.StatusOK, dtos)
+}
+
+// ShareContact godoc
+//
+//	@Summary		Share a contact
+//	@Description	Share a contact with a user or a team for reading or writing. Sharing again changes the permission.
+//	@Tags			sharing
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int				true	"Contact ID"
+//	@Param			share	body		CreateShareBody	true	"Share details"
+//	@Success		200		{object}	ShareResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/contacts/{id}/shares [post]
+func ShareContact(c *gin.Context, env *config.Env) {
+	contactID, ok := requireOwnedContact(c, env)
+	if !ok {
+		return
+	}
+	var body CreateShareBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+	if (body.UserID == nil) == (body.TeamID == nil) {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Share with exactly one of user_id and team_id"))
+		return
+	}
+
+	var share db.ContactShare
+	var err error
+	if body.UserID != nil {
+		share, err = env.ShareContactWithUser(c, db.ShareContactWithUserParams{
+			ContactID:  contactID,
+			UserID:     pgtype.Int4{Int32: *body.UserID, Valid: true},
+			Permission: body.Permission,
+		})
+	} else {
+		if _, err = env.GetTeam(c, *body.TeamID); errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, NewErrorResponse(c, "Team not found"))
+			return
+		}
+		if err == nil {
+			share, err = env.ShareContactWithTeam(c, db.ShareContactWithTeamParams{
+				ContactID:  contactID,
+				TeamID:     pgtype.Int4{Int32: *body.TeamID, Valid: true},
+				Permission: body.Permission,
+			})
+		}
+	}
+	if err != nil {
+		logError(c, "Failed to share contact", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to share contact"))
+		return
+	}
+	c.JSON(http.StatusOK, toShareResponse(share))
+}
+
+// UnshareContact godoc
+//
+//	@Summary		Stop sharing a contact
+//	@Description	Remove a share of a contact
+//	@Tags			sharing
+//	@Param			id		path		int		true	"Contact ID"
+//	@Param			shareID	path		int		true	"Share ID"
+//	@Success		204		{string}	string	"No Content"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/contacts/{id}/shares/{shareID} [delete]
+func UnshareContact(c *gin.Context, env *config.Env) {
+	contactID, ok := requireOwnedContact(c, env)
+	if !ok {
+		return
+	}
+	shareID, err := getIntFromPath(c, "shareID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid share ID"))
+		return
+	}
+
+	deleted, err := env.DeleteContactShare(c, db.DeleteContactShareParams{ID: shareID, ContactID: contactID})
+	if err != nil {
+		logError(c, "Failed to delete contact share", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to delete contact share"))
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Share not found"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// requireOwnedContact parses the contact ID from the path and checks that the
+// caller owns the contact, as only owners manage its shares. Anonymous
+// callers are refused. It writes the error response itself and returns false
+// when the request cannot proceed.
+func requireOwnedContact(c *gin.Context, env *config.Env) (int32, bool) {
+	if _, ok := requireUser(c); !ok {
+		return 0, false
+	}
+	contactID, err := getIntFromPath(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid contact ID"))
+		return 0, false
+	}
+	viewer := access.Viewer(c.Request.Context())
+	contact, err := env.GetContactByID(c, db.GetContactByIDParams{ID: contactID, ViewerID: viewer})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Contact not found"))
+		return 0, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, err.Error()))
+		return 0, false
+	}
+	if viewer.Valid && contact.OwnerID != viewer {
+		c.JSON(http.StatusForbidden, NewErrorResponse(c, "Only the owner of a contact can share it"))
+		return 0, false
+	}
+	return contactID, true
+}
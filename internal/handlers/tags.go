@@ -0,0 +1,399 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"contactsAI/contacts/internal/access"
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/db"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+func RegisterTagsRoutes(router *gin.RouterGroup, env *config.Env) {
+	tags := router.Group("/tags")
+	tags.GET("/", func(c *gin.Context) { GetTags(c, env) })
+	tags.POST("/", func(c *gin.Context) { CreateTag(c, env) })
+	tags.PUT("/:id", func(c *gin.Context) { UpdateTag(c, env) })
+	tags.DELETE("/:id", func(c *gin.Context) { DeleteTag(c, env) })
+	tags.POST("/:id/attach", func(c *gin.Context) { AttachTag(c, env) })
+	tags.POST("/:id/detach", func(c *gin.Context) { DetachTag(c, env) })
+
+	contactTags := router.Group("/contacts/:id/tags")
+	contactTags.PUT("/:tagID", func(c *gin.Context) { TagContact(c, env) })
+	contactTags.DELETE("/:tagID", func(c *gin.Context) { UntagContact(c, env) })
+}
+
+// GetTags godoc
+//
+//	@Summary		List tags
+//	@Description	List the caller's tags
+//	@Tags			tags
+//	@Produce		json
+//	@Success		200	{array}		TagDetailResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/tags [get]
+func GetTags(c *gin.Context, env *config.Env) {
+	tags, err := env.ListTags(c, access.User(c.Request.Context()))
+	if err != nil {
+		logError(c, "Failed to list tags", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to list tags"))
+		return
+	}
+	dtos := make([]TagDetailResponse, len(tags))
+	for i, tag := range tags {
+		dtos[i] = toTagDetailResponse(tag)
+	}
+	c.JSON(http.StatusOK, dtos)
+}
+
+// CreateTag godoc
+//
+//	@Summary		Create a tag
+//	@Description	Create a tag owned by the caller. Tag names are unique per owner.
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Param			tag	body		TagBody	true	"Tag details"
+//	@Success		201	{object}	TagDetailResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		409	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/tags [post]
+func CreateTag(c *gin.Context, env *config.Env) {
+	var body TagBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+
+	tag, err := env.CreateTag(c, db.CreateTagParams{
+		Name:    body.Name,
+		Color:   tagColor(body.Color),
+		OwnerID: access.User(c.Request.Context()),
+	})
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, NewErrorResponse(c, "A tag with this name already exists"))
+		return
+	}
+	if err != nil {
+		logError(c, "Failed to create tag", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to create tag"))
+		return
+	}
+	c.JSON(http.StatusCreated, toTagDetailResponse(tag))
+}
+
+// UpdateTag godoc
+//
+//	@Summary		Update a tag
+//	@Description	Rename or recolor one of the caller's tags
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int		true	"Tag ID"
+//	@Param			tag	body		TagBody	true	"Tag details"
+//	@Success		200	{object}	TagDetailResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		409	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/tags/{id} [put]
+func UpdateTag(c *gin.Context, env *config.Env) {
+	id, err := getIntFromPath(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid tag ID"))
+		return
+	}
+	var body TagBody
+	if err = c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+
+	tag, err := env.UpdateTag(c, db.UpdateTagParams{
+		ID:      id,
+		Name:    body.Name,
+		Color:   tagColor(body.Color),
+		OwnerID: access.User(c.Request.Context()),
+	})
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Tag not found"))
+	case isUniqueViolation(err):
+		c.JSON(http.StatusConflict, NewErrorResponse(c, "A tag with this name already exists"))
+	case err != nil:
+		logError(c, "Failed to update tag", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to update tag"))
+	default:
+		c.JSON(http.StatusOK, toTagDetailResponse(tag))
+	}
+}
+
+// DeleteTag godoc
+//
+//	@Summary		Delete a tag
+//	@Description	Delete one of the caller's tags, removing it from all contacts
+//	@Tags			tags
+//	@Param			id	path		int		true	"Tag ID"
+//	@Success		204	{string}	string	"No Content"
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/tags/{id} [delete]
+func DeleteTag(c *gin.Context, env *config.Env) {
+	id, err := getIntFromPath(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid tag ID"))
+		return
+	}
+	deleted, err := env.DeleteTag(c, db.DeleteTagParams{ID: id, OwnerID: access.User(c.Request.Context())})
+	if err != nil {
+		logError(c, "Failed to delete tag", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to delete tag"))
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Tag not found"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// AttachTag godoc
+//
+//	@Summary		Tag contacts
+//	@Description	Add one of the caller's tags to many contacts. Contacts not visible to the caller are skipped.
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int				true	"Tag ID"
+//	@Param			contacts	body		TagContactsBody	true	"Contacts to tag"
+//	@Success		200			{object}	TagContactsResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/tags/{id}/attach [post]
+func AttachTag(c *gin.Context, env *config.Env) {
+	tag, body, ok := bindTagContacts(c, env)
+	if !ok {
+		return
+	}
+	tagged, err := env.TagContacts(c, db.TagContactsParams{
+		TagID:      tag.ID,
+		ContactIds: body.ContactIDs,
+		ViewerID:   access.Viewer(c.Request.Context()),
+	})
+	if err != nil {
+		logError(c, "Failed to tag contacts", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to tag contacts"))
+		return
+	}
+	c.JSON(http.StatusOK, TagContactsResponse{Changed: tagged})
+}
+
+// DetachTag godoc
+//
+//	@Summary		Untag contacts
+//	@Description	Remove one of the caller's tags from many contacts
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int				true	"Tag ID"
+//	@Param			contacts	body		TagContactsBody	true	"Contacts to untag"
+//	@Success		200			{object}	TagContactsResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/tags/{id}/detach [post]
+func DetachTag(c *gin.Context, env *config.Env) {
+	tag, body, ok := bindTagContacts(c, env)
+	if !ok {
+		return
+	}
+	untagged, err := env.UntagContacts(c, db.UntagContactsParams{TagID: tag.ID, ContactIds: body.ContactIDs})
+	if err != nil {
+		logError(c, "Failed to untag contacts", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to untag contacts"))
+		return
+	}
+	c.JSON(http.StatusOK, TagContactsResponse{Changed: untagged})
+}
+
+// TagContact godoc
+//
+//	@Summary		Tag a contact
+//	@Description	Add one of the caller's tags to a contact visible to the caller
+//	@Tags			tags
+//	@Param			id		path		int		true	"Contact ID"
+//	@Param			tagID	path		int		true	"Tag ID"
+//	@Success		204		{string}	string	"No Content"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/contacts/{id}/tags/{tagID} [put]
+func TagContact(c *gin.Context, env *config.Env) {
+	contactID, tag, ok := requireContactAndTag(c, env)
+	if !ok {
+		return
+	}
+	_, err := env.TagContacts(c, db.TagContactsParams{
+		TagID:      tag.ID,
+		ContactIds: []int32{contactID},
+		ViewerID:   access.Viewer(c.Request.Context()),
+	})
+	if err != nil {
+		logError(c, "Failed to tag contact", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to tag contact"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// UntagContact godoc
+//
+//	@Summary		Untag a contact
+//	@Description	Remove one of the caller's tags from a contact
+//	@Tags			tags
+//	@Param			id		path		int		true	"Contact ID"
+//	@Param			tagID	path		int		true	"Tag ID"
+//	@Success		204		{string}	string	"No Content"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/contacts/{id}/tags/{tagID} [delete]
+func UntagContact(c *gin.Context, env *config.Env) {
+	contactID, tag, ok := requireContactAndTag(c, env)
+	if !ok {
+		return
+	}
+	_, err := env.UntagContacts(c, db.UntagContactsParams{TagID: tag.ID, ContactIds: []int32{contactID}})
+	if err != nil {
+		logError(c, "Failed to untag contact", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to untag contact"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func tagColor(color string) string {
+	if color == "" {
+		return defaultTagColor
+	}
+	return color
+}
+
+// requireTag loads the caller's tag whose ID is the path parameter name. It
+// writes the error response itself and returns false when the request cannot
+// proceed.
+func requireTag(c *gin.Context, env *config.Env, name string) (db.Tag, bool) {
+	id, err := getIntFromPath(c, name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid tag ID"))
+		return db.Tag{}, false
+	}
+	tag, err := env.GetTag(c, db.GetTagParams{ID: id, OwnerID: access.User(c.Request.Context())})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Tag not found"))
+		return db.Tag{}, false
+	}
+	if err != nil {
+		logError(c, "Failed to get tag", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to get tag"))
+		return db.Tag{}, false
+	}
+	return tag, true
+}
+
+func bindTagContacts(c *gin.Context, env *config.Env) (db.Tag, TagContactsBody, bool) {
+	var body TagContactsBody
+	tag, ok := requireTag(c, env, "id")
+	if !ok {
+		return tag, body, false
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return tag, body, false
+	}
+	return tag, body, true
+}
+
+// requireContactAndTag checks that the contact in the path is visible to the
+// caller and loads the caller's tag.
+func requireContactAndTag(c *gin.Context, env *config.Env) (int32, db.Tag, bool) {
+	contactID, err := getIntFromPath(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid contact ID"))
+		return 0, db.Tag{}, false
+	}
+	permission, err := env.GetContactAccess(c, db.GetContactAccessParams{
+		ID:       contactID,
+		ViewerID: access.Viewer(c.Request.Context()),
+	})
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && !permission.Valid) {
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Contact not found"))
+		return 0, db.Tag{}, false
+	}
+	if err != nil {
+		logError(c, "Failed to check contact access", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to check contact access"))
+		return 0, db.Tag{}, false
+	}
+	tag, ok := requireTag(c, env, "tagID")
+	return contactID, tag, ok
+}
+
+// toContactResponses converts contacts, adding the caller's tags and
+// favorites and the interaction stats with a query each.
+func toContactResponses(c *gin.Context, env *config.Env, contacts []db.Contact) ([]ContactResponse, error) {
+	dtos := make([]ContactResponse, len(contacts))
+	ids := make([]int32, len(contacts))
+	positions := make(map[int32]int, len(contacts))
+	for i, contact := range contacts {
+		dtos[i] = toContactResponse(contact)
+		ids[i] = contact.ID
+		positions[contact.ID] = i
+	}
+	if len(contacts) == 0 {
+		return dtos, nil
+	}
+
+	tags, err := env.ListContactTags(c, db.ListContactTagsParams{
+		ContactIds: ids,
+		OwnerID:    access.User(c.Request.Context()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing tags: %w", err)
+	}
+	for _, tag := range tags {
+		i := positions[tag.ContactID]
+		dtos[i].Tags = append(dtos[i].Tags, TagResponse{ID: tag.ID, Name: tag.Name, Color: tag.Color})
+	}
+
+	stats, err := env.ListInteractionStats(c, ids)
+	if err != nil {
+		return nil, fmt.Errorf("listing interaction stats: %w", err)
+	}
+	for _, stat := range stats {
+		i := positions[stat.ContactID]
+		dtos[i].LastContactedAt = optionalTime(stat.LastContactedAt)
+		dtos[i].InteractionCount = stat.InteractionCount
+	}
+
+	favorites, err := env.ListFavoriteFlags(c, db.ListFavoriteFlagsParams{
+		ContactIds: ids,
+		OwnerID:    access.User(c.Request.Context()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing favorites: %w", err)
+	}
+	for _, favorite := range favorites {
+		i := positions[favorite.ContactID]
+		dtos[i].Favorite = true
+		dtos[i].Pinned = favorite.Pinned
+	}
+	return dtos, nil
+}
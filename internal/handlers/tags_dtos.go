@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"contactsAI/contacts/internal/db"
+)
+
+// defaultTagColor is used for tags created without a color.
+const defaultTagColor = "#9e9e9e"
+
+type TagBody struct {
+	Name string `json:"name"            binding:"required,max=50"     example:"VIP"`
+	// Color is a CSS hex color, gray when left out.
+	Color string `json:"color,omitempty" binding:"omitempty,hexcolor" example:"#ffc107"`
+}
+
+// TagContactsBody lists the contacts to tag or untag at once.
+type TagContactsBody struct {
+	ContactIDs []int32 `json:"contact_ids" binding:"required,min=1,max=1000"`
+}
+
+type TagContactsResponse struct {
+	// Changed is the number of contacts that were tagged or untagged. Contacts
+	// that already were, and those not visible to the caller, are not counted.
+	Changed int64 `json:"changed"`
+}
+
+type TagResponse struct {
+	ID    int32  `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type TagDetailResponse struct {
+	TagResponse
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func toTagDetailResponse(tag db.Tag) TagDetailResponse {
+	return TagDetailResponse{
+		TagResponse: TagResponse{ID: tag.ID, Name: tag.Name, Color: tag.Color},
+		CreatedAt:   tag.CreatedAt.Time,
+	}
+}
+
+type SmartGroupBody struct {
+	Name string `json:"name"   binding:"required,max=100"  example:"Important customers"`
+	// Filter combines tag:, name: and phone: terms with AND, OR, NOT and
+	// parentheses.
+	Filter string `json:"filter" binding:"required,max=1000" example:"tag:customer AND (tag:vip OR name:kowalski)"`
+}
+
+type SmartGroupResponse struct {
+	ID        int32     `json:"id"`
+	Name      string    `json:"name"`
+	Filter    string    `json:"filter"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SmartGroupPage struct {
+	// Contacts are ordered by name.
+	Contacts []ContactResponse `json:"contacts"`
+	// NextCursor is the after parameter of the next page, left out on the
+	// last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func toSmartGroupResponse(group db.SmartGroup) SmartGroupResponse {
+	return SmartGroupResponse{
+		ID:        group.ID,
+		Name:      group.Name,
+		Filter:    group.Filter,
+		CreatedAt: group.CreatedAt.Time,
+	}
+}
+
+// Cursors encode the (name, id) keyset used to order contacts.
+func encodeContactCursor(contact db.Contact) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(int(contact.ID)) + ":" + contact.Name))
+}
+
+func decodeContactCursor(cursor string) (string, int32, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, errInvalidCursor
+	}
+	idPart, name, found := strings.Cut(string(raw), ":")
+	if !found {
+		return "", 0, errInvalidCursor
+	}
+	id, err := strconv.ParseInt(idPart, 10, 32)
+	if err != nil {
+		return "", 0, errInvalidCursor
+	}
+	return name, int32(id), nil
+}
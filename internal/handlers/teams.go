@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"contactsAI/contacts/internal/access"
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/db"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+func RegisterTeamsRoutes(router *gin.RouterGroup, env *config.Env) {
+	teams := router.Group("/teams")
+	teams.GET("/", func(c *gin.Context) { GetTeams(c, env) })
+	teams.POST("/", func(c *gin.Context) { CreateTeam(c, env) })
+	teams.GET("/:id", func(c *gin.Context) { GetTeam(c, env) })
+	teams.DELETE("/:id", func(c *gin.Context) { DeleteTeam(c, env) })
+	teams.PUT("/:id/members/:userID", func(c *gin.Context) { SetTeamMember(c, env) })
+	teams.DELETE("/:id/members/:userID", func(c *gin.Context) { RemoveTeamMember(c, env) })
+}
+
+// CreateTeam godoc
+//
+//	@Summary		Create a team
+//	@Description	Create a team with the caller as its owner
+//	@Tags			teams
+//	@Accept			json
+//	@Produce		json
+//	@Param			team	body		CreateTeamBody	true	"Team details"
+//	@Success		201		{object}	TeamResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/teams [post]
+func CreateTeam(c *gin.Context, env *config.Env) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	var body CreateTeamBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+
+	team, err := env.CreateTeam(c, db.CreateTeamParams{Name: body.Name, OwnerID: user})
+	if err != nil {
+		logError(c, "Failed to create team", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to create team"))
+		return
+	}
+	c.JSON(http.StatusCreated, toTeamResponse(team, string(access.RoleOwner)))
+}
+
+// GetTeams godoc
+//
+//	@Summary		List teams
+//	@Description	List the teams the caller is a member of, with the caller's role
+//	@Tags			teams
+//	@Produce		json
+//	@Success		200	{array}		TeamResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/teams [get]
+func GetTeams(c *gin.Context, env *config.Env) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	dtos := []TeamResponse{}
+	teams, err := env.ListTeamsForUser(c, user)
+	if err != nil {
+		logError(c, "Failed to list teams", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to list teams"))
+		return
+	}
+	for _, team := range teams {
+		dtos = append(dtos, TeamResponse{ID: team.ID, Name: team.Name, CreatedAt: team.CreatedAt.Time, Role: team.Role})
+	}
+	c.JSON(http.StatusOK, dtos)
+}
+
+// GetTeam godoc
+//
+//	@Summary		Get a team
+//	@Description	Get a team and its members. Only members can see a team.
+//	@Tags			teams
+//	@Produce		json
+//	@Param			id	path		int	true	"Team ID"
+//	@Success		200	{object}	TeamDetailResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/teams/{id} [get]
+func GetTeam(c *gin.Context, env *config.Env) {
+	team, role, ok := requireTeam(c, env)
+	if !ok {
+		return
+	}
+	members, err := env.ListTeamMembers(c, team.ID)
+	if err != nil {
+		logError(c, "Failed to list team members", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to list team members"))
+		return
+	}
+
+	dto := TeamDetailResponse{
+		TeamResponse: toTeamResponse(team, string(role)),
+		Members:      make([]TeamMemberResponse, len(members)),
+	}
+	for i, member := range members {
+		dto.Members[i] = toTeamMemberResponse(member)
+	}
+	c.JSON(http.StatusOK, dto)
+}
+
+// DeleteTeam godoc
+//
+//	@Summary		Delete a team
+//	@Description	Delete a team, its memberships and the contacts shared with it. Only owners can delete a team.
+//	@Tags			teams
+//	@Param			id	path		int		true	"Team ID"
+//	@Success		204	{string}	string	"No Content"
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/teams/{id} [delete]
+func DeleteTeam(c *gin.Context, env *config.Env) {
+	team, role, ok := requireTeam(c, env)
+	if !ok {
+		return
+	}
+	if err := access.CanManageTeam(role); err != nil {
+		c.JSON(http.StatusForbidden, NewErrorResponse(c, err.Error()))
+		return
+	}
+	if _, err := env.DeleteTeam(c, team.ID); err != nil {
+		logError(c, "Failed to delete team", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to delete team"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// SetTeamMember godoc
+//
+//	@Summary		Add a team member or change their role
+//	@Description	Owners manage all members. Admins manage admins, members and viewers.
+//	@Tags			teams
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int				true	"Team ID"
+//	@Param			userID	path		int				true	"User ID"
+//	@Param			member	body		TeamMemberBody	true	"Role of the member"
+//	@Success		200		{object}	TeamMemberResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/teams/{id}/members/{userID} [put]
+func SetTeamMember(c *gin.Context, env *config.Env) {
+	team, actor, ok := requireTeam(c, env)
+	if !ok {
+		return
+	}
+	userID, err := getIntFromPath(c, "userID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid user ID"))
+		return
+	}
+	var body TeamMemberBody
+	if err = c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+	next, err := access.ParseRole(body.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, err.Error()))
+		return
+	}
+	current, ok := memberRole(c, env, team.ID, userID)
+	if !ok || !checkRoleChange(c, env, team.ID, actor, current, next) {
+		return
+	}
+
+	member, err := env.UpsertTeamMember(c, db.UpsertTeamMemberParams{TeamID: team.ID, UserID: userID, Role: string(next)})
+	if err != nil {
+		logError(c, "Failed to set team member", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to set team member"))
+		return
+	}
+	c.JSON(http.StatusOK, toTeamMemberResponse(member))
+}
+
+// RemoveTeamMember godoc
+//
+//	@Summary		Remove a team member
+//	@Description	Remove a member from a team. Every member can leave a team, except its last owner.
+//	@Tags			teams
+//	@Param			id		path		int		true	"Team ID"
+//	@Param			userID	path		int		true	"User ID"
+//	@Success		204		{string}	string	"No Content"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/teams/{id}/members/{userID} [delete]
+func RemoveTeamMember(c *gin.Context, env *config.Env) {
+	team, actor, ok := requireTeam(c, env)
+	if !ok {
+		return
+	}
+	userID, err := getIntFromPath(c, "userID")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid user ID"))
+		return
+	}
+	current, ok := memberRole(c, env, team.ID, userID)
+	if !ok {
+		return
+	}
+	if current == "" {
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Team member not found"))
+		return
+	}
+	self := access.User(c.Request.Context()).Int32 == userID
+	if err = access.CanRemove(actor, current, self); err != nil {
+		c.JSON(http.StatusForbidden, NewErrorResponse(c, err.Error()))
+		return
+	}
+	if !checkOwners(c, env, team.ID, current, "") {
+		return
+	}
+
+	if _, err = env.DeleteTeamMember(c, db.DeleteTeamMemberParams{TeamID: team.ID, UserID: userID}); err != nil {
+		logError(c, "Failed to remove team member", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to remove team member"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// requireTeam loads the team of the path and the caller's role in it. Teams
+// are hidden from non-members, admin keys act as owners and anonymous callers
+// are refused. It writes the error response itself and returns false when the
+// request cannot proceed.
+func requireTeam(c *gin.Context, env *config.Env) (db.Team, access.Role, bool) {
+	if _, ok := requireUser(c); !ok {
+		return db.Team{}, "", false
+	}
+	teamID, err := getIntFromPath(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, NewErrorResponse(c, "Invalid team ID"))
+		return db.Team{}, "", false
+	}
+	team, err := env.GetTeam(c, teamID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Team not found"))
+		return db.Team{}, "", false
+	}
+	if err != nil {
+		logError(c, "Failed to get team", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to get team"))
+		return db.Team{}, "", false
+	}
+
+	viewer := access.Viewer(c.Request.Context())
+	if !viewer.Valid {
+		return team, access.RoleOwner, true
+	}
+	role, ok := memberRole(c, env, teamID, viewer.Int32)
+	if ok && role == "" {
+		c.JSON(http.StatusNotFound, NewErrorResponse(c, "Team not found"))
+		return db.Team{}, "", false
+	}
+	return team, role, ok
+}
+
+// memberRole returns the role of a user in a team, or an empty role for
+// non-members.
+func memberRole(c *gin.Context, env *config.Env, teamID, userID int32) (access.Role, bool) {
+	member, err := env.GetTeamMember(c, db.GetTeamMemberParams{TeamID: teamID, UserID: userID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", true
+	}
+	if err != nil {
+		logError(c, "Failed to get team member", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to get team member"))
+		return "", false
+	}
+	return access.Role(member.Role), true
+}
+
+func checkRoleChange(c *gin.Context, env *config.Env, teamID int32, actor, current, next access.Role) bool {
+	if err := access.CanSetRole(actor, current, next); err != nil {
+		c.JSON(http.StatusForbidden, NewErrorResponse(c, err.Error()))
+		return false
+	}
+	return checkOwners(c, env, teamID, current, next)
+}
+
+// checkOwners rejects changes that would leave the team without an owner.
+func checkOwners(c *gin.Context, env *config.Env, teamID int32, current, next access.Role) bool {
+	if current != access.RoleOwner || next == access.RoleOwner {
+		return true
+	}
+	owners, err := env.CountTeamOwners(c, teamID)
+	if err != nil {
+		logError(c, "Failed to count team owners", err)
+		c.JSON(http.StatusInternalServerError, NewErrorResponse(c, "Failed to count team owners"))
+		return false
+	}
+	if err = access.CheckOwners(owners, current, next); err != nil {
+		c.JSON(http.StatusConflict, NewErrorResponse(c, err.Error()))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"time"
+
+	"contactsAI/contacts/internal/db"
+)
+
+type CreateTeamBody struct {
+	Name string `json:"name" binding:"required,max=100" example:"Sales"`
+}
+
+type TeamMemberBody struct {
+	Role string `json:"role" binding:"required,oneof=owner admin member viewer" example:"member"`
+}
+
+// CreateShareBody shares a contact with either a user or a team.
+type CreateShareBody struct {
+	UserID     *int32 `json:"user_id,omitempty" example:"2"`
+	TeamID     *int32 `json:"team_id,omitempty"`
+	Permission string `json:"permission"        binding:"required,oneof=read write" example:"read"`
+}
+
+type TeamResponse struct {
+	ID        int32     `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	// Role is the caller's role in the team, if they are a member.
+	Role string `json:"role,omitempty"`
+}
+
+type TeamDetailResponse struct {
+	TeamResponse
+
+	Members []TeamMemberResponse `json:"members"`
+}
+
+type TeamMemberResponse struct {
+	UserID    int32     `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ShareResponse struct {
+	ID         int32     `json:"id"`
+	ContactID  int32     `json:"contact_id"`
+	UserID     *int32    `json:"user_id,omitempty"`
+	TeamID     *int32    `json:"team_id,omitempty"`
+	Permission string    `json:"permission"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func toTeamResponse(team db.Team, role string) TeamResponse {
+	return TeamResponse{ID: team.ID, Name: team.Name, CreatedAt: team.CreatedAt.Time, Role: role}
+}
+
+func toTeamMemberResponse(member db.TeamMember) TeamMemberResponse {
+	return TeamMemberResponse{UserID: member.UserID, Role: member.Role, CreatedAt: member.CreatedAt.Time}
+}
+
+func toShareResponse(share db.ContactShare) ShareResponse {
+	var userID, teamID *int32
+	if share.UserID.Valid {
+		userID = &share.UserID.Int32
+	}
+	if share.TeamID.Valid {
+		teamID = &share.TeamID.Int32
+	}
+	return ShareResponse{
+		ID:         share.ID,
+		ContactID:  share.ContactID,
+		UserID:     userID,
+		TeamID:     teamID,
+		Permission: share.Permission,
+		CreatedAt:  share.CreatedAt.Time,
+	}
+}
@@ -1,13 +1,44 @@
 package handlers
 
 import (
+	"errors"
+	"net/http"
 	"strconv"
 
+	"contactsAI/contacts/internal/access"
+
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// uniqueViolation is the SQLSTATE of unique constraint violations.
+const uniqueViolation = "23505"
+
 func getIntFromPath(c *gin.Context, variableName string) (int32, error) {
 	id := c.Param(variableName)
 	idInt32, err := strconv.ParseInt(id, 10, 32)
 	return int32(idInt32), err
 }
+
+// requireUser returns the user making the request. Anonymous requests get a
+// 401 response, for features that belong to a user, such as teams and shares.
+func requireUser(c *gin.Context) (int32, bool) {
+	user := access.User(c.Request.Context())
+	if !user.Valid {
+		c.JSON(http.StatusUnauthorized, NewErrorResponse(c, "An API key is required"))
+		return 0, false
+	}
+	return user.Int32, true
+}
+
+func getIntFromQuery(c *gin.Context, name string) (int32, error) {
+	value, err := strconv.ParseInt(c.Query(name), 10, 32)
+	return int32(value), err
+}
+
+// isUniqueViolation reports whether err is the violation of a unique
+// constraint, such as a duplicate name.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
@@ -0,0 +1,130 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK           = "ok"
+	StatusUnavailable  = "unavailable"
+	StatusShuttingDown = "shutting_down"
+
+	// DefaultTimeout bounds every dependency check so that a hanging dependency
+	// cannot stall the readiness probe.
+	DefaultTimeout = 2 * time.Second
+)
+
+// CheckFunc reports whether a dependency is usable.
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name string
+	run  CheckFunc
+}
+
+// Checker runs the readiness checks of all registered dependencies.
+type Checker struct {
+	timeout          time.Duration
+	checks           []check
+	migrationVersion func(ctx context.Context) (int64, error)
+	shuttingDown     atomic.Bool
+}
+
+// DependencyStatus is the result of a single dependency check. Error is left
+// out of responses, since it can reveal hosts or credentials, and is only
+// meant to be logged.
+type DependencyStatus struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"-"`
+}
+
+// Report is the readiness of the service and each of its dependencies.
+type Report struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+	// MigrationVersion is the newest applied database migration, when known.
+	MigrationVersion *int64 `json:"migration_version,omitempty"`
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	//nolint:exhaustruct // checks are added with Add
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a dependency check. It must not be called concurrently with Check.
+func (c *Checker) Add(name string, run CheckFunc) {
+	c.checks = append(c.checks, check{name: name, run: run})
+}
+
+// SetMigrationVersion sets how the database migration version of reports is read.
+func (c *Checker) SetMigrationVersion(version func(ctx context.Context) (int64, error)) {
+	c.migrationVersion = version
+}
+
+// MarkShuttingDown makes every following report fail so that load balancers
+// stop routing requests before connections are drained.
+func (c *Checker) MarkShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Check runs all dependency checks concurrently.
+func (c *Checker) Check(ctx context.Context) Report {
+	report := Report{
+		Status:           StatusOK,
+		Dependencies:     make(map[string]DependencyStatus, len(c.checks)),
+		MigrationVersion: nil,
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, chk := range c.checks {
+		wg.Go(func() {
+			status := c.run(ctx, chk.run)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Dependencies[chk.name] = status
+			if status.Status != StatusOK {
+				report.Status = StatusUnavailable
+			}
+		})
+	}
+	if c.migrationVersion != nil {
+		wg.Go(func() {
+			ctx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+			if version, err := c.migrationVersion(ctx); err == nil {
+				report.MigrationVersion = &version
+			}
+		})
+	}
+	wg.Wait()
+
+	if c.shuttingDown.Load() {
+		report.Status = StatusShuttingDown
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, run CheckFunc) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := run(ctx)
+	status := DependencyStatus{
+		Status:    StatusOK,
+		LatencyMS: float64(time.Since(start)) / float64(time.Millisecond),
+		Error:     "",
+	}
+	if err != nil {
+		status.Status = StatusUnavailable
+		status.Error = err.Error()
+	}
+	return status
+}
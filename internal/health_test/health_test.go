@@ -0,0 +1,69 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"contactsAI/contacts/internal/health"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckReportsEveryDependency(t *testing.T) {
+	checker := health.NewChecker(time.Second)
+	checker.Add("database", func(context.Context) error { return nil })
+	checker.Add("storage", func(context.Context) error { return errors.New("bucket not found") })
+
+	report := checker.Check(t.Context())
+
+	assert.Equal(t, health.StatusUnavailable, report.Status)
+	assert.Equal(t, health.StatusOK, report.Dependencies["database"].Status)
+	assert.Equal(t, health.StatusUnavailable, report.Dependencies["storage"].Status)
+	assert.Equal(t, "bucket not found", report.Dependencies["storage"].Error)
+	assert.Nil(t, report.MigrationVersion)
+
+	body, err := json.Marshal(report)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "bucket not found", "errors are logged, not reported")
+}
+
+func TestCheckReportsMigrationVersion(t *testing.T) {
+	checker := health.NewChecker(time.Second)
+	checker.SetMigrationVersion(func(context.Context) (int64, error) { return 3, nil })
+
+	report := checker.Check(t.Context())
+
+	assert.Equal(t, health.StatusOK, report.Status)
+	if assert.NotNil(t, report.MigrationVersion) {
+		assert.Equal(t, int64(3), *report.MigrationVersion)
+	}
+}
+
+func TestCheckTimesOutHangingDependencies(t *testing.T) {
+	checker := health.NewChecker(20 * time.Millisecond)
+	checker.Add("database", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	report := checker.Check(t.Context())
+
+	assert.Equal(t, health.StatusUnavailable, report.Status)
+	assert.GreaterOrEqual(t, report.Dependencies["database"].LatencyMS, 20.0)
+}
+
+func TestCheckFailsWhileShuttingDown(t *testing.T) {
+	checker := health.NewChecker(time.Second)
+	checker.Add("database", func(context.Context) error { return nil })
+	assert.Equal(t, health.StatusOK, checker.Check(t.Context()).Status)
+
+	checker.MarkShuttingDown()
+
+	report := checker.Check(t.Context())
+	assert.Equal(t, health.StatusShuttingDown, report.Status)
+	assert.Equal(t, health.StatusOK, report.Dependencies["database"].Status)
+}
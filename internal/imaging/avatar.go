@@ -0,0 +1,180 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"slices"
+
+	// Register decoders for the supported avatar formats.
+	_ "image/gif"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// MaxDimension and MaxPixels bound the decoded image so that small, highly
+	// compressed files cannot expand into gigabytes of pixels. 16 MP are about
+	// 64 MB decoded, 128 MB for 16-bit PNGs, plenty for an avatar.
+	MaxDimension = 8192
+	MaxPixels    = 16_000_000
+	// MaxHeaderLength bounds the bytes buffered to read the dimensions of an
+	// image before it is decoded. JPEG metadata such as EXIF and ICC profiles
+	// comes before the frame header.
+	MaxHeaderLength = 1 << 20
+
+	jpegQuality = 85
+	sniffLength = 512
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrInvalidImage      = errors.New("invalid image")
+	ErrImageTooLarge     = errors.New("image dimensions are too large")
+)
+
+// VariantSizes lists the square thumbnail sizes generated for every avatar.
+func VariantSizes() []int {
+	return []int{64, 256, 512}
+}
+
+// IsVariantSize reports whether size is one of VariantSizes.
+func IsVariantSize(size int) bool {
+	return slices.Contains(VariantSizes(), size)
+}
+
+// IsSupportedContentType reports whether contentType is an accepted avatar format.
+func IsSupportedContentType(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/webp", "image/gif":
+		return true
+	default:
+		return false
+	}
+}
+
+// Encoded is an image ready to be stored.
+type Encoded struct {
+	Data        []byte
+	ContentType string
+}
+
+// Avatar holds the sanitized original and its square thumbnails keyed by size.
+type Avatar struct {
+	Original Encoded
+	Variants map[int]Encoded
+}
+
+// ProcessAvatar sniffs, decodes and re-encodes an uploaded avatar. The original
+// is re-encoded with its EXIF orientation applied, which also drops all other
+// metadata such as GPS coordinates, and center-cropped thumbnails are generated
+// for every size in VariantSizes.
+//
+// r is streamed into the decoder: only the header, at most MaxHeaderLength
+// bytes, is buffered, and images larger than MaxPixels are rejected before
+// their pixels are decoded.
+func ProcessAvatar(r io.Reader) (*Avatar, error) {
+	img, format, orientation, err := decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// The center square is the same before and after rotating, so thumbnails
+	// are cropped and scaled first and only their few pixels are rotated.
+	square := centerCrop(img)
+	variants := make(map[int]Encoded, len(VariantSizes()))
+	for _, size := range VariantSizes() {
+		thumb := image.NewNRGBA(image.Rect(0, 0, size, size))
+		xdraw.CatmullRom.Scale(thumb, thumb.Bounds(), square, square.Bounds(), draw.Src, nil)
+		variants[size], err = encode(applyOrientation(thumb, orientation), format)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	original, err := encode(applyOrientation(img, orientation), format)
+	if err != nil {
+		return nil, err
+	}
+	return &Avatar{Original: original, Variants: variants}, nil
+}
+
+// decode returns the image as stored together with its EXIF orientation.
+func decode(r io.Reader) (image.Image, string, int, error) {
+	buffered := bufio.NewReaderSize(r, sniffLength)
+	head, err := buffered.Peek(sniffLength)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, "", 0, err
+	}
+	if !IsSupportedContentType(http.DetectContentType(head)) {
+		return nil, "", 0, ErrUnsupportedFormat
+	}
+
+	// Keep the bytes consumed by DecodeConfig so they can be replayed to Decode
+	// and inspected for EXIF data, which precedes the frame header in JPEGs.
+	var header bytes.Buffer
+	cfg, format, err := image.DecodeConfig(io.TeeReader(io.LimitReader(buffered, MaxHeaderLength), &header))
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("%w: %w", ErrInvalidImage, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, "", 0, ErrInvalidImage
+	}
+	if cfg.Width > MaxDimension || cfg.Height > MaxDimension || cfg.Width*cfg.Height > MaxPixels {
+		return nil, "", 0, ErrImageTooLarge
+	}
+
+	orientation := 1
+	if format == "jpeg" {
+		orientation = jpegOrientation(header.Bytes())
+	}
+
+	img, _, err := image.Decode(io.MultiReader(&header, buffered))
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("%w: %w", ErrInvalidImage, err)
+	}
+	return img, format, orientation, nil
+}
+
+// encode writes JPEG sources back as JPEG and everything else as PNG, which
+// keeps transparency and has an encoder in the standard library.
+func encode(img image.Image, format string) (Encoded, error) {
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return Encoded{}, err
+		}
+		return Encoded{Data: buf.Bytes(), ContentType: "image/jpeg"}, nil
+	}
+	if err := png.Encode(&buf, img); err != nil {
+		return Encoded{}, err
+	}
+	return Encoded{Data: buf.Bytes(), ContentType: "image/png"}, nil
+}
+
+// centerCrop returns the largest centered square of img. All decoders of the
+// supported formats return images that share their pixels with a SubImage, so
+// nothing is copied.
+func centerCrop(img image.Image) image.Image {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	x0 := bounds.Min.X + (bounds.Dx()-side)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-side)/2
+	rect := image.Rect(x0, y0, x0+side, y0+side)
+	if sub, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(rect)
+	}
+	crop := image.NewNRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(crop, crop.Bounds(), img, rect.Min, draw.Src)
+	return crop
+}
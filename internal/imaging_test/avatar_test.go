@@ -0,0 +1,165 @@
+package imaging_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"contactsAI/contacts/internal/imaging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func solidImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// withOrientation inserts an EXIF APP1 segment carrying the given orientation after the SOI marker.
+func withOrientation(jpg []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	ifd := make([]byte, 2+12+4)
+	binary.BigEndian.PutUint16(ifd[0:], 1)
+	binary.BigEndian.PutUint16(ifd[2:], 0x0112)
+	binary.BigEndian.PutUint16(ifd[4:], 3)
+	binary.BigEndian.PutUint32(ifd[6:], 1)
+	binary.BigEndian.PutUint16(ifd[10:], orientation)
+	payload := append(append([]byte("Exif\x00\x00"), tiff...), ifd...)
+
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+func TestProcessAvatarGeneratesVariants(t *testing.T) {
+	avatar, err := imaging.ProcessAvatar(bytes.NewReader(encodePNG(t, solidImage(300, 200))))
+	require.NoError(t, err)
+
+	assert.Equal(t, "image/png", avatar.Original.ContentType)
+	original, err := png.Decode(bytes.NewReader(avatar.Original.Data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 300, 200), original.Bounds())
+
+	require.Len(t, avatar.Variants, len(imaging.VariantSizes()))
+	for _, size := range imaging.VariantSizes() {
+		variant, decodeErr := png.Decode(bytes.NewReader(avatar.Variants[size].Data))
+		require.NoError(t, decodeErr)
+		assert.Equal(t, image.Rect(0, 0, size, size), variant.Bounds())
+	}
+}
+
+func TestProcessAvatarAppliesOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, solidImage(40, 20), nil))
+
+	avatar, err := imaging.ProcessAvatar(bytes.NewReader(withOrientation(buf.Bytes(), 6)))
+	require.NoError(t, err)
+
+	assert.Equal(t, "image/jpeg", avatar.Original.ContentType)
+	assert.NotContains(t, string(avatar.Original.Data), "Exif")
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(avatar.Original.Data))
+	require.NoError(t, err)
+	assert.Equal(t, 20, cfg.Width)
+	assert.Equal(t, 40, cfg.Height)
+}
+
+func TestProcessAvatarRotatesThumbnails(t *testing.T) {
+	// The left half is red and the right half blue, so turning the image
+	// clockwise puts red on top.
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	for y := range 20 {
+		for x := range 40 {
+			if x < 20 {
+				img.Set(x, y, color.NRGBA{R: 255, G: 0, B: 0, A: 255})
+			} else {
+				img.Set(x, y, color.NRGBA{R: 0, G: 0, B: 255, A: 255})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}))
+
+	avatar, err := imaging.ProcessAvatar(bytes.NewReader(withOrientation(buf.Bytes(), 6)))
+	require.NoError(t, err)
+	thumb, err := jpeg.Decode(bytes.NewReader(avatar.Variants[64].Data))
+	require.NoError(t, err)
+
+	top, _, topBlue, _ := thumb.At(32, 8).RGBA()
+	bottom, _, bottomBlue, _ := thumb.At(32, 56).RGBA()
+	assert.Greater(t, top, topBlue, "top is red")
+	assert.Greater(t, bottomBlue, bottom, "bottom is blue")
+}
+
+func TestProcessAvatarRejectsNonImages(t *testing.T) {
+	_, err := imaging.ProcessAvatar(strings.NewReader("<html><body>not an image</body></html>"))
+	require.ErrorIs(t, err, imaging.ErrUnsupportedFormat)
+}
+
+func TestProcessAvatarRejectsOversizedDimensions(t *testing.T) {
+	data := encodePNG(t, image.NewGray(image.Rect(0, 0, imaging.MaxDimension+1, 1)))
+	_, err := imaging.ProcessAvatar(bytes.NewReader(data))
+	require.ErrorIs(t, err, imaging.ErrImageTooLarge)
+}
+
+func TestProcessAvatarRejectsTooManyPixels(t *testing.T) {
+	data := encodePNG(t, image.NewGray(image.Rect(0, 0, 4001, 4001)))
+	_, err := imaging.ProcessAvatar(bytes.NewReader(data))
+	require.ErrorIs(t, err, imaging.ErrImageTooLarge)
+}
+
+func TestProcessAvatarRejectsTruncatedImages(t *testing.T) {
+	data := encodePNG(t, solidImage(64, 64))
+	_, err := imaging.ProcessAvatar(bytes.NewReader(data[:len(data)/2]))
+	require.ErrorIs(t, err, imaging.ErrInvalidImage)
+}
+
+// countingReader counts the bytes read from it.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestProcessAvatarBoundsTheHeader(t *testing.T) {
+	// A JPEG whose metadata segments never end in a frame header.
+	data := []byte{0xFF, 0xD8}
+	segment := make([]byte, 0xFFFF+2)
+	segment[0], segment[1] = 0xFF, 0xE2
+	binary.BigEndian.PutUint16(segment[2:], 0xFFFF)
+	for len(data) < 2*imaging.MaxHeaderLength {
+		data = append(data, segment...)
+	}
+
+	r := &countingReader{r: bytes.NewReader(data), n: 0}
+	_, err := imaging.ProcessAvatar(r)
+	require.ErrorIs(t, err, imaging.ErrInvalidImage)
+	assert.LessOrEqual(t, r.n, imaging.MaxHeaderLength+4096, "only the header is read")
+}
@@ -0,0 +1,96 @@
+// Package leader elects one replica to run a job, using a PostgreSQL advisory
+// lock that the leader holds on one of its connections. PostgreSQL releases
+// the lock when that connection ends, so a crashed leader is replaced by
+// another replica at its next attempt.
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// unlockTimeout bounds releasing the lock once the job stopped.
+const unlockTimeout = 5 * time.Second
+
+// Elector runs a job on the replica that holds an advisory lock.
+type Elector struct {
+	Pool   *pgxpool.Pool
+	Logger *slog.Logger
+	// Key identifies the advisory lock and must be the same on every replica.
+	Key int64
+	// Retry is how often a follower tries to take the lock, and how often the
+	// leader checks that its connection is alive.
+	Retry time.Duration
+}
+
+// Run calls lead whenever this replica takes the lock, until ctx is done.
+// The context of lead is cancelled when the connection holding the lock is
+// lost, and lead must return then.
+func (e Elector) Run(ctx context.Context, lead func(ctx context.Context)) error {
+	ticker := time.NewTicker(e.Retry)
+	defer ticker.Stop()
+	for {
+		if err := e.tryLead(ctx, lead); err != nil && ctx.Err() == nil {
+			e.Logger.Warn("Leader election failed", "lock", e.Key, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// tryLead takes the lock if it is free and runs lead while holding it.
+func (e Elector) tryLead(ctx context.Context, lead func(ctx context.Context)) error {
+	conn, err := e.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err = conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", e.Key).Scan(&locked); err != nil || !locked {
+		return err
+	}
+	e.Logger.Info("Elected leader", "lock", e.Key)
+
+	leadCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lead(leadCtx)
+	}()
+
+	ticker := time.NewTicker(e.Retry)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return e.unlock(ctx, conn)
+		case <-ticker.C:
+			if err = conn.Ping(leadCtx); err != nil && ctx.Err() == nil {
+				cancel()
+				<-done
+				// The lock may outlive an unhealthy connection unless it is closed.
+				closeErr := conn.Conn().Close(context.WithoutCancel(ctx))
+				return errors.Join(fmt.Errorf("lost leadership: %w", err), closeErr)
+			}
+		}
+	}
+}
+
+func (e Elector) unlock(ctx context.Context, conn *pgxpool.Conn) error {
+	unlockCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), unlockTimeout)
+	defer cancel()
+	if _, err := conn.Exec(unlockCtx, "SELECT pg_advisory_unlock($1)", e.Key); err != nil {
+		return errors.Join(err, conn.Conn().Close(unlockCtx))
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"contactsAI/contacts/internal/tracing"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+
+	// visibleDigits is how many trailing digits of a phone number stay readable.
+	visibleDigits = 2
+)
+
+type contextKey struct{}
+
+// New returns a logger writing records in format to w. Its level is read from
+// level on every record, so that a *slog.LevelVar changes it at runtime.
+// Phone numbers are masked and records logged with a traced context carry the
+// trace and span IDs.
+func New(w io.Writer, format string, level slog.Leveler) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{AddSource: false, Level: level, ReplaceAttr: maskSensitive}
+
+	var handler slog.Handler
+	switch format {
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+	return slog.New(tracing.LogHandler(handler)), nil
+}
+
+// NewContext returns a copy of ctx carrying logger.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the request-scoped logger stored in ctx, or the default
+// logger outside of a request.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// maskSensitive masks the attributes whose key or group names a phone number,
+// e.g. "phone" or "phone_number".
+func maskSensitive(groups []string, attr slog.Attr) slog.Attr {
+	if isSensitive(attr.Key) || slices.ContainsFunc(groups, isSensitive) {
+		attr.Value = maskValue(attr.Value)
+	}
+	return attr
+}
+
+func isSensitive(key string) bool {
+	return strings.Contains(strings.ToLower(key), "phone")
+}
+
+// maskValue masks strings, lists of strings and the values of groups, also
+// when a slog.LogValuer resolves to them.
+func maskValue(v slog.Value) slog.Value {
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.StringValue(MaskPhone(v.String()))
+	case slog.KindLogValuer:
+		return maskValue(v.Resolve())
+	case slog.KindGroup:
+		attrs := v.Group()
+		masked := make([]slog.Attr, len(attrs))
+		for i, attr := range attrs {
+			masked[i] = slog.Attr{Key: attr.Key, Value: maskValue(attr.Value)}
+		}
+		return slog.GroupValue(masked...)
+	case slog.KindAny:
+		if phones, ok := v.Any().([]string); ok {
+			masked := make([]string, len(phones))
+			for i, phone := range phones {
+				masked[i] = MaskPhone(phone)
+			}
+			return slog.AnyValue(masked)
+		}
+	}
+	return v
+}
+
+// MaskPhone replaces every digit but the last two with '*', keeping separators
+// so that the shape of the number is still recognizable.
+func MaskPhone(phone string) string {
+	digits := 0
+	for _, r := range phone {
+		if isDigit(r) {
+			digits++
+		}
+	}
+
+	var b strings.Builder
+	b.Grow(len(phone))
+	for _, r := range phone {
+		if isDigit(r) {
+			if digits > visibleDigits {
+				r = '*'
+			}
+			digits--
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
@@ -0,0 +1,91 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"contactsAI/contacts/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaskPhone(t *testing.T) {
+	tests := map[string]string{
+		"888-999-000":     "***-***-*00",
+		"+48 123 456 789": "+** *** *** *89",
+		"12":              "12",
+		"":                "",
+	}
+	for phone, want := range tests {
+		assert.Equal(t, want, logging.MaskPhone(phone), phone)
+	}
+}
+
+func TestNewMasksPhoneNumbers(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := logging.New(&out, logging.FormatJSON, slog.LevelInfo)
+	require.NoError(t, err)
+
+	logger.Info("Created contact", "name", "Jan", slog.Group("contact", "phone", "888-999-000"))
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(t, "Jan", record["name"])
+	assert.Equal(t, map[string]any{"phone": "***-***-*00"}, record["contact"])
+}
+
+// contact logs itself as a group.
+type contact struct{ phone string }
+
+func (c contact) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("phone", c.phone))
+}
+
+func TestNewMasksNestedPhoneNumbers(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := logging.New(&out, logging.FormatJSON, slog.LevelInfo)
+	require.NoError(t, err)
+
+	logger.Info("Updated contact",
+		slog.Any("contact", contact{phone: "888-999-000"}),
+		slog.Group("phones", "home", "888-999-001"),
+		slog.Any("phone_numbers", []string{"888-999-002"}),
+	)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(t, map[string]any{"phone": "***-***-*00"}, record["contact"])
+	assert.Equal(t, map[string]any{"home": "***-***-*01"}, record["phones"])
+	assert.Equal(t, []any{"***-***-*02"}, record["phone_numbers"])
+}
+
+func TestNewFollowsLevelChanges(t *testing.T) {
+	var out bytes.Buffer
+	level := new(slog.LevelVar)
+	logger, err := logging.New(&out, logging.FormatText, level)
+	require.NoError(t, err)
+
+	logger.Debug("hidden")
+	level.Set(slog.LevelDebug)
+	logger.Debug("shown")
+
+	assert.NotContains(t, out.String(), "hidden")
+	assert.Contains(t, out.String(), "msg=shown")
+}
+
+func TestNewRejectsUnknownFormat(t *testing.T) {
+	_, err := logging.New(&bytes.Buffer{}, "xml", slog.LevelInfo)
+	assert.Error(t, err)
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Same(t, slog.Default(), logging.FromContext(context.Background()))
+
+	logger := slog.New(slog.DiscardHandler)
+	ctx := logging.NewContext(context.Background(), logger)
+	assert.Same(t, logger, logging.FromContext(ctx))
+}
@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"contactsAI/contacts/internal/bucket"
+)
+
+// InstrumentBlobStore wraps store to count operations, their latency and the
+// number of transferred bytes.
+func (m *Metrics) InstrumentBlobStore(store bucket.BlobStore) bucket.BlobStore {
+	return &instrumentedStore{store: store, m: m}
+}
+
+type instrumentedStore struct {
+	store bucket.BlobStore
+	m     *Metrics
+}
+
+func (s *instrumentedStore) Upload(
+	ctx context.Context, key string, body io.Reader, size int64, contentType string,
+) error {
+	start := time.Now()
+	counted := &countingReader{reader: body, n: 0}
+	err := s.store.Upload(ctx, key, counted, size, contentType)
+	s.observe("upload", start, err)
+	s.m.bucketBytes.WithLabelValues("upload").Add(float64(counted.n))
+	return err
+}
+
+func (s *instrumentedStore) GetStream(ctx context.Context, key string, opts bucket.GetOptions) (*bucket.Object, error) {
+	start := time.Now()
+	object, err := s.store.GetStream(ctx, key, opts)
+	s.observe("get", start, err)
+	if err != nil {
+		return nil, err
+	}
+	object.Body = &countingBody{
+		ReadCloser: object.Body,
+		done:       func(n int64) { s.m.bucketBytes.WithLabelValues("get").Add(float64(n)) },
+		n:          0,
+	}
+	return object, nil
+}
+
+func (s *instrumentedStore) Head(ctx context.Context, key string, opts bucket.GetOptions) (*bucket.ObjectInfo, error) {
+	start := time.Now()
+	info, err := s.store.Head(ctx, key, opts)
+	s.observe("head", start, err)
+	return info, err
+}
+
+func (s *instrumentedStore) Delete(ctx context.Context, key string) error {
+	start := time.Now()
+	err := s.store.Delete(ctx, key)
+	s.observe("delete", start, err)
+	return err
+}
+
+func (s *instrumentedStore) PresignPut(
+	ctx context.Context, key, contentType string, size int64, ttl time.Duration,
+) (*bucket.PresignedRequest, error) {
+	start := time.Now()
+	req, err := s.store.PresignPut(ctx, key, contentType, size, ttl)
+	s.observe("presign_put", start, err)
+	return req, err
+}
+
+func (s *instrumentedStore) PresignGet(ctx context.Context, key string, ttl time.Duration) (*bucket.PresignedRequest, error) {
+	start := time.Now()
+	req, err := s.store.PresignGet(ctx, key, ttl)
+	s.observe("presign_get", start, err)
+	return req, err
+}
+
+func (s *instrumentedStore) Ping(ctx context.Context) error {
+	start := time.Now()
+	err := s.store.Ping(ctx)
+	s.observe("ping", start, err)
+	return err
+}
+
+func (s *instrumentedStore) observe(operation string, start time.Time, err error) {
+	s.m.bucketTime.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	s.m.bucketOps.WithLabelValues(operation, bucketResult(err)).Inc()
+}
+
+// bucketResult keeps expected outcomes such as cache hits apart from failures.
+func bucketResult(err error) string {
+	switch {
+	case err == nil:
+		return resultOK
+	case errors.Is(err, bucket.ErrNotFound):
+		return "not_found"
+	case errors.Is(err, bucket.ErrNotModified):
+		return "not_modified"
+	case errors.Is(err, bucket.ErrInvalidRange):
+		return "invalid_range"
+	case errors.Is(err, bucket.ErrPresignNotSupported):
+		return "not_supported"
+	default:
+		return resultError
+	}
+}
+
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// countingBody reports the bytes read from an object body when it is closed.
+type countingBody struct {
+	io.ReadCloser
+
+	done func(n int64)
+	n    int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	b.done(b.n)
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"contactsAI/contacts/internal/db"
+)
+
+// BusinessWorker returns a background worker that refreshes the entity gauges
+// every interval until its context is cancelled.
+func (m *Metrics) BusinessWorker(queries db.Querier, logger *slog.Logger, interval time.Duration) func(context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := m.RefreshBusiness(ctx, queries); err != nil && ctx.Err() == nil {
+				logger.Warn("Failed to refresh business metrics", "error", err)
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// RefreshBusiness updates the entity and avatar storage gauges from the
+// database.
+func (m *Metrics) RefreshBusiness(ctx context.Context, queries db.Querier) error {
+	stats, err := queries.GetContactStats(ctx)
+	if err != nil {
+		return err
+	}
+	m.business.WithLabelValues("contacts").Set(float64(stats.Contacts))
+	m.business.WithLabelValues("avatars").Set(float64(stats.Avatars))
+	m.business.WithLabelValues("owners").Set(float64(stats.Owners))
+	m.avatarBytes.Set(float64(stats.AvatarBytes))
+	return nil
+}
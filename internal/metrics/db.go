@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"contactsAI/contacts/internal/db"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	resultOK    = "ok"
+	resultError = "error"
+)
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	name string
+	at   time.Time
+}
+
+// QueryTracer returns a pgx tracer recording the latency of every query,
+// labeled with the sqlc query name.
+func (m *Metrics) QueryTracer() pgx.QueryTracer {
+	return queryTracer{m: m}
+}
+
+type queryTracer struct {
+	m *Metrics
+}
+
+func (t queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{name: db.QueryName(data.SQL), at: time.Now()})
+}
+
+func (t queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	result := resultOK
+	if data.Err != nil {
+		result = resultError
+	}
+	t.m.dbQueries.WithLabelValues(start.name, result).Observe(time.Since(start.at).Seconds())
+}
+
+// RegisterPool exports the connection statistics of pool.
+func (m *Metrics) RegisterPool(pool *pgxpool.Pool) {
+	m.registry.MustRegister(&poolCollector{pool: pool, descs: newPoolDescs()})
+}
+
+type poolDescs struct {
+	acquired, idle, total, maxConns *prometheus.Desc
+	acquires, emptyAcquires         *prometheus.Desc
+	canceledAcquires                *prometheus.Desc
+	acquireDuration                 *prometheus.Desc
+}
+
+func newPoolDescs() poolDescs {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+	return poolDescs{
+		acquired:         desc("acquired_connections", "Connections currently in use."),
+		idle:             desc("idle_connections", "Idle connections in the pool."),
+		total:            desc("total_connections", "Open connections in the pool."),
+		maxConns:         desc("max_connections", "Maximum size of the pool."),
+		acquires:         desc("acquires_total", "Successful connection acquires."),
+		emptyAcquires:    desc("empty_acquires_total", "Acquires that had to wait for a connection."),
+		canceledAcquires: desc("canceled_acquires_total", "Acquires canceled by their context."),
+		acquireDuration:  desc("acquire_duration_seconds_total", "Total time spent acquiring connections."),
+	}
+}
+
+type poolCollector struct {
+	pool  *pgxpool.Pool
+	descs poolDescs
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		c.descs.acquired, c.descs.idle, c.descs.total, c.descs.maxConns,
+		c.descs.acquires, c.descs.emptyAcquires, c.descs.canceledAcquires, c.descs.acquireDuration,
+	} {
+		ch <- d
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	gauge := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+	counter := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value)
+	}
+	gauge(c.descs.acquired, float64(stat.AcquiredConns()))
+	gauge(c.descs.idle, float64(stat.IdleConns()))
+	gauge(c.descs.total, float64(stat.TotalConns()))
+	gauge(c.descs.maxConns, float64(stat.MaxConns()))
+	counter(c.descs.acquires, float64(stat.AcquireCount()))
+	counter(c.descs.emptyAcquires, float64(stat.EmptyAcquireCount()))
+	counter(c.descs.canceledAcquires, float64(stat.CanceledAcquireCount()))
+	counter(c.descs.acquireDuration, stat.AcquireDuration().Seconds())
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "contacts"
+
+// Metrics owns a registry with all collectors of the service. Each Env gets
+// its own, so tests can build several routers in one process.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	dbQueries    *prometheus.HistogramVec
+	bucketOps    *prometheus.CounterVec
+	bucketTime   *prometheus.HistogramVec
+	bucketBytes  *prometheus.CounterVec
+	business     *prometheus.GaugeVec
+	avatarBytes  prometheus.Gauge
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests by method, route template and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by method and route template.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		dbQueries: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "query_duration_seconds",
+			Help:      "Database query latency by sqlc query name and result.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"query", "result"}),
+		bucketOps: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "bucket",
+			Name:      "operations_total",
+			Help:      "Blob storage operations by operation and result.",
+		}, []string{"operation", "result"}),
+		bucketTime: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "bucket",
+			Name:      "operation_duration_seconds",
+			Help:      "Blob storage operation latency by operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation"}),
+		bucketBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "bucket",
+			Name:      "transferred_bytes_total",
+			Help:      "Bytes uploaded to and downloaded from blob storage.",
+		}, []string{"operation"}),
+		business: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "entities",
+			Help:      "Number of stored entities by kind, refreshed periodically.",
+		}, []string{"kind"}),
+		avatarBytes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "avatar_storage_bytes",
+			Help:      "Total size of the stored avatars, refreshed periodically.",
+		}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}), //nolint:exhaustruct // defaults
+		m.httpRequests,
+		m.httpDuration,
+		m.dbQueries,
+		m.bucketOps,
+		m.bucketTime,
+		m.bucketBytes,
+		m.business,
+		m.avatarBytes,
+	)
+	return m
+}
+
+// Registry exposes the registry, e.g. to register additional collectors.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	//nolint:exhaustruct // defaults
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
@@ -0,0 +1,63 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"contactsAI/contacts/internal/bucket"
+	"contactsAI/contacts/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	return w.Body.String()
+}
+
+func TestMiddlewareLabelsRouteTemplates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := metrics.New()
+	router := gin.New()
+	router.Use(m.Middleware())
+	router.GET("/api/contacts/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, path := range []string{"/api/contacts/1", "/api/contacts/2", "/does-not-exist"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	out := scrape(t, m)
+	assert.Contains(t, out, `contacts_http_requests_total{method="GET",route="/api/contacts/:id",status="204"} 2`)
+	assert.Contains(t, out, `contacts_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.NotContains(t, out, "/api/contacts/1")
+}
+
+func TestInstrumentBlobStore(t *testing.T) {
+	m := metrics.New()
+	store := m.InstrumentBlobStore(bucket.NewMemoryStore())
+	ctx := context.Background()
+
+	require.NoError(t, store.Upload(ctx, "a", strings.NewReader("hello"), 5, "text/plain"))
+	object, err := store.GetStream(ctx, "a", bucket.GetOptions{})
+	require.NoError(t, err)
+	_, err = io.ReadAll(object.Body)
+	require.NoError(t, err)
+	require.NoError(t, object.Body.Close())
+	_, err = store.Head(ctx, "missing", bucket.GetOptions{})
+	require.ErrorIs(t, err, bucket.ErrNotFound)
+
+	out := scrape(t, m)
+	assert.Contains(t, out, `contacts_bucket_operations_total{operation="upload",result="ok"} 1`)
+	assert.Contains(t, out, `contacts_bucket_operations_total{operation="head",result="not_found"} 1`)
+	assert.Contains(t, out, `contacts_bucket_transferred_bytes_total{operation="upload"} 5`)
+	assert.Contains(t, out, `contacts_bucket_transferred_bytes_total{operation="get"} 5`)
+}
@@ -7,19 +7,36 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-const maxCorsAge = 12
-
-func setupCORS(router *gin.Engine) {
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"http://localhost:5173"}
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
-	config.AllowCredentials = true
-	config.MaxAge = maxCorsAge * time.Hour
-
-	router.Use(cors.New(config))
+// CORSPolicy describes the cross-origin requests browsers may make.
+type CORSPolicy struct {
+	// AllowedOrigins are origins like "https://app.example.com", patterns whose
+	// leftmost label is a wildcard like "https://*.example.com", or "*" for any
+	// origin.
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers that scripts may read.
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache the result of a preflight request.
+	MaxAge time.Duration
 }
 
-func SetupMiddlewares(router *gin.Engine) {
-	setupCORS(router)
+// CORS answers preflight requests and adds CORS headers to the responses to
+// allowed origins; requests from other origins are rejected. Without allowed
+// origins it does nothing, so browsers refuse cross-origin requests.
+func CORS(policy CORSPolicy) gin.HandlerFunc {
+	if len(policy.AllowedOrigins) == 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	//nolint:exhaustruct // the remaining options are for non-HTTP schemes and custom origin checks
+	return cors.New(cors.Config{
+		AllowOrigins:     policy.AllowedOrigins,
+		AllowWildcard:    true,
+		AllowMethods:     policy.AllowedMethods,
+		AllowHeaders:     policy.AllowedHeaders,
+		ExposeHeaders:    policy.ExposedHeaders,
+		AllowCredentials: policy.AllowCredentials,
+		MaxAge:           policy.MaxAge,
+	})
 }
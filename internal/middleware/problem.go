@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	"contactsAI/contacts/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 9457 problem details response.
+type Problem struct {
+	Type    string `json:"type"`
+	Title   string `json:"title"`
+	Status  int    `json:"status"`
+	Detail  string `json:"detail,omitempty"`
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+// AbortWithProblem stops the handler chain with a problem response of the
+// given status.
+func AbortWithProblem(c *gin.Context, status int, detail string) {
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(status, Problem{
+		Type:    "about:blank",
+		Title:   http.StatusText(status),
+		Status:  status,
+		Detail:  detail,
+		TraceID: tracing.TraceID(c.Request.Context()),
+	})
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"contactsAI/contacts/internal/logging"
+	"contactsAI/contacts/internal/ratelimit"
+	"contactsAI/contacts/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitPolicy maps routes to limits.
+type RateLimitPolicy struct {
+	Default ratelimit.Limit
+	// Routes replaces Default for the routes keyed by method and template,
+	// e.g. "PUT /api/contacts/:id/avatar". Each route has its own buckets.
+	Routes map[string]ratelimit.Limit
+}
+
+// RateLimit allows every client a limited rate of requests and rejects the
+// rest with 429 Too Many Requests. Clients are identified by user, then API
+// key, then IP address, which honors X-Forwarded-For only from the engine's
+// trusted proxies. If the store fails the request is let through.
+func RateLimit(store ratelimit.Store, policy RateLimitPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name, limit := "default", policy.Default
+		route := c.Request.Method + " " + c.FullPath()
+		if routeLimit, ok := policy.Routes[route]; ok {
+			name, limit = route, routeLimit
+		}
+		take(c, store, name+"|"+clientKey(c), limit)
+	}
+}
+
+// IPRateLimit allows every client IP a limited rate of requests, whoever makes
+// them. It runs before authentication, so that it also limits requests with
+// invalid API keys.
+func IPRateLimit(store ratelimit.Store, limit ratelimit.Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		take(c, store, "ip|"+c.ClientIP(), limit)
+	}
+}
+
+// take takes a token of the bucket key and continues, or rejects the request
+// if the bucket is empty.
+func take(c *gin.Context, store ratelimit.Store, key string, limit ratelimit.Limit) {
+	ctx := c.Request.Context()
+	result, err := store.Take(ctx, key, limit)
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "Rate limit store failed", "error", err)
+		c.Next()
+		return
+	}
+
+	c.Header("RateLimit-Limit", strconv.Itoa(limit.Burst))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Burst, ceilSeconds(limit.Period)))
+	if !result.Allowed {
+		retryAfter := ceilSeconds(result.RetryAfter)
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		AbortWithProblem(c, http.StatusTooManyRequests,
+			fmt.Sprintf("Rate limit of %d requests per %s exceeded, retry in %ds", limit.Burst, limit.Period, retryAfter))
+		return
+	}
+	c.Next()
+}
+
+func clientKey(c *gin.Context) string {
+	if userID, ok := c.Get(UserIDKey); ok {
+		// User IDs are only unique within their tenant.
+		tenantID, _ := tenant.FromContext(c.Request.Context())
+		return fmt.Sprintf("tenant:%d:user:%v", tenantID, userID)
+	}
+	if keyID, ok := c.Get(APIKeyIDKey); ok {
+		return fmt.Sprintf("key:%v", keyID)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
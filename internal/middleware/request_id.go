@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDKey is the gin context key holding the request ID.
+	RequestIDKey = "requestID"
+	// UserIDKey is the gin context key under which authentication stores the
+	// ID of the calling user, if any.
+	UserIDKey = "userID"
+	// APIKeyIDKey is the gin context key under which authentication stores the
+	// ID of the API key used for the request, if any.
+	APIKeyIDKey = "apiKeyID"
+)
+
+// RequestID accepts the X-Request-ID header of the client or generates a new
+// ID, stores it in the context and echoes it in the response.
+func RequestID() gin.HandlerFunc {
+	// Client-supplied IDs are limited to short tokens that are safe to echo in
+	// headers and logs.
+	validRequestID := regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = rand.Text()
+		}
+		c.Set(RequestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
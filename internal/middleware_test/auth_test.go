@@ -0,0 +1,113 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"contactsAI/contacts/internal/apikey"
+	"contactsAI/contacts/internal/middleware"
+	"contactsAI/contacts/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAuthenticator map[string]*apikey.Principal
+
+func (f fakeAuthenticator) Authenticate(_ context.Context, secret string) (*apikey.Principal, error) {
+	switch secret {
+	case "revoked":
+		return nil, apikey.ErrRevokedKey
+	case "broken":
+		return nil, errors.New("database unavailable")
+	}
+	if principal, ok := f[secret]; ok {
+		return principal, nil
+	}
+	return nil, apikey.ErrInvalidKey
+}
+
+func newAuthRouter(t *testing.T, required bool) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	authn := fakeAuthenticator{
+		"reader": {KeyID: 1, OwnerID: 1, TenantID: 1, Scopes: []string{apikey.ScopeContactsRead}},
+		"admin":  {KeyID: 2, OwnerID: 1, TenantID: 2, Scopes: []string{apikey.ScopeAdmin}},
+	}
+	scopes := map[string]string{
+		"GET /contacts":  apikey.ScopeContactsRead,
+		"POST /contacts": apikey.ScopeContactsWrite,
+		"GET /admin":     apikey.ScopeAdmin,
+	}
+	router := gin.New()
+	router.Use(middleware.Authenticate(authn), middleware.Authorize(scopes, required))
+	ok := func(c *gin.Context) {
+		keyID, _ := c.Get(middleware.APIKeyIDKey)
+		userID, hasUser := c.Get(middleware.UserIDKey)
+		tenantID, ok := tenant.FromContext(c.Request.Context())
+		assert.True(t, ok, "every request should have a tenant")
+		if principal := apikey.FromContext(c.Request.Context()); principal != nil {
+			assert.Equal(t, principal.KeyID, keyID)
+			assert.Equal(t, principal.OwnerID, userID)
+			assert.Equal(t, principal.TenantID, tenantID)
+		} else {
+			assert.False(t, hasUser)
+			assert.Equal(t, tenant.DefaultID, tenantID)
+		}
+		c.Status(http.StatusNoContent)
+	}
+	router.GET("/contacts", ok)
+	router.POST("/contacts", ok)
+	router.GET("/admin", ok)
+	router.GET("/undeclared", ok)
+	return router
+}
+
+func TestAuthenticateAndAuthorize(t *testing.T) {
+	router := newAuthRouter(t, false)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		status  int
+	}{
+		{"anonymous read", http.MethodGet, "/contacts", nil, http.StatusNoContent},
+		{"anonymous admin", http.MethodGet, "/admin", nil, http.StatusUnauthorized},
+		{"bearer token", http.MethodGet, "/contacts", map[string]string{"Authorization": "Bearer reader"}, http.StatusNoContent},
+		{"api key header", http.MethodGet, "/contacts", map[string]string{"X-API-Key": "reader"}, http.StatusNoContent},
+		{"missing scope", http.MethodPost, "/contacts", map[string]string{"X-API-Key": "reader"}, http.StatusForbidden},
+		{"admin implies scopes", http.MethodPost, "/contacts", map[string]string{"X-API-Key": "admin"}, http.StatusNoContent},
+		{"admin route", http.MethodGet, "/admin", map[string]string{"X-API-Key": "admin"}, http.StatusNoContent},
+		{"reader on admin route", http.MethodGet, "/admin", map[string]string{"X-API-Key": "reader"}, http.StatusForbidden},
+		{"unknown key", http.MethodGet, "/contacts", map[string]string{"X-API-Key": "ck_nope"}, http.StatusUnauthorized},
+		{"revoked key", http.MethodGet, "/contacts", map[string]string{"X-API-Key": "revoked"}, http.StatusUnauthorized},
+		{"store failure", http.MethodGet, "/contacts", map[string]string{"X-API-Key": "broken"}, http.StatusInternalServerError},
+		{"undeclared route", http.MethodGet, "/undeclared", map[string]string{"X-API-Key": "admin"}, http.StatusForbidden},
+		{"unknown route", http.MethodGet, "/missing", nil, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := request(router, tt.method, tt.path, "192.0.2.1:1234", tt.headers)
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusUnauthorized {
+				assert.Equal(t, `Bearer realm="contacts"`, w.Header().Get("WWW-Authenticate"))
+				assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestAuthorizeRequired(t *testing.T) {
+	router := newAuthRouter(t, true)
+
+	w := request(router, http.MethodGet, "/contacts", "192.0.2.1:1234", nil)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = request(router, http.MethodGet, "/contacts", "192.0.2.1:1234", map[string]string{"X-API-Key": "reader"})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
@@ -0,0 +1,117 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"contactsAI/contacts/internal/logging"
+	"contactsAI/contacts/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRouter returns a router with request IDs and access logging writing JSON
+// records to out.
+func newRouter(t *testing.T, out *bytes.Buffer) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	logger, err := logging.New(out, logging.FormatJSON, slog.LevelInfo)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(middleware.RequestID(), middleware.AccessLog(logger), middleware.Recovery())
+	return router
+}
+
+func decodeRecords(t *testing.T, out *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	decoder := json.NewDecoder(out)
+	for decoder.More() {
+		var record map[string]any
+		require.NoError(t, decoder.Decode(&record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestRequestIDIsAcceptedOrGenerated(t *testing.T) {
+	router := newRouter(t, &bytes.Buffer{})
+	router.GET("/", func(c *gin.Context) { c.String(http.StatusOK, c.GetString(middleware.RequestIDKey)) })
+
+	tests := []struct {
+		name, header string
+		kept         bool
+	}{
+		{name: "client ID", header: "abc-123", kept: true},
+		{name: "missing", header: "", kept: false},
+		{name: "unsafe", header: "bad id\n", kept: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(middleware.RequestIDHeader, tt.header)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			id := w.Header().Get(middleware.RequestIDHeader)
+			assert.NotEmpty(t, id)
+			assert.Equal(t, id, w.Body.String())
+			assert.Equal(t, tt.kept, id == tt.header)
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	var out bytes.Buffer
+	router := newRouter(t, &out)
+	router.GET("/api/contacts/:id", func(c *gin.Context) {
+		c.Set(middleware.UserIDKey, 42)
+		logging.FromContext(c.Request.Context()).Info("Loading contact")
+		c.String(http.StatusOK, "hello")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/contacts/7", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	records := decodeRecords(t, &out)
+	require.Len(t, records, 2)
+	assert.Equal(t, "Loading contact", records[0]["msg"])
+	assert.Equal(t, "req-1", records[0]["request_id"])
+
+	access := records[1]
+	assert.Equal(t, "INFO", access["level"])
+	assert.Equal(t, "req-1", access["request_id"])
+	assert.Equal(t, "GET", access["method"])
+	assert.Equal(t, "/api/contacts/:id", access["route"])
+	assert.Equal(t, "/api/contacts/7", access["path"])
+	assert.InDelta(t, http.StatusOK, access["status"], 0)
+	assert.InDelta(t, len("hello"), access["bytes"], 0)
+	assert.InDelta(t, 42, access["user_id"], 0)
+	assert.Contains(t, access, "latency")
+}
+
+func TestAccessLogLevelFollowsStatus(t *testing.T) {
+	var out bytes.Buffer
+	router := newRouter(t, &out)
+	router.GET("/panic", func(*gin.Context) { panic("boom") })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	records := decodeRecords(t, &out)
+	require.Len(t, records, 3)
+	assert.Equal(t, "Recovered from panic", records[0]["msg"])
+	assert.Equal(t, "ERROR", records[1]["level"])
+	assert.Equal(t, "WARN", records[2]["level"])
+	assert.Empty(t, records[2]["route"])
+}
@@ -0,0 +1,137 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"contactsAI/contacts/internal/middleware"
+	"contactsAI/contacts/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRateLimitedRouter(t *testing.T, policy middleware.RateLimitPolicy) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	require.NoError(t, router.SetTrustedProxies([]string{"10.0.0.1"}))
+	router.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-Test-User"); user != "" {
+			c.Set(middleware.UserIDKey, user)
+		}
+	})
+	router.Use(middleware.RateLimit(ratelimit.NewMemoryStore(), policy))
+	router.GET("/contacts", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	router.PUT("/avatar", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	return router
+}
+
+func request(router *gin.Engine, method, path, remoteAddr string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = remoteAddr
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitRejectsWithProblem(t *testing.T) {
+	router := newRateLimitedRouter(t, middleware.RateLimitPolicy{
+		Default: ratelimit.Limit{Burst: 2, Period: time.Minute},
+		Routes:  nil,
+	})
+
+	w := request(router, http.MethodGet, "/contacts", "192.0.2.1:1234", nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "2;w=60", w.Header().Get("RateLimit-Policy"))
+
+	request(router, http.MethodGet, "/contacts", "192.0.2.1:1234", nil)
+	w = request(router, http.MethodGet, "/contacts", "192.0.2.1:1234", nil)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+	var problem middleware.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, http.StatusTooManyRequests, problem.Status)
+	assert.Equal(t, "Too Many Requests", problem.Title)
+	assert.NotEmpty(t, problem.Detail)
+
+	// Another client is not affected.
+	w = request(router, http.MethodGet, "/contacts", "192.0.2.2:1234", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestRateLimitClientKeys(t *testing.T) {
+	router := newRateLimitedRouter(t, middleware.RateLimitPolicy{
+		Default: ratelimit.Limit{Burst: 1, Period: time.Minute},
+		Routes:  nil,
+	})
+
+	t.Run("forwarded IP from a trusted proxy", func(t *testing.T) {
+		forwarded := map[string]string{"X-Forwarded-For": "198.51.100.7"}
+		assert.Equal(t, http.StatusNoContent, request(router, http.MethodGet, "/contacts", "10.0.0.1:1", forwarded).Code)
+		assert.Equal(t, http.StatusTooManyRequests,
+			request(router, http.MethodGet, "/contacts", "10.0.0.1:1", forwarded).Code)
+		// The proxy itself and other clients behind it have their own buckets.
+		assert.Equal(t, http.StatusNoContent, request(router, http.MethodGet, "/contacts", "10.0.0.1:1", nil).Code)
+	})
+
+	t.Run("forwarded IP from an untrusted client is ignored", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, request(router, http.MethodGet, "/contacts", "203.0.113.9:1", nil).Code)
+		spoofed := map[string]string{"X-Forwarded-For": "198.51.100.99"}
+		assert.Equal(t, http.StatusTooManyRequests,
+			request(router, http.MethodGet, "/contacts", "203.0.113.9:1", spoofed).Code)
+	})
+
+	t.Run("users are limited across IPs", func(t *testing.T) {
+		user := map[string]string{"X-Test-User": "7"}
+		assert.Equal(t, http.StatusNoContent, request(router, http.MethodGet, "/contacts", "192.0.2.10:1", user).Code)
+		assert.Equal(t, http.StatusTooManyRequests,
+			request(router, http.MethodGet, "/contacts", "192.0.2.11:1", user).Code)
+	})
+}
+
+func TestRateLimitPerRoute(t *testing.T) {
+	router := newRateLimitedRouter(t, middleware.RateLimitPolicy{
+		Default: ratelimit.Limit{Burst: 5, Period: time.Minute},
+		Routes:  map[string]ratelimit.Limit{"PUT /avatar": {Burst: 1, Period: time.Minute}},
+	})
+
+	assert.Equal(t, http.StatusNoContent, request(router, http.MethodPut, "/avatar", "192.0.2.1:1", nil).Code)
+	w := request(router, http.MethodPut, "/avatar", "192.0.2.1:1", nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	w = request(router, http.MethodGet, "/contacts", "192.0.2.1:1", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "4", w.Header().Get("RateLimit-Remaining"))
+}
+
+func TestIPRateLimitIgnoresUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.IPRateLimit(ratelimit.NewMemoryStore(), ratelimit.Limit{Burst: 1, Period: time.Minute}))
+	router.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-Test-User"); user != "" {
+			c.Set(middleware.UserIDKey, user)
+		}
+	})
+	router.GET("/contacts", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	assert.Equal(t, http.StatusNoContent,
+		request(router, http.MethodGet, "/contacts", "192.0.2.1:1", map[string]string{"X-Test-User": "7"}).Code)
+	assert.Equal(t, http.StatusTooManyRequests,
+		request(router, http.MethodGet, "/contacts", "192.0.2.1:1", map[string]string{"X-Test-User": "8"}).Code)
+	assert.Equal(t, http.StatusNoContent, request(router, http.MethodGet, "/contacts", "192.0.2.2:1", nil).Code)
+}
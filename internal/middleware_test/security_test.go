@@ -0,0 +1,114 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"contactsAI/contacts/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecureHeadersCanBeTunedPerGroup(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.Secure(middleware.SecurityHeaders{
+		StrictTransportSecurity: "max-age=31536000",
+		ContentTypeOptions:      "nosniff",
+		FrameOptions:            "DENY",
+		ReferrerPolicy:          "",
+		ContentSecurityPolicy:   "default-src 'none'",
+	}))
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router.GET("/api", ok)
+	//nolint:exhaustruct // only the policy is replaced
+	router.Group("/docs", middleware.Secure(middleware.SecurityHeaders{ContentSecurityPolicy: "default-src 'self'"})).
+		GET("", ok)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api", nil))
+	assert.Equal(t, "max-age=31536000", w.Header().Get("Strict-Transport-Security"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "default-src 'none'", w.Header().Get("Content-Security-Policy"))
+	assert.NotContains(t, w.Header(), "Referrer-Policy", "empty values are not sent")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, "default-src 'self'", w.Header().Get("Content-Security-Policy"))
+	assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"), "other headers are kept")
+}
+
+func newCORSRouter(origins ...string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.CORS(middleware.CORSPolicy{
+		AllowedOrigins:   origins,
+		AllowedMethods:   []string{"GET", "PUT"},
+		AllowedHeaders:   []string{"Authorization"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	}))
+	router.GET("/contacts", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	return router
+}
+
+func TestCORS(t *testing.T) {
+	router := newCORSRouter("https://app.example.com", "https://*.staging.example.com")
+
+	tests := []struct {
+		name, origin string
+		allowed      bool
+	}{
+		{"exact origin", "https://app.example.com", true},
+		{"wildcard subdomain", "https://pr-42.staging.example.com", true},
+		{"other scheme", "http://app.example.com", false},
+		{"other origin", "https://evil.example.org", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/contacts", nil)
+			req.Header.Set("Origin", tt.origin)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if !tt.allowed {
+				assert.Equal(t, http.StatusForbidden, w.Code)
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+				return
+			}
+			assert.Equal(t, http.StatusNoContent, w.Code)
+			assert.Equal(t, tt.origin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+			assert.Equal(t, "X-Request-Id", w.Header().Get("Access-Control-Expose-Headers"))
+		})
+	}
+
+	t.Run("preflight", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/contacts", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "PUT")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "GET,PUT", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "Authorization", w.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "3600", w.Header().Get("Access-Control-Max-Age"))
+	})
+}
+
+func TestCORSWithoutOrigins(t *testing.T) {
+	router := newCORSRouter()
+	req := httptest.NewRequest(http.MethodGet, "/contacts", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
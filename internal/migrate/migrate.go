@@ -0,0 +1,284 @@
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// lockKey identifies the advisory lock held while migrating, so that replicas
+// starting at the same time apply every migration exactly once.
+const lockKey int64 = 0x636f6e7461637473 // "contacts"
+
+const createVersionTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`
+
+var ErrNoMigrations = errors.New("no migrations to roll back")
+
+// Migration is a pair of up and down scripts sharing a version.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a known migration and when it was applied, if it was.
+type Status struct {
+	Migration
+
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	pool       *pgxpool.Pool
+	logger     *slog.Logger
+	migrations []Migration
+}
+
+// New reads the migrations in fsys and returns a Migrator applying them to pool.
+func New(pool *pgxpool.Pool, fsys fs.FS, logger *slog.Logger) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{pool: pool, logger: logger, migrations: migrations}, nil
+}
+
+// Load parses NNNN_name.up.sql and NNNN_name.down.sql files into migrations
+// sorted by version. Every version needs both scripts.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	pattern := regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := pattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, parseErr := strconv.ParseInt(match[1], 10, 64)
+		if parseErr != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+		script, readErr := fs.ReadFile(fsys, entry.Name())
+		if readErr != nil {
+			return nil, readErr
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2], Up: "", Down: ""}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(script)
+		} else {
+			m.Down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	return migrations, nil
+}
+
+// Latest is the version of the newest known migration, or 0 without migrations.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies all pending migrations in order, each in its own transaction.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			m.logger.Info("Applying migration", "version", migration.Version, "name", migration.Name)
+			err = pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, execErr := tx.Exec(ctx, migration.Up); execErr != nil {
+					return execErr
+				}
+				_, execErr := tx.Exec(ctx,
+					"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+				return execErr
+			})
+			if err != nil {
+				return fmt.Errorf("apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the most recently applied migration.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var rolledBack *Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		var version int64
+		err := conn.QueryRow(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+		if err != nil {
+			return err
+		}
+		if version == 0 {
+			return ErrNoMigrations
+		}
+		idx := slices.IndexFunc(m.migrations, func(migration Migration) bool { return migration.Version == version })
+		if idx < 0 {
+			return fmt.Errorf("applied migration %d is unknown to this build", version)
+		}
+		migration := m.migrations[idx]
+
+		m.logger.Info("Rolling back migration", "version", migration.Version, "name", migration.Name)
+		err = pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			if _, execErr := tx.Exec(ctx, migration.Down); execErr != nil {
+				return execErr
+			}
+			_, execErr := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+			return execErr
+		})
+		if err != nil {
+			return fmt.Errorf("roll back migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		rolledBack = &migration
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Status lists every known migration with the time it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	done, err := appliedVersions(ctx, m.pool)
+	if isUndefinedTable(err) {
+		done, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration, AppliedAt: nil}
+		if appliedAt, ok := done[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Version returns the newest applied migration version, or 0 when the
+// database has not been migrated yet.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	var version int64
+	err := m.pool.QueryRow(ctx,
+		"SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	if isUndefinedTable(err) {
+		return 0, nil
+	}
+	return version, err
+}
+
+// CheckCurrent fails while migrations of this build are still pending.
+func (m *Migrator) CheckCurrent(ctx context.Context) error {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if version < m.Latest() {
+		return fmt.Errorf("database is at migration %d, expected %d", version, m.Latest())
+	}
+	return nil
+}
+
+// withLock runs fn on a single connection holding the migration advisory lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		// Unlock even if ctx was cancelled, otherwise the pooled connection
+		// would keep holding the lock.
+		if _, unlockErr := conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockKey); unlockErr != nil {
+			m.logger.Error("Failed to release migration lock", "error", unlockErr)
+		}
+	}()
+
+	if _, err = conn.Exec(ctx, createVersionTable); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func appliedVersions(ctx context.Context, conn querier) (map[int64]time.Time, error) {
+	rows, err := conn.Query(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var (
+			version   int64
+			appliedAt time.Time
+		)
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+func isUndefinedTable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "42P01"
+}
@@ -0,0 +1,50 @@
+package migrate_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"contactsAI/contacts/internal/migrate"
+	"contactsAI/contacts/sql/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSortsByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0010_add_index.up.sql":      {Data: []byte("CREATE INDEX i ON t (c);")},
+		"0010_add_index.down.sql":    {Data: []byte("DROP INDEX i;")},
+		"0002_create_table.up.sql":   {Data: []byte("CREATE TABLE t (c INT);")},
+		"0002_create_table.down.sql": {Data: []byte("DROP TABLE t;")},
+		"README.md":                  {Data: []byte("ignored")},
+	}
+
+	loaded, err := migrate.Load(fsys)
+	require.NoError(t, err)
+
+	require.Len(t, loaded, 2)
+	assert.Equal(t, int64(2), loaded[0].Version)
+	assert.Equal(t, "create_table", loaded[0].Name)
+	assert.Equal(t, "DROP TABLE t;", loaded[0].Down)
+	assert.Equal(t, int64(10), loaded[1].Version)
+}
+
+func TestLoadRequiresDownScripts(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0001_create_table.up.sql": {Data: []byte("CREATE TABLE t (c INT);")},
+	}
+
+	_, err := migrate.Load(fsys)
+	assert.Error(t, err)
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	loaded, err := migrate.Load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, loaded)
+
+	for i, m := range loaded {
+		assert.Equal(t, int64(i+1), m.Version, "migration versions should be contiguous")
+	}
+}
@@ -0,0 +1,35 @@
+package ratelimit
+
+import (
+	"context"
+
+	"contactsAI/contacts/internal/db"
+)
+
+// PostgresStore keeps buckets in the rate_limits table so that limits hold
+// across replicas. Every Take is a single atomic upsert evaluated with the
+// database clock, so replicas with skewed clocks agree.
+type PostgresStore struct {
+	queries db.Querier
+}
+
+func NewPostgresStore(queries db.Querier) *PostgresStore {
+	return &PostgresStore{queries: queries}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	row, err := s.queries.TakeRateLimitToken(ctx, db.TakeRateLimitTokenParams{
+		Key:   key,
+		Burst: float64(limit.Burst),
+		Rate:  limit.Rate(),
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return newResult(limit, row.Tokens, row.Allowed), nil
+}
+
+func (s *PostgresStore) Sweep(ctx context.Context) error {
+	_, err := s.queries.DeleteFullRateLimits(ctx)
+	return err
+}
@@ -0,0 +1,132 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SweepInterval is how often full buckets should be swept.
+const SweepInterval = time.Minute
+
+// Store keeps token buckets. MemoryStore and PostgresStore implement it.
+type Store interface {
+	// Take removes a token from the bucket of key, creating a full bucket for
+	// unknown keys.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+	// Sweep forgets buckets that have refilled completely, as they are
+	// indistinguishable from new ones.
+	Sweep(ctx context.Context) error
+}
+
+var (
+	_ Store = (*MemoryStore)(nil)
+	_ Store = (*PostgresStore)(nil)
+)
+
+// Limit allows bursts of Burst requests and refills the bucket continuously
+// over Period. It is written as "<burst>/<period>", e.g. "100/1m".
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// Rate is the number of tokens added per second.
+func (l Limit) Rate() float64 {
+	return float64(l.Burst) / l.Period.Seconds()
+}
+
+func (l Limit) Validate() error {
+	if l.Burst < 1 {
+		return errors.New("burst must be at least 1")
+	}
+	if l.Period <= 0 {
+		return errors.New("period must be positive")
+	}
+	return nil
+}
+
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Burst, l.Period)
+}
+
+func (l Limit) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (l *Limit) UnmarshalText(text []byte) error {
+	burst, period, ok := strings.Cut(string(text), "/")
+	if !ok {
+		return fmt.Errorf("invalid limit %q, expected <burst>/<period> such as 100/1m", text)
+	}
+	n, err := strconv.Atoi(burst)
+	if err != nil {
+		return fmt.Errorf("invalid burst in limit %q: %w", text, err)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil {
+		return fmt.Errorf("invalid period in limit %q: %w", text, err)
+	}
+	l.Burst, l.Period = n, d
+	return nil
+}
+
+// Result describes a bucket after a Take.
+type Result struct {
+	Allowed bool
+	Limit   Limit
+	// Remaining is the number of whole tokens left.
+	Remaining int
+	// RetryAfter is how long to wait for the next token when not Allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+func newResult(limit Limit, tokens float64, allowed bool) Result {
+	rate := limit.Rate()
+	result := Result{
+		Allowed:    allowed,
+		Limit:      limit,
+		Remaining:  int(math.Floor(tokens)),
+		RetryAfter: 0,
+		Reset:      seconds((float64(limit.Burst) - tokens) / rate),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return result
+}
+
+// refill returns the tokens of a bucket that held tokens elapsed ago.
+func refill(limit Limit, tokens float64, elapsed time.Duration) float64 {
+	return min(float64(limit.Burst), tokens+max(elapsed.Seconds(), 0)*limit.Rate())
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(max(s, 0) * float64(time.Second))
+}
+
+// SweepWorker returns a background worker that sweeps store every interval
+// until its context is cancelled.
+func SweepWorker(store Store, logger *slog.Logger, interval time.Duration) func(context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+			if err := store.Sweep(ctx); err != nil && ctx.Err() == nil {
+				logger.Warn("Failed to sweep rate limits", "error", err)
+			}
+		}
+	}
+}
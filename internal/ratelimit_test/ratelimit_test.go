@@ -0,0 +1,77 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"contactsAI/contacts/internal/ratelimit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitText(t *testing.T) {
+	var limit ratelimit.Limit
+	require.NoError(t, limit.UnmarshalText([]byte("100/1m")))
+	assert.Equal(t, ratelimit.Limit{Burst: 100, Period: time.Minute}, limit)
+	assert.InDelta(t, 100.0/60, limit.Rate(), 1e-9)
+
+	text, err := limit.MarshalText()
+	require.NoError(t, err)
+	var roundTrip ratelimit.Limit
+	require.NoError(t, roundTrip.UnmarshalText(text))
+	assert.Equal(t, limit, roundTrip)
+
+	for _, invalid := range []string{"100", "x/1m", "100/minute"} {
+		assert.Error(t, limit.UnmarshalText([]byte(invalid)), invalid)
+	}
+	assert.Error(t, ratelimit.Limit{Burst: 0, Period: time.Minute}.Validate())
+	assert.Error(t, ratelimit.Limit{Burst: 1, Period: 0}.Validate())
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	ctx := context.Background()
+	limit := ratelimit.Limit{Burst: 2, Period: time.Hour}
+
+	for remaining := 1; remaining >= 0; remaining-- {
+		result, err := store.Take(ctx, "a", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, remaining, result.Remaining)
+		assert.Zero(t, result.RetryAfter)
+	}
+
+	result, err := store.Take(ctx, "a", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.InDelta(t, 30*time.Minute, result.RetryAfter, float64(time.Second))
+	assert.InDelta(t, time.Hour, result.Reset, float64(time.Second))
+
+	// Keys have separate buckets.
+	result, err = store.Take(ctx, "b", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestMemoryStoreRefillsAndSweeps(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	ctx := context.Background()
+	limit := ratelimit.Limit{Burst: 1, Period: 20 * time.Millisecond}
+
+	result, err := store.Take(ctx, "a", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.NoError(t, store.Sweep(ctx))
+	assert.Equal(t, 1, store.Len(), "a bucket that is not full is kept")
+
+	time.Sleep(2 * limit.Period)
+	require.NoError(t, store.Sweep(ctx))
+	assert.Equal(t, 0, store.Len())
+
+	result, err = store.Take(ctx, "a", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
@@ -0,0 +1,102 @@
+// Package relations defines the typed relations between contacts.
+//
+// A relation (contact, related, type) says that related is the type of
+// contact, e.g. that Anna is the assistant of Jan. Every type has an inverse
+// that reads the relation from the other contact: Jan is the executive of
+// Anna. Spouses and colleagues are their own inverse. Relations are stored
+// once, in the canonical form returned by Canonical.
+package relations
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Type is the type of a relation.
+type Type string
+
+const (
+	TypeSpouse    Type = "spouse"
+	TypeColleague Type = "colleague"
+	TypeParent    Type = "parent"
+	TypeChild     Type = "child"
+	TypeManager   Type = "manager"
+	TypeReport    Type = "report"
+	TypeAssistant Type = "assistant"
+	TypeExecutive Type = "executive"
+)
+
+// MaxDepth bounds the depth of the neighbourhood of a contact.
+const MaxDepth = 4
+
+var (
+	// ErrType is returned for unknown relation types.
+	ErrType = errors.New("unknown relation type")
+	// ErrSelf is returned for a relation of a contact with itself.
+	ErrSelf = errors.New("a contact cannot be related to itself")
+)
+
+// Inverse returns the type of a relation read from the other contact.
+func (t Type) Inverse() (Type, error) {
+	switch t {
+	case TypeSpouse, TypeColleague:
+		return t, nil
+	case TypeParent:
+		return TypeChild, nil
+	case TypeChild:
+		return TypeParent, nil
+	case TypeManager:
+		return TypeReport, nil
+	case TypeReport:
+		return TypeManager, nil
+	case TypeAssistant:
+		return TypeExecutive, nil
+	case TypeExecutive:
+		return TypeAssistant, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrType, string(t))
+}
+
+// Symmetric reports whether t is its own inverse.
+func (t Type) Symmetric() bool {
+	return t == TypeSpouse || t == TypeColleague
+}
+
+// Relation says that Related is the Type of Contact.
+type Relation struct {
+	Contact int32
+	Related int32
+	Type    Type
+}
+
+// Canonical returns the form r is stored in: child, report and executive
+// relations become the inverse parent, manager and assistant relations, and
+// symmetric relations start from the lower id.
+func (r Relation) Canonical() (Relation, error) {
+	if r.Contact == r.Related {
+		return Relation{}, ErrSelf
+	}
+	inverse, err := r.Type.Inverse()
+	if err != nil {
+		return Relation{}, err
+	}
+	flipped := Relation{Contact: r.Related, Related: r.Contact, Type: inverse}
+	switch {
+	case r.Type.Symmetric() && r.Contact > r.Related:
+		return flipped, nil
+	case r.Type == TypeChild || r.Type == TypeReport || r.Type == TypeExecutive:
+		return flipped, nil
+	}
+	return r, nil
+}
+
+// From reads r from the contact id, which is either end of r. Reading it
+// from Related gives the inverse relation.
+func (r Relation) From(id int32) Relation {
+	if id == r.Contact {
+		return r
+	}
+	// Stored relations have known types.
+	inverse, _ := r.Type.Inverse()
+	return Relation{Contact: r.Related, Related: r.Contact, Type: inverse}
+}
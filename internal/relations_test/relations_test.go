@@ -0,0 +1,72 @@
+package relations_test
+
+import (
+	"testing"
+
+	"contactsAI/contacts/internal/relations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		name string
+		in   relations.Relation
+		want relations.Relation
+	}{
+		{
+			"assistant is stored as it is",
+			relations.Relation{Contact: 1, Related: 2, Type: relations.TypeAssistant},
+			relations.Relation{Contact: 1, Related: 2, Type: relations.TypeAssistant},
+		},
+		{
+			"executive is stored as the inverse assistant",
+			relations.Relation{Contact: 2, Related: 1, Type: relations.TypeExecutive},
+			relations.Relation{Contact: 1, Related: 2, Type: relations.TypeAssistant},
+		},
+		{
+			"child is stored as the inverse parent",
+			relations.Relation{Contact: 3, Related: 4, Type: relations.TypeChild},
+			relations.Relation{Contact: 4, Related: 3, Type: relations.TypeParent},
+		},
+		{
+			"report is stored as the inverse manager",
+			relations.Relation{Contact: 3, Related: 4, Type: relations.TypeReport},
+			relations.Relation{Contact: 4, Related: 3, Type: relations.TypeManager},
+		},
+		{
+			"spouses start from the lower id",
+			relations.Relation{Contact: 7, Related: 5, Type: relations.TypeSpouse},
+			relations.Relation{Contact: 5, Related: 7, Type: relations.TypeSpouse},
+		},
+		{
+			"colleagues from the lower id are stored as they are",
+			relations.Relation{Contact: 5, Related: 7, Type: relations.TypeColleague},
+			relations.Relation{Contact: 5, Related: 7, Type: relations.TypeColleague},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.in.Canonical()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCanonicalRejectsInvalidRelations(t *testing.T) {
+	_, err := relations.Relation{Contact: 1, Related: 1, Type: relations.TypeSpouse}.Canonical()
+	require.ErrorIs(t, err, relations.ErrSelf)
+
+	_, err = relations.Relation{Contact: 1, Related: 2, Type: "friend"}.Canonical()
+	require.ErrorIs(t, err, relations.ErrType)
+}
+
+func TestFromReadsTheInverseFromTheRelatedContact(t *testing.T) {
+	// Anna (2) is the assistant of Jan (1).
+	relation := relations.Relation{Contact: 1, Related: 2, Type: relations.TypeAssistant}
+
+	assert.Equal(t, relation, relation.From(1))
+	assert.Equal(t, relations.Relation{Contact: 2, Related: 1, Type: relations.TypeExecutive}, relation.From(2))
+}
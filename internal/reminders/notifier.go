@@ -0,0 +1,254 @@
+package reminders
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	NotifierLog     = "log"
+	NotifierWebhook = "webhook"
+	NotifierSMTP    = "smtp"
+
+	// SignatureHeader carries the HMAC-SHA256 of webhook bodies, hex encoded
+	// and prefixed with "sha256=".
+	SignatureHeader = "X-Signature"
+
+	webhookTimeout = 10 * time.Second
+	// smtpTimeout bounds a whole conversation with the mail server.
+	smtpTimeout = 30 * time.Second
+)
+
+// Notification is sent when a reminder is due.
+type Notification struct {
+	ReminderID  int32  `json:"reminder_id"`
+	TenantID    int32  `json:"tenant_id"`
+	OwnerID     *int32 `json:"owner_id,omitempty"`
+	ContactID   int32  `json:"contact_id"`
+	ContactName string `json:"contact_name"`
+	Kind        string `json:"kind"`
+	Label       string `json:"label,omitempty"`
+	Note        string `json:"note,omitempty"`
+	// OccursOn is the day of a birthday or anniversary, YYYY-MM-DD.
+	OccursOn string    `json:"occurs_on,omitempty"`
+	DueAt    time.Time `json:"due_at"`
+	// Email is where the owner receives notifications by email, if anywhere.
+	Email string `json:"-"`
+}
+
+// Subject summarizes the notification in one line.
+func (n Notification) Subject() string {
+	switch n.Kind {
+	case KindBirthday:
+		return fmt.Sprintf("Birthday of %s on %s", n.ContactName, n.OccursOn)
+	case KindAnniversary:
+		return fmt.Sprintf("Anniversary (%s) of %s on %s", n.Label, n.ContactName, n.OccursOn)
+	default:
+		return "Follow up with " + n.ContactName
+	}
+}
+
+// Notifier delivers notifications.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// NamedNotifier is a notifier under the name that deliveries are recorded by.
+type NamedNotifier struct {
+	Name     string
+	Notifier Notifier
+}
+
+// Notifiers delivers each notification with all of its notifiers.
+type Notifiers []NamedNotifier
+
+// Deliver sends n with the notifiers that are not in delivered, and returns
+// the names of those that have delivered it, before or now. It fails when any
+// of them does, so that only the failed ones are tried again.
+func (ns Notifiers) Deliver(ctx context.Context, n Notification, delivered []string) ([]string, error) {
+	// Never nil, because delivered_to is not NULL.
+	delivered = append(make([]string, 0, len(ns)), delivered...)
+	var errs []error
+	for _, notifier := range ns {
+		if slices.Contains(delivered, notifier.Name) {
+			continue
+		}
+		if err := notifier.Notifier.Notify(ctx, n); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", notifier.Name, err))
+			continue
+		}
+		delivered = append(delivered, notifier.Name)
+	}
+	return delivered, errors.Join(errs...)
+}
+
+// LogNotifier writes notifications to the log.
+type LogNotifier struct {
+	Logger *slog.Logger
+}
+
+func (l LogNotifier) Notify(ctx context.Context, n Notification) error {
+	l.Logger.InfoContext(ctx, "Reminder due",
+		"reminder_id", n.ReminderID,
+		"tenant_id", n.TenantID,
+		"contact_id", n.ContactID,
+		"kind", n.Kind,
+		"subject", n.Subject(),
+		"due_at", n.DueAt,
+	)
+	return nil
+}
+
+// WebhookNotifier posts notifications as JSON to a URL.
+type WebhookNotifier struct {
+	URL string
+	// Secret signs the bodies in SignatureHeader when it is not empty.
+	Secret string
+	Client *http.Client
+}
+
+func NewWebhookNotifier(url, secret string) *WebhookNotifier {
+	//nolint:exhaustruct // the defaults of the client are fine besides the timeout
+	return &WebhookNotifier{URL: url, Secret: secret, Client: &http.Client{Timeout: webhookTimeout}}
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.Secret))
+		mac.Write(body)
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post reminder webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("reminder webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+// SMTPNotifier emails notifications to the address of their owner.
+// Notifications of owners without an address are skipped.
+type SMTPNotifier struct {
+	// Addr is the host:port of the mail server.
+	Addr string
+	From string
+	// Auth is nil for servers that do not require authentication.
+	Auth smtp.Auth
+}
+
+func NewSMTPNotifier(addr, from, username, password string) *SMTPNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPNotifier{Addr: addr, From: from, Auth: auth}
+}
+
+func (s *SMTPNotifier) Notify(ctx context.Context, n Notification) error {
+	if n.Email == "" {
+		return nil
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", s.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", n.Email)
+	// Q-encoding also keeps line breaks in contact names out of the headers.
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", n.Subject()))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(n.Subject() + "\r\n")
+	if n.Note != "" {
+		msg.WriteString("\r\n" + strings.ReplaceAll(strings.ReplaceAll(n.Note, "\r\n", "\n"), "\n", "\r\n") + "\r\n")
+	}
+	if err := s.send(ctx, n.Email, msg.String()); err != nil {
+		return fmt.Errorf("send reminder email: %w", err)
+	}
+	return nil
+}
+
+// send is smtp.SendMail, but it gives up when ctx is done or after
+// smtpTimeout, whichever comes first.
+func (s *SMTPNotifier) send(ctx context.Context, to, msg string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(smtpTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err = conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	host, _, _ := net.SplitHostPort(s.Addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}); err != nil {
+			return err
+		}
+	}
+	if s.Auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err = client.Auth(s.Auth); err != nil {
+			return err
+		}
+	}
+	if err = client.Mail(s.From); err != nil {
+		return err
+	}
+	if err = client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = io.WriteString(w, msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,122 @@
+// Package reminders schedules the reminders of contacts and sends their
+// notifications. Follow-ups are created by users, while birthdays and
+// anniversaries become reminders when they come within the horizon of the
+// Scheduler. Reminders for a date are due at the notify hour of their
+// owner's time zone on that date.
+package reminders
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	// Time zones of users must not depend on the zoneinfo of the host.
+	_ "time/tzdata"
+
+	"contactsAI/contacts/internal/vcard"
+)
+
+const (
+	KindFollowUp    = "follow_up"
+	KindBirthday    = "birthday"
+	KindAnniversary = "anniversary"
+
+	// DefaultTimeZone and DefaultNotifyHour apply to users without settings.
+	DefaultTimeZone   = "UTC"
+	DefaultNotifyHour = 9
+)
+
+// ErrTimeZone is returned for names that are not IANA time zones.
+var ErrTimeZone = errors.New("unknown time zone")
+
+// LoadLocation loads an IANA time zone such as Europe/Warsaw. Unlike
+// time.LoadLocation it refuses "Local" and the empty name, which mean the
+// time zone of the host.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("%w %q", ErrTimeZone, name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q", ErrTimeZone, name)
+	}
+	return loc, nil
+}
+
+// Anniversary is a labelled date of a contact, as stored in its anniversaries.
+type Anniversary struct {
+	Label string `json:"label"`
+	Date  string `json:"date"`
+}
+
+// Dates are the dates of a contact that reminders are created for.
+type Dates struct {
+	// Birthday is zero when it is unknown.
+	Birthday      time.Time
+	Anniversaries []Anniversary
+}
+
+// Occurrence is a birthday or anniversary on a given day.
+type Occurrence struct {
+	Kind  string
+	Label string
+	// On is the day of the occurrence, at midnight UTC.
+	On    time.Time
+	DueAt time.Time
+}
+
+// Today is the current day in loc, at midnight UTC like the dates of
+// contacts.
+func Today(now time.Time, loc *time.Location) time.Time {
+	year, month, day := now.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// DueAt is hour o'clock on day in loc.
+func DueAt(day time.Time, hour int, loc *time.Location) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, 0, 0, 0, loc)
+}
+
+// NextOccurrence is the first day from day on that falls on the month and
+// day of date. February 29 falls on February 28 in common years.
+func NextOccurrence(date, day time.Time) time.Time {
+	next := onYear(date, day.Year())
+	if next.Before(day) {
+		next = onYear(date, day.Year()+1)
+	}
+	return next
+}
+
+func onYear(date time.Time, year int) time.Time {
+	month, day := date.Month(), date.Day()
+	if month == time.February && day == 29 && time.Date(year, time.February, 29, 0, 0, 0, 0, time.UTC).Day() != 29 {
+		day = 28
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Upcoming returns the next occurrence of each of dates that is due before
+// now plus horizon. An occurrence today is included even when its hour has
+// passed, so that it is still notified. Anniversaries with invalid dates are
+// skipped.
+func Upcoming(dates Dates, now time.Time, horizon time.Duration, loc *time.Location, hour int) []Occurrence {
+	today := Today(now, loc)
+	until := now.Add(horizon)
+	var occurrences []Occurrence
+	add := func(kind, label string, date time.Time) {
+		on := NextOccurrence(date, today)
+		if due := DueAt(on, hour, loc); due.Before(until) {
+			occurrences = append(occurrences, Occurrence{Kind: kind, Label: label, On: on, DueAt: due})
+		}
+	}
+	if !dates.Birthday.IsZero() {
+		add(KindBirthday, "", dates.Birthday)
+	}
+	for _, anniversary := range dates.Anniversaries {
+		date, err := vcard.ParseDate(anniversary.Date)
+		if err != nil {
+			continue
+		}
+		add(KindAnniversary, anniversary.Label, date)
+	}
+	return occurrences
+}
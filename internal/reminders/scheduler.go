@@ -0,0 +1,260 @@
+package reminders
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"contactsAI/contacts/internal/db"
+	"contactsAI/contacts/internal/leader"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// lockKey is the advisory lock of the scheduler, "reminder" in ASCII.
+	lockKey = 0x72656d696e646572
+	// planInterval is how often birthdays and anniversaries are turned into
+	// reminders. The horizon is long enough that hourly is plenty.
+	planInterval = time.Hour
+	batchSize    = 100
+	// claimLease is how long a claimed reminder is left to the scheduler that
+	// claimed it before it is due again, in case that scheduler died.
+	claimLease = 15 * time.Minute
+	// MaxAttempts is how often a reminder is tried before it is given up on.
+	MaxAttempts = 10
+	retryBase   = time.Minute
+	retryMax    = 6 * time.Hour
+)
+
+// Scheduler turns birthdays and anniversaries into reminders and notifies
+// due reminders, for every tenant. Only one replica should run it at a time,
+// see Worker.
+type Scheduler struct {
+	queries   db.Querier
+	notifiers Notifiers
+	logger    *slog.Logger
+	horizon   time.Duration
+	// planned is when the last plan was made.
+	planned time.Time
+}
+
+// NewScheduler creates a scheduler that runs queries without tenant
+// isolation. Birthdays and anniversaries become reminders once they are due
+// within horizon.
+func NewScheduler(queries db.Querier, notifiers Notifiers, logger *slog.Logger, horizon time.Duration) *Scheduler {
+	return &Scheduler{queries: queries, notifiers: notifiers, logger: logger, horizon: horizon, planned: time.Time{}}
+}
+
+// Worker runs the scheduler every interval while this replica holds the
+// scheduler's advisory lock, so that each reminder is notified once however
+// many replicas run.
+func (s *Scheduler) Worker(pool *pgxpool.Pool, interval time.Duration) func(context.Context) error {
+	elector := leader.Elector{Pool: pool, Logger: s.logger, Key: lockKey, Retry: interval}
+	return func(ctx context.Context) error {
+		return elector.Run(ctx, func(ctx context.Context) {
+			// A new leader plans first, whatever the previous one did.
+			s.planned = time.Time{}
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				if err := s.Tick(ctx); err != nil && ctx.Err() == nil {
+					s.logger.Warn("Failed to run reminders", "error", err)
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		})
+	}
+}
+
+// Tick drops the reminders of dates that changed, plans the reminders of dates
+// if it has not for an hour, and notifies the reminders that are due.
+func (s *Scheduler) Tick(ctx context.Context) error {
+	if err := s.DropStale(ctx); err != nil {
+		return err
+	}
+	if now := time.Now(); now.Sub(s.planned) >= planInterval {
+		if err := s.Plan(ctx, now); err != nil {
+			return err
+		}
+		s.planned = now
+	}
+	return s.NotifyDue(ctx)
+}
+
+// DropStale deletes the pending reminders of birthdays and anniversaries that
+// were changed or removed, so that they are not notified on the old date.
+func (s *Scheduler) DropStale(ctx context.Context) error {
+	deleted, err := s.queries.DeleteStaleOccurrenceReminders(ctx)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		s.logger.Info("Dropped stale reminders", "deleted", deleted)
+	}
+	return nil
+}
+
+// Plan creates the reminders of the birthdays and anniversaries that are due
+// within the horizon from now.
+func (s *Scheduler) Plan(ctx context.Context, now time.Time) error {
+	var created int64
+	var afterID int32
+	for {
+		contacts, err := s.queries.ListContactDates(ctx, db.ListContactDatesParams{
+			AfterID:  afterID,
+			PageSize: batchSize,
+		})
+		if err != nil {
+			return err
+		}
+		for _, contact := range contacts {
+			rows, err := s.planContact(ctx, contact, now)
+			if err != nil {
+				return err
+			}
+			created += rows
+		}
+		if len(contacts) < batchSize {
+			break
+		}
+		afterID = contacts[len(contacts)-1].ID
+	}
+	if created > 0 {
+		s.logger.Info("Planned reminders", "created", created)
+	}
+	return nil
+}
+
+// planContact creates the reminders of the dates of contact and returns how
+// many it created.
+func (s *Scheduler) planContact(ctx context.Context, contact db.ListContactDatesRow, now time.Time) (int64, error) {
+	loc, hour := s.settings(contact)
+	dates := Dates{Birthday: time.Time{}, Anniversaries: nil}
+	if contact.Birthday.Valid {
+		dates.Birthday = contact.Birthday.Time
+	}
+	if err := json.Unmarshal(contact.Anniversaries, &dates.Anniversaries); err != nil {
+		s.logger.Warn("Skipping invalid anniversaries", "contact_id", contact.ID, "error", err)
+		dates.Anniversaries = nil
+	}
+	var created int64
+	for _, occurrence := range Upcoming(dates, now, s.horizon, loc, hour) {
+		rows, err := s.queries.CreateOccurrenceReminder(ctx, db.CreateOccurrenceReminderParams{
+			ContactID: contact.ID,
+			OwnerID:   contact.OwnerID,
+			Kind:      occurrence.Kind,
+			Label:     occurrence.Label,
+			OccursOn:  pgtype.Date{Time: occurrence.On, InfinityModifier: pgtype.Finite, Valid: true},
+			DueAt:     pgtype.Timestamptz{Time: occurrence.DueAt, InfinityModifier: pgtype.Finite, Valid: true},
+			TenantID:  contact.TenantID,
+		})
+		if err != nil {
+			return created, err
+		}
+		created += rows
+	}
+	return created, nil
+}
+
+// settings are the time zone and notify hour of the owner of contact.
+func (s *Scheduler) settings(contact db.ListContactDatesRow) (*time.Location, int) {
+	hour := DefaultNotifyHour
+	if contact.NotifyHour.Valid {
+		hour = int(contact.NotifyHour.Int32)
+	}
+	if !contact.TimeZone.Valid {
+		return time.UTC, hour
+	}
+	loc, err := LoadLocation(contact.TimeZone.String)
+	if err != nil {
+		s.logger.Warn("Using UTC for reminders", "contact_id", contact.ID, "error", err)
+		return time.UTC, hour
+	}
+	return loc, hour
+}
+
+// NotifyDue sends the notifications of due reminders. Reminders that some
+// notifiers failed to send are tried again with those notifiers after
+// RetryDelay, up to MaxAttempts times.
+func (s *Scheduler) NotifyDue(ctx context.Context) error {
+	for {
+		due, err := s.queries.ClaimDueReminders(ctx, db.ClaimDueRemindersParams{
+			LeaseSeconds: int32(claimLease / time.Second),
+			MaxAttempts:  MaxAttempts,
+			BatchSize:    batchSize,
+		})
+		if err != nil {
+			return err
+		}
+		for _, reminder := range due {
+			if err = s.notify(ctx, reminder); err != nil {
+				return err
+			}
+		}
+		if len(due) < batchSize {
+			return nil
+		}
+	}
+}
+
+// notify delivers a claimed reminder and records the outcome.
+func (s *Scheduler) notify(ctx context.Context, reminder db.ClaimDueRemindersRow) error {
+	delivered, err := s.notifiers.Deliver(ctx, toNotification(reminder), reminder.DeliveredTo)
+	if err == nil {
+		return s.queries.MarkReminderNotified(ctx, db.MarkReminderNotifiedParams{
+			ID:          reminder.ID,
+			DeliveredTo: delivered,
+		})
+	}
+	level, msg := slog.LevelWarn, "Failed to notify reminder"
+	if reminder.Attempts >= MaxAttempts {
+		level, msg = slog.LevelError, "Giving up on reminder"
+	}
+	s.logger.Log(ctx, level, msg, "reminder_id", reminder.ID, "attempts", reminder.Attempts, "error", err)
+	retryAt := time.Now().Add(RetryDelay(reminder.Attempts))
+	return s.queries.RetryReminder(ctx, db.RetryReminderParams{
+		ID:          reminder.ID,
+		DeliveredTo: delivered,
+		RetryAt:     pgtype.Timestamptz{Time: retryAt, InfinityModifier: pgtype.Finite, Valid: true},
+	})
+}
+
+// RetryDelay is how long a reminder waits after its attempts-th failed
+// attempt: a minute, doubling with every attempt up to six hours.
+func RetryDelay(attempts int32) time.Duration {
+	delay := retryBase
+	for i := int32(1); i < attempts && delay < retryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, retryMax)
+}
+
+func toNotification(reminder db.ClaimDueRemindersRow) Notification {
+	n := Notification{
+		ReminderID:  reminder.ID,
+		TenantID:    reminder.TenantID,
+		OwnerID:     nil,
+		ContactID:   reminder.ContactID,
+		ContactName: reminder.ContactName,
+		Kind:        reminder.Kind,
+		Label:       reminder.Label,
+		Note:        reminder.Note,
+		OccursOn:    "",
+		DueAt:       reminder.DueAt.Time,
+		Email:       reminder.Email,
+	}
+	if reminder.OwnerID.Valid {
+		n.OwnerID = &reminder.OwnerID.Int32
+	}
+	if reminder.OccursOn.Valid {
+		n.OccursOn = reminder.OccursOn.Time.Format(time.DateOnly)
+	}
+	return n
+}
@@ -0,0 +1,185 @@
+package reminders_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"contactsAI/contacts/internal/reminders"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func followUp() reminders.Notification {
+	owner := int32(1)
+	return reminders.Notification{
+		ReminderID:  3,
+		TenantID:    1,
+		OwnerID:     &owner,
+		ContactID:   2,
+		ContactName: "Anna Nowak",
+		Kind:        reminders.KindFollowUp,
+		Note:        "Call back about the offer",
+		DueAt:       time.Date(2026, 4, 23, 9, 0, 0, 0, time.UTC),
+		Email:       "jan@example.com",
+	}
+}
+
+func TestWebhookNotifierSignsBody(t *testing.T) {
+	var body []byte
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(reminders.SignatureHeader)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	err := reminders.NewWebhookNotifier(server.URL, "secret").Notify(context.Background(), followUp())
+
+	require.NoError(t, err)
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(body, &got))
+	assert.Equal(t, "Anna Nowak", got["contact_name"])
+	assert.NotContains(t, got, "email")
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), signature)
+}
+
+func TestWebhookNotifierFailsOnErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	err := reminders.NewWebhookNotifier(server.URL, "").Notify(context.Background(), followUp())
+
+	require.ErrorContains(t, err, "502")
+}
+
+// fakeSMTP accepts one message on a local port, like a mail catcher.
+func fakeSMTP(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost ready")
+		var data bytes.Buffer
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case inData && line == ".\r\n":
+				inData = false
+				messages <- data.String()
+				reply("250 queued")
+			case inData:
+				data.WriteString(line)
+			case strings.HasPrefix(line, "DATA"):
+				inData = true
+				reply("354 go ahead")
+			case strings.HasPrefix(line, "QUIT"):
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return listener.Addr().String(), messages
+}
+
+func TestSMTPNotifierEmailsOwner(t *testing.T) {
+	addr, messages := fakeSMTP(t)
+	n := followUp()
+	n.ContactName = "Anna\r\nBcc: evil@example.com"
+
+	require.NoError(t, reminders.NewSMTPNotifier(addr, "reminders@example.com", "", "").Notify(context.Background(), n))
+
+	header, body, _ := strings.Cut(<-messages, "\r\n\r\n")
+	assert.Contains(t, header, "To: jan@example.com\r\n")
+	assert.Contains(t, header, "Subject: =?utf-8?q?")
+	assert.NotContains(t, header, "\r\nBcc:")
+	assert.Contains(t, body, "Call back about the offer")
+}
+
+func TestSMTPNotifierGivesUpWithTheContext(t *testing.T) {
+	// The server accepts connections but never greets.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	conns := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			conns <- conn
+		}
+	}()
+	t.Cleanup(func() {
+		select {
+		case conn := <-conns:
+			conn.Close()
+		default:
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = reminders.NewSMTPNotifier(listener.Addr().String(), "reminders@example.com", "", "").Notify(ctx, followUp())
+
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestSMTPNotifierSkipsOwnersWithoutEmail(t *testing.T) {
+	n := followUp()
+	n.Email = ""
+	// Nothing listens on the address, so sending would fail.
+	require.NoError(t, reminders.NewSMTPNotifier("127.0.0.1:1", "reminders@example.com", "", "").
+		Notify(context.Background(), n))
+}
+
+func TestNotifiersSkipDeliveredOnes(t *testing.T) {
+	var logged bytes.Buffer
+	sent := &recorder{}
+	failing := &recorder{fail: map[int32]bool{3: true}}
+	notifiers := reminders.Notifiers{
+		{Name: "log", Notifier: reminders.LogNotifier{Logger: slog.New(slog.NewTextHandler(&logged, nil))}},
+		{Name: "sent", Notifier: sent},
+		{Name: "failing", Notifier: failing},
+	}
+
+	delivered, err := notifiers.Deliver(context.Background(), followUp(), []string{"sent"})
+
+	require.ErrorContains(t, err, "failing")
+	assert.Equal(t, []string{"sent", "log"}, delivered)
+	assert.Contains(t, logged.String(), "Follow up with Anna Nowak")
+	assert.Empty(t, sent.notified)
+}
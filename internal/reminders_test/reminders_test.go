@@ -0,0 +1,265 @@
+package reminders_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"contactsAI/contacts/internal/db"
+	"contactsAI/contacts/internal/reminders"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(s string) time.Time {
+	d, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestNextOccurrence(t *testing.T) {
+	tests := []struct {
+		name, date, day, want string
+	}{
+		{"later this year", "1980-04-23", "2026-03-01", "2026-04-23"},
+		{"today", "1980-04-23", "2026-04-23", "2026-04-23"},
+		{"passed this year", "1980-04-23", "2026-04-24", "2027-04-23"},
+		{"leap day in a common year", "1992-02-29", "2026-01-01", "2026-02-28"},
+		{"leap day in a leap year", "1992-02-29", "2028-01-01", "2028-02-29"},
+		{"leap day after February 28", "1992-02-29", "2027-03-01", "2028-02-29"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, date(tt.want), reminders.NextOccurrence(date(tt.date), date(tt.day)))
+		})
+	}
+}
+
+func TestTodayAndDueAtFollowTheTimeZone(t *testing.T) {
+	tokyo, err := reminders.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	now := time.Date(2026, 4, 22, 20, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, date("2026-04-22"), reminders.Today(now, time.UTC))
+	assert.Equal(t, date("2026-04-23"), reminders.Today(now, tokyo))
+	assert.Equal(t, time.Date(2026, 4, 23, 0, 0, 0, 0, time.UTC),
+		reminders.DueAt(date("2026-04-23"), 9, tokyo).UTC())
+}
+
+func TestLoadLocationRejectsHostTimeZone(t *testing.T) {
+	for _, name := range []string{"", "Local", "Mars/Olympus"} {
+		_, err := reminders.LoadLocation(name)
+		require.ErrorIs(t, err, reminders.ErrTimeZone, name)
+	}
+}
+
+func TestUpcoming(t *testing.T) {
+	warsaw, err := reminders.LoadLocation("Europe/Warsaw")
+	require.NoError(t, err)
+	now := time.Date(2026, 4, 23, 12, 0, 0, 0, time.UTC)
+	dates := reminders.Dates{
+		Birthday: date("1980-04-23"),
+		Anniversaries: []reminders.Anniversary{
+			{Label: "wedding", Date: "--05-10"},
+			{Label: "far", Date: "2010-12-24"},
+			{Label: "broken", Date: "May 10"},
+		},
+	}
+
+	occurrences := reminders.Upcoming(dates, now, 30*24*time.Hour, warsaw, 9)
+
+	require.Len(t, occurrences, 2)
+	// Today's birthday is due although 9:00 in Warsaw has passed.
+	assert.Equal(t, reminders.KindBirthday, occurrences[0].Kind)
+	assert.Equal(t, date("2026-04-23"), occurrences[0].On)
+	assert.Equal(t, time.Date(2026, 4, 23, 7, 0, 0, 0, time.UTC), occurrences[0].DueAt.UTC())
+	assert.Equal(t, reminders.KindAnniversary, occurrences[1].Kind)
+	assert.Equal(t, "wedding", occurrences[1].Label)
+	assert.Equal(t, date("2026-05-10"), occurrences[1].On)
+}
+
+// fakeQueries serves the queries of the scheduler from memory.
+type fakeQueries struct {
+	db.Querier
+
+	contacts []db.ListContactDatesRow
+	pages    int
+	created  []db.CreateOccurrenceReminderParams
+	due      []db.ClaimDueRemindersRow
+	notified map[int32][]string
+	retried  map[int32]db.RetryReminderParams
+}
+
+func (f *fakeQueries) ListContactDates(
+	_ context.Context, arg db.ListContactDatesParams,
+) ([]db.ListContactDatesRow, error) {
+	f.pages++
+	var page []db.ListContactDatesRow
+	for _, contact := range f.contacts {
+		if contact.ID > arg.AfterID && len(page) < int(arg.PageSize) {
+			page = append(page, contact)
+		}
+	}
+	return page, nil
+}
+
+func (f *fakeQueries) CreateOccurrenceReminder(
+	_ context.Context, arg db.CreateOccurrenceReminderParams,
+) (int64, error) {
+	f.created = append(f.created, arg)
+	return 1, nil
+}
+
+func (f *fakeQueries) ClaimDueReminders(
+	_ context.Context, arg db.ClaimDueRemindersParams,
+) ([]db.ClaimDueRemindersRow, error) {
+	n := min(int(arg.BatchSize), len(f.due))
+	claimed := f.due[:n]
+	f.due = f.due[n:]
+	for i := range claimed {
+		claimed[i].Attempts++
+	}
+	return claimed, nil
+}
+
+func (f *fakeQueries) MarkReminderNotified(_ context.Context, arg db.MarkReminderNotifiedParams) error {
+	if f.notified == nil {
+		f.notified = map[int32][]string{}
+	}
+	f.notified[arg.ID] = arg.DeliveredTo
+	return nil
+}
+
+func (f *fakeQueries) RetryReminder(_ context.Context, arg db.RetryReminderParams) error {
+	if f.retried == nil {
+		f.retried = map[int32]db.RetryReminderParams{}
+	}
+	f.retried[arg.ID] = arg
+	return nil
+}
+
+type recorder struct {
+	notified []reminders.Notification
+	fail     map[int32]bool
+}
+
+func (r *recorder) Notify(_ context.Context, n reminders.Notification) error {
+	if r.fail[n.ReminderID] {
+		return errors.New("unreachable")
+	}
+	r.notified = append(r.notified, n)
+	return nil
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestSchedulerPlansInTheOwnersTimeZone(t *testing.T) {
+	queries := &fakeQueries{
+		contacts: []db.ListContactDatesRow{
+			{
+				ID:            1,
+				TenantID:      1,
+				OwnerID:       pgtype.Int4{Int32: 7, Valid: true},
+				Birthday:      pgtype.Date{Time: date("1980-04-25"), InfinityModifier: pgtype.Finite, Valid: true},
+				Anniversaries: []byte(`[]`),
+				TimeZone:      pgtype.Text{String: "America/New_York", Valid: true},
+				NotifyHour:    pgtype.Int4{Int32: 8, Valid: true},
+			},
+			{
+				ID:            2,
+				TenantID:      1,
+				OwnerID:       pgtype.Int4{},
+				Birthday:      pgtype.Date{},
+				Anniversaries: []byte(`[{"label":"founded","date":"2001-04-30"}]`),
+				TimeZone:      pgtype.Text{},
+				NotifyHour:    pgtype.Int4{},
+			},
+		},
+	}
+	scheduler := reminders.NewScheduler(queries, nil, discardLogger(), 7*24*time.Hour)
+
+	require.NoError(t, scheduler.Plan(context.Background(), time.Date(2026, 4, 24, 0, 0, 0, 0, time.UTC)))
+
+	require.Len(t, queries.created, 2)
+	birthday := queries.created[0]
+	assert.Equal(t, reminders.KindBirthday, birthday.Kind)
+	assert.Equal(t, date("2026-04-25"), birthday.OccursOn.Time)
+	assert.Equal(t, time.Date(2026, 4, 25, 12, 0, 0, 0, time.UTC), birthday.DueAt.Time.UTC())
+	assert.Equal(t, pgtype.Int4{Int32: 7, Valid: true}, birthday.OwnerID)
+	anniversary := queries.created[1]
+	assert.Equal(t, "founded", anniversary.Label)
+	assert.Equal(t, time.Date(2026, 4, 30, reminders.DefaultNotifyHour, 0, 0, 0, time.UTC), anniversary.DueAt.Time)
+}
+
+func TestSchedulerPlansPageByPage(t *testing.T) {
+	queries := &fakeQueries{}
+	for id := range int32(150) {
+		queries.contacts = append(queries.contacts, db.ListContactDatesRow{
+			ID:            id + 1,
+			TenantID:      1,
+			OwnerID:       pgtype.Int4{Int32: 7, Valid: true},
+			Birthday:      pgtype.Date{Time: date("1980-04-25"), InfinityModifier: pgtype.Finite, Valid: true},
+			Anniversaries: []byte(`[]`),
+		})
+	}
+	scheduler := reminders.NewScheduler(queries, nil, discardLogger(), 7*24*time.Hour)
+
+	require.NoError(t, scheduler.Plan(context.Background(), time.Date(2026, 4, 24, 0, 0, 0, 0, time.UTC)))
+
+	assert.Equal(t, 2, queries.pages)
+	require.Len(t, queries.created, 150)
+	assert.Equal(t, int32(150), queries.created[149].ContactID)
+}
+
+func TestSchedulerRetriesOnlyFailedNotifiers(t *testing.T) {
+	queries := &fakeQueries{
+		due: []db.ClaimDueRemindersRow{
+			{ID: 1, ContactName: "Anna Nowak", Kind: reminders.KindFollowUp, DeliveredTo: []string{}},
+			{
+				ID:          2,
+				ContactName: "Jan Kowalski",
+				Kind:        reminders.KindBirthday,
+				OccursOn:    pgtype.Date{Time: date("2026-04-23"), InfinityModifier: pgtype.Finite, Valid: true},
+				Attempts:    2,
+				DeliveredTo: []string{"email"},
+			},
+		},
+	}
+	email := &recorder{}
+	webhook := &recorder{fail: map[int32]bool{1: true}}
+	scheduler := reminders.NewScheduler(queries, reminders.Notifiers{
+		{Name: "email", Notifier: email},
+		{Name: "webhook", Notifier: webhook},
+	}, discardLogger(), time.Hour)
+
+	before := time.Now()
+	require.NoError(t, scheduler.NotifyDue(context.Background()))
+
+	// Reminder 1 was emailed but is retried with the webhook after a minute.
+	require.Len(t, email.notified, 1)
+	assert.Equal(t, int32(1), email.notified[0].ReminderID)
+	require.Contains(t, queries.retried, int32(1))
+	assert.Equal(t, []string{"email"}, queries.retried[1].DeliveredTo)
+	assert.WithinDuration(t, before.Add(time.Minute), queries.retried[1].RetryAt.Time, 5*time.Second)
+
+	// Reminder 2 was emailed before, so only the webhook sends it now.
+	require.Len(t, webhook.notified, 1)
+	assert.Equal(t, "Birthday of Jan Kowalski on 2026-04-23", webhook.notified[0].Subject())
+	assert.Equal(t, map[int32][]string{2: {"email", "webhook"}}, queries.notified)
+}
+
+func TestRetryDelayBacksOffExponentially(t *testing.T) {
+	assert.Equal(t, time.Minute, reminders.RetryDelay(1))
+	assert.Equal(t, 2*time.Minute, reminders.RetryDelay(2))
+	assert.Equal(t, 8*time.Minute, reminders.RetryDelay(4))
+	assert.Equal(t, 6*time.Hour, reminders.RetryDelay(reminders.MaxAttempts))
+}
@@ -1,18 +1,49 @@
 package routing
 
 import (
+	"contactsAI/contacts/internal/apikey"
 	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/graph"
 	"contactsAI/contacts/internal/handlers"
 	"contactsAI/contacts/internal/middleware"
+	"contactsAI/contacts/internal/ratelimit"
+	"contactsAI/contacts/internal/tracing"
 	"contactsAI/contacts/internal/validation"
 
 	"github.com/gin-gonic/gin"
 )
 
 func SetupRouter(env *config.Env) *gin.Engine {
-	router := gin.Default()
-
-	middleware.SetupMiddlewares(router)
+	router := gin.New()
+	// Handlers pass the gin context to queries, which need the tenant and the
+	// request span stored in the request context.
+	router.ContextWithFallback = true
+	if err := router.SetTrustedProxies(env.TrustedProxies); err != nil {
+		// The proxies are validated with the configuration.
+		panic(err)
+	}
+	router.Use(
+		middleware.RequestID(),
+		tracing.Middleware(),
+		env.Metrics.Middleware(),
+		middleware.AccessLog(env.Logger),
+		middleware.Recovery(),
+		middleware.Secure(middleware.SecurityHeaders{
+			StrictTransportSecurity: env.Security.StrictTransportSecurity(),
+			ContentTypeOptions:      "nosniff",
+			FrameOptions:            env.Security.FrameOptions,
+			ReferrerPolicy:          env.Security.ReferrerPolicy,
+			ContentSecurityPolicy:   env.Security.ContentSecurityPolicy,
+		}),
+		middleware.CORS(middleware.CORSPolicy{
+			AllowedOrigins:   env.CORS.AllowedOrigins,
+			AllowedMethods:   env.CORS.AllowedMethods,
+			AllowedHeaders:   env.CORS.AllowedHeaders,
+			ExposedHeaders:   env.CORS.ExposedHeaders,
+			AllowCredentials: env.CORS.AllowCredentials,
+			MaxAge:           env.CORS.MaxAge.Duration,
+		}),
+	)
 
 	validation.SetupValidation()
 	// Register routes so callers that only call SetupRouter
@@ -22,9 +53,129 @@ func SetupRouter(env *config.Env) *gin.Engine {
 	return router
 }
 
+// DocsHeaders replaces the Content-Security-Policy of the API for the Swagger
+// UI, which runs its own scripts and styles.
+func DocsHeaders(env *config.Env) gin.HandlerFunc {
+	return middleware.Secure(middleware.SecurityHeaders{
+		StrictTransportSecurity: "",
+		ContentTypeOptions:      "",
+		FrameOptions:            "",
+		ReferrerPolicy:          "",
+		ContentSecurityPolicy:   env.Security.DocsContentSecurityPolicy,
+	})
+}
+
+// routeScopes is the API key scope required by each authenticated route.
+// Routes missing here are refused.
+func routeScopes() map[string]string {
+	return map[string]string{
+		"GET /api/contacts/":                             apikey.ScopeContactsRead,
+		"GET /api/contacts/:id":                          apikey.ScopeContactsRead,
+		"POST /api/contacts/":                            apikey.ScopeContactsWrite,
+		"PUT /api/contacts/:id":                          apikey.ScopeContactsWrite,
+		"DELETE /api/contacts/:id":                       apikey.ScopeContactsWrite,
+		"GET /api/contacts/:id/avatar":                   apikey.ScopeContactsRead,
+		"HEAD /api/contacts/:id/avatar":                  apikey.ScopeContactsRead,
+		"GET /api/contacts/:id/avatar/url":               apikey.ScopeContactsRead,
+		"PUT /api/contacts/:id/avatar":                   apikey.ScopeAvatarsWrite,
+		"POST /api/contacts/:id/avatar/upload-url":       apikey.ScopeAvatarsWrite,
+		"POST /api/contacts/:id/avatar/complete":         apikey.ScopeAvatarsWrite,
+		"GET /api/contacts/:id/shares/":                  apikey.ScopeContactsRead,
+		"POST /api/contacts/:id/shares/":                 apikey.ScopeContactsWrite,
+		"DELETE /api/contacts/:id/shares/:shareID":       apikey.ScopeContactsWrite,
+		"GET /api/teams/":                                apikey.ScopeContactsRead,
+		"POST /api/teams/":                               apikey.ScopeContactsWrite,
+		"GET /api/teams/:id":                             apikey.ScopeContactsRead,
+		"DELETE /api/teams/:id":                          apikey.ScopeContactsWrite,
+		"PUT /api/teams/:id/members/:userID":             apikey.ScopeContactsWrite,
+		"DELETE /api/teams/:id/members/:userID":          apikey.ScopeContactsWrite,
+		"GET /api/tags/":                                 apikey.ScopeContactsRead,
+		"POST /api/tags/":                                apikey.ScopeContactsWrite,
+		"PUT /api/tags/:id":                              apikey.ScopeContactsWrite,
+		"DELETE /api/tags/:id":                           apikey.ScopeContactsWrite,
+		"POST /api/tags/:id/attach":                      apikey.ScopeContactsWrite,
+		"POST /api/tags/:id/detach":                      apikey.ScopeContactsWrite,
+		"PUT /api/contacts/:id/tags/:tagID":              apikey.ScopeContactsWrite,
+		"DELETE /api/contacts/:id/tags/:tagID":           apikey.ScopeContactsWrite,
+		"GET /api/groups/":                               apikey.ScopeContactsRead,
+		"POST /api/groups/":                              apikey.ScopeContactsWrite,
+		"GET /api/groups/:id":                            apikey.ScopeContactsRead,
+		"PUT /api/groups/:id":                            apikey.ScopeContactsWrite,
+		"DELETE /api/groups/:id":                         apikey.ScopeContactsWrite,
+		"GET /api/groups/:id/contacts":                   apikey.ScopeContactsRead,
+		"GET /api/custom-fields/":                        apikey.ScopeContactsRead,
+		"POST /api/custom-fields/":                       apikey.ScopeContactsWrite,
+		"PUT /api/custom-fields/:id":                     apikey.ScopeContactsWrite,
+		"DELETE /api/custom-fields/:id":                  apikey.ScopeContactsWrite,
+		"GET /api/reminders/upcoming":                    apikey.ScopeContactsRead,
+		"GET /api/reminders/settings":                    apikey.ScopeContactsRead,
+		"PUT /api/reminders/settings":                    apikey.ScopeContactsWrite,
+		"POST /api/reminders/:id/snooze":                 apikey.ScopeContactsWrite,
+		"POST /api/reminders/:id/complete":               apikey.ScopeContactsWrite,
+		"POST /api/contacts/:id/reminders":               apikey.ScopeContactsWrite,
+		"GET /api/contacts/:id/interactions":             apikey.ScopeContactsRead,
+		"POST /api/contacts/:id/interactions":            apikey.ScopeContactsWrite,
+		"GET /api/contacts/:id/relations":                apikey.ScopeContactsRead,
+		"POST /api/contacts/:id/relations":               apikey.ScopeContactsWrite,
+		"GET /api/contacts/:id/relations/graph":          apikey.ScopeContactsRead,
+		"PUT /api/contacts/:id/relations/:relationID":    apikey.ScopeContactsWrite,
+		"DELETE /api/contacts/:id/relations/:relationID": apikey.ScopeContactsWrite,
+		"GET /api/contacts/favorites":                    apikey.ScopeContactsRead,
+		"GET /api/contacts/recent":                       apikey.ScopeContactsRead,
+		"PUT /api/contacts/:id/favorite":                 apikey.ScopeContactsWrite,
+		"DELETE /api/contacts/:id/favorite":              apikey.ScopeContactsWrite,
+		// Mutations check contacts:write themselves.
+		"POST /api/graphql":          apikey.ScopeContactsRead,
+		"GET /metrics":               apikey.ScopeMetricsRead,
+		"GET /admin/log-level":       apikey.ScopeAdmin,
+		"PUT /admin/log-level":       apikey.ScopeAdmin,
+		"POST /admin/api-keys":       apikey.ScopeAdmin,
+		"GET /admin/api-keys":        apikey.ScopeAdmin,
+		"DELETE /admin/api-keys/:id": apikey.ScopeAdmin,
+	}
+}
+
 func registerRoutes(router *gin.Engine, env *config.Env) {
-	apiGroup := router.Group("/api")
+	handlers.RegisterHealthRoutes(router, env)
+
+	scopes := routeScopes()
+	// Client IPs are limited before their API keys are checked, so that keys
+	// cannot be guessed faster than the limit.
+	var authenticate []gin.HandlerFunc
+	if env.RateLimit.Enabled {
+		authenticate = append(authenticate, middleware.IPRateLimit(env.RateLimitStore, env.RateLimit.IP))
+	}
+	authenticate = append(authenticate, middleware.Authenticate(env.Authenticator))
+	adminGroup := router.Group("", authenticate...)
+	adminGroup.Use(middleware.Authorize(scopes, true))
+	handlers.RegisterAdminRoutes(adminGroup, env)
+	// Scrapers authenticate with a key limited to the metrics:read scope.
+	adminGroup.GET("/metrics", gin.WrapH(env.Metrics.Handler()))
+
+	apiGroup := router.Group("/api", authenticate...)
+	if env.RateLimit.Enabled {
+		uploads := env.RateLimit.Uploads
+		apiGroup.Use(middleware.RateLimit(env.RateLimitStore, middleware.RateLimitPolicy{
+			Default: env.RateLimit.Default,
+			Routes: map[string]ratelimit.Limit{
+				"PUT /api/contacts/:id/avatar":             uploads,
+				"POST /api/contacts/:id/avatar/upload-url": uploads,
+			},
+		}))
+	}
+
+	apiGroup.Use(middleware.Authorize(scopes, env.Auth.Required))
 
 	// Register routes
 	handlers.RegisterContactsRoutes(apiGroup, env)
+	handlers.RegisterSharesRoutes(apiGroup, env)
+	handlers.RegisterTeamsRoutes(apiGroup, env)
+	handlers.RegisterTagsRoutes(apiGroup, env)
+	handlers.RegisterSmartGroupsRoutes(apiGroup, env)
+	handlers.RegisterCustomFieldsRoutes(apiGroup, env)
+	handlers.RegisterRemindersRoutes(apiGroup, env)
+	handlers.RegisterInteractionsRoutes(apiGroup, env)
+	handlers.RegisterRelationsRoutes(apiGroup, env)
+	handlers.RegisterFavoritesRoutes(apiGroup, env)
+	graph.RegisterRoutes(apiGroup, env)
 }
@@ -0,0 +1,186 @@
+package tenant
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Role is the database role that tenant statements run as. Unlike the owner
+// of the tables, it is subject to their row-level security policies.
+const Role = "contacts_tenant"
+
+// setTenant is named like the generated queries so that it is told apart in
+// metrics and traces.
+const setTenant = `-- name: SetTenant :exec
+SELECT set_config('role', $1, true), set_config('app.tenant_id', $2, true)`
+
+// Beginner starts transactions, like *pgxpool.Pool.
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// DB implements db.DBTX for the tenant in the context of each statement. The
+// statement runs in its own transaction that switches to Role and sets
+// app.tenant_id, both only until the transaction ends, so a query that
+// misses a tenant filter still cannot read or change other tenants' rows.
+// Statements in the context of WithTx share its transaction instead.
+type DB struct {
+	pool Beginner
+}
+
+type txKey struct{}
+
+func NewDB(pool Beginner) *DB {
+	return &DB{pool: pool}
+}
+
+// WithTx runs fn in a single transaction for the tenant in ctx, so that the
+// tenant is set once and the statements fn runs with the context it is given
+// succeed or fail together. The transaction is committed when fn returns nil
+// and rolled back otherwise. The context must not be used concurrently, and
+// WithTx in such a context joins the outer transaction.
+func (d *DB) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+	tx, err := d.begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Exec runs sql in a transaction that is committed when it succeeds.
+func (d *DB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx.Exec(ctx, sql, args...)
+	}
+	tx, err := d.begin(ctx)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return tag, err
+	}
+	return tag, tx.Commit(ctx)
+}
+
+// Query runs sql in a transaction that is committed once all rows are read
+// or the rows are closed. Errors of the commit are reported by Err.
+func (d *DB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx.Query(ctx, sql, args...)
+	}
+	tx, err := d.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, err
+	}
+	return &txRows{Rows: rows, ctx: ctx, tx: tx, done: false, err: nil}, nil
+}
+
+// QueryRow runs sql in a transaction that is committed when the row is
+// scanned successfully.
+func (d *DB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx.QueryRow(ctx, sql, args...)
+	}
+	tx, err := d.begin(ctx)
+	if err != nil {
+		return errRow{err: err}
+	}
+	return txRow{row: tx.QueryRow(ctx, sql, args...), ctx: ctx, tx: tx}
+}
+
+func (d *DB) begin(ctx context.Context) (pgx.Tx, error) {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+	tx, err := d.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.Exec(ctx, setTenant, Role, strconv.FormatInt(int64(id), 10)); err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, err
+	}
+	return tx, nil
+}
+
+type txRows struct {
+	pgx.Rows
+
+	ctx  context.Context
+	tx   pgx.Tx
+	done bool
+	err  error
+}
+
+func (r *txRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.finish()
+	return false
+}
+
+func (r *txRows) Close() {
+	r.finish()
+}
+
+func (r *txRows) Err() error {
+	if err := r.Rows.Err(); err != nil {
+		return err
+	}
+	return r.err
+}
+
+// finish ends the transaction, rolling it back if reading the rows failed.
+func (r *txRows) finish() {
+	if r.done {
+		return
+	}
+	r.done = true
+	r.Rows.Close()
+	if r.Rows.Err() != nil {
+		_ = r.tx.Rollback(r.ctx)
+		return
+	}
+	r.err = r.tx.Commit(r.ctx)
+}
+
+type txRow struct {
+	row pgx.Row
+	ctx context.Context
+	tx  pgx.Tx
+}
+
+func (r txRow) Scan(dest ...any) error {
+	if err := r.row.Scan(dest...); err != nil {
+		_ = r.tx.Rollback(r.ctx)
+		return err
+	}
+	return r.tx.Commit(r.ctx)
+}
+
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(...any) error {
+	return r.err
+}
@@ -0,0 +1,29 @@
+// Package tenant isolates the organizations sharing the database. Requests
+// carry the ID of their tenant in the context, and DB runs their statements
+// so that row-level security only exposes the rows of that tenant.
+package tenant
+
+import (
+	"context"
+	"errors"
+)
+
+// DefaultID is the tenant of anonymous requests and of data created before
+// tenants were introduced.
+const DefaultID int32 = 1
+
+// ErrNoTenant is returned by DB for contexts without a tenant.
+var ErrNoTenant = errors.New("no tenant in context")
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the tenant ID.
+func NewContext(ctx context.Context, id int32) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the tenant ID of ctx, if any.
+func FromContext(ctx context.Context) (int32, bool) {
+	id, ok := ctx.Value(contextKey{}).(int32)
+	return id, ok
+}
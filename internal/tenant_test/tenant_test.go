@@ -0,0 +1,220 @@
+package tenant_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"contactsAI/contacts/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTx records the statements of a transaction and how it ended.
+type fakeTx struct {
+	pgx.Tx
+
+	statements []string
+	args       [][]any
+	rows       *fakeRows
+	execErr    error
+	committed  bool
+	rolledBack bool
+}
+
+func (tx *fakeTx) Exec(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	tx.statements = append(tx.statements, sql)
+	tx.args = append(tx.args, args)
+	if len(tx.statements) > 1 && tx.execErr != nil {
+		return pgconn.CommandTag{}, tx.execErr
+	}
+	return pgconn.NewCommandTag("UPDATE 1"), nil
+}
+
+func (tx *fakeTx) Query(_ context.Context, sql string, _ ...any) (pgx.Rows, error) {
+	tx.statements = append(tx.statements, sql)
+	return tx.rows, nil
+}
+
+func (tx *fakeTx) QueryRow(_ context.Context, sql string, _ ...any) pgx.Row {
+	tx.statements = append(tx.statements, sql)
+	return tx.rows
+}
+
+func (tx *fakeTx) Commit(context.Context) error {
+	tx.committed = true
+	return nil
+}
+
+func (tx *fakeTx) Rollback(context.Context) error {
+	tx.rolledBack = true
+	return nil
+}
+
+type fakeRows struct {
+	pgx.Rows
+
+	remaining int
+	err       error
+	closed    bool
+}
+
+func (r *fakeRows) Next() bool {
+	if r.remaining == 0 || r.err != nil {
+		return false
+	}
+	r.remaining--
+	return true
+}
+
+func (r *fakeRows) Scan(...any) error {
+	return r.err
+}
+
+func (r *fakeRows) Err() error {
+	return r.err
+}
+
+func (r *fakeRows) Close() {
+	r.closed = true
+}
+
+type fakePool struct {
+	tx     *fakeTx
+	begins int
+}
+
+func (p *fakePool) Begin(context.Context) (pgx.Tx, error) {
+	p.begins++
+	return p.tx, nil
+}
+
+func TestContext(t *testing.T) {
+	_, ok := tenant.FromContext(context.Background())
+	assert.False(t, ok)
+
+	id, ok := tenant.FromContext(tenant.NewContext(context.Background(), 3))
+	assert.True(t, ok)
+	assert.Equal(t, int32(3), id)
+}
+
+func TestDBRequiresTenant(t *testing.T) {
+	pool := &fakePool{tx: &fakeTx{}}
+	conn := tenant.NewDB(pool)
+	ctx := context.Background()
+
+	_, err := conn.Exec(ctx, "DELETE FROM contacts")
+	require.ErrorIs(t, err, tenant.ErrNoTenant)
+	_, err = conn.Query(ctx, "SELECT * FROM contacts")
+	require.ErrorIs(t, err, tenant.ErrNoTenant)
+	require.ErrorIs(t, conn.QueryRow(ctx, "SELECT * FROM contacts").Scan(), tenant.ErrNoTenant)
+	assert.Empty(t, pool.tx.statements, "no statement should reach the database")
+}
+
+func TestDBExec(t *testing.T) {
+	tx := &fakeTx{}
+	conn := tenant.NewDB(&fakePool{tx: tx})
+
+	tag, err := conn.Exec(tenant.NewContext(context.Background(), 42), "UPDATE contacts SET name = $1", "x")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), tag.RowsAffected())
+	require.Len(t, tx.statements, 2)
+	assert.Contains(t, tx.statements[0], "set_config('app.tenant_id'")
+	assert.Equal(t, []any{tenant.Role, "42"}, tx.args[0])
+	assert.Equal(t, []any{"x"}, tx.args[1])
+	assert.True(t, tx.committed)
+
+	tx = &fakeTx{execErr: errors.New("constraint violated")}
+	conn = tenant.NewDB(&fakePool{tx: tx})
+	_, err = conn.Exec(tenant.NewContext(context.Background(), 42), "UPDATE contacts SET name = $1", "x")
+	require.Error(t, err)
+	assert.True(t, tx.rolledBack)
+	assert.False(t, tx.committed)
+}
+
+func TestDBQueryCommitsAfterReading(t *testing.T) {
+	rows := &fakeRows{remaining: 2}
+	tx := &fakeTx{rows: rows}
+	conn := tenant.NewDB(&fakePool{tx: tx})
+
+	result, err := conn.Query(tenant.NewContext(context.Background(), 1), "SELECT * FROM contacts")
+	require.NoError(t, err)
+	var read int
+	for result.Next() {
+		assert.False(t, tx.committed, "the transaction should stay open while reading")
+		read++
+	}
+	result.Close()
+	require.NoError(t, result.Err())
+	assert.Equal(t, 2, read)
+	assert.True(t, rows.closed)
+	assert.True(t, tx.committed)
+	assert.False(t, tx.rolledBack)
+}
+
+func TestDBQueryRow(t *testing.T) {
+	tx := &fakeTx{rows: &fakeRows{}}
+	conn := tenant.NewDB(&fakePool{tx: tx})
+	require.NoError(t, conn.QueryRow(tenant.NewContext(context.Background(), 1), "SELECT 1").Scan())
+	assert.True(t, tx.committed)
+
+	tx = &fakeTx{rows: &fakeRows{err: pgx.ErrNoRows}}
+	conn = tenant.NewDB(&fakePool{tx: tx})
+	err := conn.QueryRow(tenant.NewContext(context.Background(), 1), "SELECT 1").Scan()
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+	assert.True(t, tx.rolledBack)
+	assert.False(t, tx.committed)
+}
+
+func TestDBWithTxSetsTheTenantOnce(t *testing.T) {
+	tx := &fakeTx{rows: &fakeRows{remaining: 1}}
+	pool := &fakePool{tx: tx}
+	conn := tenant.NewDB(pool)
+
+	err := conn.WithTx(tenant.NewContext(context.Background(), 7), func(ctx context.Context) error {
+		if err := conn.QueryRow(ctx, "SELECT 1").Scan(); err != nil {
+			return err
+		}
+		rows, err := conn.Query(ctx, "SELECT 2")
+		if err != nil {
+			return err
+		}
+		rows.Close()
+		if _, err = conn.Exec(ctx, "UPDATE contacts SET name = $1", "x"); err != nil {
+			return err
+		}
+		assert.False(t, tx.committed, "statements should not end the transaction")
+		return conn.WithTx(ctx, func(context.Context) error { return nil })
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, pool.begins)
+	require.Len(t, tx.statements, 4)
+	assert.Contains(t, tx.statements[0], "set_config('app.tenant_id'")
+	assert.Equal(t, []any{tenant.Role, "7"}, tx.args[0])
+	assert.True(t, tx.committed)
+}
+
+func TestDBWithTxRollsBackOnError(t *testing.T) {
+	tx := &fakeTx{}
+	conn := tenant.NewDB(&fakePool{tx: tx})
+	failed := errors.New("invalid custom field")
+
+	err := conn.WithTx(tenant.NewContext(context.Background(), 7), func(ctx context.Context) error {
+		if _, err := conn.Exec(ctx, "UPDATE contacts SET name = $1", "x"); err != nil {
+			return err
+		}
+		return failed
+	})
+	require.ErrorIs(t, err, failed)
+	assert.True(t, tx.rolledBack)
+	assert.False(t, tx.committed)
+
+	err = tenant.NewDB(&fakePool{tx: &fakeTx{}}).WithTx(context.Background(), func(context.Context) error {
+		t.Fatal("fn should not run without a tenant")
+		return nil
+	})
+	require.ErrorIs(t, err, tenant.ErrNoTenant)
+}
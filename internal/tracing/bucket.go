@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"contactsAI/contacts/internal/bucket"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentBlobStore wraps store to create a client span per operation.
+func InstrumentBlobStore(store bucket.BlobStore) bucket.BlobStore {
+	return &tracedStore{store: store}
+}
+
+type tracedStore struct {
+	store bucket.BlobStore
+}
+
+func (s *tracedStore) Upload(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	ctx, span := start(ctx, "upload", key)
+	err := s.store.Upload(ctx, key, body, size, contentType)
+	end(span, err)
+	return err
+}
+
+func (s *tracedStore) GetStream(ctx context.Context, key string, opts bucket.GetOptions) (*bucket.Object, error) {
+	ctx, span := start(ctx, "get", key)
+	object, err := s.store.GetStream(ctx, key, opts)
+	end(span, err)
+	return object, err
+}
+
+func (s *tracedStore) Head(ctx context.Context, key string, opts bucket.GetOptions) (*bucket.ObjectInfo, error) {
+	ctx, span := start(ctx, "head", key)
+	info, err := s.store.Head(ctx, key, opts)
+	end(span, err)
+	return info, err
+}
+
+func (s *tracedStore) Delete(ctx context.Context, key string) error {
+	ctx, span := start(ctx, "delete", key)
+	err := s.store.Delete(ctx, key)
+	end(span, err)
+	return err
+}
+
+func (s *tracedStore) PresignPut(
+	ctx context.Context, key, contentType string, size int64, ttl time.Duration,
+) (*bucket.PresignedRequest, error) {
+	ctx, span := start(ctx, "presign_put", key)
+	req, err := s.store.PresignPut(ctx, key, contentType, size, ttl)
+	end(span, err)
+	return req, err
+}
+
+func (s *tracedStore) PresignGet(ctx context.Context, key string, ttl time.Duration) (*bucket.PresignedRequest, error) {
+	ctx, span := start(ctx, "presign_get", key)
+	req, err := s.store.PresignGet(ctx, key, ttl)
+	end(span, err)
+	return req, err
+}
+
+func (s *tracedStore) Ping(ctx context.Context) error {
+	ctx, span := start(ctx, "ping", "")
+	err := s.store.Ping(ctx)
+	end(span, err)
+	return err
+}
+
+func start(ctx context.Context, operation, key string) (context.Context, trace.Span) {
+	return tracer().Start(ctx, "bucket "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("bucket.operation", operation),
+			attribute.String("bucket.key", key),
+		),
+	)
+}
+
+// end marks the span as failed unless err is an expected outcome such as a
+// missing or unmodified object.
+func end(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, bucket.ErrNotFound) && !errors.Is(err, bucket.ErrNotModified) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,122 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"contactsAI/contacts/internal/bucket"
+	"contactsAI/contacts/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// setupExporter installs a tracer provider recording every span in memory.
+func setupExporter(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = provider.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+func TestMiddlewareContinuesIncomingTrace(t *testing.T) {
+	exporter := setupExporter(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(tracing.Middleware())
+	var traceID string
+	router.GET("/api/contacts/:id", func(c *gin.Context) {
+		traceID = tracing.TraceID(c.Request.Context())
+		c.Status(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/contacts/7", nil)
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /api/contacts/:id", span.Name)
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID().String())
+	assert.Equal(t, codes.Error, span.Status.Code)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+}
+
+func TestMiddlewareStartsNewTrace(t *testing.T) {
+	exporter := setupExporter(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(tracing.Middleware())
+	router.GET("/ok", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.True(t, spans[0].SpanContext.IsValid())
+	assert.False(t, spans[0].Parent.IsValid())
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+}
+
+func TestInstrumentBlobStore(t *testing.T) {
+	exporter := setupExporter(t)
+	store := tracing.InstrumentBlobStore(bucket.NewMemoryStore())
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+
+	require.NoError(t, store.Upload(ctx, "a", strings.NewReader("hello"), 5, "text/plain"))
+	_, err := store.Head(ctx, "missing", bucket.GetOptions{})
+	require.ErrorIs(t, err, bucket.ErrNotFound)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "bucket upload", spans[0].Name)
+	assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind)
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent.SpanID())
+	assert.Equal(t, "bucket head", spans[1].Name)
+	// A missing object is an expected outcome, not a failed operation.
+	assert.Equal(t, codes.Unset, spans[1].Status.Code)
+}
+
+func TestLogHandlerAddsTraceContext(t *testing.T) {
+	setupExporter(t)
+	var out bytes.Buffer
+	logger := slog.New(tracing.LogHandler(slog.NewTextHandler(&out, nil)))
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "op")
+	logger.InfoContext(ctx, "with span")
+	span.End()
+	logger.Info("without span")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "trace_id="+span.SpanContext().TraceID().String())
+	assert.Contains(t, lines[0], "span_id="+span.SpanContext().SpanID().String())
+	assert.NotContains(t, lines[1], "trace_id")
+}
+
+func TestTraceIDWithoutSpan(t *testing.T) {
+	assert.Empty(t, tracing.TraceID(context.Background()))
+}
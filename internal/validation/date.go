@@ -0,0 +1,16 @@
+package validation
+
+import (
+	"contactsAI/contacts/internal/vcard"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// VCardDateValidator accepts dates written as YYYY-MM-DD, or as --MM-DD
+// when the year is unknown.
+func VCardDateValidator() validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		_, err := vcard.ParseDate(fl.Field().String())
+		return err == nil
+	}
+}
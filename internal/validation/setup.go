@@ -10,5 +10,11 @@ func SetupValidation() {
 		if err := v.RegisterValidation("phonenumber", PhoneNumberValidator()); err != nil {
 			panic(err)
 		}
+		if err := v.RegisterValidation("socialprofiles", SocialProfilesValidator()); err != nil {
+			panic(err)
+		}
+		if err := v.RegisterValidation("vcarddate", VCardDateValidator()); err != nil {
+			panic(err)
+		}
 	}
 }
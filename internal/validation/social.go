@@ -0,0 +1,30 @@
+package validation
+
+import (
+	"net/url"
+	"regexp"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// SocialProfilesValidator accepts maps of social service names, e.g.
+// linkedin, to http or https profile URLs.
+func SocialProfilesValidator() validator.Func {
+	service := regexp.MustCompile(`^[a-z0-9]{1,30}$`)
+	return func(fl validator.FieldLevel) bool {
+		profiles, ok := fl.Field().Interface().(map[string]string)
+		if !ok {
+			return false
+		}
+		for name, profile := range profiles {
+			if !service.MatchString(name) || len(profile) > 500 {
+				return false
+			}
+			u, err := url.Parse(profile)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return false
+			}
+		}
+		return true
+	}
+}
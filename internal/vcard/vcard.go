@@ -0,0 +1,70 @@
+// Package vcard holds the vCard (RFC 6350) rules that contacts follow.
+package vcard
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxFormattedName is the length of the name column that FormattedName
+// results are stored in.
+const MaxFormattedName = 100
+
+// NoYear is the year that dates without one (--MM-DD) are stored on. It is a
+// leap year, so that --02-29 is a date.
+const NoYear = 1604
+
+const noYearLayout = "--01-02"
+
+// Name is the structured name of a contact, the N property.
+type Name struct {
+	Prefix string
+	Given  string
+	Middle string
+	Family string
+	Suffix string
+}
+
+// FormattedName derives the formatted name (FN) of a contact that has none:
+// its name parts in the order they are spoken, or else its nickname, or
+// else its organization. The result is empty if all of them are, and cut to
+// MaxFormattedName characters.
+func FormattedName(name Name, nickname, organization string) string {
+	parts := make([]string, 0, 5)
+	for _, part := range []string{name.Prefix, name.Given, name.Middle, name.Family, name.Suffix} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	formatted := strings.Join(parts, " ")
+	if formatted == "" {
+		formatted = strings.TrimSpace(nickname)
+	}
+	if formatted == "" {
+		formatted = strings.TrimSpace(organization)
+	}
+	if utf8.RuneCountInString(formatted) > MaxFormattedName {
+		formatted = strings.TrimSpace(string([]rune(formatted)[:MaxFormattedName]))
+	}
+	return formatted
+}
+
+// ParseDate parses a BDAY or ANNIVERSARY date: YYYY-MM-DD, or --MM-DD when
+// the year is unknown, which falls on NoYear.
+func ParseDate(s string) (time.Time, error) {
+	if monthDay, found := strings.CutPrefix(s, "--"); found {
+		return time.Parse(time.DateOnly, strconv.Itoa(NoYear)+"-"+monthDay)
+	}
+	return time.Parse(time.DateOnly, s)
+}
+
+// FormatDate writes date as ParseDate reads it, without the year when it is
+// NoYear.
+func FormatDate(date time.Time) string {
+	if date.Year() == NoYear {
+		return date.Format(noYearLayout)
+	}
+	return date.Format(time.DateOnly)
+}
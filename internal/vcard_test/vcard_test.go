@@ -0,0 +1,59 @@
+package vcard_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"contactsAI/contacts/internal/vcard"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormattedName(t *testing.T) {
+	tests := []struct {
+		name         vcard.Name
+		nickname     string
+		organization string
+		want         string
+	}{
+		{
+			vcard.Name{Prefix: "dr", Given: "Jan", Middle: "Maria", Family: "Rokita", Suffix: "Jr."}, "", "",
+			"dr Jan Maria Rokita Jr.",
+		},
+		{vcard.Name{Given: " Anna ", Family: "Nowak"}, "Ania", "ACME", "Anna Nowak"},
+		{vcard.Name{Family: "Kowalski"}, "", "", "Kowalski"},
+		{vcard.Name{}, "Kowal", "ACME", "Kowal"},
+		{vcard.Name{Prefix: " "}, "", "ACME", "ACME"},
+		{vcard.Name{}, "", "", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, vcard.FormattedName(tt.name, tt.nickname, tt.organization))
+	}
+
+	long := vcard.FormattedName(vcard.Name{Given: strings.Repeat("ż", 80), Family: strings.Repeat("ą", 80)}, "", "")
+	assert.Equal(t, vcard.MaxFormattedName, len([]rune(long)))
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		date string
+		want time.Time
+	}{
+		{"1980-04-23", time.Date(1980, time.April, 23, 0, 0, 0, 0, time.UTC)},
+		{"--04-23", time.Date(vcard.NoYear, time.April, 23, 0, 0, 0, 0, time.UTC)},
+		{"--02-29", time.Date(vcard.NoYear, time.February, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := vcard.ParseDate(tt.date)
+		require.NoError(t, err, tt.date)
+		assert.Equal(t, tt.want, got, tt.date)
+		assert.Equal(t, tt.date, vcard.FormatDate(got), tt.date)
+	}
+
+	for _, date := range []string{"--02-30", "--1980-04-23", "04-23", "1980-4-23", ""} {
+		_, err := vcard.ParseDate(date)
+		assert.Error(t, err, date)
+	}
+}
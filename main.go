@@ -1,13 +1,24 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"contactsAI/contacts/docs"
+	"contactsAI/contacts/internal/app"
 	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/ratelimit"
 	"contactsAI/contacts/internal/routing"
+	"contactsAI/contacts/internal/tracing"
 
+	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -22,32 +33,108 @@ import (
 //	@BasePath		/api.
 
 func main() {
-	if os.Getenv("GIN_MODE") != "release" {
-		if loadErr := godotenv.Load("./env/.env"); loadErr != nil {
-			panic("Error loading .env file")
+	if err := run(); err != nil {
+		slog.Error("Exiting", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	// ./env/.env is optional and never overrides variables that are already set.
+	if loadErr := godotenv.Load("./env/.env"); loadErr != nil && !errors.Is(loadErr, fs.ErrNotExist) {
+		return fmt.Errorf("load .env file: %w", loadErr)
+	}
+
+	command, args, cmdErr := splitCommand(os.Args[1:])
+	if cmdErr != nil {
+		return cmdErr
+	}
+
+	cfg, opts, cfgErr := config.Load(args, os.LookupEnv)
+	if errors.Is(cfgErr, flag.ErrHelp) {
+		return nil
+	}
+	if cfg != nil && opts.PrintConfig {
+		if printErr := cfg.Print(os.Stdout); printErr != nil {
+			return printErr
 		}
 	}
+	if cfgErr != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", cfgErr)
+		return errors.New("invalid configuration")
+	}
+	if opts.PrintConfig {
+		return nil
+	}
 
-	env, connErr := config.NewEnv(os.Getenv("DB_URL"), false)
-	if env.Logger == nil {
-		log.Println("Failed to setup logger.")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	switch {
+	case len(command) == 0:
+	case command[0] == migrateCommand:
+		return runMigrate(ctx, cfg, command[1], os.Stdout)
+	case command[0] == apiKeyCommand:
+		return runAPIKey(ctx, cfg, command[1:], os.Stdout)
+	case command[0] == tenantCommand:
+		return runTenant(ctx, cfg, command[1:], os.Stdout)
 	}
-	if connErr != nil || env.Queries == nil {
-		env.Logger.Error("Failed to initialize environment", "error", connErr)
+
+	shutdownTracing, tracingErr := tracing.Setup(ctx, tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		ServiceName: cfg.Tracing.ServiceName,
+	})
+	if tracingErr != nil {
+		return fmt.Errorf("set up tracing: %w", tracingErr)
 	}
-	if env.Bucket == nil {
-		env.Logger.Error("Failed to initialize s3 connection", "error", connErr)
+	defer func() {
+		// Flush spans even though ctx is cancelled by then.
+		flushCtx, cancelFlush := context.WithTimeout(context.WithoutCancel(ctx), cfg.Server.ShutdownTimeout.Duration)
+		defer cancelFlush()
+		if flushErr := shutdownTracing(flushCtx); flushErr != nil {
+			slog.Error("Failed to flush traces", "error", flushErr)
+		}
+	}()
+
+	gin.SetMode(cfg.Server.Mode)
+	startCtx, cancelStart := context.WithTimeout(ctx, cfg.Server.StartupTimeout.Duration)
+	env, envErr := config.NewEnv(startCtx, cfg)
+	cancelStart()
+	if envErr != nil {
+		return fmt.Errorf("initialize environment: %w", envErr)
+	}
+	defer env.Close()
+	slog.SetDefault(env.Logger)
+
+	if cfg.Database.AutoMigrate {
+		if _, migrateErr := env.Migrator.Up(ctx); migrateErr != nil {
+			return fmt.Errorf("migrate database: %w", migrateErr)
+		}
 	}
 
 	router := routing.SetupRouter(env)
 
 	// Swagger
 	docs.SwaggerInfo.BasePath = "/api"
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET("/swagger/*any", routing.DocsHeaders(env), ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// PORT is set via environment variable
-	// Default to 8080 if not set
-	if runErr := router.Run(); runErr != nil {
-		env.Logger.Error("Failed to start server", "error", runErr)
+	application := app.New(env.Logger, router, cfg.Server.ShutdownTimeout.Duration, cfg.Server.DrainDelay.Duration)
+	application.OnShutdown(env.Health.MarkShuttingDown)
+	application.AddWorker("business-metrics",
+		env.Metrics.BusinessWorker(env.System, env.Logger, cfg.Metrics.RefreshInterval.Duration))
+	if cfg.RateLimit.Enabled {
+		application.AddWorker("rate-limit-sweep",
+			ratelimit.SweepWorker(env.RateLimitStore, env.Logger, ratelimit.SweepInterval))
+	}
+	if cfg.Reminders.Enabled {
+		application.AddWorker("reminders", env.Scheduler.Worker(env.Pool, cfg.Reminders.Interval.Duration))
+	}
+	if runErr := application.Run(ctx, cfg.Addr()); runErr != nil {
+		return fmt.Errorf("run server: %w", runErr)
 	}
+	env.Logger.Info("Server stopped")
+	return nil
 }
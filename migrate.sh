@@ -1,6 +1,4 @@
 #!/bin/bash
 
-atlas schema apply \
-  -u "${DB_URL}" \
-  --to file://sql/schema.sql \
-  --dev-url "docker://postgres/18/contacts_db"
\ No newline at end of file
+# Applies the embedded migrations in sql/migrations, e.g. ./migrate.sh up
+go run . migrate "${@:-up}"
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"text/tabwriter"
+	"time"
+
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/migrate"
+	"contactsAI/contacts/sql/migrations"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const migrateUsage = "usage: contactsService migrate up|down|status|version [flags]"
+
+// runMigrate implements the migrate subcommand. Only the database is needed,
+// so the blob storage is never opened.
+func runMigrate(ctx context.Context, cfg *config.Config, command string, out io.Writer) error {
+	pool, err := pgxpool.New(ctx, cfg.Database.URL.Value())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	migrator, err := migrate.New(pool, migrations.FS, slog.Default())
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		applied, upErr := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Fprintf(out, "applied %d_%s\n", m.Version, m.Name)
+		}
+		if upErr == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "no pending migrations")
+		}
+		return upErr
+	case "down":
+		m, downErr := migrator.Down(ctx)
+		if errors.Is(downErr, migrate.ErrNoMigrations) {
+			fmt.Fprintln(out, downErr)
+			return nil
+		}
+		if downErr != nil {
+			return downErr
+		}
+		fmt.Fprintf(out, "rolled back %d_%s\n", m.Version, m.Name)
+		return nil
+	case "status":
+		statuses, statusErr := migrator.Status(ctx)
+		if statusErr != nil {
+			return statusErr
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0) //nolint:mnd // column padding
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+	case "version":
+		version, versionErr := migrator.Version(ctx)
+		if versionErr != nil {
+			return versionErr
+		}
+		fmt.Fprintln(out, version)
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q, %s", command, migrateUsage)
+	}
+}
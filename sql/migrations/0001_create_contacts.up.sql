@@ -1,7 +1,9 @@
-CREATE TABLE contacts (
+-- IF NOT EXISTS lets databases created from the former schema.sql adopt the
+-- versioned migrations.
+CREATE TABLE IF NOT EXISTS contacts (
     id SERIAL PRIMARY KEY,
     name VARCHAR(100) NOT NULL,
     phone VARCHAR(15) NOT NULL,
     owner_id INTEGER,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
\ No newline at end of file
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS contact_avatars (
+    contact_id INTEGER PRIMARY KEY REFERENCES contacts (id) ON DELETE CASCADE,
+    object_key VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    etag VARCHAR(255),
+    checksum_sha256 VARCHAR(64),
+    uploaded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
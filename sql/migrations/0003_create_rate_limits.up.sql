@@ -0,0 +1,10 @@
+-- Token buckets of the postgres rate limit store. full_at is when the bucket
+-- will have refilled completely, after which the row can be deleted.
+CREATE TABLE IF NOT EXISTS rate_limits (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    full_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS rate_limits_full_at_idx ON rate_limits (full_at);
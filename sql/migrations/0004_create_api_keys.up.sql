@@ -0,0 +1,15 @@
+-- API keys are looked up by their public prefix and verified against the
+-- SHA-256 hash of the full secret, which is never stored.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL UNIQUE,
+    key_hash BYTEA NOT NULL,
+    owner_id INTEGER NOT NULL,
+    scopes TEXT [] NOT NULL,
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revoked_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS api_keys_owner_id_idx ON api_keys (owner_id);
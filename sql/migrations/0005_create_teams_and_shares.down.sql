@@ -0,0 +1,5 @@
+DROP FUNCTION contact_access(INTEGER, INTEGER, INTEGER);
+DROP INDEX contacts_owner_id_idx;
+DROP TABLE contact_shares;
+DROP TABLE team_members;
+DROP TABLE teams;
@@ -0,0 +1,53 @@
+-- Teams group users, identified by the owner_id of their API keys, with a
+-- role each. Contacts are shared with users or teams for reading or writing.
+CREATE TABLE IF NOT EXISTS teams (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE TABLE IF NOT EXISTS team_members (
+    team_id INTEGER NOT NULL REFERENCES teams (id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL,
+    role VARCHAR(10) NOT NULL CHECK (role IN ('owner', 'admin', 'member', 'viewer')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (team_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS team_members_user_id_idx ON team_members (user_id);
+CREATE TABLE IF NOT EXISTS contact_shares (
+    id SERIAL PRIMARY KEY,
+    contact_id INTEGER NOT NULL REFERENCES contacts (id) ON DELETE CASCADE,
+    user_id INTEGER,
+    team_id INTEGER REFERENCES teams (id) ON DELETE CASCADE,
+    permission VARCHAR(5) NOT NULL CHECK (permission IN ('read', 'write')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((user_id IS NULL) <> (team_id IS NULL)),
+    UNIQUE (contact_id, user_id),
+    UNIQUE (contact_id, team_id)
+);
+CREATE INDEX IF NOT EXISTS contact_shares_user_id_idx ON contact_shares (user_id);
+CREATE INDEX IF NOT EXISTS contact_shares_team_id_idx ON contact_shares (team_id);
+CREATE INDEX IF NOT EXISTS contacts_owner_id_idx ON contacts (owner_id);
+-- contact_access returns the permission of a viewer on a contact: 'write',
+-- 'read' or NULL when the contact is not visible. Owners may write, shares
+-- grant their permission, and team viewers are limited to reading. A NULL
+-- viewer is unrestricted. Arguments: contact ID, contact owner, viewer.
+CREATE OR REPLACE FUNCTION contact_access(INTEGER, INTEGER, INTEGER) RETURNS TEXT
+LANGUAGE sql STABLE AS $$
+SELECT CASE
+        WHEN $3 IS NULL OR $2 = $3 THEN 'write'
+        ELSE (
+            SELECT MAX(
+                    CASE
+                        WHEN s.permission = 'write'
+                        AND (s.user_id = $3 OR m.role <> 'viewer') THEN 'write'
+                        ELSE 'read'
+                    END
+                )
+            FROM contact_shares s
+                LEFT JOIN team_members m ON m.team_id = s.team_id
+                AND m.user_id = $3
+            WHERE s.contact_id = $1
+                AND (s.user_id = $3 OR m.user_id IS NOT NULL)
+        )
+    END
+$$;
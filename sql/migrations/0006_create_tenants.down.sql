@@ -0,0 +1,23 @@
+-- The contacts_tenant role is shared by all databases of the cluster and is
+-- left in place.
+DROP POLICY tenant_isolation ON api_keys;
+DROP POLICY tenant_isolation ON contact_shares;
+DROP POLICY tenant_isolation ON team_members;
+DROP POLICY tenant_isolation ON teams;
+DROP POLICY tenant_isolation ON contact_avatars;
+DROP POLICY tenant_isolation ON contacts;
+ALTER TABLE api_keys DISABLE ROW LEVEL SECURITY;
+ALTER TABLE contact_shares DISABLE ROW LEVEL SECURITY;
+ALTER TABLE team_members DISABLE ROW LEVEL SECURITY;
+ALTER TABLE teams DISABLE ROW LEVEL SECURITY;
+ALTER TABLE contact_avatars DISABLE ROW LEVEL SECURITY;
+ALTER TABLE contacts DISABLE ROW LEVEL SECURITY;
+ALTER TABLE api_keys DROP COLUMN tenant_id;
+ALTER TABLE contact_shares DROP COLUMN tenant_id;
+ALTER TABLE team_members DROP COLUMN tenant_id;
+ALTER TABLE teams DROP COLUMN tenant_id;
+ALTER TABLE contact_avatars DROP COLUMN tenant_id;
+ALTER TABLE contacts DROP COLUMN tenant_id;
+REVOKE ALL ON contacts, contact_avatars, teams, team_members, contact_shares, api_keys FROM contacts_tenant;
+REVOKE ALL ON SEQUENCE contacts_id_seq, teams_id_seq, contact_shares_id_seq, api_keys_id_seq FROM contacts_tenant;
+DROP TABLE tenants;
@@ -0,0 +1,62 @@
+-- Tenants are the customer organizations sharing the database. Every row of
+-- the tables below belongs to one tenant, and row-level security limits the
+-- contacts_tenant role, which serves API requests, to the tenant set in
+-- app.tenant_id for the current transaction. New tables holding tenant data
+-- need a tenant_id column, grants to contacts_tenant and a policy as well.
+CREATE TABLE IF NOT EXISTS tenants (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+-- Existing data belongs to the default tenant.
+INSERT INTO tenants (id, name)
+VALUES (1, 'default') ON CONFLICT (id) DO NOTHING;
+SELECT setval('tenants_id_seq', (SELECT MAX(id) FROM tenants));
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'contacts_tenant') THEN
+        CREATE ROLE contacts_tenant NOLOGIN;
+    END IF;
+END
+$$;
+GRANT contacts_tenant TO CURRENT_USER;
+GRANT SELECT, INSERT, UPDATE, DELETE ON contacts, contact_avatars, teams, team_members, contact_shares, api_keys
+    TO contacts_tenant;
+GRANT USAGE ON SEQUENCE contacts_id_seq, teams_id_seq, contact_shares_id_seq, api_keys_id_seq TO contacts_tenant;
+-- Rows inserted without a tenant_id take the one of the transaction, or fail
+-- when it is not set.
+ALTER TABLE contacts ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants (id);
+ALTER TABLE contacts ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::int;
+ALTER TABLE contact_avatars ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants (id);
+ALTER TABLE contact_avatars ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::int;
+ALTER TABLE teams ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants (id);
+ALTER TABLE teams ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::int;
+ALTER TABLE team_members ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants (id);
+ALTER TABLE team_members ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::int;
+ALTER TABLE contact_shares ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants (id);
+ALTER TABLE contact_shares ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::int;
+ALTER TABLE api_keys ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants (id);
+ALTER TABLE api_keys ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::int;
+CREATE INDEX IF NOT EXISTS contacts_tenant_id_idx ON contacts (tenant_id);
+CREATE INDEX IF NOT EXISTS teams_tenant_id_idx ON teams (tenant_id);
+CREATE INDEX IF NOT EXISTS api_keys_tenant_id_idx ON api_keys (tenant_id);
+-- The table owner, used by migrations and background jobs, is not subject to
+-- the policies as they are not forced.
+ALTER TABLE contacts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE contact_avatars ENABLE ROW LEVEL SECURITY;
+ALTER TABLE teams ENABLE ROW LEVEL SECURITY;
+ALTER TABLE team_members ENABLE ROW LEVEL SECURITY;
+ALTER TABLE contact_shares ENABLE ROW LEVEL SECURITY;
+ALTER TABLE api_keys ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON contacts TO contacts_tenant
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
+CREATE POLICY tenant_isolation ON contact_avatars TO contacts_tenant
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
+CREATE POLICY tenant_isolation ON teams TO contacts_tenant
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
+CREATE POLICY tenant_isolation ON team_members TO contacts_tenant
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
+CREATE POLICY tenant_isolation ON contact_shares TO contacts_tenant
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
+CREATE POLICY tenant_isolation ON api_keys TO contacts_tenant
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
@@ -0,0 +1,3 @@
+DROP TABLE smart_groups;
+DROP TABLE contact_tags;
+DROP TABLE tags;
@@ -0,0 +1,40 @@
+-- Tags and smart groups belong to the user who created them, the owner of
+-- their API key, or to nobody for anonymous requests. Smart groups store a
+-- filter expression over name, phone and tags that is evaluated when the
+-- group is listed.
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    owner_id INTEGER,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '#9e9e9e' CHECK (color ~ '^#([0-9a-fA-F]{3}){1,2}$'),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    tenant_id INTEGER NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::int REFERENCES tenants (id),
+    UNIQUE NULLS NOT DISTINCT (tenant_id, owner_id, name)
+);
+CREATE TABLE IF NOT EXISTS contact_tags (
+    contact_id INTEGER NOT NULL REFERENCES contacts (id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    tenant_id INTEGER NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::int REFERENCES tenants (id),
+    PRIMARY KEY (contact_id, tag_id)
+);
+CREATE INDEX IF NOT EXISTS contact_tags_tag_id_idx ON contact_tags (tag_id);
+CREATE TABLE IF NOT EXISTS smart_groups (
+    id SERIAL PRIMARY KEY,
+    owner_id INTEGER,
+    name VARCHAR(100) NOT NULL,
+    filter TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    tenant_id INTEGER NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::int REFERENCES tenants (id),
+    UNIQUE NULLS NOT DISTINCT (tenant_id, owner_id, name)
+);
+GRANT SELECT, INSERT, UPDATE, DELETE ON tags, contact_tags, smart_groups TO contacts_tenant;
+GRANT USAGE ON SEQUENCE tags_id_seq, smart_groups_id_seq TO contacts_tenant;
+ALTER TABLE tags ENABLE ROW LEVEL SECURITY;
+ALTER TABLE contact_tags ENABLE ROW LEVEL SECURITY;
+ALTER TABLE smart_groups ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON tags TO contacts_tenant
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
+CREATE POLICY tenant_isolation ON contact_tags TO contacts_tenant
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
+CREATE POLICY tenant_isolation ON smart_groups TO contacts_tenant
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
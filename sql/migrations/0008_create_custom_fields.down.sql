@@ -0,0 +1,2 @@
+ALTER TABLE contacts DROP COLUMN custom_fields;
+DROP TABLE custom_fields;
@@ -0,0 +1,26 @@
+-- Custom fields are defined per owner, like tags, and their values are stored
+-- in contacts.custom_fields keyed by field name. Values are validated by the
+-- application against the definitions of the contact's owner.
+CREATE TABLE IF NOT EXISTS custom_fields (
+    id SERIAL PRIMARY KEY,
+    owner_id INTEGER,
+    name VARCHAR(50) NOT NULL CHECK (name ~ '^[a-z][a-z0-9_]*$'),
+    type VARCHAR(10) NOT NULL CHECK (type IN ('text', 'number', 'date', 'bool', 'enum', 'url')),
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    pattern TEXT,
+    options TEXT [] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    tenant_id INTEGER NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::int REFERENCES tenants (id),
+    UNIQUE NULLS NOT DISTINCT (tenant_id, owner_id, name)
+);
+GRANT SELECT, INSERT, UPDATE, DELETE ON custom_fields TO contacts_tenant;
+GRANT USAGE ON SEQUENCE custom_fields_id_seq TO contacts_tenant;
+ALTER TABLE custom_fields ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON custom_fields TO contacts_tenant
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
+
+ALTER TABLE contacts
+ADD COLUMN IF NOT EXISTS custom_fields JSONB NOT NULL DEFAULT '{}';
+-- Filters match values with containment (custom_fields @> '{"nip": "123"}'),
+-- which this index serves for every field at once.
+CREATE INDEX IF NOT EXISTS contacts_custom_fields_idx ON contacts USING GIN (custom_fields jsonb_path_ops);
@@ -0,0 +1,13 @@
+ALTER TABLE contacts DROP COLUMN prefix,
+    DROP COLUMN given_name,
+    DROP COLUMN middle_name,
+    DROP COLUMN family_name,
+    DROP COLUMN suffix,
+    DROP COLUMN nickname,
+    DROP COLUMN company,
+    DROP COLUMN job_title,
+    DROP COLUMN birthday,
+    DROP COLUMN anniversaries,
+    DROP COLUMN notes,
+    DROP COLUMN website,
+    DROP COLUMN social_profiles;
@@ -0,0 +1,20 @@
+-- Contact details follow vCard (RFC 6350): the name parts are N, nickname is
+-- NICKNAME, company ORG, job_title TITLE, birthday BDAY, anniversaries
+-- ANNIVERSARY, notes NOTE and website URL. Social profiles map a service name
+-- to a profile URL. name remains the formatted name (FN) shown in lists.
+ALTER TABLE contacts
+ADD COLUMN IF NOT EXISTS prefix VARCHAR(50) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS given_name VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS middle_name VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS family_name VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS suffix VARCHAR(50) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS nickname VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS company VARCHAR(200) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS job_title VARCHAR(200) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS birthday DATE,
+    -- [{"label": "wedding", "date": "2010-06-12"}]
+    ADD COLUMN IF NOT EXISTS anniversaries JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS notes TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS website VARCHAR(500) NOT NULL DEFAULT '',
+    -- {"linkedin": "https://www.linkedin.com/in/jan-kowalski"}
+    ADD COLUMN IF NOT EXISTS social_profiles JSONB NOT NULL DEFAULT '{}';
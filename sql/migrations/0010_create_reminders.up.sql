@@ -0,0 +1,51 @@
+-- Reminders are due to the user who owns them: follow-ups are created by a
+-- user on a contact they can see, birthday and anniversary reminders are
+-- created by the scheduler for the owner of the contact ahead of each
+-- occurrence. The scheduler sends a notification once a reminder is due and
+-- records it in notified_at; snoozing moves due_at and clears it.
+CREATE TABLE IF NOT EXISTS reminder_settings (
+    owner_id INTEGER,
+    -- time_zone is an IANA name such as Europe/Warsaw.
+    time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    -- notify_hour is the local hour at which reminders for a date are due.
+    notify_hour INTEGER NOT NULL DEFAULT 9 CHECK (notify_hour BETWEEN 0 AND 23),
+    -- email receives the notifications of the smtp notifier.
+    email VARCHAR(254) NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    tenant_id INTEGER NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::int REFERENCES tenants (id),
+    UNIQUE NULLS NOT DISTINCT (tenant_id, owner_id)
+);
+CREATE TABLE IF NOT EXISTS reminders (
+    id SERIAL PRIMARY KEY,
+    contact_id INTEGER NOT NULL REFERENCES contacts (id) ON DELETE CASCADE,
+    owner_id INTEGER,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('follow_up', 'birthday', 'anniversary')),
+    -- label names the anniversary, note is the text of a follow-up.
+    label VARCHAR(50) NOT NULL DEFAULT '',
+    note VARCHAR(500) NOT NULL DEFAULT '',
+    -- occurs_on is the day a birthday or anniversary reminder is for.
+    occurs_on DATE,
+    due_at TIMESTAMPTZ NOT NULL,
+    notified_at TIMESTAMPTZ,
+    snoozed_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    tenant_id INTEGER NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::int REFERENCES tenants (id),
+    CHECK ((kind = 'follow_up') = (occurs_on IS NULL))
+);
+-- The scheduler creates each occurrence once, however often it runs.
+CREATE UNIQUE INDEX IF NOT EXISTS reminders_occurrence_idx ON reminders (contact_id, kind, label, occurs_on)
+WHERE occurs_on IS NOT NULL;
+CREATE INDEX IF NOT EXISTS reminders_due_idx ON reminders (due_at)
+WHERE notified_at IS NULL
+    AND completed_at IS NULL;
+CREATE INDEX IF NOT EXISTS reminders_owner_idx ON reminders (tenant_id, owner_id, due_at)
+WHERE completed_at IS NULL;
+GRANT SELECT, INSERT, UPDATE, DELETE ON reminder_settings, reminders TO contacts_tenant;
+GRANT USAGE ON SEQUENCE reminders_id_seq TO contacts_tenant;
+ALTER TABLE reminder_settings ENABLE ROW LEVEL SECURITY;
+ALTER TABLE reminders ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON reminder_settings TO contacts_tenant
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
+CREATE POLICY tenant_isolation ON reminders TO contacts_tenant
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
@@ -0,0 +1,23 @@
+-- Interactions record when a user talked to a contact: calls, messages,
+-- emails and meetings. They are visible to everyone who can see the contact
+-- and derive its last_contacted_at and interaction_count.
+CREATE TABLE IF NOT EXISTS interactions (
+    id SERIAL PRIMARY KEY,
+    contact_id INTEGER NOT NULL REFERENCES contacts (id) ON DELETE CASCADE,
+    -- owner_id is the user who recorded the interaction.
+    owner_id INTEGER,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('call', 'message', 'email', 'meeting', 'other')),
+    direction VARCHAR(10) NOT NULL CHECK (direction IN ('inbound', 'outbound')),
+    occurred_at TIMESTAMPTZ NOT NULL,
+    duration_seconds INTEGER CHECK (duration_seconds >= 0),
+    notes VARCHAR(2000) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    tenant_id INTEGER NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::int REFERENCES tenants (id)
+);
+-- Listing pages of a contact and deriving its last interaction use the index.
+CREATE INDEX IF NOT EXISTS interactions_contact_idx ON interactions (contact_id, occurred_at DESC, id DESC);
+GRANT SELECT, INSERT, UPDATE, DELETE ON interactions TO contacts_tenant;
+GRANT USAGE ON SEQUENCE interactions_id_seq TO contacts_tenant;
+ALTER TABLE interactions ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON interactions TO contacts_tenant
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
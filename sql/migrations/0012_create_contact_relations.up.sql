@@ -0,0 +1,22 @@
+-- A relation says that related_id is the type of contact_id, e.g. that Anna
+-- is the assistant of Jan. Each relation is stored once, with the type read
+-- from contact_id: child, report and executive are stored as the inverse
+-- parent, manager and assistant, and the symmetric spouse and colleague with
+-- the lower id as contact_id. Relations go with either contact.
+CREATE TABLE IF NOT EXISTS contact_relations (
+    id SERIAL PRIMARY KEY,
+    contact_id INTEGER NOT NULL REFERENCES contacts (id) ON DELETE CASCADE,
+    related_id INTEGER NOT NULL REFERENCES contacts (id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('spouse', 'colleague', 'parent', 'manager', 'assistant')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    tenant_id INTEGER NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::int REFERENCES tenants (id),
+    CHECK (contact_id <> related_id),
+    CHECK (type NOT IN ('spouse', 'colleague') OR contact_id < related_id),
+    UNIQUE (contact_id, related_id, type)
+);
+CREATE INDEX IF NOT EXISTS contact_relations_related_idx ON contact_relations (related_id);
+GRANT SELECT, INSERT, UPDATE, DELETE ON contact_relations TO contacts_tenant;
+GRANT USAGE ON SEQUENCE contact_relations_id_seq TO contacts_tenant;
+ALTER TABLE contact_relations ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON contact_relations TO contacts_tenant
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
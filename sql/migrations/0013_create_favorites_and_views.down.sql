@@ -0,0 +1,2 @@
+DROP TABLE contact_views;
+DROP TABLE contact_favorites;
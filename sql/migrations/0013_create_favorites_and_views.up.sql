@@ -0,0 +1,26 @@
+-- Users mark contacts as favorites, optionally pinned above the other
+-- favorites, and the contacts they open are recorded as recently viewed,
+-- once per contact with the time of the latest view.
+CREATE TABLE IF NOT EXISTS contact_favorites (
+    owner_id INTEGER,
+    contact_id INTEGER NOT NULL REFERENCES contacts (id) ON DELETE CASCADE,
+    pinned BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    tenant_id INTEGER NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::int REFERENCES tenants (id),
+    UNIQUE NULLS NOT DISTINCT (tenant_id, owner_id, contact_id)
+);
+CREATE TABLE IF NOT EXISTS contact_views (
+    owner_id INTEGER,
+    contact_id INTEGER NOT NULL REFERENCES contacts (id) ON DELETE CASCADE,
+    viewed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    tenant_id INTEGER NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::int REFERENCES tenants (id),
+    UNIQUE NULLS NOT DISTINCT (tenant_id, owner_id, contact_id)
+);
+CREATE INDEX IF NOT EXISTS contact_views_recent_idx ON contact_views (tenant_id, owner_id, viewed_at DESC);
+GRANT SELECT, INSERT, UPDATE, DELETE ON contact_favorites, contact_views TO contacts_tenant;
+ALTER TABLE contact_favorites ENABLE ROW LEVEL SECURITY;
+ALTER TABLE contact_views ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON contact_favorites TO contacts_tenant
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
+CREATE POLICY tenant_isolation ON contact_views TO contacts_tenant
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
@@ -0,0 +1,21 @@
+CREATE OR REPLACE FUNCTION contact_access(INTEGER, INTEGER, INTEGER) RETURNS TEXT
+LANGUAGE sql STABLE AS $$
+SELECT CASE
+        WHEN $3 IS NULL OR $2 = $3 THEN 'write'
+        ELSE (
+            SELECT MAX(
+                    CASE
+                        WHEN s.permission = 'write'
+                        AND (s.user_id = $3 OR m.role <> 'viewer') THEN 'write'
+                        ELSE 'read'
+                    END
+                )
+            FROM contact_shares s
+                LEFT JOIN team_members m ON m.team_id = s.team_id
+                AND m.user_id = $3
+            WHERE s.contact_id = $1
+                AND (s.user_id = $3 OR m.user_id IS NOT NULL)
+        )
+    END
+$$;
+ALTER TABLE api_keys DROP CONSTRAINT api_keys_owner_id_positive;
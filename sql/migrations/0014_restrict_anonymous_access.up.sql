@@ -0,0 +1,29 @@
+-- Anonymous requests have viewer 0: they see the contacts without an owner,
+-- which anonymous requests create, and nothing shared. Only a NULL viewer,
+-- an admin key, is unrestricted. API key owners are positive, so that no user
+-- is mistaken for the anonymous viewer.
+ALTER TABLE api_keys ADD CONSTRAINT api_keys_owner_id_positive CHECK (owner_id > 0);
+CREATE OR REPLACE FUNCTION contact_access(INTEGER, INTEGER, INTEGER) RETURNS TEXT
+LANGUAGE sql STABLE AS $$
+SELECT CASE
+        WHEN $3 IS NULL THEN 'write'
+        WHEN $3 = 0 THEN CASE
+            WHEN $2 IS NULL THEN 'write'
+        END
+        WHEN $2 = $3 THEN 'write'
+        ELSE (
+            SELECT MAX(
+                    CASE
+                        WHEN s.permission = 'write'
+                        AND (s.user_id = $3 OR m.role <> 'viewer') THEN 'write'
+                        ELSE 'read'
+                    END
+                )
+            FROM contact_shares s
+                LEFT JOIN team_members m ON m.team_id = s.team_id
+                AND m.user_id = $3
+            WHERE s.contact_id = $1
+                AND (s.user_id = $3 OR m.user_id IS NOT NULL)
+        )
+    END
+$$;
@@ -0,0 +1,4 @@
+ALTER TABLE reminders
+    DROP COLUMN IF EXISTS delivered_to,
+    DROP COLUMN IF EXISTS next_attempt_at,
+    DROP COLUMN IF EXISTS attempts;
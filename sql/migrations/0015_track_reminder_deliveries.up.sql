@@ -0,0 +1,8 @@
+-- A claimed reminder is leased until next_attempt_at rather than marked
+-- notified, so that a scheduler that dies while sending does not lose it.
+-- delivered_to names the notifiers that have sent it, so that retries skip
+-- them, and attempts caps the retries.
+ALTER TABLE reminders
+    ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS delivered_to TEXT [] NOT NULL DEFAULT '{}';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS tags_name_idx;
+ALTER TABLE tags
+ADD CONSTRAINT tags_tenant_id_owner_id_name_key UNIQUE NULLS NOT DISTINCT (tenant_id, owner_id, name);
@@ -0,0 +1,24 @@
+-- Tag names are unique and matched regardless of case, like smart group
+-- filters match them. Tags that only differ in case are merged into the
+-- oldest of them first.
+CREATE TEMPORARY TABLE tag_merges ON COMMIT DROP AS
+SELECT id,
+    first_value(id) OVER (
+        PARTITION BY tenant_id,
+        owner_id,
+        lower(name)
+        ORDER BY id
+    ) AS keep_id
+FROM tags;
+INSERT INTO contact_tags (contact_id, tag_id, tenant_id)
+SELECT ct.contact_id,
+    m.keep_id,
+    ct.tenant_id
+FROM contact_tags ct
+    JOIN tag_merges m ON m.id = ct.tag_id
+WHERE m.id <> m.keep_id ON CONFLICT DO NOTHING;
+DELETE FROM tags t USING tag_merges m
+WHERE t.id = m.id
+    AND m.id <> m.keep_id;
+ALTER TABLE tags DROP CONSTRAINT IF EXISTS tags_tenant_id_owner_id_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS tags_name_idx ON tags (tenant_id, owner_id, lower(name)) NULLS NOT DISTINCT;
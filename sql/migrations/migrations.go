@@ -0,0 +1,8 @@
+// Package migrations embeds the versioned schema migrations. Files are named
+// NNNN_description.up.sql and NNNN_description.down.sql.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
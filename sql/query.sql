@@ -1,21 +1,863 @@
+-- Contact queries only see the contacts that viewer_id may access, see
+-- contact_access. A NULL viewer_id, an admin key, is unrestricted.
 -- name: GetContacts :many
+-- Without tags every visible contact is returned. Otherwise contacts need one
+-- of the tags of tag_owner_id with these names, or all of them with match_all.
+-- Tag names are matched regardless of case.
+-- Contacts also need to contain the custom_fields object, if given. They are
+-- sorted by sort_by, last_contacted_at or interaction_count, with contacts
+-- never contacted as the least recently contacted ones, or by the custom
+-- field sort_field, missing values last, then by name, or only by name
+-- without either. With favorites_first, the pinned contacts and then the
+-- other favorites of favorite_owner_id come before the rest.
 SELECT *
 FROM contacts c
-ORDER BY c.name ASC;
+WHERE contact_access(c.id, c.owner_id, sqlc.narg('viewer_id')::int) IS NOT NULL
+    AND (
+        sqlc.narg('tags')::text[] IS NULL
+        OR (
+            SELECT COUNT(*)
+            FROM contact_tags ct
+                JOIN tags t ON t.id = ct.tag_id
+            WHERE ct.contact_id = c.id
+                AND t.owner_id IS NOT DISTINCT FROM sqlc.narg('tag_owner_id')::int
+                AND lower(t.name) IN (
+                    SELECT lower(tag)
+                    FROM unnest(sqlc.narg('tags')::text[]) tag
+                )
+        ) >= CASE
+            WHEN sqlc.arg('match_all')::bool THEN (
+                SELECT COUNT(DISTINCT lower(tag))
+                FROM unnest(sqlc.narg('tags')::text[]) tag
+            )
+            ELSE 1
+        END
+    )
+    AND (
+        sqlc.narg('custom_fields')::jsonb IS NULL
+        OR c.custom_fields @> sqlc.narg('custom_fields')::jsonb
+    )
+ORDER BY CASE
+        WHEN sqlc.arg('favorites_first')::bool THEN (
+            SELECT CASE
+                    WHEN f.pinned THEN 0
+                    ELSE 1
+                END
+            FROM contact_favorites f
+            WHERE f.contact_id = c.id
+                AND f.owner_id IS NOT DISTINCT FROM sqlc.narg('favorite_owner_id')::int
+        )
+    END ASC NULLS LAST,
+    CASE
+        WHEN NOT sqlc.arg('sort_desc')::bool
+        AND sqlc.narg('sort_by')::text = 'last_contacted_at' THEN (
+            SELECT MAX(i.occurred_at)
+            FROM interactions i
+            WHERE i.contact_id = c.id
+        )
+    END ASC NULLS FIRST,
+    CASE
+        WHEN sqlc.arg('sort_desc')::bool
+        AND sqlc.narg('sort_by')::text = 'last_contacted_at' THEN (
+            SELECT MAX(i.occurred_at)
+            FROM interactions i
+            WHERE i.contact_id = c.id
+        )
+    END DESC NULLS LAST,
+    CASE
+        WHEN NOT sqlc.arg('sort_desc')::bool
+        AND sqlc.narg('sort_by')::text = 'interaction_count' THEN (
+            SELECT COUNT(*)
+            FROM interactions i
+            WHERE i.contact_id = c.id
+        )
+    END ASC,
+    CASE
+        WHEN sqlc.arg('sort_desc')::bool
+        AND sqlc.narg('sort_by')::text = 'interaction_count' THEN (
+            SELECT COUNT(*)
+            FROM interactions i
+            WHERE i.contact_id = c.id
+        )
+    END DESC,
+    CASE
+        WHEN NOT sqlc.arg('sort_desc')::bool THEN c.custom_fields -> sqlc.narg('sort_field')::text
+    END ASC NULLS LAST,
+    CASE
+        WHEN sqlc.arg('sort_desc')::bool THEN c.custom_fields -> sqlc.narg('sort_field')::text
+    END DESC NULLS LAST,
+    CASE
+        WHEN sqlc.arg('sort_desc')::bool
+        AND sqlc.narg('sort_field')::text IS NULL
+        AND sqlc.narg('sort_by')::text IS NULL THEN c.name
+    END DESC,
+    c.name ASC;
 -- name: GetContactByID :one
 SELECT *
 FROM contacts
+WHERE id = $1
+    AND contact_access(id, owner_id, sqlc.narg('viewer_id')::int) IS NOT NULL;
+-- name: GetContactAccess :one
+SELECT contact_access(id, owner_id, sqlc.narg('viewer_id')::int) AS access
+FROM contacts
 WHERE id = $1;
 -- name: CreateContact :one
-INSERT INTO contacts (name, phone)
-VALUES ($1, $2)
+INSERT INTO contacts (
+        name,
+        phone,
+        owner_id,
+        prefix,
+        given_name,
+        middle_name,
+        family_name,
+        suffix,
+        nickname,
+        company,
+        job_title,
+        birthday,
+        anniversaries,
+        notes,
+        website,
+        social_profiles,
+        custom_fields
+    )
+VALUES (
+        $1,
+        $2,
+        $3,
+        $4,
+        $5,
+        $6,
+        $7,
+        $8,
+        $9,
+        $10,
+        $11,
+        $12,
+        $13,
+        $14,
+        $15,
+        $16,
+        COALESCE(sqlc.narg('custom_fields')::jsonb, '{}')
+    )
 RETURNING *;
 -- name: UpdateContact :one
+-- A NULL custom_fields keeps the current values.
 UPDATE contacts
 SET name = $2,
-    phone = $3
+    phone = $3,
+    prefix = $4,
+    given_name = $5,
+    middle_name = $6,
+    family_name = $7,
+    suffix = $8,
+    nickname = $9,
+    company = $10,
+    job_title = $11,
+    birthday = $12,
+    anniversaries = $13,
+    notes = $14,
+    website = $15,
+    social_profiles = $16,
+    custom_fields = COALESCE(sqlc.narg('custom_fields')::jsonb, custom_fields)
 WHERE id = $1
+    AND contact_access(id, owner_id, sqlc.narg('viewer_id')::int) = 'write'
 RETURNING *;
--- name: DeleteContact :exec
+-- name: DeleteContact :execrows
 DELETE FROM contacts
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1
+    AND contact_access(id, owner_id, sqlc.narg('viewer_id')::int) = 'write';
+-- name: GetContactsByIDs :many
+SELECT *
+FROM contacts
+WHERE id = ANY(sqlc.arg('ids')::int[])
+    AND contact_access(id, owner_id, sqlc.narg('viewer_id')::int) IS NOT NULL;
+-- name: ListContactsPage :many
+SELECT *
+FROM contacts c
+WHERE contact_access(c.id, c.owner_id, sqlc.narg('viewer_id')::int) IS NOT NULL
+    AND (
+        sqlc.narg('search')::text IS NULL
+        OR c.name ILIKE '%' || sqlc.narg('search') || '%'
+        OR c.phone ILIKE '%' || sqlc.narg('search') || '%'
+    )
+    AND (
+        sqlc.narg('after_name')::text IS NULL
+        OR (c.name, c.id) > (sqlc.narg('after_name'), sqlc.narg('after_id')::int)
+    )
+ORDER BY c.name ASC,
+    c.id ASC
+LIMIT sqlc.arg('page_size');
+-- name: CountContactsByOwnerIDs :many
+SELECT owner_id,
+    COUNT(*) AS contact_count
+FROM contacts
+WHERE owner_id = ANY(sqlc.arg('owner_ids')::int[])
+    AND contact_access(id, owner_id, sqlc.narg('viewer_id')::int) IS NOT NULL
+GROUP BY owner_id;
+-- name: GetContactAvatar :one
+SELECT a.*
+FROM contact_avatars a
+    JOIN contacts c ON c.id = a.contact_id
+WHERE a.contact_id = $1
+    AND contact_access(c.id, c.owner_id, sqlc.narg('viewer_id')::int) IS NOT NULL;
+-- name: UpsertContactAvatar :one
+INSERT INTO contact_avatars (
+        contact_id,
+        object_key,
+        content_type,
+        size_bytes,
+        etag,
+        checksum_sha256
+    )
+VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (contact_id) DO
+UPDATE
+SET object_key = EXCLUDED.object_key,
+    content_type = EXCLUDED.content_type,
+    size_bytes = EXCLUDED.size_bytes,
+    etag = EXCLUDED.etag,
+    checksum_sha256 = EXCLUDED.checksum_sha256,
+    uploaded_at = CURRENT_TIMESTAMP
+RETURNING *;
+-- name: GetContactStats :one
+SELECT (
+        SELECT COUNT(*)
+        FROM contacts
+    ) AS contacts,
+    (
+        SELECT COUNT(*)
+        FROM contact_avatars
+    ) AS avatars,
+    (
+        SELECT COUNT(DISTINCT owner_id)
+        FROM contacts
+    ) AS owners,
+    (
+        SELECT COALESCE(SUM(size_bytes), 0)
+        FROM contact_avatars
+    )::bigint AS avatar_bytes;
+-- name: TakeRateLimitToken :one
+INSERT INTO rate_limits AS r (key, tokens, allowed, updated_at, full_at)
+VALUES (
+        sqlc.arg('key'),
+        sqlc.arg('burst')::float8 - 1,
+        TRUE,
+        now(),
+        now() + make_interval(secs => 1 / sqlc.arg('rate')::float8)
+    ) ON CONFLICT (key) DO
+UPDATE
+SET (tokens, allowed, updated_at, full_at) = (
+        SELECT taken.tokens,
+            taken.allowed,
+            now(),
+            now() + make_interval(
+                secs => (sqlc.arg('burst')::float8 - taken.tokens) / sqlc.arg('rate')::float8
+            )
+        FROM (
+                SELECT refill.tokens - CASE
+                        WHEN refill.tokens >= 1 THEN 1
+                        ELSE 0
+                    END AS tokens,
+                    refill.tokens >= 1 AS allowed
+                FROM (
+                        SELECT LEAST(
+                                sqlc.arg('burst')::float8,
+                                r.tokens + GREATEST(EXTRACT(EPOCH FROM now() - r.updated_at)::float8, 0) * sqlc.arg('rate')::float8
+                            ) AS tokens
+                    ) AS refill
+            ) AS taken
+    )
+RETURNING tokens,
+    allowed;
+-- name: DeleteFullRateLimits :execrows
+DELETE FROM rate_limits
+WHERE full_at <= now();
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+        name,
+        prefix,
+        key_hash,
+        owner_id,
+        scopes,
+        expires_at,
+        tenant_id
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+-- name: GetAPIKeyByPrefix :one
+SELECT *
+FROM api_keys
+WHERE prefix = $1;
+-- name: ListAPIKeys :many
+SELECT *
+FROM api_keys
+WHERE sqlc.narg('owner_id')::int IS NULL
+    OR owner_id = sqlc.narg('owner_id')
+ORDER BY id ASC;
+-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = COALESCE(revoked_at, now())
+WHERE id = $1
+RETURNING *;
+-- name: TouchAPIKey :exec
+-- Writes are skipped while the recorded time is recent, so that busy keys do
+-- not update their row on every request.
+UPDATE api_keys
+SET last_used_at = now()
+WHERE id = $1
+    AND (
+        last_used_at IS NULL
+        OR last_used_at < now() - INTERVAL '1 minute'
+    );
+-- name: CreateTeam :one
+-- The creator becomes the first owner of the team.
+WITH team AS (
+    INSERT INTO teams (name)
+    VALUES (sqlc.arg('name'))
+    RETURNING *
+),
+owner AS (
+    INSERT INTO team_members (team_id, user_id, role)
+    SELECT id,
+        sqlc.arg('owner_id'),
+        'owner'
+    FROM team
+)
+SELECT *
+FROM team;
+-- name: GetTeam :one
+SELECT *
+FROM teams
+WHERE id = $1;
+-- name: ListTeamsForUser :many
+SELECT t.id,
+    t.name,
+    t.created_at,
+    m.role
+FROM teams t
+    JOIN team_members m ON m.team_id = t.id
+WHERE m.user_id = $1
+ORDER BY t.name ASC,
+    t.id ASC;
+-- name: DeleteTeam :execrows
+DELETE FROM teams
+WHERE id = $1;
+-- name: GetTeamMember :one
+SELECT *
+FROM team_members
+WHERE team_id = $1
+    AND user_id = $2;
+-- name: ListTeamMembers :many
+SELECT *
+FROM team_members
+WHERE team_id = $1
+ORDER BY user_id ASC;
+-- name: CountTeamOwners :one
+SELECT COUNT(*)
+FROM team_members
+WHERE team_id = $1
+    AND role = 'owner';
+-- name: UpsertTeamMember :one
+INSERT INTO team_members (team_id, user_id, role)
+VALUES ($1, $2, $3) ON CONFLICT (team_id, user_id) DO
+UPDATE
+SET role = EXCLUDED.role
+RETURNING *;
+-- name: DeleteTeamMember :execrows
+DELETE FROM team_members
+WHERE team_id = $1
+    AND user_id = $2;
+-- name: ListContactShares :many
+SELECT *
+FROM contact_shares
+WHERE contact_id = $1
+ORDER BY id ASC;
+-- name: ShareContactWithUser :one
+INSERT INTO contact_shares (contact_id, user_id, permission)
+VALUES ($1, $2, $3) ON CONFLICT (contact_id, user_id) DO
+UPDATE
+SET permission = EXCLUDED.permission
+RETURNING *;
+-- name: ShareContactWithTeam :one
+INSERT INTO contact_shares (contact_id, team_id, permission)
+VALUES ($1, $2, $3) ON CONFLICT (contact_id, team_id) DO
+UPDATE
+SET permission = EXCLUDED.permission
+RETURNING *;
+-- name: DeleteContactShare :execrows
+DELETE FROM contact_shares
+WHERE id = $1
+    AND contact_id = $2;
+-- name: CreateTenant :one
+INSERT INTO tenants (name)
+VALUES ($1)
+RETURNING *;
+-- name: ListTenants :many
+SELECT *
+FROM tenants
+ORDER BY id ASC;
+-- Tags and smart groups are only visible to their owner, owner_id.
+-- name: ListTags :many
+SELECT *
+FROM tags
+WHERE owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int
+ORDER BY name ASC;
+-- name: GetTag :one
+SELECT *
+FROM tags
+WHERE id = $1
+    AND owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int;
+-- name: CreateTag :one
+INSERT INTO tags (name, color, owner_id)
+VALUES ($1, $2, $3)
+RETURNING *;
+-- name: UpdateTag :one
+UPDATE tags
+SET name = $2,
+    color = $3
+WHERE id = $1
+    AND owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int
+RETURNING *;
+-- name: DeleteTag :execrows
+DELETE FROM tags
+WHERE id = $1
+    AND owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int;
+-- name: ListContactTags :many
+SELECT ct.contact_id,
+    t.id,
+    t.name,
+    t.color
+FROM contact_tags ct
+    JOIN tags t ON t.id = ct.tag_id
+WHERE ct.contact_id = ANY(sqlc.arg('contact_ids')::int[])
+    AND t.owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int
+ORDER BY t.name ASC;
+-- name: TagContacts :execrows
+-- Contacts that viewer_id cannot see and those already tagged are skipped.
+INSERT INTO contact_tags (contact_id, tag_id)
+SELECT c.id,
+    sqlc.arg('tag_id')::int
+FROM contacts c
+WHERE c.id = ANY(sqlc.arg('contact_ids')::int[])
+    AND contact_access(c.id, c.owner_id, sqlc.narg('viewer_id')::int) IS NOT NULL ON CONFLICT DO NOTHING;
+-- name: UntagContacts :execrows
+DELETE FROM contact_tags
+WHERE tag_id = $1
+    AND contact_id = ANY(sqlc.arg('contact_ids')::int[]);
+-- name: ListSmartGroups :many
+SELECT *
+FROM smart_groups
+WHERE owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int
+ORDER BY name ASC;
+-- name: GetSmartGroup :one
+SELECT *
+FROM smart_groups
+WHERE id = $1
+    AND owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int;
+-- name: CreateSmartGroup :one
+INSERT INTO smart_groups (name, filter, owner_id)
+VALUES ($1, $2, $3)
+RETURNING *;
+-- name: UpdateSmartGroup :one
+UPDATE smart_groups
+SET name = $2,
+    filter = $3
+WHERE id = $1
+    AND owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int
+RETURNING *;
+-- name: DeleteSmartGroup :execrows
+DELETE FROM smart_groups
+WHERE id = $1
+    AND owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int;
+-- name: ListCustomFields :many
+SELECT *
+FROM custom_fields
+WHERE owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int
+ORDER BY name ASC;
+-- name: GetCustomField :one
+SELECT *
+FROM custom_fields
+WHERE id = $1
+    AND owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int;
+-- name: CreateCustomField :one
+INSERT INTO custom_fields (name, type, required, pattern, options, owner_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+-- name: UpdateCustomField :one
+-- The name and type of a field are fixed, as contacts store values by name.
+UPDATE custom_fields
+SET required = $2,
+    pattern = $3,
+    options = $4
+WHERE id = $1
+    AND owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int
+RETURNING *;
+-- name: DeleteCustomField :one
+-- Deleting a field removes its values from the contacts of its owner.
+WITH deleted AS (
+    DELETE FROM custom_fields
+    WHERE id = $1
+        AND owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int
+    RETURNING owner_id,
+        name
+),
+cleared AS (
+    UPDATE contacts c
+    SET custom_fields = c.custom_fields - d.name
+    FROM deleted d
+    WHERE c.owner_id IS NOT DISTINCT FROM d.owner_id
+        AND c.custom_fields ? d.name
+    RETURNING c.id
+)
+SELECT COUNT(*)
+FROM deleted;
+-- name: GetReminderSettings :one
+SELECT *
+FROM reminder_settings
+WHERE owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int;
+-- name: UpsertReminderSettings :one
+-- Birthday and anniversary reminders that are pending and not snoozed move
+-- to the new hour and time zone.
+WITH settings AS (
+    INSERT INTO reminder_settings (time_zone, notify_hour, email, owner_id)
+    VALUES ($1, $2, $3, $4) ON CONFLICT (tenant_id, owner_id) DO
+    UPDATE
+    SET time_zone = EXCLUDED.time_zone,
+        notify_hour = EXCLUDED.notify_hour,
+        email = EXCLUDED.email,
+        updated_at = now()
+    RETURNING *
+),
+rescheduled AS (
+    UPDATE reminders r
+    SET due_at = (r.occurs_on + make_interval(hours => s.notify_hour)) AT TIME ZONE s.time_zone
+    FROM settings s
+    WHERE r.owner_id IS NOT DISTINCT FROM s.owner_id
+        AND r.occurs_on IS NOT NULL
+        AND r.notified_at IS NULL
+        AND r.snoozed_at IS NULL
+        AND r.completed_at IS NULL
+    RETURNING r.id
+)
+SELECT *
+FROM settings;
+-- name: CreateFollowUp :one
+INSERT INTO reminders (contact_id, kind, note, due_at, owner_id)
+VALUES ($1, 'follow_up', $2, $3, $4)
+RETURNING *;
+-- name: ListUpcomingReminders :many
+-- Open reminders of the owner that are due before until, overdue ones
+-- included, on contacts the viewer can still see.
+SELECT r.*,
+    c.name AS contact_name
+FROM reminders r
+    JOIN contacts c ON c.id = r.contact_id
+WHERE r.owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int
+    AND r.completed_at IS NULL
+    AND r.due_at < sqlc.arg('until')
+    AND contact_access(c.id, c.owner_id, sqlc.narg('viewer_id')::int) IS NOT NULL
+ORDER BY r.due_at ASC,
+    r.id ASC;
+-- name: SnoozeReminder :one
+-- Snoozing makes the reminder due again at until, even after its
+-- notification was sent.
+UPDATE reminders
+SET due_at = sqlc.arg('until'),
+    snoozed_at = now(),
+    notified_at = NULL,
+    attempts = 0,
+    next_attempt_at = NULL,
+    delivered_to = '{}'
+WHERE id = $1
+    AND owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int
+    AND completed_at IS NULL
+RETURNING *;
+-- name: CompleteReminder :one
+UPDATE reminders
+SET completed_at = COALESCE(completed_at, now())
+WHERE id = $1
+    AND owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int
+RETURNING *;
+-- name: ListContactDates :many
+-- A page of the contacts of every tenant with an owner and a birthday or
+-- anniversaries, after the contact after_id, with the reminder settings of
+-- their owners if they have any. Reminders belong to users, so contacts
+-- created anonymously get none.
+SELECT c.id,
+    c.tenant_id,
+    c.owner_id,
+    c.birthday,
+    c.anniversaries,
+    s.time_zone,
+    s.notify_hour
+FROM contacts c
+    LEFT JOIN reminder_settings s ON s.tenant_id = c.tenant_id
+    AND s.owner_id = c.owner_id
+WHERE c.owner_id IS NOT NULL
+    AND (
+        c.birthday IS NOT NULL
+        OR c.anniversaries <> '[]'
+    )
+    AND c.id > sqlc.arg('after_id')::int
+ORDER BY c.id ASC
+LIMIT sqlc.arg('page_size');
+-- name: DeleteStaleOccurrenceReminders :execrows
+-- Deletes the pending birthday and anniversary reminders of every tenant whose
+-- date was changed or removed since they were planned. A February 29 date
+-- falls on February 28 in other years.
+DELETE FROM reminders r USING contacts c
+WHERE c.id = r.contact_id
+    AND r.occurs_on IS NOT NULL
+    AND r.notified_at IS NULL
+    AND r.completed_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1
+        FROM (
+                SELECT 'birthday' AS kind,
+                    '' AS label,
+                    to_char(c.birthday, 'MM-DD') AS month_day
+                WHERE c.birthday IS NOT NULL
+                UNION ALL
+                SELECT 'anniversary',
+                    a->>'label',
+                    right(a->>'date', 5)
+                FROM jsonb_array_elements(c.anniversaries) a
+            ) d
+        WHERE d.kind = r.kind
+            AND d.label = r.label
+            AND (
+                d.month_day = to_char(r.occurs_on, 'MM-DD')
+                OR (
+                    d.month_day = '02-29'
+                    AND to_char(r.occurs_on, 'MM-DD') = '02-28'
+                )
+            )
+    );
+-- name: CreateOccurrenceReminder :execrows
+-- An occurrence that has a reminder already is left alone, whether it is
+-- done or not.
+INSERT INTO reminders (
+        contact_id,
+        owner_id,
+        kind,
+        label,
+        occurs_on,
+        due_at,
+        tenant_id
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (contact_id, kind, label, occurs_on)
+WHERE occurs_on IS NOT NULL DO NOTHING;
+-- name: ClaimDueReminders :many
+-- Leases up to batch_size due reminders of every tenant for lease_seconds and
+-- returns what their notifications need. Replicas that claim at the same time
+-- get different reminders, and a lease that runs out without the reminder
+-- being marked notified or retried makes it due again. Reminders that were
+-- tried max_attempts times are left alone.
+UPDATE reminders r
+SET attempts = r.attempts + 1,
+    next_attempt_at = now() + make_interval(secs => sqlc.arg('lease_seconds')::int)
+FROM contacts c
+WHERE c.id = r.contact_id
+    AND r.id IN (
+        SELECT id
+        FROM reminders
+        WHERE notified_at IS NULL
+            AND completed_at IS NULL
+            AND due_at <= now()
+            AND (
+                next_attempt_at IS NULL
+                OR next_attempt_at <= now()
+            )
+            AND attempts < sqlc.arg('max_attempts')::int
+        ORDER BY due_at ASC
+        LIMIT sqlc.arg('batch_size') FOR
+        UPDATE SKIP LOCKED
+    )
+RETURNING r.id,
+    r.tenant_id,
+    r.owner_id,
+    r.contact_id,
+    c.name AS contact_name,
+    r.kind,
+    r.label,
+    r.note,
+    r.occurs_on,
+    r.due_at,
+    r.attempts,
+    r.delivered_to,
+    COALESCE(
+        (
+            SELECT s.email
+            FROM reminder_settings s
+            WHERE s.tenant_id = r.tenant_id
+                AND s.owner_id IS NOT DISTINCT FROM r.owner_id
+        ),
+        ''
+    )::text AS email;
+-- name: MarkReminderNotified :exec
+-- Ends the lease of a reminder that every notifier has sent.
+UPDATE reminders
+SET notified_at = now(),
+    next_attempt_at = NULL,
+    delivered_to = $2
+WHERE id = $1;
+-- name: RetryReminder :exec
+-- Makes a reminder that some notifiers failed to send due again at retry_at,
+-- remembering the notifiers that did send it.
+UPDATE reminders
+SET next_attempt_at = sqlc.arg('retry_at'),
+    delivered_to = $2
+WHERE id = $1;
+-- name: CreateInteraction :one
+INSERT INTO interactions (
+        contact_id,
+        owner_id,
+        type,
+        direction,
+        occurred_at,
+        duration_seconds,
+        notes
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+-- name: ListInteractions :many
+-- A page of the interactions with a contact the viewer can see, latest
+-- first, after the interaction before_at and before_id if given.
+SELECT i.*
+FROM interactions i
+    JOIN contacts c ON c.id = i.contact_id
+WHERE i.contact_id = $1
+    AND contact_access(c.id, c.owner_id, sqlc.narg('viewer_id')::int) IS NOT NULL
+    AND (
+        sqlc.narg('before_at')::timestamptz IS NULL
+        OR (i.occurred_at, i.id) < (sqlc.narg('before_at'), sqlc.narg('before_id')::int)
+    )
+ORDER BY i.occurred_at DESC,
+    i.id DESC
+LIMIT sqlc.arg('page_size');
+-- name: ListInteractionStats :many
+-- The time of the last interaction and the number of interactions with
+-- each of the contacts that have any.
+SELECT contact_id,
+    MAX(occurred_at)::timestamptz AS last_contacted_at,
+    COUNT(*) AS interaction_count
+FROM interactions
+WHERE contact_id = ANY(sqlc.arg('contact_ids')::int[])
+GROUP BY contact_id;
+-- name: CreateRelation :one
+INSERT INTO contact_relations (contact_id, related_id, type)
+VALUES ($1, $2, $3)
+RETURNING *;
+-- name: GetRelation :one
+-- The relation if it has the contact at either end.
+SELECT *
+FROM contact_relations
+WHERE id = $1
+    AND sqlc.arg('contact_id')::int IN (contact_id, related_id);
+-- name: UpdateRelation :one
+UPDATE contact_relations
+SET contact_id = $2,
+    related_id = $3,
+    type = $4
+WHERE id = $1
+RETURNING *;
+-- name: DeleteRelation :execrows
+DELETE FROM contact_relations
+WHERE id = $1;
+-- name: ListRelations :many
+-- The relations of a contact with the contacts the viewer can see, by the
+-- name of the other contact.
+SELECT r.*,
+    o.name AS other_name
+FROM contact_relations r
+    JOIN contacts o ON o.id = CASE
+        WHEN r.contact_id = sqlc.arg('contact_id')::int THEN r.related_id
+        ELSE r.contact_id
+    END
+WHERE sqlc.arg('contact_id')::int IN (r.contact_id, r.related_id)
+    AND contact_access(o.id, o.owner_id, sqlc.narg('viewer_id')::int) IS NOT NULL
+ORDER BY o.name ASC,
+    r.id ASC;
+-- name: GetRelationGraph :many
+-- The contacts related to a contact through at most depth relations, with the
+-- number of relations to the nearest path. Paths only lead through contacts
+-- the viewer can see.
+WITH RECURSIVE edges AS (
+    SELECT contact_id AS from_id,
+        related_id AS to_id
+    FROM contact_relations
+    UNION ALL
+    SELECT related_id,
+        contact_id
+    FROM contact_relations
+),
+reach (id, depth) AS (
+    SELECT sqlc.arg('contact_id')::int,
+        0
+    UNION
+    SELECT e.to_id,
+        r.depth + 1
+    FROM reach r
+        JOIN edges e ON e.from_id = r.id
+        JOIN contacts c ON c.id = e.to_id
+    WHERE r.depth < sqlc.arg('depth')::int
+        AND contact_access(c.id, c.owner_id, sqlc.narg('viewer_id')::int) IS NOT NULL
+)
+SELECT c.id,
+    c.name,
+    MIN(r.depth)::int AS depth
+FROM reach r
+    JOIN contacts c ON c.id = r.id
+GROUP BY c.id,
+    c.name
+ORDER BY depth ASC,
+    c.name ASC;
+-- name: ListRelationsAmong :many
+-- The relations between the contacts.
+SELECT *
+FROM contact_relations
+WHERE contact_id = ANY(sqlc.arg('contact_ids')::int[])
+    AND related_id = ANY(sqlc.arg('contact_ids')::int[])
+ORDER BY id ASC;
+-- name: UpsertFavorite :one
+INSERT INTO contact_favorites (contact_id, pinned, owner_id)
+VALUES ($1, $2, $3) ON CONFLICT (tenant_id, owner_id, contact_id) DO
+UPDATE
+SET pinned = EXCLUDED.pinned
+RETURNING *;
+-- name: DeleteFavorite :execrows
+DELETE FROM contact_favorites
+WHERE contact_id = $1
+    AND owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int;
+-- name: ListFavoriteContacts :many
+-- The favorites of the owner that the viewer can see, pinned ones first.
+SELECT c.*
+FROM contact_favorites f
+    JOIN contacts c ON c.id = f.contact_id
+WHERE f.owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int
+    AND contact_access(c.id, c.owner_id, sqlc.narg('viewer_id')::int) IS NOT NULL
+ORDER BY f.pinned DESC,
+    c.name ASC,
+    c.id ASC;
+-- name: ListFavoriteFlags :many
+-- The contacts among contact_ids that are favorites of the owner.
+SELECT contact_id,
+    pinned
+FROM contact_favorites
+WHERE contact_id = ANY(sqlc.arg('contact_ids')::int[])
+    AND owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int;
+-- name: RecordContactView :exec
+INSERT INTO contact_views (contact_id, owner_id)
+VALUES ($1, $2) ON CONFLICT (tenant_id, owner_id, contact_id) DO
+UPDATE
+SET viewed_at = now();
+-- name: ListRecentContacts :many
+-- The contacts the owner viewed that the viewer can still see, the most
+-- recently viewed first.
+SELECT c.*
+FROM contact_views v
+    JOIN contacts c ON c.id = v.contact_id
+WHERE v.owner_id IS NOT DISTINCT FROM sqlc.narg('owner_id')::int
+    AND contact_access(c.id, c.owner_id, sqlc.narg('viewer_id')::int) IS NOT NULL
+ORDER BY v.viewed_at DESC, c.id DESC
+LIMIT sqlc.arg('page_size');
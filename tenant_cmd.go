@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/db"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const tenantUsage = "usage: contactsService tenant create <name>|list [flags]"
+
+// runTenant implements the tenant subcommand. Tenants are managed by the
+// operators of the service, as admin keys are limited to their own tenant.
+func runTenant(ctx context.Context, cfg *config.Config, command []string, out io.Writer) error {
+	pool, err := pgxpool.New(ctx, cfg.Database.URL.Value())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	queries := db.New(pool)
+
+	switch command[0] {
+	case "create":
+		created, createErr := queries.CreateTenant(ctx, command[1])
+		if createErr != nil {
+			return fmt.Errorf("create tenant: %w", createErr)
+		}
+		fmt.Fprintf(out, "created tenant %d (%s)\n", created.ID, created.Name)
+	case "list":
+		tenants, listErr := queries.ListTenants(ctx)
+		if listErr != nil {
+			return fmt.Errorf("list tenants: %w", listErr)
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0) //nolint:mnd // column padding
+		fmt.Fprintln(w, "ID\tNAME\tCREATED AT")
+		for _, t := range tenants {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", t.ID, t.Name, t.CreatedAt.Time.Format(time.RFC3339))
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown tenant command %q, %s", command[0], tenantUsage)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"contactsAI/contacts/internal/apikey"
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/handlers"
+	integration "contactsAI/contacts/tests/integration_test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeysIntegration(t *testing.T) {
+	var env *config.Env
+	router, teardownSuite := setupSuiteWith(t, func(e *config.Env) {
+		e.Auth.Required = true
+		env = e
+	})
+	defer teardownSuite(t)
+
+	admin, err := integration.CreateAPIKey(context.Background(), env.System, 1, apikey.ScopeAdmin)
+	require.NoError(t, err)
+
+	var created handlers.CreatedAPIKeyResponse
+	t.Run("create a key", func(t *testing.T) {
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/admin/api-keys", router, handlers.CreateAPIKeyBody{
+			Name:      "reader",
+			OwnerID:   2,
+			Scopes:    []string{apikey.ScopeContactsRead},
+			ExpiresAt: nil,
+		}, admin)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.NotEmpty(t, created.Secret)
+		assert.Equal(t, int32(2), created.OwnerID)
+
+		w = integration.MkJSONRequestWithHeaders(t, "POST", "/admin/api-keys", router, handlers.CreateAPIKeyBody{
+			Name:      "bad",
+			OwnerID:   2,
+			Scopes:    []string{"contacts:everything"},
+			ExpiresAt: nil,
+		}, admin)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	reader := map[string]string{"X-API-Key": created.Secret}
+	t.Run("scopes are enforced", func(t *testing.T) {
+		w := integration.MkRequest(t, "GET", "/api/contacts/2", router, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "keys are required")
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/1", router, reader)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = integration.MkRequest(t, "DELETE", "/api/contacts/2", router, reader)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/graphql", router, map[string]string{
+			"query": `mutation { deleteContact(id: 2) }`,
+		}, reader)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "missing scope contacts:write")
+
+		w = integration.MkRequest(t, "GET", "/admin/api-keys", router, reader)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("list and revoke", func(t *testing.T) {
+		w := integration.MkRequest(t, "GET", "/admin/api-keys?owner_id=2", router, admin)
+		require.Equal(t, http.StatusOK, w.Code)
+		var keys []handlers.APIKeyResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &keys))
+		require.Len(t, keys, 1)
+		assert.NotNil(t, keys[0].LastUsedAt)
+		assert.NotContains(t, w.Body.String(), created.Secret)
+
+		w = integration.MkRequest(t, "DELETE", fmt.Sprintf("/admin/api-keys/%d", created.ID), router, admin)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/1", router, reader)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = integration.MkRequest(t, "DELETE", "/admin/api-keys/9999", router, admin)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,157 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io/fs"
+	"maps"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"contactsAI/contacts/internal/bucket"
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/handlers"
+	"contactsAI/contacts/internal/imaging"
+	integration "contactsAI/contacts/tests/integration_test"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAvatarIntegration(t *testing.T) {
+	router, admin, teardownSuite := setupAdminSuite(t, func(*config.Env) {})
+	defer teardownSuite(t)
+
+	t.Run("GET /api/contacts/:id/avatar/url without avatar", func(t *testing.T) {
+		w := integration.MkJSONRequestWithHeaders(t, "GET", "/api/contacts/3/avatar/url", router, nil, admin)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("POST /api/contacts/:id/avatar/upload-url rejects unsupported type", func(t *testing.T) {
+		body := handlers.AvatarUploadURLBody{ContentType: "application/pdf", Size: 1024}
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/3/avatar/upload-url", router, body, admin)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("POST /api/contacts/:id/avatar/upload-url for missing contact", func(t *testing.T) {
+		body := handlers.AvatarUploadURLBody{ContentType: "image/png", Size: 1024}
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/999/avatar/upload-url", router, body, admin)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("POST /api/contacts/:id/avatar/upload-url on local storage", func(t *testing.T) {
+		body := handlers.AvatarUploadURLBody{ContentType: "image/png", Size: 1024}
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/3/avatar/upload-url", router, body, admin)
+		assert.Equal(t, http.StatusNotImplemented, w.Code)
+	})
+
+	t.Run("POST /api/contacts/:id/avatar/complete rejects foreign keys", func(t *testing.T) {
+		body := handlers.CompleteAvatarUploadBody{ObjectKey: "avatars/4/abc"}
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/3/avatar/complete", router, body, admin)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("memory backend", func(t *testing.T) {
+		testAvatarRoundTrip(t, router, admin)
+	})
+}
+
+func TestAvatarFSBackendIntegration(t *testing.T) {
+	root := t.TempDir()
+	store, err := bucket.NewFSStore(root)
+	require.NoError(t, err)
+
+	router, admin, teardownSuite := setupAdminSuite(t, func(env *config.Env) { env.Bucket = store })
+	defer teardownSuite(t)
+
+	testAvatarRoundTrip(t, router, admin)
+
+	t.Run("a new avatar replaces the objects of the previous one", func(t *testing.T) {
+		var img bytes.Buffer
+		require.NoError(t, png.Encode(&img, image.NewNRGBA(image.Rect(0, 0, 40, 40))))
+		w := integration.MkMultipartRequest(t, "PUT", "/api/contacts/4/avatar", router, "avatar", "b.png",
+			img.Bytes(), admin)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var objects []string
+		require.NoError(t, filepath.WalkDir(filepath.Join(root, "avatars", "4"),
+			func(path string, entry fs.DirEntry, err error) error {
+				if err == nil && !entry.IsDir() && !strings.HasSuffix(path, ".meta.json") {
+					objects = append(objects, path)
+				}
+				return err
+			}))
+		assert.Len(t, objects, 1+len(imaging.VariantSizes()), "the original and its thumbnails")
+	})
+}
+
+func testAvatarRoundTrip(t *testing.T, router *gin.Engine, admin map[string]string) {
+	t.Helper()
+
+	var img bytes.Buffer
+	require.NoError(t, png.Encode(&img, image.NewNRGBA(image.Rect(0, 0, 120, 80))))
+
+	w := integration.MkMultipartRequest(t, "PUT", "/api/contacts/4/avatar", router, "avatar", "a.png", img.Bytes(), admin)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = integration.MkMultipartRequest(t, "PUT", "/api/contacts/4/avatar", router, "avatar", "a.png",
+		[]byte("not an image"), admin)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	full := integration.MkRequest(t, "GET", "/api/contacts/4/avatar", router, admin)
+	require.Equal(t, http.StatusOK, full.Code)
+	assert.Equal(t, "image/png", full.Header().Get("Content-Type"))
+	assert.NotEmpty(t, full.Header().Get("Cache-Control"))
+	etag := full.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	decoded, err := png.Decode(bytes.NewReader(full.Body.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 120, 80), decoded.Bounds())
+
+	thumb := integration.MkRequest(t, "GET", "/api/contacts/4/avatar?size=64", router, admin)
+	require.Equal(t, http.StatusOK, thumb.Code)
+	decoded, err = png.Decode(bytes.NewReader(thumb.Body.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 64, 64), decoded.Bounds())
+
+	w = integration.MkRequest(t, "GET", "/api/contacts/4/avatar?size=100", router, admin)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = integration.MkRequest(t, "GET", "/api/contacts/4/avatar", router, withHeader(admin, "If-None-Match", etag))
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.Bytes())
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+	assert.NotEmpty(t, w.Header().Get("Cache-Control"))
+
+	w = integration.MkRequest(t, "GET", "/api/contacts/4/avatar", router,
+		withHeader(admin, "If-Modified-Since", full.Header().Get("Last-Modified")))
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+
+	w = integration.MkRequest(t, "HEAD", "/api/contacts/4/avatar", router, withHeader(admin, "If-None-Match", etag))
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+
+	w = integration.MkRequest(t, "GET", "/api/contacts/4/avatar", router, withHeader(admin, "Range", "bytes=0-7"))
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, full.Body.Bytes()[:8], w.Body.Bytes())
+	assert.Contains(t, w.Header().Get("Content-Range"), "bytes 0-7/")
+
+	w = integration.MkRequest(t, "HEAD", "/api/contacts/4/avatar", router, admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+}
+
+// withHeader returns a copy of headers with name set to value.
+func withHeader(headers map[string]string, name, value string) map[string]string {
+	out := maps.Clone(headers)
+	out[name] = value
+	return out
+}
@@ -0,0 +1,130 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"contactsAI/contacts/internal/customfields"
+	"contactsAI/contacts/internal/handlers"
+	integration "contactsAI/contacts/tests/integration_test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomFieldsIntegration(t *testing.T) {
+	router, _, users, teardownSuite := newAuthSuite(t, 1, 2)
+	defer teardownSuite(t)
+	owner, other := users[1], users[2]
+
+	define := func(body handlers.CustomFieldBody) handlers.CustomFieldResponse {
+		t.Helper()
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/custom-fields/", router, body, owner)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var field handlers.CustomFieldResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &field))
+		return field
+	}
+	nip := define(handlers.CustomFieldBody{Name: "nip", Type: customfields.TypeText,
+		UpdateCustomFieldBody: handlers.UpdateCustomFieldBody{Pattern: `^\d{10}$`}})
+	define(handlers.CustomFieldBody{Name: "score", Type: customfields.TypeNumber})
+	language := define(handlers.CustomFieldBody{Name: "language", Type: customfields.TypeEnum,
+		UpdateCustomFieldBody: handlers.UpdateCustomFieldBody{Options: []string{"pl", "en"}}})
+
+	update := func(id int, fields map[string]any, headers map[string]string, want int) handlers.ContactResponse {
+		t.Helper()
+		w := integration.MkRequest(t, "GET", fmt.Sprintf("/api/contacts/%d", id), router, headers)
+		require.Equal(t, http.StatusOK, w.Code)
+		var contact handlers.ContactResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &contact))
+		w = integration.MkJSONRequestWithHeaders(t, "PUT", fmt.Sprintf("/api/contacts/%d", id), router,
+			handlers.UpdateContactBody{Name: contact.Name, Phone: contact.Phone, CustomFields: fields}, headers)
+		require.Equal(t, want, w.Code, w.Body.String())
+		if want == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &contact))
+		}
+		return contact
+	}
+
+	t.Run("definitions are validated and per owner", func(t *testing.T) {
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/custom-fields/", router,
+			handlers.CustomFieldBody{Name: "nip", Type: customfields.TypeText}, owner)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/custom-fields/", router,
+			handlers.CustomFieldBody{Name: "tier", Type: customfields.TypeEnum}, owner)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "enums need options")
+
+		w = integration.MkRequest(t, "GET", "/api/custom-fields/", router, other)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[]`, w.Body.String())
+	})
+
+	t.Run("values are validated against the owner's fields", func(t *testing.T) {
+		contact := update(2, map[string]any{"nip": "1234567890", "score": 3, "language": "pl"}, owner, http.StatusOK)
+		assert.Equal(t, map[string]any{"nip": "1234567890", "score": 3.0, "language": "pl"}, contact.CustomFields)
+
+		update(3, map[string]any{"nip": "12345"}, owner, http.StatusBadRequest)   // pattern
+		update(3, map[string]any{"language": "de"}, owner, http.StatusBadRequest) // enum option
+		update(3, map[string]any{"fax": "123"}, owner, http.StatusBadRequest)     // undefined field
+		update(1, map[string]any{"score": 1}, other, http.StatusBadRequest)       // other users have no fields
+
+		update(3, map[string]any{"nip": "0987654321", "score": 5, "language": "en"}, owner, http.StatusOK)
+		contact = update(3, nil, owner, http.StatusOK)
+		assert.Equal(t, "en", contact.CustomFields["language"], "values are kept without custom_fields")
+	})
+
+	t.Run("required fields are needed on new contacts", func(t *testing.T) {
+		w := integration.MkJSONRequestWithHeaders(t, "PUT", fmt.Sprintf("/api/custom-fields/%d", nip.ID), router,
+			handlers.UpdateCustomFieldBody{Required: true, Pattern: `^\d{10}$`}, owner)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/", router,
+			handlers.CreateContactBody{Name: "New Customer", Phone: "600-100-200"}, owner)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/", router,
+			handlers.CreateContactBody{Name: "New Customer", Phone: "600-100-200",
+				CustomFields: map[string]any{"nip": "5555555555", "score": 4}}, owner)
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	})
+
+	t.Run("contacts are filtered and sorted by custom fields", func(t *testing.T) {
+		w := integration.MkRequest(t, "GET", "/api/contacts/?field[language]=pl", router, owner)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, []int32{2}, contactIDs(t, w.Body.Bytes()))
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/?field[score]=5&field[language]=en", router, owner)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []int32{3}, contactIDs(t, w.Body.Bytes()))
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/?sort=field.score&order=desc", router, owner)
+		require.Equal(t, http.StatusOK, w.Code)
+		ids := contactIDs(t, w.Body.Bytes())
+		require.Len(t, ids, 4)
+		assert.Equal(t, []int32{3}, ids[:1])
+		assert.Equal(t, int32(6), ids[3], "contacts without a score come last")
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/?field[fax]=1", router, owner)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = integration.MkRequest(t, "GET", "/api/contacts/?field[score]=high", router, owner)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = integration.MkRequest(t, "GET", "/api/contacts/?sort=phone", router, owner)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("deleting a field removes its values", func(t *testing.T) {
+		w := integration.MkRequest(t, "DELETE", fmt.Sprintf("/api/custom-fields/%d", language.ID), router, owner)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		w = integration.MkRequest(t, "DELETE", fmt.Sprintf("/api/custom-fields/%d", language.ID), router, owner)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/2", router, owner)
+		require.Equal(t, http.StatusOK, w.Code)
+		var contact handlers.ContactResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &contact))
+		assert.NotContains(t, contact.CustomFields, "language")
+	})
+}
@@ -0,0 +1,145 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/handlers"
+	integration "contactsAI/contacts/tests/integration_test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFavoritesIntegration(t *testing.T) {
+	router, env, users, teardownSuite := newAuthSuite(t, 1, 3)
+	defer teardownSuite(t)
+	ctx := context.Background()
+	user1, user3 := users[1], users[3]
+
+	favorite := func(path string, body any) handlers.FavoriteResponse {
+		t.Helper()
+		w := integration.MkJSONRequestWithHeaders(t, "PUT", path, router, body, user1)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response handlers.FavoriteResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+	list := func(path string, headers map[string]string) []handlers.ContactResponse {
+		t.Helper()
+		w := integration.MkRequest(t, "GET", path, router, headers)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var contacts []handlers.ContactResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &contacts))
+		return contacts
+	}
+
+	t.Run("visible contacts are favorited and pinned", func(t *testing.T) {
+		assert.False(t, favorite("/api/contacts/6/favorite", nil).Pinned)
+		assert.True(t, favorite("/api/contacts/3/favorite", handlers.FavoriteBody{Pinned: true}).Pinned)
+
+		w := integration.MkJSONRequestWithHeaders(t, "PUT", "/api/contacts/4/favorite", router, nil, user1)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("favorites are listed per user, pinned first", func(t *testing.T) {
+		favorites := list("/api/contacts/favorites", user1)
+		require.Len(t, favorites, 2)
+		assert.Equal(t, int32(3), favorites[0].ID)
+		assert.True(t, favorites[0].Favorite)
+		assert.True(t, favorites[0].Pinned)
+		assert.Equal(t, int32(6), favorites[1].ID)
+		assert.False(t, favorites[1].Pinned)
+
+		assert.Empty(t, list("/api/contacts/favorites", user3))
+	})
+
+	t.Run("favorites float to the top of the list", func(t *testing.T) {
+		w := integration.MkRequest(t, "GET", "/api/contacts/?favorites_first=true", router, user1)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, []int32{3, 6, 2}, contactIDs(t, w.Body.Bytes()))
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/?favorites_first=maybe", router, user1)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("favorites are unpinned and removed", func(t *testing.T) {
+		assert.False(t, favorite("/api/contacts/3/favorite", handlers.FavoriteBody{Pinned: false}).Pinned)
+
+		w := integration.MkRequest(t, "DELETE", "/api/contacts/6/favorite", router, user1)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		w = integration.MkRequest(t, "DELETE", "/api/contacts/6/favorite", router, user1)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		favorites := list("/api/contacts/favorites", user1)
+		require.Len(t, favorites, 1)
+		assert.Equal(t, int32(3), favorites[0].ID)
+		assert.False(t, favorites[0].Pinned)
+	})
+
+	t.Run("viewed contacts are listed the latest first", func(t *testing.T) {
+		for _, path := range []string{"/api/contacts/2", "/api/contacts/6", "/api/contacts/2"} {
+			w := integration.MkRequest(t, "GET", path, router, user1)
+			require.Equal(t, http.StatusOK, w.Code)
+		}
+
+		recent := list("/api/contacts/recent", user1)
+		require.Len(t, recent, 2)
+		assert.Equal(t, int32(2), recent[0].ID)
+		assert.Equal(t, int32(6), recent[1].ID)
+		assert.Len(t, list("/api/contacts/recent?limit=1", user1), 1)
+		assert.Empty(t, list("/api/contacts/recent", user3))
+
+		w := integration.MkRequest(t, "GET", "/api/contacts/recent?limit=0", router, user1)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("favorites and views are deleted with their contact", func(t *testing.T) {
+		w := integration.MkRequest(t, "DELETE", "/api/contacts/3", router, user1)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, list("/api/contacts/favorites", user1))
+
+		w = integration.MkRequest(t, "DELETE", "/api/contacts/2", router, user1)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		var views int
+		require.NoError(t, env.Pool.QueryRow(ctx,
+			"SELECT COUNT(*) FROM contact_views WHERE contact_id IN (2, 3)").Scan(&views))
+		assert.Zero(t, views)
+		recent := list("/api/contacts/recent", user1)
+		require.Len(t, recent, 1)
+		assert.Equal(t, int32(6), recent[0].ID)
+	})
+}
+
+func TestFavoritesNeedAUser(t *testing.T) {
+	var env *config.Env
+	router, teardownSuite := setupSuiteWith(t, func(e *config.Env) { env = e })
+	defer teardownSuite(t)
+
+	for _, req := range []struct{ method, path string }{
+		{"GET", "/api/contacts/favorites"},
+		{"GET", "/api/contacts/recent"},
+		{"PUT", "/api/contacts/2/favorite"},
+		{"DELETE", "/api/contacts/2/favorite"},
+	} {
+		w := integration.MkRequest(t, req.method, req.path, router, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, req.path)
+	}
+
+	w := integration.MkJSONRequest(t, "POST", "/api/contacts/", router,
+		handlers.CreateContactBody{Name: "Walk-in", Phone: "600-700-800"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created handlers.ContactResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	w = integration.MkRequest(t, "GET", fmt.Sprintf("/api/contacts/%d", created.ID), router, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var views int
+	require.NoError(t, env.Pool.QueryRow(context.Background(), "SELECT COUNT(*) FROM contact_views").Scan(&views))
+	assert.Zero(t, views, "anonymous views are not recorded")
+}
@@ -0,0 +1,105 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/graph"
+	integration "contactsAI/contacts/tests/integration_test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type graphQLResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func TestGraphQLIntegration(t *testing.T) {
+	router, admin, teardownSuite := setupAdminSuite(t, func(*config.Env) {})
+	defer teardownSuite(t)
+
+	t.Run("contacts connection paginates by name", func(t *testing.T) {
+		req := graph.Request{Query: `query($after: String) {
+			contacts(first: 3, after: $after) {
+				edges { cursor node { id name owner { id contactCount } } }
+				pageInfo { hasNextPage endCursor }
+			}
+		}`}
+
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/graphql", router, req, admin)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response graphQLResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Empty(t, response.Errors)
+
+		var page struct {
+			Edges []struct {
+				Node struct {
+					Name  string `json:"name"`
+					Owner struct {
+						ContactCount int `json:"contactCount"`
+					} `json:"owner"`
+				} `json:"node"`
+			} `json:"edges"`
+			PageInfo struct {
+				HasNextPage bool   `json:"hasNextPage"`
+				EndCursor   string `json:"endCursor"`
+			} `json:"pageInfo"`
+		}
+		require.NoError(t, json.Unmarshal(response.Data["contacts"], &page))
+
+		require.Len(t, page.Edges, 3)
+		assert.Equal(t, "Agnieszka Szymańska", page.Edges[0].Node.Name)
+		assert.Equal(t, 3, page.Edges[0].Node.Owner.ContactCount)
+		assert.True(t, page.PageInfo.HasNextPage)
+
+		req.Variables = map[string]any{"after": page.PageInfo.EndCursor}
+		w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/graphql", router, req, admin)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.NoError(t, json.Unmarshal(response.Data["contacts"], &page))
+		assert.Equal(t, "Katarzyna Lewandowska", page.Edges[0].Node.Name)
+	})
+
+	t.Run("aliased contact lookups", func(t *testing.T) {
+		req := graph.Request{Query: `{ a: contact(id: 2) { name } b: contact(id: 3) { name } missing: contact(id: 999) { name } }`}
+
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/graphql", router, req, admin)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response graphQLResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Empty(t, response.Errors)
+		assert.JSONEq(t, `{"name":"Anna Nowak"}`, string(response.Data["a"]))
+		assert.JSONEq(t, `{"name":"Jan Kowalski"}`, string(response.Data["b"]))
+		assert.JSONEq(t, `null`, string(response.Data["missing"]))
+	})
+
+	t.Run("createContact validates input", func(t *testing.T) {
+		req := graph.Request{Query: `mutation { createContact(input: {name: "x", phone: "not a phone"}) { id } }`}
+
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/graphql", router, req, admin)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response graphQLResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.NotEmpty(t, response.Errors)
+	})
+
+	t.Run("search filters contacts", func(t *testing.T) {
+		req := graph.Request{Query: `{ contacts(search: "kowal") { edges { node { name } } } }`}
+
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/graphql", router, req, admin)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Jan Kowalski")
+		assert.NotContains(t, w.Body.String(), "Anna Nowak")
+	})
+}
@@ -0,0 +1,49 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/health"
+	integration "contactsAI/contacts/tests/integration_test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthIntegration(t *testing.T) {
+	var env *config.Env
+	router, teardownSuite := setupSuiteWith(t, func(e *config.Env) { env = e })
+	defer teardownSuite(t)
+
+	t.Run("GET /healthz", func(t *testing.T) {
+		w := integration.MkRequest(t, "GET", "/healthz", router, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /readyz", func(t *testing.T) {
+		w := integration.MkRequest(t, "GET", "/readyz", router, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var report health.Report
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		assert.Equal(t, health.StatusOK, report.Status)
+		assert.Equal(t, health.StatusOK, report.Dependencies["database"].Status)
+		assert.Equal(t, health.StatusOK, report.Dependencies["storage"].Status)
+		assert.Equal(t, health.StatusOK, report.Dependencies["migrations"].Status)
+		if assert.NotNil(t, report.MigrationVersion) {
+			assert.Equal(t, env.Migrator.Latest(), *report.MigrationVersion)
+		}
+	})
+
+	t.Run("GET /readyz while shutting down", func(t *testing.T) {
+		env.Health.MarkShuttingDown()
+
+		w := integration.MkRequest(t, "GET", "/readyz", router, nil)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
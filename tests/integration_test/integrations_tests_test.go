@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"testing"
 
+	"contactsAI/contacts/internal/apikey"
 	"contactsAI/contacts/internal/config"
 	"contactsAI/contacts/internal/db"
 	"contactsAI/contacts/internal/handlers"
@@ -22,6 +23,12 @@ import (
 )
 
 func setupSuite(t *testing.T) (*gin.Engine, func(t *testing.T)) {
+	return setupSuiteWith(t, func(*config.Env) {})
+}
+
+// setupSuiteWith lets a test adjust the environment, e.g. swap the blob
+// storage backend, before the router is built.
+func setupSuiteWith(t *testing.T, configure func(env *config.Env)) (*gin.Engine, func(t *testing.T)) {
 	ctx := context.Background()
 	dbContainer, err := integration.SetupTestDB(ctx)
 	require.NoError(t, err, "testcontainer creation failed")
@@ -29,22 +36,69 @@ func setupSuite(t *testing.T) (*gin.Engine, func(t *testing.T)) {
 	dbURL, err := dbContainer.ConnectionString(ctx, "sslmode=disable")
 	require.NoError(t, err, "failed to get conn string")
 
-	env, err := config.NewEnv(dbURL, true)
+	cfg := config.Default()
+	cfg.Database.URL = config.Secret(dbURL)
+	// Tests run against in-memory blob storage unless they swap in another backend.
+	cfg.Storage.Backend = config.StorageMemory
+	env, err := config.NewEnv(ctx, cfg)
 	require.NoError(t, err, "db connection failed")
 
+	_, err = env.Migrator.Up(ctx)
+	require.NoError(t, err, "migrations failed")
+	require.NoError(t, integration.SeedTestDB(ctx, env.Pool), "seeding failed")
+	configure(env)
+
 	router := routing.SetupRouter(env)
 
 	return router, func(t *testing.T) {
+		env.Close()
 		testcontainers.CleanupContainer(t, dbContainer)
 	}
 }
 
+// setupAdminSuite is setupSuiteWith with the Authorization header of an admin
+// key, which sees every contact of the seed data. Anonymous requests only see
+// the contacts created anonymously.
+func setupAdminSuite(
+	t *testing.T, configure func(env *config.Env),
+) (*gin.Engine, map[string]string, func(t *testing.T)) {
+	var env *config.Env
+	router, teardownSuite := setupSuiteWith(t, func(e *config.Env) {
+		configure(e)
+		env = e
+	})
+	admin, err := integration.CreateAPIKey(context.Background(), env.System, 1, apikey.ScopeAdmin)
+	require.NoError(t, err)
+	return router, admin, teardownSuite
+}
+
+// newAuthSuite is setupSuiteWith with API keys required. It returns the
+// Authorization headers of contacts:read and contacts:write keys of the users
+// with ids, see integration.SeedTestDB for the contacts they own.
+func newAuthSuite(
+	t *testing.T, ids ...int32,
+) (*gin.Engine, *config.Env, map[int32]map[string]string, func(t *testing.T)) {
+	var env *config.Env
+	router, teardownSuite := setupSuiteWith(t, func(e *config.Env) {
+		e.Auth.Required = true
+		env = e
+	})
+	users := make(map[int32]map[string]string, len(ids))
+	for _, id := range ids {
+		headers, err := integration.CreateAPIKey(context.Background(), env.System, id,
+			apikey.ScopeContactsRead, apikey.ScopeContactsWrite)
+		require.NoError(t, err)
+		users[id] = headers
+	}
+	return router, env, users, teardownSuite
+}
+
 func TestIntegration(t *testing.T) {
-	router, teardownSuite := setupSuite(t)
+	router, admin, teardownSuite := setupAdminSuite(t, func(*config.Env) {})
 	defer teardownSuite(t)
 
 	t.Run("GET /api/contacts", func(t *testing.T) {
-		w := integration.MkJSONRequest(t, "GET", "/api/contacts/", router, nil)
+		w := integration.MkJSONRequestWithHeaders(t, "GET", "/api/contacts/", router, nil, admin)
 		assert.Equal(t, http.StatusOK, w.Code)
 
 		var response []handlers.ContactResponse
@@ -59,7 +113,7 @@ func TestIntegration(t *testing.T) {
 	})
 
 	t.Run("GET /api/contacts/:id", func(t *testing.T) {
-		w := integration.MkGetContactByIDRequest(t, 2, router)
+		w := integration.MkGetContactByIDRequest(t, 2, router, admin)
 
 		assert.Equal(t, http.StatusOK, w.Code)
 
@@ -77,7 +131,7 @@ func TestIntegration(t *testing.T) {
 			Phone: "+48 123 123 123",
 		}
 
-		w := integration.MkJSONRequest(t, "POST", "/api/contacts/", router, newContactBody)
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/", router, newContactBody, admin)
 
 		assert.Exactly(t, http.StatusCreated, w.Code)
 
@@ -98,7 +152,8 @@ func TestIntegration(t *testing.T) {
 			Phone: "+48123456789",
 		}
 
-		w := integration.MkJSONRequest(t, "PUT", fmt.Sprintf("/api/contacts/%d", contactID), router, updateBody)
+		w := integration.MkJSONRequestWithHeaders(t, "PUT", fmt.Sprintf("/api/contacts/%d", contactID), router,
+			updateBody, admin)
 		assert.Equal(t, http.StatusOK, w.Code)
 
 		var response handlers.ContactResponse
@@ -108,7 +163,7 @@ func TestIntegration(t *testing.T) {
 		assert.Exactly(t, updateBody.Phone, response.Phone)
 		assert.Exactly(t, int32(contactID), response.ID)
 
-		wUpdatedContact := integration.MkGetContactByIDRequest(t, contactID, router)
+		wUpdatedContact := integration.MkGetContactByIDRequest(t, contactID, router, admin)
 
 		assert.Equal(t, http.StatusOK, wUpdatedContact.Code)
 
@@ -123,11 +178,38 @@ func TestIntegration(t *testing.T) {
 	t.Run("DELETE /api/contacts", func(t *testing.T) {
 		contactID := 2
 
-		wDel := integration.MkJSONRequest(t, "DELETE",
-			fmt.Sprintf("/api/contacts/%d", contactID), router, nil)
+		wDel := integration.MkJSONRequestWithHeaders(t, "DELETE",
+			fmt.Sprintf("/api/contacts/%d", contactID), router, nil, admin)
 		assert.Equal(t, http.StatusNoContent, wDel.Code)
 
-		wAfterDel := integration.MkGetContactByIDRequest(t, contactID, router)
+		wAfterDel := integration.MkGetContactByIDRequest(t, contactID, router, admin)
 		assert.Exactly(t, http.StatusNotFound, wAfterDel.Code)
 	})
+
+	t.Run("anonymous requests only see anonymous contacts", func(t *testing.T) {
+		w := integration.MkJSONRequest(t, "GET", "/api/contacts/", router, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, contactIDs(t, w.Body.Bytes()))
+
+		w = integration.MkJSONRequest(t, "POST", "/api/contacts/", router,
+			db.CreateContactParams{Name: "anonymous", Phone: "+48 123 123 123"})
+		require.Equal(t, http.StatusCreated, w.Code)
+		var created handlers.ContactResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+		w = integration.MkJSONRequest(t, "GET", "/api/contacts/", router, nil)
+		assert.Equal(t, []int32{created.ID}, contactIDs(t, w.Body.Bytes()))
+		assert.Equal(t, http.StatusOK, integration.MkGetContactByIDRequest(t, int(created.ID), router, nil).Code)
+		assert.Equal(t, http.StatusNotFound, integration.MkGetContactByIDRequest(t, 3, router, nil).Code)
+		w = integration.MkJSONRequest(t, "PUT", "/api/contacts/3", router,
+			handlers.UpdateContactBody{Name: "taken over", Phone: "+48123456789"})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = integration.MkJSONRequest(t, "GET", "/api/teams/", router, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		w = integration.MkJSONRequest(t, "DELETE", "/api/teams/1", router, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		w = integration.MkJSONRequest(t, "GET", fmt.Sprintf("/api/contacts/%d/shares/", created.ID), router, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
 }
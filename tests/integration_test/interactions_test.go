@@ -0,0 +1,140 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"contactsAI/contacts/internal/handlers"
+	integration "contactsAI/contacts/tests/integration_test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInteractionsIntegration(t *testing.T) {
+	router, env, users, teardownSuite := newAuthSuite(t, 1, 3)
+	defer teardownSuite(t)
+	ctx := context.Background()
+	user1, user3 := users[1], users[3]
+
+	record := func(contactID int, body handlers.InteractionBody) handlers.InteractionResponse {
+		t.Helper()
+		w := integration.MkJSONRequestWithHeaders(t, "POST", fmt.Sprintf("/api/contacts/%d/interactions", contactID),
+			router, body, user1)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var interaction handlers.InteractionResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &interaction))
+		return interaction
+	}
+	page := func(path string) handlers.InteractionPage {
+		t.Helper()
+		w := integration.MkRequest(t, "GET", path, router, user1)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page handlers.InteractionPage
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page
+	}
+
+	weekAgo := time.Now().Add(-7 * 24 * time.Hour).Truncate(time.Second)
+	yesterday := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	minutes := int32(300)
+
+	t.Run("interactions are recorded on visible contacts", func(t *testing.T) {
+		call := record(2, handlers.InteractionBody{Type: "call", Direction: "inbound", OccurredAt: &weekAgo,
+			DurationSeconds: &minutes, Notes: "Asked about the offer"})
+		assert.Equal(t, "inbound", call.Direction)
+		assert.True(t, weekAgo.Equal(call.OccurredAt))
+		assert.Equal(t, &minutes, call.DurationSeconds)
+		record(2, handlers.InteractionBody{Type: "meeting", OccurredAt: &yesterday})
+		message := record(2, handlers.InteractionBody{Type: "message"})
+		assert.Equal(t, "outbound", message.Direction)
+		record(6, handlers.InteractionBody{Type: "email", OccurredAt: &weekAgo})
+
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/4/interactions", router,
+			handlers.InteractionBody{Type: "call"}, user1)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/2/interactions", router,
+			handlers.InteractionBody{Type: "fax"}, user1)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		tomorrow := time.Now().Add(24 * time.Hour)
+		w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/2/interactions", router,
+			handlers.InteractionBody{Type: "call", OccurredAt: &tomorrow}, user1)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("interactions are listed in pages, the latest first", func(t *testing.T) {
+		first := page("/api/contacts/2/interactions?limit=2")
+		require.Len(t, first.Interactions, 2)
+		assert.Equal(t, "message", first.Interactions[0].Type)
+		assert.Equal(t, "meeting", first.Interactions[1].Type)
+		require.NotEmpty(t, first.NextCursor)
+
+		second := page("/api/contacts/2/interactions?limit=2&before=" + first.NextCursor)
+		require.Len(t, second.Interactions, 1)
+		assert.Equal(t, "call", second.Interactions[0].Type)
+		assert.Empty(t, second.NextCursor)
+
+		w := integration.MkRequest(t, "GET", "/api/contacts/2/interactions?before=nonsense", router, user1)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = integration.MkRequest(t, "GET", "/api/contacts/2/interactions", router, user3)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("contacts show when they were last contacted", func(t *testing.T) {
+		w := integration.MkRequest(t, "GET", "/api/contacts/2", router, user1)
+		require.Equal(t, http.StatusOK, w.Code)
+		var contact handlers.ContactResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &contact))
+		assert.Equal(t, int64(3), contact.InteractionCount)
+		require.NotNil(t, contact.LastContactedAt)
+		assert.WithinDuration(t, time.Now(), *contact.LastContactedAt, time.Minute)
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/3", router, user1)
+		require.Equal(t, http.StatusOK, w.Code)
+		contact = handlers.ContactResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &contact))
+		assert.Zero(t, contact.InteractionCount)
+		assert.Nil(t, contact.LastContactedAt)
+	})
+
+	t.Run("contacts sort by their interactions", func(t *testing.T) {
+		w := integration.MkRequest(t, "GET", "/api/contacts/?sort=last_contacted_at", router, user1)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, []int32{3, 6, 2}, contactIDs(t, w.Body.Bytes()), "neglected contacts come first")
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/?sort=interaction_count&order=desc", router, user1)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, []int32{2, 6, 3}, contactIDs(t, w.Body.Bytes()))
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/?sort=phone", router, user1)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("read-only shares list interactions but cannot record them", func(t *testing.T) {
+		user := int32(3)
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/2/shares/", router,
+			handlers.CreateShareBody{UserID: &user, Permission: "read"}, user1)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/2/interactions", router, user3)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/2/interactions", router,
+			handlers.InteractionBody{Type: "call"}, user3)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("interactions are deleted with their contact", func(t *testing.T) {
+		w := integration.MkRequest(t, "DELETE", "/api/contacts/6", router, user1)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		var count int
+		require.NoError(t, env.Pool.QueryRow(ctx,
+			"SELECT COUNT(*) FROM interactions WHERE contact_id = 6").Scan(&count))
+		assert.Zero(t, count)
+	})
+}
@@ -0,0 +1,60 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"contactsAI/contacts/internal/apikey"
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/handlers"
+	"contactsAI/contacts/internal/middleware"
+	integration "contactsAI/contacts/tests/integration_test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggingIntegration(t *testing.T) {
+	var env *config.Env
+	router, teardownSuite := setupSuiteWith(t, func(e *config.Env) { env = e })
+	defer teardownSuite(t)
+
+	t.Run("X-Request-ID is echoed", func(t *testing.T) {
+		w := integration.MkRequest(t, "GET", "/api/contacts/", router, map[string]string{
+			middleware.RequestIDHeader: "integration-1",
+		})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "integration-1", w.Header().Get(middleware.RequestIDHeader))
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/", router, nil)
+		assert.NotEmpty(t, w.Header().Get(middleware.RequestIDHeader))
+	})
+
+	t.Run("PUT /admin/log-level", func(t *testing.T) {
+		admin, err := integration.CreateAPIKey(context.Background(), env.System, 1, apikey.ScopeAdmin)
+		require.NoError(t, err)
+
+		w := integration.MkJSONRequest(t, "PUT", "/admin/log-level", router, handlers.LogLevelBody{Level: "debug"})
+		require.Equal(t, http.StatusUnauthorized, w.Code, "admin routes require a key")
+
+		w = integration.MkJSONRequestWithHeaders(t, "PUT", "/admin/log-level", router,
+			handlers.LogLevelBody{Level: "debug"}, admin)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, slog.LevelDebug, env.LogLevel.Level())
+
+		w = integration.MkRequest(t, "GET", "/admin/log-level", router, admin)
+		var body handlers.LogLevelBody
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "DEBUG", body.Level)
+
+		w = integration.MkJSONRequestWithHeaders(t, "PUT", "/admin/log-level", router,
+			handlers.LogLevelBody{Level: "loud"}, admin)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, slog.LevelDebug, env.LogLevel.Level())
+	})
+}
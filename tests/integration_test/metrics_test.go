@@ -0,0 +1,45 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"contactsAI/contacts/internal/apikey"
+	"contactsAI/contacts/internal/config"
+	integration "contactsAI/contacts/tests/integration_test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsIntegration(t *testing.T) {
+	var env *config.Env
+	router, teardownSuite := setupSuiteWith(t, func(e *config.Env) { env = e })
+	defer teardownSuite(t)
+
+	user, err := integration.CreateAPIKey(context.Background(), env.System, 1, apikey.ScopeContactsRead)
+	require.NoError(t, err)
+	w := integration.MkRequest(t, "GET", "/api/contacts/2", router, user)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, env.Metrics.RefreshBusiness(context.Background(), env.System))
+
+	w = integration.MkRequest(t, "GET", "/metrics", router, nil)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	w = integration.MkRequest(t, "GET", "/metrics", router, user)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	scraper, err := integration.CreateAPIKey(context.Background(), env.System, 1, apikey.ScopeMetricsRead)
+	require.NoError(t, err)
+	w = integration.MkRequest(t, "GET", "/metrics", router, scraper)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	out := w.Body.String()
+	assert.Contains(t, out, `contacts_http_requests_total{method="GET",route="/api/contacts/:id",status="200"} 1`)
+	assert.Contains(t, out, `contacts_db_query_duration_seconds_count{query="GetContactByID",result="ok"} 1`)
+	assert.Contains(t, out, `contacts_entities{kind="contacts"} 8`)
+	assert.Contains(t, out, "contacts_avatar_storage_bytes 0")
+	assert.Contains(t, out, "contacts_db_pool_total_connections")
+}
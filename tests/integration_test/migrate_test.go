@@ -0,0 +1,57 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/migrate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateIntegration(t *testing.T) {
+	var env *config.Env
+	_, teardownSuite := setupSuiteWith(t, func(e *config.Env) { env = e })
+	defer teardownSuite(t)
+
+	ctx := context.Background()
+	migrator := env.Migrator
+	latest := migrator.Latest()
+
+	version, err := migrator.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, latest, version)
+	require.NoError(t, migrator.CheckCurrent(ctx))
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.NotNil(t, s.AppliedAt, "migration %d should be applied", s.Version)
+	}
+
+	rolledBack, err := migrator.Down(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, latest, rolledBack.Version)
+	assert.Error(t, migrator.CheckCurrent(ctx))
+
+	// Replicas starting together must apply the pending migration exactly once.
+	applied := make([][]migrate.Migration, 2)
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i := range applied {
+		wg.Go(func() { applied[i], errs[i] = migrator.Up(ctx) })
+	}
+	wg.Wait()
+	require.NoError(t, errors.Join(errs...))
+	assert.Len(t, append(applied[0], applied[1]...), 1)
+
+	version, err = migrator.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, latest, version)
+}
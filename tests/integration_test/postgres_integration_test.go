@@ -4,10 +4,15 @@ package integration_test
 
 import (
 	"context"
+	"net/url"
 	"testing"
+	"time"
 
+	"contactsAI/contacts/internal/config"
 	integration "contactsAI/contacts/tests/integration_test"
 
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
 )
@@ -24,3 +29,35 @@ func TestPostgresContainer(t *testing.T) {
 
 	t.Logf("Connection string: %s", connStr)
 }
+
+func TestNewEnvFailsFastOnPermanentErrors(t *testing.T) {
+	ctx := context.Background()
+	dbContainer, err := integration.SetupTestDB(ctx)
+	testcontainers.CleanupContainer(t, dbContainer)
+	require.NoError(t, err)
+	connStr, err := dbContainer.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	for name, change := range map[string]func(u *url.URL){
+		"bad credentials":  func(u *url.URL) { u.User = url.UserPassword(u.User.Username(), "wrong") },
+		"unknown database": func(u *url.URL) { u.Path = "/missing" },
+	} {
+		t.Run(name, func(t *testing.T) {
+			u, err := url.Parse(connStr)
+			require.NoError(t, err)
+			change(u)
+			cfg := config.Default()
+			cfg.Database.URL = config.Secret(u.String())
+			cfg.Storage.Backend = config.StorageMemory
+
+			startupCtx, cancel := context.WithTimeout(ctx, time.Minute)
+			defer cancel()
+			start := time.Now()
+			_, err = config.NewEnv(startupCtx, cfg)
+			require.Error(t, err)
+			var pgErr *pgconn.PgError
+			assert.ErrorAs(t, err, &pgErr)
+			assert.Less(t, time.Since(start), 10*time.Second, "permanent errors are not retried")
+		})
+	}
+}
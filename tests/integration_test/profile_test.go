@@ -0,0 +1,106 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"contactsAI/contacts/internal/handlers"
+	integration "contactsAI/contacts/tests/integration_test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContactProfileIntegration(t *testing.T) {
+	router, teardownSuite := setupSuite(t)
+	defer teardownSuite(t)
+
+	profile := handlers.ContactProfile{
+		Prefix:        "dr",
+		GivenName:     "Ewa",
+		FamilyName:    "Mazur",
+		Company:       "ACME",
+		JobTitle:      "Sales manager",
+		Birthday:      "1980-04-23",
+		Anniversaries: []handlers.Anniversary{{Label: "wedding", Date: "2010-06-12"}},
+		Notes:         "Prefers calls in the morning.",
+		Website:       "https://acme.example",
+		SocialProfiles: map[string]string{
+			"linkedin": "https://www.linkedin.com/in/ewa-mazur",
+		},
+	}
+
+	var created handlers.ContactResponse
+	t.Run("the name is derived from the profile", func(t *testing.T) {
+		w := integration.MkJSONRequest(t, "POST", "/api/contacts/", router,
+			handlers.CreateContactBody{Phone: "600-100-200", ContactProfile: profile})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(t, "dr Ewa Mazur", created.Name)
+		assert.Equal(t, profile, created.ContactProfile)
+
+		w = integration.MkJSONRequest(t, "POST", "/api/contacts/", router,
+			handlers.CreateContactBody{Phone: "600-100-200", ContactProfile: handlers.ContactProfile{Company: "ACME"}})
+		require.Equal(t, http.StatusCreated, w.Code)
+		var company handlers.ContactResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &company))
+		assert.Equal(t, "ACME", company.Name)
+
+		w = integration.MkJSONRequest(t, "POST", "/api/contacts/", router,
+			handlers.CreateContactBody{Phone: "600-100-200", ContactProfile: handlers.ContactProfile{JobTitle: "CEO"}})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "there is nothing to derive a name from")
+	})
+
+	t.Run("profiles are validated", func(t *testing.T) {
+		invalid := []handlers.ContactProfile{
+			{GivenName: "Ewa", Birthday: "1980-02-30"},
+			{GivenName: "Ewa", Birthday: "--02-30"},
+			{GivenName: "Ewa", Anniversaries: []handlers.Anniversary{{Label: "wedding", Date: "06-12"}}},
+			{GivenName: "Ewa", Website: "acme.example"},
+			{GivenName: "Ewa", Anniversaries: []handlers.Anniversary{{Label: "", Date: "2010-06-12"}}},
+			{GivenName: "Ewa", SocialProfiles: map[string]string{"LinkedIn": "https://linkedin.com/in/ewa"}},
+			{GivenName: "Ewa", SocialProfiles: map[string]string{"linkedin": "javascript:alert(1)"}},
+		}
+		for _, p := range invalid {
+			w := integration.MkJSONRequest(t, "POST", "/api/contacts/", router,
+				handlers.CreateContactBody{Phone: "600-100-200", ContactProfile: p})
+			assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("dates may leave out the year", func(t *testing.T) {
+		yearless := handlers.ContactProfile{
+			GivenName:     "Ewa",
+			Birthday:      "--02-29",
+			Anniversaries: []handlers.Anniversary{{Label: "wedding", Date: "--06-12"}},
+		}
+		w := integration.MkJSONRequest(t, "POST", "/api/contacts/", router,
+			handlers.CreateContactBody{Phone: "600-100-200", ContactProfile: yearless})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var contact handlers.ContactResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &contact))
+		assert.Equal(t, yearless, contact.ContactProfile)
+	})
+
+	t.Run("updates replace the profile", func(t *testing.T) {
+		path := fmt.Sprintf("/api/contacts/%d", created.ID)
+		w := integration.MkJSONRequest(t, "PUT", path, router, handlers.UpdateContactBody{
+			Name:           "Ewa from ACME",
+			Phone:          "600-100-200",
+			ContactProfile: handlers.ContactProfile{GivenName: "Ewa", Company: "ACME"},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = integration.MkRequest(t, "GET", path, router, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var updated handlers.ContactResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+		assert.Equal(t, "Ewa from ACME", updated.Name)
+		assert.Equal(t, handlers.ContactProfile{GivenName: "Ewa", Company: "ACME"}, updated.ContactProfile)
+		assert.NotContains(t, w.Body.String(), "birthday")
+	})
+}
@@ -0,0 +1,112 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/ratelimit"
+	integration "contactsAI/contacts/tests/integration_test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresRateLimitStore(t *testing.T) {
+	var env *config.Env
+	_, teardownSuite := setupSuiteWith(t, func(e *config.Env) { env = e })
+	defer teardownSuite(t)
+
+	ctx := context.Background()
+	store := ratelimit.NewPostgresStore(env.System)
+	limit := ratelimit.Limit{Burst: 3, Period: time.Hour}
+
+	t.Run("concurrent takes never exceed the burst", func(t *testing.T) {
+		var (
+			wg      sync.WaitGroup
+			mu      sync.Mutex
+			allowed int
+		)
+		for range 10 {
+			wg.Go(func() {
+				result, err := store.Take(ctx, "concurrent", limit)
+				assert.NoError(t, err)
+				if result.Allowed {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			})
+		}
+		wg.Wait()
+		assert.Equal(t, limit.Burst, allowed)
+
+		result, err := store.Take(ctx, "concurrent", limit)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.InDelta(t, 20*time.Minute, result.RetryAfter, float64(time.Minute))
+	})
+
+	t.Run("refills over time and sweeps full buckets", func(t *testing.T) {
+		fast := ratelimit.Limit{Burst: 1, Period: 100 * time.Millisecond}
+		result, err := store.Take(ctx, "fast", fast)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		result, err = store.Take(ctx, "fast", fast)
+		require.NoError(t, err)
+		require.False(t, result.Allowed)
+
+		time.Sleep(3 * fast.Period)
+		require.NoError(t, store.Sweep(ctx))
+		var rows int
+		require.NoError(t, env.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM rate_limits WHERE key = 'fast'").Scan(&rows))
+		assert.Zero(t, rows)
+
+		result, err = store.Take(ctx, "fast", fast)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+}
+
+func TestRateLimitIntegration(t *testing.T) {
+	router, teardownSuite := setupSuiteWith(t, func(e *config.Env) {
+		e.RateLimit.Default = ratelimit.Limit{Burst: 2, Period: time.Minute}
+		e.RateLimitStore = ratelimit.NewPostgresStore(e.System)
+	})
+	defer teardownSuite(t)
+
+	for range 2 {
+		w := integration.MkRequest(t, "GET", "/api/contacts/", router, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+	w := integration.MkRequest(t, "GET", "/api/contacts/", router, nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// Probes are not limited.
+	w = integration.MkRequest(t, "GET", "/healthz", router, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestIPRateLimitIntegration(t *testing.T) {
+	router, teardownSuite := setupSuiteWith(t, func(e *config.Env) {
+		e.RateLimit.IP = ratelimit.Limit{Burst: 2, Period: time.Minute}
+	})
+	defer teardownSuite(t)
+
+	// Guessed keys count against the client before they are checked.
+	guess := map[string]string{"X-API-Key": "ck_guess"}
+	for range 2 {
+		w := integration.MkRequest(t, "GET", "/admin/api-keys", router, guess)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+	w := integration.MkRequest(t, "GET", "/admin/api-keys", router, guess)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	w = integration.MkRequest(t, "GET", "/api/contacts/", router, nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
@@ -0,0 +1,149 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"contactsAI/contacts/internal/handlers"
+	integration "contactsAI/contacts/tests/integration_test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelationsIntegration(t *testing.T) {
+	router, env, users, teardownSuite := newAuthSuite(t, 1, 3)
+	defer teardownSuite(t)
+	ctx := context.Background()
+	user1, user3 := users[1], users[3]
+
+	relate := func(contactID int, body handlers.RelationBody) *httptest.ResponseRecorder {
+		t.Helper()
+		return integration.MkJSONRequestWithHeaders(t, "POST", fmt.Sprintf("/api/contacts/%d/relations", contactID),
+			router, body, user1)
+	}
+	list := func(contactID int) []handlers.RelationResponse {
+		t.Helper()
+		w := integration.MkRequest(t, "GET", fmt.Sprintf("/api/contacts/%d/relations", contactID), router, user1)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var relations []handlers.RelationResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &relations))
+		return relations
+	}
+	graph := func(contactID, depth int) handlers.RelationGraphResponse {
+		t.Helper()
+		w := integration.MkRequest(t, "GET", fmt.Sprintf("/api/contacts/%d/relations/graph?depth=%d", contactID, depth),
+			router, user1)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var graph handlers.RelationGraphResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &graph))
+		return graph
+	}
+
+	var assistant handlers.RelationResponse
+	t.Run("relations read from both contacts", func(t *testing.T) {
+		w := relate(3, handlers.RelationBody{RelatedID: 2, Type: "assistant"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &assistant))
+		assert.Equal(t, handlers.RelationResponse{ID: assistant.ID, ContactID: 3, RelatedID: 2,
+			RelatedName: "Anna Nowak", Type: "assistant", CreatedAt: assistant.CreatedAt}, assistant)
+
+		fromAnna := list(2)
+		require.Len(t, fromAnna, 1)
+		assert.Equal(t, int32(3), fromAnna[0].RelatedID)
+		assert.Equal(t, "Jan Kowalski", fromAnna[0].RelatedName)
+		assert.Equal(t, "executive", fromAnna[0].Type)
+
+		w = relate(6, handlers.RelationBody{RelatedID: 3, Type: "spouse"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		require.Len(t, list(3), 2)
+	})
+
+	t.Run("read-only shares do not change relations", func(t *testing.T) {
+		readOnly := int32(3)
+		for _, contactID := range []int{2, 3} {
+			w := integration.MkJSONRequestWithHeaders(t, "POST", fmt.Sprintf("/api/contacts/%d/shares/", contactID),
+				router, handlers.CreateShareBody{UserID: &readOnly, TeamID: nil, Permission: "read"}, user1)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		}
+
+		w := integration.MkRequest(t, "GET", "/api/contacts/3/relations", router, user3)
+		assert.Equal(t, http.StatusOK, w.Code, "the relations are visible")
+		w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/3/relations", router,
+			handlers.RelationBody{RelatedID: 2, Type: "colleague"}, user3)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		path := fmt.Sprintf("/api/contacts/2/relations/%d", assistant.ID)
+		w = integration.MkJSONRequestWithHeaders(t, "PUT", path, router,
+			handlers.RelationBody{RelatedID: 3, Type: "spouse"}, user3)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = integration.MkRequest(t, "DELETE", path, router, user3)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "executive", list(2)[0].Type, "the relation is unchanged")
+	})
+
+	t.Run("invalid relations are rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, relate(2, handlers.RelationBody{RelatedID: 2, Type: "spouse"}).Code)
+		assert.Equal(t, http.StatusBadRequest, relate(2, handlers.RelationBody{RelatedID: 3, Type: "friend"}).Code)
+		assert.Equal(t, http.StatusNotFound, relate(2, handlers.RelationBody{RelatedID: 4, Type: "colleague"}).Code)
+		assert.Equal(t, http.StatusNotFound, relate(4, handlers.RelationBody{RelatedID: 2, Type: "colleague"}).Code)
+		assert.Equal(t, http.StatusConflict, relate(2, handlers.RelationBody{RelatedID: 3, Type: "executive"}).Code,
+			"the inverse of an existing relation")
+	})
+
+	t.Run("the graph covers the neighbourhood the caller can see", func(t *testing.T) {
+		_, err := env.Pool.Exec(ctx, `INSERT INTO contact_relations (contact_id, related_id, type, tenant_id)
+			VALUES (4, 6, 'colleague', 1), (4, 8, 'colleague', 1)`)
+		require.NoError(t, err)
+
+		near := graph(2, 1)
+		require.Len(t, near.Nodes, 2)
+		assert.Equal(t, handlers.RelationGraphNode{ID: 2, Name: "Anna Nowak", Depth: 0}, near.Nodes[0])
+		assert.Equal(t, handlers.RelationGraphNode{ID: 3, Name: "Jan Kowalski", Depth: 1}, near.Nodes[1])
+		require.Len(t, near.Edges, 1)
+		assert.Equal(t, assistant.ID, near.Edges[0].ID)
+
+		far := graph(2, 4)
+		ids := make([]int32, len(far.Nodes))
+		for i, node := range far.Nodes {
+			ids[i] = node.ID
+		}
+		assert.Equal(t, []int32{2, 3, 6}, ids, "contact 4 is neither listed nor passed through")
+		assert.Len(t, far.Edges, 2)
+
+		w := integration.MkRequest(t, "GET", "/api/contacts/2/relations/graph?depth=5", router, user1)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("relations are updated and deleted", func(t *testing.T) {
+		path := fmt.Sprintf("/api/contacts/2/relations/%d", assistant.ID)
+		w := integration.MkJSONRequestWithHeaders(t, "PUT", path, router,
+			handlers.RelationBody{RelatedID: 3, Type: "report"}, user1)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var updated handlers.RelationResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+		assert.Equal(t, "report", updated.Type)
+		assert.Equal(t, "manager", list(3)[0].Type, "Anna is now the manager of Jan")
+
+		w = integration.MkRequest(t, "DELETE", fmt.Sprintf("/api/contacts/6/relations/%d", assistant.ID), router, user1)
+		assert.Equal(t, http.StatusNotFound, w.Code, "the relation is not one of contact 6")
+		w = integration.MkRequest(t, "DELETE", path, router, user1)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, list(2))
+	})
+
+	t.Run("relations are deleted with their contacts", func(t *testing.T) {
+		w := integration.MkRequest(t, "DELETE", "/api/contacts/6", router, user1)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		var count int
+		require.NoError(t, env.Pool.QueryRow(ctx,
+			"SELECT COUNT(*) FROM contact_relations WHERE 6 IN (contact_id, related_id)").Scan(&count))
+		assert.Zero(t, count)
+		assert.Empty(t, list(3))
+	})
+}
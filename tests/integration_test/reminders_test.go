@@ -0,0 +1,292 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"contactsAI/contacts/internal/apikey"
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/handlers"
+	"contactsAI/contacts/internal/leader"
+	"contactsAI/contacts/internal/reminders"
+	integration "contactsAI/contacts/tests/integration_test"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type notificationRecorder struct {
+	mu       sync.Mutex
+	received []reminders.Notification
+	failing  bool
+}
+
+func (r *notificationRecorder) Notify(_ context.Context, n reminders.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failing {
+		return errors.New("unreachable")
+	}
+	r.received = append(r.received, n)
+	return nil
+}
+
+func (r *notificationRecorder) setFailing(failing bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failing = failing
+}
+
+func (r *notificationRecorder) take() []reminders.Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	received := r.received
+	r.received = nil
+	return received
+}
+
+func upcoming(t *testing.T, router *gin.Engine, headers map[string]string) []handlers.ReminderResponse {
+	t.Helper()
+	w := integration.MkRequest(t, "GET", "/api/reminders/upcoming?days=30", router, headers)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var reminders []handlers.ReminderResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &reminders))
+	return reminders
+}
+
+func TestRemindersIntegration(t *testing.T) {
+	var env *config.Env
+	notifications := &notificationRecorder{}
+	router, teardownSuite := setupSuiteWith(t, func(e *config.Env) {
+		env = e
+		env.Scheduler = reminders.NewScheduler(env.System, reminders.Notifiers{
+			{Name: "recorder", Notifier: notifications},
+		}, env.Logger, 30*24*time.Hour)
+	})
+	defer teardownSuite(t)
+	ctx := context.Background()
+
+	user1, err := integration.CreateAPIKey(ctx, env.System, 1, apikey.ScopeContactsRead, apikey.ScopeContactsWrite)
+	require.NoError(t, err)
+	user3, err := integration.CreateAPIKey(ctx, env.System, 3, apikey.ScopeContactsRead, apikey.ScopeContactsWrite)
+	require.NoError(t, err)
+
+	warsaw, err := reminders.LoadLocation("Europe/Warsaw")
+	require.NoError(t, err)
+	hour := int32(8)
+
+	t.Run("settings default to UTC and accept IANA time zones", func(t *testing.T) {
+		w := integration.MkRequest(t, "GET", "/api/reminders/settings", router, user1)
+		require.Equal(t, http.StatusOK, w.Code)
+		var settings handlers.ReminderSettingsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &settings))
+		assert.Equal(t, handlers.ReminderSettingsResponse{TimeZone: "UTC", NotifyHour: 9}, settings)
+
+		w = integration.MkJSONRequestWithHeaders(t, "PUT", "/api/reminders/settings", router,
+			handlers.ReminderSettingsBody{TimeZone: "Mars/Olympus", NotifyHour: &hour}, user1)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = integration.MkJSONRequestWithHeaders(t, "PUT", "/api/reminders/settings", router,
+			handlers.ReminderSettingsBody{TimeZone: "Europe/Warsaw", NotifyHour: &hour, Email: "jan@example.com"}, user1)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("anonymous callers have no reminders", func(t *testing.T) {
+		w := integration.MkRequest(t, "GET", "/api/reminders/settings", router, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		w = integration.MkJSONRequest(t, "PUT", "/api/reminders/settings", router,
+			handlers.ReminderSettingsBody{TimeZone: "UTC", NotifyHour: &hour, Email: "anyone@example.com"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		w = integration.MkRequest(t, "GET", "/api/reminders/upcoming", router, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		w = integration.MkJSONRequest(t, "POST", "/api/reminders/1/snooze", router,
+			handlers.SnoozeReminderBody{Minutes: 5})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		w = integration.MkRequest(t, "POST", "/api/reminders/1/complete", router, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	var inTwoWeeks, overdue handlers.ReminderResponse
+	t.Run("follow-ups are due at a time or in days at the notify hour", func(t *testing.T) {
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/2/reminders", router,
+			handlers.FollowUpBody{Note: "Call back", DueInDays: 14}, user1)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &inTwoWeeks))
+		day := reminders.Today(time.Now(), warsaw).AddDate(0, 0, 14)
+		assert.True(t, reminders.DueAt(day, 8, warsaw).Equal(inTwoWeeks.DueAt), inTwoWeeks.DueAt)
+		assert.Equal(t, "Anna Nowak", inTwoWeeks.ContactName)
+
+		past := time.Now().Add(-time.Minute)
+		w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/2/reminders", router,
+			handlers.FollowUpBody{Note: "Send the offer", DueAt: &past}, user1)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &overdue))
+
+		w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/2/reminders", router,
+			handlers.FollowUpBody{Note: "Both", DueAt: &past, DueInDays: 1}, user1)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/4/reminders", router,
+			handlers.FollowUpBody{Note: "Not my contact", DueInDays: 1}, user1)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("the scheduler plans birthdays and notifies due reminders", func(t *testing.T) {
+		tomorrow := reminders.Today(time.Now(), warsaw).AddDate(0, 0, 1)
+		_, err := env.Pool.Exec(ctx, "UPDATE contacts SET birthday = $1 WHERE id = 2",
+			tomorrow.AddDate(-40, 0, 0))
+		require.NoError(t, err)
+
+		require.NoError(t, env.Scheduler.Tick(ctx))
+
+		received := notifications.take()
+		require.Len(t, received, 1)
+		assert.Equal(t, overdue.ID, received[0].ReminderID)
+		assert.Equal(t, "jan@example.com", received[0].Email)
+		assert.Equal(t, "Follow up with Anna Nowak", received[0].Subject())
+
+		list := upcoming(t, router, user1)
+		require.Len(t, list, 3)
+		assert.Equal(t, overdue.ID, list[0].ID)
+		assert.NotNil(t, list[0].NotifiedAt)
+		assert.Equal(t, reminders.KindBirthday, list[1].Kind)
+		assert.Equal(t, tomorrow.Format(time.DateOnly), list[1].OccursOn)
+		assert.True(t, reminders.DueAt(tomorrow, 8, warsaw).Equal(list[1].DueAt))
+		assert.Equal(t, inTwoWeeks.ID, list[2].ID)
+
+		assert.Empty(t, upcoming(t, router, user3), "reminders belong to their owner")
+
+		require.NoError(t, env.Scheduler.Plan(ctx, time.Now()))
+		assert.Len(t, upcoming(t, router, user1), 3, "occurrences are planned once")
+	})
+
+	t.Run("time zone changes move pending birthdays", func(t *testing.T) {
+		w := integration.MkJSONRequestWithHeaders(t, "PUT", "/api/reminders/settings", router,
+			handlers.ReminderSettingsBody{TimeZone: "Asia/Tokyo", NotifyHour: &hour, Email: "jan@example.com"}, user1)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		tokyo, err := reminders.LoadLocation("Asia/Tokyo")
+		require.NoError(t, err)
+		for _, reminder := range upcoming(t, router, user1) {
+			if reminder.Kind == reminders.KindBirthday {
+				on, err := time.Parse(time.DateOnly, reminder.OccursOn)
+				require.NoError(t, err)
+				assert.True(t, reminders.DueAt(on, 8, tokyo).Equal(reminder.DueAt), reminder.DueAt)
+			}
+		}
+	})
+
+	t.Run("changed birthdays drop their pending reminders", func(t *testing.T) {
+		_, err := env.Pool.Exec(ctx, "UPDATE contacts SET birthday = birthday + 1 WHERE id = 2")
+		require.NoError(t, err)
+		require.NoError(t, env.Scheduler.DropStale(ctx))
+
+		for _, reminder := range upcoming(t, router, user1) {
+			assert.NotEqual(t, reminders.KindBirthday, reminder.Kind)
+		}
+	})
+
+	t.Run("snoozed reminders are notified again later", func(t *testing.T) {
+		path := fmt.Sprintf("/api/reminders/%d/snooze", overdue.ID)
+		w := integration.MkJSONRequestWithHeaders(t, "POST", path, router,
+			handlers.SnoozeReminderBody{Minutes: 60}, user3)
+		assert.Equal(t, http.StatusNotFound, w.Code, "only the owner snoozes")
+
+		w = integration.MkJSONRequestWithHeaders(t, "POST", path, router,
+			handlers.SnoozeReminderBody{Minutes: 60}, user1)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var snoozed handlers.ReminderResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &snoozed))
+		assert.Nil(t, snoozed.NotifiedAt)
+		assert.NotNil(t, snoozed.SnoozedAt)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), snoozed.DueAt, time.Minute)
+
+		require.NoError(t, env.Scheduler.NotifyDue(ctx))
+		assert.Empty(t, notifications.take())
+	})
+
+	t.Run("completed reminders are no longer upcoming", func(t *testing.T) {
+		path := fmt.Sprintf("/api/reminders/%d/complete", overdue.ID)
+		w := integration.MkRequest(t, "POST", path, router, user1)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		w = integration.MkRequest(t, "POST", path, router, user1)
+		assert.Equal(t, http.StatusOK, w.Code, "completing is idempotent")
+
+		for _, reminder := range upcoming(t, router, user1) {
+			assert.NotEqual(t, overdue.ID, reminder.ID)
+		}
+		w = integration.MkJSONRequestWithHeaders(t, "POST", fmt.Sprintf("/api/reminders/%d/snooze", overdue.ID),
+			router, handlers.SnoozeReminderBody{Minutes: 5}, user1)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("failed notifications are retried with backoff", func(t *testing.T) {
+		_, err := env.Pool.Exec(ctx, "UPDATE reminders SET due_at = now() WHERE id = $1", inTwoWeeks.ID)
+		require.NoError(t, err)
+		notifications.setFailing(true)
+		require.NoError(t, env.Scheduler.NotifyDue(ctx))
+
+		var attempts int32
+		var nextAttemptAt time.Time
+		require.NoError(t, env.Pool.QueryRow(ctx,
+			"SELECT attempts, next_attempt_at FROM reminders WHERE id = $1", inTwoWeeks.ID,
+		).Scan(&attempts, &nextAttemptAt))
+		assert.Equal(t, int32(1), attempts)
+		assert.WithinDuration(t, time.Now().Add(reminders.RetryDelay(1)), nextAttemptAt, 10*time.Second)
+
+		notifications.setFailing(false)
+		require.NoError(t, env.Scheduler.NotifyDue(ctx))
+		assert.Empty(t, notifications.take(), "retries wait for their backoff")
+
+		_, err = env.Pool.Exec(ctx, "UPDATE reminders SET next_attempt_at = now() WHERE id = $1", inTwoWeeks.ID)
+		require.NoError(t, err)
+		require.NoError(t, env.Scheduler.NotifyDue(ctx))
+		received := notifications.take()
+		require.Len(t, received, 1)
+		assert.Equal(t, inTwoWeeks.ID, received[0].ReminderID)
+	})
+
+	t.Run("one replica leads at a time", func(t *testing.T) {
+		logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+		elector := leader.Elector{Pool: env.Pool, Logger: logger, Key: 42, Retry: 50 * time.Millisecond}
+		leading := make(chan int, 2)
+		run := func(replica int) context.CancelFunc {
+			runCtx, cancel := context.WithCancel(ctx)
+			go func() {
+				_ = elector.Run(runCtx, func(leadCtx context.Context) {
+					leading <- replica
+					<-leadCtx.Done()
+				})
+			}()
+			return cancel
+		}
+
+		stopFirst := run(1)
+		require.Equal(t, 1, <-leading)
+		stopSecond := run(2)
+		defer stopSecond()
+		select {
+		case replica := <-leading:
+			t.Fatalf("replica %d leads as well", replica)
+		case <-time.After(300 * time.Millisecond):
+		}
+
+		stopFirst()
+		select {
+		case replica := <-leading:
+			assert.Equal(t, 2, replica)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the second replica did not take over")
+		}
+	})
+}
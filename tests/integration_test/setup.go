@@ -4,25 +4,26 @@ package integration
 
 import (
 	"context"
+	"os"
 	"path/filepath"
 	"runtime"
 
+	"contactsAI/contacts/internal/apikey"
+	"contactsAI/contacts/internal/db"
+	"contactsAI/contacts/internal/tenant"
+
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 )
 
 const dbImage = "postgres:18"
 
+// SetupTestDB starts an empty database. The schema is created by applying the
+// embedded migrations, see SeedTestDB for the test data.
 func SetupTestDB(ctx context.Context) (*postgres.PostgresContainer, error) {
-	_, filename, _, _ := runtime.Caller(0)
-	testDir := filepath.Dir(filename)
-
-	schemaFilePath := filepath.Join(testDir, "..", "..", "sql", "schema.sql")
-	startupFilePath := filepath.Join(testDir, "..", "testdata", "init-db.sql")
-
 	dbContainer, err := postgres.Run(
 		ctx,
 		dbImage,
-		postgres.WithOrderedInitScripts(schemaFilePath, startupFilePath),
 		postgres.WithDatabase("contacts_db"),
 		postgres.WithUsername("root"),
 		postgres.WithPassword("example"),
@@ -31,3 +32,41 @@ func SetupTestDB(ctx context.Context) (*postgres.PostgresContainer, error) {
 
 	return dbContainer, err
 }
+
+// SeedTestDB inserts the test data into a migrated database. It has contacts
+// 2, 3 and 6 owned by user 1, 1, 5 and 7 owned by user 2, and 4 and 8 owned by
+// user 3, all of the default tenant.
+func SeedTestDB(ctx context.Context, pool *pgxpool.Pool) error {
+	_, filename, _, _ := runtime.Caller(0)
+	testDir := filepath.Dir(filename)
+
+	seed, err := os.ReadFile(filepath.Join(testDir, "..", "testdata", "init-db.sql"))
+	if err != nil {
+		return err
+	}
+	_, err = pool.Exec(ctx, string(seed))
+	return err
+}
+
+// CreateAPIKey stores a new key of the default tenant and returns the
+// Authorization header that authenticates with it.
+func CreateAPIKey(ctx context.Context, queries db.Querier, ownerID int32, scopes ...string) (map[string]string, error) {
+	return CreateTenantAPIKey(ctx, queries, tenant.DefaultID, ownerID, scopes...)
+}
+
+// CreateTenantAPIKey is CreateAPIKey for a key of another tenant.
+func CreateTenantAPIKey(
+	ctx context.Context, queries db.Querier, tenantID, ownerID int32, scopes ...string,
+) (map[string]string, error) {
+	key := apikey.Generate()
+	//nolint:exhaustruct // test keys do not expire
+	_, err := queries.CreateAPIKey(ctx, db.CreateAPIKeyParams{
+		Name:     "test",
+		Prefix:   key.Prefix,
+		KeyHash:  key.Hash,
+		OwnerID:  ownerID,
+		Scopes:   scopes,
+		TenantID: tenantID,
+	})
+	return map[string]string{"Authorization": "Bearer " + key.Secret}, err
+}
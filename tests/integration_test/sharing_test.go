@@ -0,0 +1,177 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"contactsAI/contacts/internal/graph"
+	"contactsAI/contacts/internal/handlers"
+	integration "contactsAI/contacts/tests/integration_test"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharingIntegration(t *testing.T) {
+	router, _, users, teardownSuite := newAuthSuite(t, 1, 2, 3)
+	defer teardownSuite(t)
+	owner, other, teammate := users[1], users[2], users[3]
+
+	t.Run("contacts are only visible to their owner", func(t *testing.T) {
+		w := integration.MkRequest(t, "GET", "/api/contacts/", router, other)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.ElementsMatch(t, []int32{1, 5, 7}, contactIDs(t, w.Body.Bytes()))
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/2", router, other)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = integration.MkJSONRequestWithHeaders(t, "PUT", "/api/contacts/2", router,
+			handlers.UpdateContactBody{Name: "Taken Over", Phone: "123-456-789"}, other)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = integration.MkRequest(t, "DELETE", "/api/contacts/2", router, other)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = integration.MkRequest(t, "GET", "/api/contacts/2/avatar", router, other)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/graphql", router,
+			graph.Request{Query: `{ contacts(first: 10) { edges { node { id } } } }`}, other)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), `"id":2`)
+	})
+
+	t.Run("new contacts belong to their creator", func(t *testing.T) {
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/", router,
+			handlers.CreateContactBody{Name: "Private Person", Phone: "600-700-800"}, other)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created handlers.ContactResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		require.NotNil(t, created.OwnerID)
+		assert.Equal(t, int32(2), *created.OwnerID)
+
+		w = integration.MkRequest(t, "GET", fmt.Sprintf("/api/contacts/%d", created.ID), router, owner)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	var share handlers.ShareResponse
+	t.Run("read shares allow reading only", func(t *testing.T) {
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/2/shares/", router,
+			map[string]any{"user_id": 2, "permission": "read"}, owner)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &share))
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/2", router, other)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = integration.MkJSONRequestWithHeaders(t, "PUT", "/api/contacts/2", router,
+			handlers.UpdateContactBody{Name: "Taken Over", Phone: "123-456-789"}, other)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = integration.MkRequest(t, "DELETE", "/api/contacts/2", router, other)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		for _, mutation := range []string{
+			`mutation { updateContact(id: 2, input: {name: "Taken Over", phone: "123-456-789"}) { id } }`,
+			`mutation { deleteContact(id: 2) }`,
+		} {
+			w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/graphql", router,
+				graph.Request{Query: mutation}, other)
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), "contact is shared read-only", mutation)
+		}
+
+		w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/2/shares/", router,
+			map[string]any{"user_id": 3, "permission": "write"}, other)
+		assert.Equal(t, http.StatusForbidden, w.Code, "only owners share")
+		w = integration.MkRequest(t, "GET", "/api/contacts/2/shares/", router, other)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("write shares allow changes", func(t *testing.T) {
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/2/shares/", router,
+			map[string]any{"user_id": 2, "permission": "write"}, owner)
+		require.Equal(t, http.StatusOK, w.Code)
+		var updated handlers.ShareResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+		assert.Equal(t, share.ID, updated.ID, "sharing again changes the permission")
+
+		w = integration.MkJSONRequestWithHeaders(t, "PUT", "/api/contacts/2", router,
+			handlers.UpdateContactBody{Name: "Anna Nowak", Phone: "987-654-321"}, other)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = integration.MkRequest(t, "DELETE", fmt.Sprintf("/api/contacts/2/shares/%d", share.ID), router, owner)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		w = integration.MkRequest(t, "GET", "/api/contacts/2", router, other)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("teams share contacts by role", func(t *testing.T) {
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/teams/", router,
+			handlers.CreateTeamBody{Name: "Sales"}, owner)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var team handlers.TeamResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &team))
+		assert.Equal(t, "owner", team.Role)
+		teamPath := fmt.Sprintf("/api/teams/%d", team.ID)
+
+		w = setRole(t, router, teamPath, 3, "viewer", owner)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/3/shares/", router,
+			map[string]any{"team_id": team.ID, "permission": "write"}, owner)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/3", router, teammate)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = integration.MkJSONRequestWithHeaders(t, "PUT", "/api/contacts/3", router,
+			handlers.UpdateContactBody{Name: "Jan Kowalski", Phone: "123-456-789"}, teammate)
+		assert.Equal(t, http.StatusForbidden, w.Code, "viewers only read")
+
+		w = setRole(t, router, teamPath, 2, "member", teammate)
+		assert.Equal(t, http.StatusForbidden, w.Code, "viewers cannot add members")
+		w = integration.MkRequest(t, "GET", teamPath, router, other)
+		assert.Equal(t, http.StatusNotFound, w.Code, "teams are hidden from non-members")
+
+		w = setRole(t, router, teamPath, 3, "member", owner)
+		require.Equal(t, http.StatusOK, w.Code)
+		w = integration.MkJSONRequestWithHeaders(t, "PUT", "/api/contacts/3", router,
+			handlers.UpdateContactBody{Name: "Jan Kowalski", Phone: "123-456-789"}, teammate)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = integration.MkRequest(t, "GET", teamPath, router, teammate)
+		require.Equal(t, http.StatusOK, w.Code)
+		var detail handlers.TeamDetailResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &detail))
+		assert.Len(t, detail.Members, 2)
+		assert.Equal(t, "member", detail.Role)
+
+		w = integration.MkRequest(t, "DELETE", teamPath+"/members/1", router, owner)
+		assert.Equal(t, http.StatusConflict, w.Code, "the last owner cannot leave")
+		w = integration.MkRequest(t, "DELETE", teamPath, router, teammate)
+		assert.Equal(t, http.StatusForbidden, w.Code, "only owners delete teams")
+
+		w = integration.MkRequest(t, "DELETE", teamPath, router, owner)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		w = integration.MkRequest(t, "GET", "/api/contacts/3", router, teammate)
+		assert.Equal(t, http.StatusNotFound, w.Code, "team shares are removed with the team")
+	})
+}
+
+func setRole(
+	t *testing.T, router *gin.Engine, teamPath string, userID int, role string, headers map[string]string,
+) *httptest.ResponseRecorder {
+	t.Helper()
+	return integration.MkJSONRequestWithHeaders(t, "PUT", fmt.Sprintf("%s/members/%d", teamPath, userID), router,
+		handlers.TeamMemberBody{Role: role}, headers)
+}
+
+func contactIDs(t *testing.T, body []byte) []int32 {
+	t.Helper()
+	var contacts []handlers.ContactResponse
+	require.NoError(t, json.Unmarshal(body, &contacts))
+	ids := make([]int32, len(contacts))
+	for i, contact := range contacts {
+		ids[i] = contact.ID
+	}
+	return ids
+}
@@ -0,0 +1,191 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"contactsAI/contacts/internal/handlers"
+	integration "contactsAI/contacts/tests/integration_test"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagsIntegration(t *testing.T) {
+	router, _, users, teardownSuite := newAuthSuite(t, 1, 2)
+	defer teardownSuite(t)
+	owner, other := users[1], users[2]
+
+	customer := createTag(t, router, owner, handlers.TagBody{Name: "customer", Color: ""})
+	vip := createTag(t, router, owner, handlers.TagBody{Name: "vip", Color: "#ff0000"})
+
+	t.Run("tags are per owner", func(t *testing.T) {
+		assert.Equal(t, "#9e9e9e", customer.Color)
+
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/tags/", router,
+			handlers.TagBody{Name: "customer", Color: ""}, owner)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/tags/", router,
+			handlers.TagBody{Name: "Customer", Color: ""}, owner)
+		assert.Equal(t, http.StatusConflict, w.Code, "tag names ignore case")
+		w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/tags/", router,
+			handlers.TagBody{Name: "bad color", Color: "red"}, owner)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		theirs := createTag(t, router, other, handlers.TagBody{Name: "customer", Color: ""})
+		w = integration.MkRequest(t, "GET", "/api/tags/", router, other)
+		require.Equal(t, http.StatusOK, w.Code)
+		var tags []handlers.TagDetailResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tags))
+		require.Len(t, tags, 1)
+		assert.Equal(t, theirs.ID, tags[0].ID)
+
+		w = integration.MkJSONRequestWithHeaders(t, "PUT", fmt.Sprintf("/api/tags/%d", customer.ID), router,
+			handlers.TagBody{Name: "renamed", Color: ""}, other)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("tags are attached in bulk to visible contacts", func(t *testing.T) {
+		w := integration.MkJSONRequestWithHeaders(t, "POST", fmt.Sprintf("/api/tags/%d/attach", customer.ID), router,
+			handlers.TagContactsBody{ContactIDs: []int32{2, 3, 6, 1}}, owner)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var result handlers.TagContactsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Equal(t, int64(3), result.Changed, "contact 1 belongs to another user")
+
+		w = integration.MkRequest(t, "PUT", fmt.Sprintf("/api/contacts/3/tags/%d", vip.ID), router, owner)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		w = integration.MkRequest(t, "PUT", fmt.Sprintf("/api/contacts/6/tags/%d", vip.ID), router, owner)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		w = integration.MkRequest(t, "PUT", fmt.Sprintf("/api/contacts/1/tags/%d", vip.ID), router, owner)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/3", router, owner)
+		require.Equal(t, http.StatusOK, w.Code)
+		var contact handlers.ContactResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &contact))
+		assert.ElementsMatch(t, []handlers.TagResponse{
+			{ID: customer.ID, Name: "customer", Color: "#9e9e9e"},
+			{ID: vip.ID, Name: "vip", Color: "#ff0000"},
+		}, contact.Tags)
+	})
+
+	t.Run("contacts are filtered by tags", func(t *testing.T) {
+		w := integration.MkRequest(t, "GET", "/api/contacts/?tag=customer&tag=vip", router, owner)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.ElementsMatch(t, []int32{2, 3, 6}, contactIDs(t, w.Body.Bytes()))
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/?tag=customer&tag=vip&match=all", router, owner)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.ElementsMatch(t, []int32{3, 6}, contactIDs(t, w.Body.Bytes()))
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/?tag=Customer&tag=VIP&tag=vip&match=all", router, owner)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.ElementsMatch(t, []int32{3, 6}, contactIDs(t, w.Body.Bytes()), "tag names ignore case")
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/?tag=customer", router, other)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, contactIDs(t, w.Body.Bytes()), "other users' tags do not match")
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/?tag=vip&match=some", router, owner)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("smart groups evaluate their filter", func(t *testing.T) {
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/groups/", router,
+			handlers.SmartGroupBody{Name: "Broken", Filter: "tag:vip AND ("}, owner)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/groups/", router,
+			handlers.SmartGroupBody{Name: "Key customers", Filter: "tag:customer AND (tag:vip OR name:nowak)"}, owner)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var group handlers.SmartGroupResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &group))
+
+		groupPath := fmt.Sprintf("/api/groups/%d", group.ID)
+		page := smartGroupPage(t, router, groupPath+"/contacts", owner)
+		assert.ElementsMatch(t, []int32{2, 3, 6}, page.ids)
+		assert.Empty(t, page.next)
+
+		var paged []int32
+		for path := groupPath + "/contacts?limit=1"; path != ""; {
+			page = smartGroupPage(t, router, path, owner)
+			require.LessOrEqual(t, len(page.ids), 1)
+			paged = append(paged, page.ids...)
+			path = ""
+			if page.next != "" {
+				path = groupPath + "/contacts?limit=1&after=" + page.next
+			}
+		}
+		assert.ElementsMatch(t, []int32{2, 3, 6}, paged)
+
+		w = integration.MkRequest(t, "GET", groupPath+"/contacts?after=nope", router, owner)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = integration.MkRequest(t, "GET", groupPath+"/contacts?limit=101", router, owner)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = integration.MkJSONRequestWithHeaders(t, "PUT", groupPath, router,
+			handlers.SmartGroupBody{Name: "Key customers", Filter: "tag:customer NOT tag:vip"}, owner)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []int32{2}, smartGroupPage(t, router, groupPath+"/contacts", owner).ids)
+
+		w = integration.MkRequest(t, "GET", groupPath, router, other)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = integration.MkRequest(t, "DELETE", groupPath, router, owner)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("detaching and deleting tags", func(t *testing.T) {
+		w := integration.MkJSONRequestWithHeaders(t, "POST", fmt.Sprintf("/api/tags/%d/detach", customer.ID), router,
+			handlers.TagContactsBody{ContactIDs: []int32{2, 3}}, owner)
+		require.Equal(t, http.StatusOK, w.Code)
+		w = integration.MkRequest(t, "DELETE", fmt.Sprintf("/api/contacts/6/tags/%d", vip.ID), router, owner)
+		require.Equal(t, http.StatusNoContent, w.Code)
+
+		w = integration.MkRequest(t, "GET", "/api/contacts/?tag=customer", router, owner)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.ElementsMatch(t, []int32{6}, contactIDs(t, w.Body.Bytes()))
+
+		w = integration.MkRequest(t, "DELETE", fmt.Sprintf("/api/tags/%d", vip.ID), router, owner)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		w = integration.MkRequest(t, "GET", "/api/contacts/3", router, owner)
+		require.Equal(t, http.StatusOK, w.Code)
+		var contact handlers.ContactResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &contact))
+		assert.Empty(t, contact.Tags)
+	})
+}
+
+func createTag(
+	t *testing.T, router *gin.Engine, headers map[string]string, body handlers.TagBody,
+) handlers.TagDetailResponse {
+	t.Helper()
+	w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/tags/", router, body, headers)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var tag handlers.TagDetailResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tag))
+	return tag
+}
+
+type groupPage struct {
+	ids  []int32
+	next string
+}
+
+func smartGroupPage(t *testing.T, router *gin.Engine, path string, headers map[string]string) groupPage {
+	t.Helper()
+	w := integration.MkRequest(t, "GET", path, router, headers)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var page handlers.SmartGroupPage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	ids := make([]int32, len(page.Contacts))
+	for i, contact := range page.Contacts {
+		ids[i] = contact.ID
+	}
+	return groupPage{ids: ids, next: page.NextCursor}
+}
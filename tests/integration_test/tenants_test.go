@@ -0,0 +1,186 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"contactsAI/contacts/internal/apikey"
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/db"
+	"contactsAI/contacts/internal/graph"
+	"contactsAI/contacts/internal/handlers"
+	"contactsAI/contacts/internal/tenant"
+	integration "contactsAI/contacts/tests/integration_test"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// All seed contacts belong to the default tenant. The second tenant has a user
+// with the same ID as the owner of contacts 2, 3 and 6 in the default tenant.
+func TestTenantIsolationIntegration(t *testing.T) {
+	var env *config.Env
+	router, teardownSuite := setupSuiteWith(t, func(e *config.Env) {
+		e.Auth.Required = true
+		env = e
+	})
+	defer teardownSuite(t)
+
+	ctx := context.Background()
+	other, err := env.System.CreateTenant(ctx, "other")
+	require.NoError(t, err)
+	owner, err := integration.CreateAPIKey(ctx, env.System, 1,
+		apikey.ScopeContactsRead, apikey.ScopeContactsWrite)
+	require.NoError(t, err)
+	admin, err := integration.CreateAPIKey(ctx, env.System, 1, apikey.ScopeAdmin)
+	require.NoError(t, err)
+	intruder, err := integration.CreateTenantAPIKey(ctx, env.System, other.ID, 1,
+		apikey.ScopeContactsRead, apikey.ScopeContactsWrite)
+	require.NoError(t, err)
+	otherAdmin, err := integration.CreateTenantAPIKey(ctx, env.System, other.ID, 1, apikey.ScopeAdmin)
+	require.NoError(t, err)
+
+	t.Run("contacts of other tenants are invisible", func(t *testing.T) {
+		for _, headers := range []map[string]string{intruder, otherAdmin} {
+			w := integration.MkRequest(t, "GET", "/api/contacts/", router, headers)
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Empty(t, contactIDs(t, w.Body.Bytes()))
+
+			w = integration.MkRequest(t, "GET", "/api/contacts/2", router, headers)
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			w = integration.MkJSONRequestWithHeaders(t, "PUT", "/api/contacts/2", router,
+				handlers.UpdateContactBody{Name: "Taken Over", Phone: "123-456-789"}, headers)
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			w = integration.MkRequest(t, "DELETE", "/api/contacts/2", router, headers)
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/2/shares/", router,
+				map[string]any{"user_id": 3, "permission": "write"}, headers)
+			assert.Equal(t, http.StatusNotFound, w.Code)
+
+			w = integration.MkJSONRequestWithHeaders(t, "POST", "/api/graphql", router,
+				graph.Request{Query: `{ contacts(first: 10) { edges { node { id } } } }`}, headers)
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.NotContains(t, w.Body.String(), `"id"`)
+		}
+
+		w := integration.MkRequest(t, "GET", "/api/contacts/2", router, owner)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Anna Nowak")
+	})
+
+	t.Run("new contacts belong to the tenant of the key", func(t *testing.T) {
+		w := integration.MkJSONRequestWithHeaders(t, "POST", "/api/contacts/", router,
+			handlers.CreateContactBody{Name: "Other Person", Phone: "600-700-800"}, intruder)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created handlers.ContactResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+		var tenantID int32
+		require.NoError(t, env.Pool.QueryRow(ctx, "SELECT tenant_id FROM contacts WHERE id = $1",
+			created.ID).Scan(&tenantID))
+		assert.Equal(t, other.ID, tenantID)
+
+		w = integration.MkRequest(t, "GET", fmt.Sprintf("/api/contacts/%d", created.ID), router, admin)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = integration.MkRequest(t, "GET", "/api/contacts/", router, intruder)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []int32{created.ID}, contactIDs(t, w.Body.Bytes()))
+	})
+
+	t.Run("API keys are managed per tenant", func(t *testing.T) {
+		w := integration.MkRequest(t, "GET", "/admin/api-keys", router, otherAdmin)
+		require.Equal(t, http.StatusOK, w.Code)
+		var keys []handlers.APIKeyResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &keys))
+		require.Len(t, keys, 2)
+		for _, key := range keys {
+			assert.Equal(t, other.ID, key.TenantID)
+		}
+
+		w = integration.MkRequest(t, "GET", "/admin/api-keys", router, admin)
+		require.Equal(t, http.StatusOK, w.Code)
+		keys = nil
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &keys))
+		require.NotEmpty(t, keys)
+		w = integration.MkRequest(t, "DELETE", fmt.Sprintf("/admin/api-keys/%d", keys[0].ID), router, otherAdmin)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = integration.MkJSONRequestWithHeaders(t, "POST", "/admin/api-keys", router,
+			map[string]any{"name": "sync", "owner_id": 4, "scopes": []string{"contacts:read"}}, otherAdmin)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created handlers.CreatedAPIKeyResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(t, other.ID, created.TenantID)
+	})
+
+	t.Run("queries without a tenant filter only reach the current tenant", func(t *testing.T) {
+		conn := tenant.NewDB(env.Pool)
+		otherCtx := tenant.NewContext(ctx, other.ID)
+
+		var count int
+		require.NoError(t, conn.QueryRow(otherCtx, "SELECT COUNT(*) FROM contacts").Scan(&count))
+		assert.Equal(t, 1, count)
+
+		tag, err := conn.Exec(otherCtx, "UPDATE contacts SET name = 'Taken Over'")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), tag.RowsAffected())
+		tag, err = conn.Exec(otherCtx, "DELETE FROM contact_shares")
+		require.NoError(t, err)
+		assert.Zero(t, tag.RowsAffected())
+
+		rows, err := conn.Query(otherCtx, "SELECT name FROM contacts WHERE id <= 8")
+		require.NoError(t, err)
+		var names []string
+		for rows.Next() {
+			var name string
+			require.NoError(t, rows.Scan(&name))
+			names = append(names, name)
+		}
+		require.NoError(t, rows.Err())
+		assert.Empty(t, names)
+
+		var pgErr *pgconn.PgError
+		_, err = conn.Exec(otherCtx,
+			"INSERT INTO contacts (name, phone, tenant_id) VALUES ('Planted', '100-200-300', 1)")
+		require.ErrorAs(t, err, &pgErr)
+		assert.Equal(t, "42501", pgErr.Code, "row-level security should reject rows of other tenants")
+
+		_, err = db.New(conn).GetContacts(ctx, db.GetContactsParams{})
+		assert.ErrorIs(t, err, tenant.ErrNoTenant)
+
+		require.NoError(t, env.Pool.QueryRow(ctx,
+			"SELECT COUNT(*) FROM contacts WHERE tenant_id = $1 AND name = 'Taken Over'",
+			tenant.DefaultID).Scan(&count))
+		assert.Zero(t, count)
+	})
+
+	t.Run("statements of a unit of work share one transaction", func(t *testing.T) {
+		otherCtx := tenant.NewContext(ctx, other.ID)
+		failed := errors.New("validation failed")
+
+		err := env.Tenant.WithTx(otherCtx, func(ctx context.Context) error {
+			if _, err := env.Tenant.Exec(ctx, "UPDATE contacts SET name = 'Renamed'"); err != nil {
+				return err
+			}
+			var name string
+			if err := env.Tenant.QueryRow(ctx, "SELECT name FROM contacts").Scan(&name); err != nil {
+				return err
+			}
+			assert.Equal(t, "Renamed", name, "later statements see earlier changes")
+			return failed
+		})
+		require.ErrorIs(t, err, failed)
+
+		var count int
+		require.NoError(t, env.Tenant.QueryRow(otherCtx,
+			"SELECT COUNT(*) FROM contacts WHERE name = 'Renamed'").Scan(&count))
+		assert.Zero(t, count, "the changes are rolled back together")
+	})
+}
@@ -0,0 +1,59 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"contactsAI/contacts/internal/config"
+	"contactsAI/contacts/internal/handlers"
+	integration "contactsAI/contacts/tests/integration_test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracingIntegration(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(previous)
+		_ = provider.Shutdown(context.Background())
+	}()
+
+	router, teardownSuite := setupSuiteWith(t, func(*config.Env) {})
+	defer teardownSuite(t)
+	exporter.Reset()
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	w := integration.MkRequest(t, "GET", "/api/contacts/9999", router, map[string]string{
+		"Traceparent": "00-" + traceID + "-00f067aa0ba902b7-01",
+	})
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	var response handlers.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, traceID, response.TraceID)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range exporter.GetSpans().Snapshots() {
+		spans[span.Name()] = span
+	}
+	request, query := spans["GET /api/contacts/:id"], spans["db GetContactByID"]
+	require.NotNil(t, request)
+	require.NotNil(t, query)
+	assert.Equal(t, traceID, request.SpanContext().TraceID().String())
+	// Handlers pass the gin context to queries, so the query is only a child
+	// of the request while the router falls back to the request context.
+	assert.Equal(t, request.SpanContext().SpanID(), query.Parent().SpanID())
+}
@@ -7,18 +7,28 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 )
 
-func MkGetContactByIDRequest(t *testing.T, id int, router *gin.Engine) *httptest.ResponseRecorder {
-	return MkJSONRequest(t, "GET", fmt.Sprintf("/api/contacts/%d", id), router, nil)
+func MkGetContactByIDRequest(
+	t *testing.T, id int, router *gin.Engine, headers map[string]string,
+) *httptest.ResponseRecorder {
+	return MkJSONRequestWithHeaders(t, "GET", fmt.Sprintf("/api/contacts/%d", id), router, nil, headers)
 }
 
 func MkJSONRequest(t *testing.T, method, path string, router *gin.Engine, body interface{}) *httptest.ResponseRecorder {
 	t.Helper()
+	return MkJSONRequestWithHeaders(t, method, path, router, body, nil)
+}
+
+func MkJSONRequestWithHeaders(
+	t *testing.T, method, path string, router *gin.Engine, body interface{}, headers map[string]string,
+) *httptest.ResponseRecorder {
+	t.Helper()
 
 	w := httptest.NewRecorder()
 
@@ -33,6 +43,54 @@ func MkJSONRequest(t *testing.T, method, path string, router *gin.Engine, body i
 
 	req := httptest.NewRequest(method, path, bodyReader)
 	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func MkRequest(
+	t *testing.T, method, path string, router *gin.Engine, headers map[string]string,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func MkMultipartRequest(
+	t *testing.T, method, path string, router *gin.Engine, field, filename string, data []byte,
+	headers map[string]string,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(field, filename)
+	if err != nil {
+		t.Fatalf("failed to create form file: %v", err)
+	}
+	if _, err = part.Write(data); err != nil {
+		t.Fatalf("failed to write form file: %v", err)
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatalf("failed to close multipart writer: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
 
 	router.ServeHTTP(w, req)
 	return w
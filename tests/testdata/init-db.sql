@@ -1,9 +1,9 @@
-INSERT INTO contacts (name, phone, owner_id)
-VALUES ('Agnieszka Szymańska', '888-999-000', 2),
-    ('Anna Nowak', '987-654-321', 1),
-    ('Jan Kowalski', '123-456-789', 1),
-    ('Katarzyna Lewandowska', '222-333-444', 3),
-    ('Maria Wójcik', '444-555-666', 2),
-    ('Michał Zieliński', '555-666-777', 1),
-    ('Piotr Wiśniewski', '111-222-333', 2),
-    ('Tomasz Kamiński', '777-888-999', 3);
\ No newline at end of file
+INSERT INTO contacts (name, phone, owner_id, tenant_id)
+VALUES ('Agnieszka Szymańska', '888-999-000', 2, 1),
+    ('Anna Nowak', '987-654-321', 1, 1),
+    ('Jan Kowalski', '123-456-789', 1, 1),
+    ('Katarzyna Lewandowska', '222-333-444', 3, 1),
+    ('Maria Wójcik', '444-555-666', 2, 1),
+    ('Michał Zieliński', '555-666-777', 1, 1),
+    ('Piotr Wiśniewski', '111-222-333', 2, 1),
+    ('Tomasz Kamiński', '777-888-999', 3, 1);
\ No newline at end of file